@@ -1,15 +1,19 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"log"
 	"time"
 
 	"lumon-backend/internal/config"
+	"lumon-backend/internal/fees"
 	"lumon-backend/internal/handler"
 	"lumon-backend/internal/migrations"
 	"lumon-backend/internal/repository/database"
 	"lumon-backend/internal/service"
+	"lumon-backend/internal/statements"
+	"lumon-backend/pkg/common/storage"
 
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
@@ -38,28 +42,150 @@ func main() {
 		log.Fatal("Failed to perform Database Migrations")
 	}
 
+	if err := migrations.RunDataMigrations(db, cfg.PhoneCountryCode); err != nil {
+		log.Fatal("Failed to perform data migrations:", err)
+	}
+
 	userRepo := database.NewUserRepository(db)
 	documentRepo := database.NewDocumentRepository(db)
 	transactionRepo := database.NewTransactionRepository(db)
 	loanRequestRepo := database.NewLoanRequestRepository(db)
 	accountRepo := database.NewAccountRepository(db)
 	walletRepo := database.NewWalletRepository(db)
+	creditScoreSnapshotRepo := database.NewCreditScoreSnapshotRepository(db)
+	scoringProfileRepo := database.NewScoringProfileRepository(db)
+	recalculationRunRepo := database.NewScoreRecalculationRunRepository(db)
+	creditScoreAlertRepo := database.NewCreditScoreAlertRepository(db)
+	creditReportRepo := database.NewCreditReportRepository(db)
+	scoreMonitoringRepo := database.NewScoreMonitoringRepository(db)
+	statementImportRepo := database.NewStatementImportRepository(db)
+	categoryRuleRepo := database.NewCategoryRuleRepository(db)
+	transactionAnalyticsRepo := database.NewTransactionAnalyticsRepository(db)
+	transactionFlagRepo := database.NewTransactionFlagRepository(db)
+	counterpartyRepo := database.NewCounterpartyRepository(db)
+	transactionTagRepo := database.NewTransactionTagRepository(db)
+	transactionNoteRepo := database.NewTransactionNoteRepository(db)
+	transactionAttachmentRepo := database.NewTransactionAttachmentRepository(db)
+	notificationRepo := database.NewNotificationRepository(db)
+	budgetRepo := database.NewBudgetRepository(db)
 
 	documentService := service.NewDocumentService(documentRepo)
-	transactionService := service.NewTransactionService(transactionRepo)
+	analyticsService := service.NewAnalyticsService(
+		transactionAnalyticsRepo, cfg.AnalyticsCacheTTL, cfg.AnalyticsCacheMaxEntries,
+	)
+	transactionService := service.NewTransactionService(transactionRepo, analyticsService)
+	statementStorage, err := storage.NewLocalStorage(cfg.StatementStorageDir)
+	if err != nil {
+		log.Fatal("Failed to initialise statement storage:", err)
+	}
+	bankMappings, err := statements.LoadBankCSVMappings(cfg.StatementBankMappings)
+	if err != nil {
+		log.Fatal("Failed to load bank statement mappings:", err)
+	}
+	statementParsers, err := statements.NewDefaultRegistry(bankMappings...)
+	if err != nil {
+		log.Fatal("Failed to register statement parsers:", err)
+	}
+	statementService := service.NewStatementService(statementStorage, statementParsers, service.StatementServiceConfig{
+		MaxSize:      cfg.StatementMaxUploadSize,
+		AllowedHosts: cfg.StatementAllowedHosts,
+	})
+	feeTables, err := fees.LoadTables(cfg.FeeTablesFile)
+	if err != nil {
+		log.Fatal("Failed to load fee tables:", err)
+	}
+	feeEngine, err := fees.NewEngine(append(fees.WalletTables, feeTables...)...)
+	if err != nil {
+		log.Fatal("Failed to load fee tables:", err)
+	}
+	anomalyService := service.NewAnomalyService(transactionFlagRepo, transactionRepo)
+	notificationService := service.NewNotificationService(notificationRepo)
+	budgetService := service.NewBudgetService(
+		budgetRepo, transactionAnalyticsRepo, notificationService, cfg.BudgetAlertThresholds,
+	)
+	categoryService := service.NewCategoryService(categoryRuleRepo, transactionRepo, analyticsService, service.CategoryServiceConfig{
+		LLMFallback: cfg.CategoryLLMFallback,
+	})
+	counterpartyService := service.NewCounterpartyService(counterpartyRepo, transactionRepo, cfg.PhoneCountryCode)
+	transactionAnnotationService := service.NewTransactionAnnotationService(
+		transactionRepo, transactionTagRepo, transactionNoteRepo, transactionAttachmentRepo,
+		statementStorage, cfg.AttachmentMaxUploadSize,
+	)
 	userService := service.NewUserService(userRepo)
-	loanRequestService := service.NewLoanRequestService(loanRequestRepo)
+	loanRequestService := service.NewLoanRequestService(loanRequestRepo, anomalyService)
 	accountService := service.NewAccountService(accountRepo)
-	walletService := service.NewWalletService(walletRepo)
+	walletService := service.NewWalletService(walletRepo, anomalyService, analyticsService, feeEngine)
+	scoringProfileService := service.NewScoringProfileService(scoringProfileRepo)
+	scoringModel, err := service.NewScoringModel(cfg.ScoringModel, cfg.ScoringModelPath)
+	if err != nil {
+		log.Fatal("Failed to load scoring model:", err)
+	}
+
+	var shadowScoringModel service.ScoringModel
+	if cfg.ShadowScoringModel != "" {
+		shadowScoringModel, err = service.NewScoringModel(cfg.ShadowScoringModel, cfg.ShadowScoringModelPath)
+		if err != nil {
+			log.Fatal("Failed to load shadow scoring model:", err)
+		}
+	}
+
+	creditScoreService := service.NewCreditScoreService(
+		transactionRepo, userRepo, creditScoreSnapshotRepo, scoringProfileRepo, scoringModel, shadowScoringModel,
+	)
+	creditScoreScheduler := service.NewCreditScoreScheduler(
+		creditScoreService, creditScoreSnapshotRepo, recalculationRunRepo, creditScoreAlertRepo,
+		service.CreditScoreSchedulerConfig{
+			Workers:        cfg.CreditScoreWorkers,
+			QueueSize:      cfg.CreditScoreQueueSize,
+			AlertThreshold: cfg.CreditScoreAlertThreshold,
+			NightlyHour:    cfg.CreditScoreNightlyHour,
+		},
+	)
+	creditScoreScheduler.Start(context.Background())
+
+	statementImportService := service.NewStatementImportService(
+		statementImportRepo, statementService, categoryService, counterpartyService, feeEngine, analyticsService,
+		anomalyService, budgetService, creditScoreScheduler,
+		service.StatementImportServiceConfig{
+			Workers:   cfg.StatementImportWorkers,
+			QueueSize: cfg.StatementImportQueue,
+		},
+	)
+	statementImportService.Start(context.Background())
+
+	scoreMonitoringService := service.NewScoreMonitoringService(
+		scoreMonitoringRepo, scoringProfileRepo, cfg.CreditScoreMonitoringHour,
+	)
+	scoreMonitoringService.Start(context.Background())
+
+	creditReportSigner, err := service.NewCreditReportSigner(cfg.CreditReportSigningKey)
+	if err != nil {
+		log.Fatal("Failed to load credit report signing key:", err)
+	}
+	creditReportService := service.NewCreditReportService(
+		userRepo, transactionRepo, loanRequestRepo, creditReportRepo, creditScoreService, creditReportSigner,
+	)
 
-	userHandler := handler.NewUserHandler(userService, cfg)
+	userHandler := handler.NewUserHandler(userService, creditScoreService, cfg)
 	authHandler := handler.NewAuthHandler(userService, cfg)
 	documentHandler := handler.NewDocumentHandler(documentService, cfg)
-	transactionHandler := handler.NewTransactionHandler(transactionService, userService, cfg)
+	transactionHandler := handler.NewTransactionHandler(
+		transactionService, statementImportService, categoryService, userService, creditScoreService, cfg,
+	)
 	chatHandler := handler.NewChatHandler(transactionService, cfg)
 	loanRequestHandler := handler.NewLoanRequestHandler(loanRequestService, userService, cfg)
 	accountHandler := handler.NewAccountHandler(accountService, cfg)
 	walletHandler := handler.NewWalletsHandler(walletService, cfg)
+	scoringProfileHandler := handler.NewScoringProfileHandler(scoringProfileService, cfg)
+	creditScoreHandler := handler.NewCreditScoreHandler(creditScoreScheduler, cfg)
+	creditReportHandler := handler.NewCreditReportHandler(creditReportService, cfg)
+	scoreMonitoringHandler := handler.NewScoreMonitoringHandler(scoreMonitoringService, cfg)
+	analyticsHandler := handler.NewAnalyticsHandler(analyticsService, cfg)
+	transactionFlagHandler := handler.NewTransactionFlagHandler(anomalyService, cfg)
+	counterpartyHandler := handler.NewCounterpartyHandler(counterpartyService, cfg)
+	transactionAnnotationHandler := handler.NewTransactionAnnotationHandler(transactionAnnotationService, cfg)
+	budgetHandler := handler.NewBudgetHandler(budgetService, cfg)
+	notificationHandler := handler.NewNotificationHandler(notificationService, cfg)
 
 	r := gin.Default()
 
@@ -85,6 +211,16 @@ func main() {
 		loanRequestHandler.RegisterRoutes(api)
 		accountHandler.RegisterRoutes(api)
 		walletHandler.RegisterRoutes(api)
+		scoringProfileHandler.RegisterRoutes(api)
+		creditScoreHandler.RegisterRoutes(api)
+		creditReportHandler.RegisterRoutes(api)
+		scoreMonitoringHandler.RegisterRoutes(api)
+		analyticsHandler.RegisterRoutes(api)
+		transactionFlagHandler.RegisterRoutes(api)
+		counterpartyHandler.RegisterRoutes(api)
+		transactionAnnotationHandler.RegisterRoutes(api)
+		budgetHandler.RegisterRoutes(api)
+		notificationHandler.RegisterRoutes(api)
 	}
 
 	if err := r.Run(fmt.Sprintf(":%s", cfg.Port)); err != nil {
@@ -0,0 +1,146 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strconv"
+
+	"lumon-backend/internal/domain/models"
+	"lumon-backend/internal/service"
+)
+
+func main() {
+	fixturePath := flag.String("fixture", "cmd/backtest/testdata/loans.json", "dataset of borrowers, transactions and loan outcomes")
+	profilesPath := flag.String("profiles", "", "JSON array of scoring profiles to compare (defaults to the built-in profile)")
+	outDir := flag.String("out", "backtest-output", "directory for the JSON and CSV reports")
+	modelKind := flag.String("model", service.ScoringModelHeuristic, "scoring model to evaluate (heuristic or logistic)")
+	modelPath := flag.String("model-path", "", "model file for file-based scoring models")
+	flag.Parse()
+
+	var dataset service.BacktestDataset
+	if err := readJSON(*fixturePath, &dataset); err != nil {
+		log.Fatal("Cannot load fixture:", err)
+	}
+
+	profiles := []models.ScoringProfile{*service.DefaultScoringProfile()}
+	if *profilesPath != "" {
+		profiles = nil
+		if err := readJSON(*profilesPath, &profiles); err != nil {
+			log.Fatal("Cannot load scoring profiles:", err)
+		}
+	}
+
+	model, err := service.NewScoringModel(*modelKind, *modelPath)
+	if err != nil {
+		log.Fatal("Cannot load scoring model:", err)
+	}
+
+	var reports []*service.BacktestReport
+	for i := range profiles {
+		if err := service.ValidateScoringProfile(&profiles[i]); err != nil {
+			log.Fatalf("Scoring profile %q is invalid: %v", profiles[i].Name, err)
+		}
+
+		report, err := service.RunBacktest(dataset, &profiles[i], model)
+		if err != nil {
+			log.Fatal("Backtest failed:", err)
+		}
+		reports = append(reports, report)
+	}
+
+	if err := os.MkdirAll(*outDir, 0o755); err != nil {
+		log.Fatal("Cannot create output directory:", err)
+	}
+
+	if err := writeJSON(filepath.Join(*outDir, "backtest.json"), reports); err != nil {
+		log.Fatal("Failed to write JSON report:", err)
+	}
+
+	if err := writeCSVReports(*outDir, reports); err != nil {
+		log.Fatal("Failed to write CSV reports:", err)
+	}
+
+	for _, report := range reports {
+		fmt.Printf("%-20s model=%s loans=%d scored=%d auc=%.4f ks=%.4f gini=%.4f\n",
+			report.Profile, report.ModelVersion, report.Loans, report.Scored, report.AUC, report.KS, report.Gini)
+	}
+}
+
+func readJSON(path string, v any) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
+
+func writeJSON(path string, v any) error {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0o644)
+}
+
+func writeCSVReports(outDir string, reports []*service.BacktestReport) error {
+	summary := [][]string{{"profile", "model_version", "loans", "scored", "unscored", "defaults", "auc", "ks", "gini"}}
+	bands := [][]string{{"profile", "band", "min_score", "max_score", "loans", "defaults", "default_rate"}}
+	calibration := [][]string{{"profile", "bucket", "loans", "mean_score", "predicted_default_rate", "observed_default_rate"}}
+
+	for _, r := range reports {
+		summary = append(summary, []string{
+			r.Profile, r.ModelVersion, strconv.Itoa(r.Loans), strconv.Itoa(r.Scored), strconv.Itoa(r.Unscored),
+			strconv.Itoa(r.Defaults), formatFloat(r.AUC), formatFloat(r.KS), formatFloat(r.Gini),
+		})
+
+		for _, b := range r.Bands {
+			bands = append(bands, []string{
+				r.Profile, b.Label, formatFloat(b.MinScore), formatFloat(b.MaxScore),
+				strconv.Itoa(b.Loans), strconv.Itoa(b.Defaults), formatFloat(b.DefaultRate),
+			})
+		}
+
+		for _, b := range r.Calibration {
+			calibration = append(calibration, []string{
+				r.Profile, strconv.Itoa(b.Bucket), strconv.Itoa(b.Loans), formatFloat(b.MeanScore),
+				formatFloat(b.PredictedDefaultPct), formatFloat(b.ObservedDefaultPct),
+			})
+		}
+	}
+
+	files := map[string][][]string{
+		"summary.csv":     summary,
+		"bands.csv":       bands,
+		"calibration.csv": calibration,
+	}
+	for name, rows := range files {
+		if err := writeCSV(filepath.Join(outDir, name), rows); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func writeCSV(path string, rows [][]string) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	w := csv.NewWriter(f)
+	if err := w.WriteAll(rows); err != nil {
+		return err
+	}
+	return f.Close()
+}
+
+func formatFloat(v float64) string {
+	return strconv.FormatFloat(v, 'f', 4, 64)
+}
@@ -0,0 +1,161 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"lumon-backend/internal/domain/schemas"
+	"lumon-backend/internal/service"
+	"lumon-backend/internal/statements"
+)
+
+// goldenFile is the expected output stored next to each fixture statement.
+const goldenFile = "expected.json"
+
+// parseOutput is what the tool prints for a statement and what expected.json
+// holds.
+type parseOutput struct {
+	Provider     string                        `json:"provider"`
+	Transactions []schemas.TransactionResponse `json:"transactions"`
+}
+
+var extensionTypes = map[string]string{
+	".pdf":  statements.PDFMIMEType,
+	".csv":  statements.CSVMIMEType,
+	".xlsx": statements.XLSXMIMEType,
+}
+
+func main() {
+	filePath := flag.String("file", "", "statement to parse and print as JSON")
+	checkDir := flag.String("check", "", "directory of fixture statements to compare against their expected.json")
+	update := flag.Bool("update", false, "rewrite expected.json from the parser output instead of comparing")
+	provider := flag.String("provider", "", "provider to parse -file as instead of detecting it")
+	bankMappings := flag.String("bank-mappings", "", "JSON file of bank CSV mappings to register")
+	flag.Parse()
+
+	mappings, err := statements.LoadBankCSVMappings(*bankMappings)
+	if err != nil {
+		log.Fatal("Cannot load bank mappings:", err)
+	}
+	registry, err := statements.NewDefaultRegistry(mappings...)
+	if err != nil {
+		log.Fatal("Cannot register statement parsers:", err)
+	}
+
+	switch {
+	case *filePath != "":
+		output, problems, err := parse(registry, *filePath, *provider)
+		if err != nil {
+			log.Fatal("Cannot parse statement:", err)
+		}
+		os.Stdout.Write(output)
+		for _, problem := range problems {
+			fmt.Fprintln(os.Stderr, "invalid", problem)
+		}
+	case *checkDir != "":
+		if failures := check(registry, *checkDir, *update); failures > 0 {
+			log.Fatalf("%d fixture(s) did not match", failures)
+		}
+	default:
+		flag.Usage()
+		os.Exit(2)
+	}
+}
+
+// parse returns the statement's transactions as JSON, with the problems
+// validation found in them.
+func parse(registry *statements.Registry, path, provider string) ([]byte, []statements.RowError, error) {
+	mimeType, ok := extensionTypes[strings.ToLower(filepath.Ext(path))]
+	if !ok {
+		return nil, nil, fmt.Errorf("unsupported statement file %s", path)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	statement, err := statements.NewStatement(data, mimeType)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	parser, transactions, err := registry.Parse(statement, provider)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	result := parseOutput{Provider: parser.Provider()}
+	for _, transaction := range transactions {
+		result.Transactions = append(result.Transactions, service.NewTransactionResponse(*transaction))
+	}
+
+	output, err := json.MarshalIndent(result, "", "  ")
+	if err != nil {
+		return nil, nil, err
+	}
+	return append(output, '\n'), statements.Validate(transactions), nil
+}
+
+// check parses every statement in each fixture directory and compares the
+// result with the directory's expected.json. All formats of a statement in one
+// directory must produce the same transactions, the provider must be detected
+// from the file alone, and every transaction must pass validation.
+func check(registry *statements.Registry, root string, update bool) int {
+	failures := 0
+
+	err := filepath.WalkDir(root, func(path string, entry os.DirEntry, err error) error {
+		if err != nil || entry.IsDir() {
+			return err
+		}
+		if _, ok := extensionTypes[strings.ToLower(filepath.Ext(path))]; !ok {
+			return nil
+		}
+
+		goldenPath := filepath.Join(filepath.Dir(path), goldenFile)
+
+		output, problems, err := parse(registry, path, "")
+		if err != nil {
+			fmt.Printf("FAIL %s: %v\n", path, err)
+			failures++
+			return nil
+		}
+		if len(problems) > 0 {
+			for _, problem := range problems {
+				fmt.Printf("FAIL %s: %v\n", path, problem)
+			}
+			failures++
+			return nil
+		}
+
+		if update {
+			fmt.Printf("UPDATE %s from %s\n", goldenPath, path)
+			return os.WriteFile(goldenPath, output, 0o644)
+		}
+
+		expected, err := os.ReadFile(goldenPath)
+		if err != nil {
+			return err
+		}
+
+		if !bytes.Equal(bytes.TrimSpace(expected), bytes.TrimSpace(output)) {
+			fmt.Printf("FAIL %s: output differs from %s\n", path, goldenPath)
+			failures++
+			return nil
+		}
+
+		fmt.Printf("ok   %s\n", path)
+		return nil
+	})
+	if err != nil {
+		log.Fatal("Cannot check fixtures:", err)
+	}
+
+	return failures
+}
@@ -0,0 +1,461 @@
+// Package anomaly looks for transactions that stand out from a user's usual
+// activity or that suggest a statement was tampered with: amount spikes,
+// activity at unusual hours, bursts of new counterparties, money sent and
+// received back from the same number, breaks in the balance chain of a
+// statement, and fees or E-levy other than the rate tables give.
+package anomaly
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"lumon-backend/internal/categories"
+	"lumon-backend/internal/domain/models"
+	"lumon-backend/internal/fees"
+
+	"github.com/google/uuid"
+)
+
+const (
+	KindAmountSpike       = "amount_spike"
+	KindUnusualHour       = "unusual_hour"
+	KindCounterpartyBurst = "counterparty_burst"
+	KindRoundTrip         = "round_trip"
+	KindEditedBalance     = "edited_balance"
+	KindFeeMismatch       = "fee_mismatch"
+	KindELevyMismatch     = "e_levy_mismatch"
+)
+
+const (
+	SeverityLow    = "low"
+	SeverityMedium = "medium"
+	SeverityHigh   = "high"
+)
+
+const (
+	// minBaseline is the fewest transactions a user's usual amounts and
+	// hours are judged from.
+	minBaseline = 10
+
+	// spikeRatio and spikeDeviations are how many times the median amount,
+	// and how many robust deviations above it, an amount must be to count as
+	// a spike. Amounts of highSpikeRatio times the median are severe.
+	spikeRatio      = 3
+	spikeDeviations = 6
+	highSpikeRatio  = 10
+
+	// nightStart and nightEnd bound the hours that are unusual unless a user
+	// often transacts in them.
+	nightStart        = 0
+	nightEnd          = 5
+	usualNightShare   = 0.05
+	burstWindow       = 24 * time.Hour
+	burstCounterparts = 5
+	highBurstCount    = 10
+
+	roundTripWindow    = 72 * time.Hour
+	roundTripTolerance = 0.1
+	highRoundTrips     = 3
+
+	balanceTolerance = 0.011
+
+	// A charge is severely off when it is off by more than highChargeShare
+	// of the expected charge and by more than highChargeDifference.
+	highChargeShare      = 0.5
+	highChargeDifference = 1
+)
+
+// Flag is an anomaly found on a transaction.
+type Flag struct {
+	Kind        string
+	Severity    string
+	Reason      string
+	Transaction *models.Transaction
+}
+
+// Detect returns the anomalies in a user's transactions, judged against the
+// rest of them.
+func Detect(transactions []models.Transaction) []Flag {
+	sorted := make([]*models.Transaction, 0, len(transactions))
+	for i := range transactions {
+		if !transactions[i].TransactionDate.IsZero() && transactions[i].Amount > 0 {
+			sorted = append(sorted, &transactions[i])
+		}
+	}
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].TransactionDate.Before(sorted[j].TransactionDate)
+	})
+
+	baselines := newBaselines(sorted)
+
+	var flags []Flag
+	for _, transaction := range sorted {
+		flags = append(flags, checkMovement(baselines[categories.IsIncoming(transaction)], transaction)...)
+	}
+	flags = append(flags, detectBursts(sorted)...)
+	flags = append(flags, detectRoundTrips(sorted)...)
+	flags = append(flags, detectEditedBalances(sorted)...)
+	flags = append(flags, detectChargeMismatches(sorted)...)
+
+	return flags
+}
+
+// CheckMovement returns the anomalies of a single movement of money, such as
+// a wallet top-up, judged against the user's transactions.
+func CheckMovement(history []models.Transaction, movement *models.Transaction) []Flag {
+	sorted := make([]*models.Transaction, 0, len(history))
+	for i := range history {
+		if history[i].Amount > 0 {
+			sorted = append(sorted, &history[i])
+		}
+	}
+
+	return checkMovement(newBaselines(sorted)[categories.IsIncoming(movement)], movement)
+}
+
+// baseline is what a user's transactions in one direction usually look like.
+type baseline struct {
+	count  int
+	median float64
+	// spread is the median absolute deviation scaled to be comparable to a
+	// standard deviation.
+	spread float64
+	timed  int
+	night  int
+}
+
+func newBaselines(transactions []*models.Transaction) map[bool]baseline {
+	amounts := make(map[bool][]float64)
+	baselines := make(map[bool]baseline)
+
+	for _, transaction := range transactions {
+		incoming := categories.IsIncoming(transaction)
+		amounts[incoming] = append(amounts[incoming], transaction.Amount)
+
+		b := baselines[incoming]
+		if hasTime(transaction.TransactionDate) {
+			b.timed++
+			if isNight(transaction.TransactionDate) {
+				b.night++
+			}
+		}
+		baselines[incoming] = b
+	}
+
+	for incoming, values := range amounts {
+		b := baselines[incoming]
+		b.count = len(values)
+		b.median = median(values)
+
+		deviations := make([]float64, len(values))
+		for i, value := range values {
+			deviations[i] = math.Abs(value - b.median)
+		}
+		b.spread = 1.4826 * median(deviations)
+		baselines[incoming] = b
+	}
+
+	return baselines
+}
+
+func checkMovement(b baseline, transaction *models.Transaction) []Flag {
+	var flags []Flag
+	if b.count < minBaseline || b.median <= 0 {
+		return flags
+	}
+
+	ratio := transaction.Amount / b.median
+	spike := ratio >= spikeRatio &&
+		(b.spread == 0 || (transaction.Amount-b.median)/b.spread >= spikeDeviations)
+	if spike {
+		severity := SeverityMedium
+		if ratio >= highSpikeRatio {
+			severity = SeverityHigh
+		}
+		flags = append(flags, Flag{
+			Kind:     KindAmountSpike,
+			Severity: severity,
+			Reason: fmt.Sprintf("amount %.2f is %.1f times the usual %.2f",
+				transaction.Amount, ratio, b.median),
+			Transaction: transaction,
+		})
+	}
+
+	date := transaction.TransactionDate
+	if hasTime(date) && isNight(date) && b.timed >= minBaseline &&
+		float64(b.night)/float64(b.timed) < usualNightShare {
+		severity := SeverityLow
+		if ratio >= spikeRatio {
+			severity = SeverityMedium
+		}
+		flags = append(flags, Flag{
+			Kind:     KindUnusualHour,
+			Severity: severity,
+			Reason: fmt.Sprintf("made at %s, when %d of %d of the user's transactions were made",
+				date.UTC().Format("15:04"), b.night, b.timed),
+			Transaction: transaction,
+		})
+	}
+
+	return flags
+}
+
+// detectBursts flags the transaction at which the user had paid
+// burstCounterparts counterparties never paid before within burstWindow. One
+// flag is raised per burst, on the transaction that completed it, and grows
+// more severe the more new counterparties the burst went on to include.
+func detectBursts(transactions []*models.Transaction) []Flag {
+	var flags []Flag
+
+	seen := make(map[string]bool)
+	var window []*models.Transaction
+	burst := -1
+	for _, transaction := range transactions {
+		if categories.IsIncoming(transaction) {
+			continue
+		}
+
+		counterparty := categories.CounterpartyKey(transaction)
+		if counterparty == "" || seen[counterparty] {
+			continue
+		}
+		seen[counterparty] = true
+
+		window = append(window, transaction)
+		for len(window) > 0 && transaction.TransactionDate.Sub(window[0].TransactionDate) > burstWindow {
+			window = window[1:]
+		}
+
+		if len(window) < burstCounterparts {
+			burst = -1
+			continue
+		}
+
+		if burst >= 0 {
+			count := len(window)
+			flags[burst].Reason = fmt.Sprintf("%d new counterparties paid within %.0f hours", count, burstWindow.Hours())
+			if count >= highBurstCount {
+				flags[burst].Severity = SeverityHigh
+			}
+			continue
+		}
+
+		flags = append(flags, Flag{
+			Kind:        KindCounterpartyBurst,
+			Severity:    SeverityMedium,
+			Reason:      fmt.Sprintf("%d new counterparties paid within %.0f hours", len(window), burstWindow.Hours()),
+			Transaction: transaction,
+		})
+		burst = len(flags) - 1
+	}
+
+	return flags
+}
+
+// detectRoundTrips flags money that comes back from the number it was sent
+// to, or goes back to the number it came from, for about the same amount
+// within roundTripWindow. The flag is raised on the return leg.
+func detectRoundTrips(transactions []*models.Transaction) []Flag {
+	var flags []Flag
+
+	trips := make(map[string]int)
+	for i, transaction := range transactions {
+		_, number := categories.Counterparty(transaction)
+		if number == "" {
+			continue
+		}
+		counterparty := categories.CounterpartyKey(transaction)
+		incoming := categories.IsIncoming(transaction)
+
+		for j := i - 1; j >= 0; j-- {
+			earlier := transactions[j]
+			if transaction.TransactionDate.Sub(earlier.TransactionDate) > roundTripWindow {
+				break
+			}
+			if categories.IsIncoming(earlier) == incoming || categories.CounterpartyKey(earlier) != counterparty {
+				continue
+			}
+			if math.Abs(transaction.Amount-earlier.Amount) > earlier.Amount*roundTripTolerance {
+				continue
+			}
+
+			trips[counterparty]++
+			severity := SeverityMedium
+			if trips[counterparty] >= highRoundTrips {
+				severity = SeverityHigh
+			}
+			flags = append(flags, Flag{
+				Kind:     KindRoundTrip,
+				Severity: severity,
+				Reason: fmt.Sprintf("%.2f moved back with %s %.1f hours after %.2f moved the other way",
+					transaction.Amount, number, transaction.TransactionDate.Sub(earlier.TransactionDate).Hours(),
+					earlier.Amount),
+				Transaction: transaction,
+			})
+			break
+		}
+	}
+
+	return flags
+}
+
+// detectEditedBalances looks for rows of a statement whose balance carries on
+// from no other row of the same statement and that no other row carries on
+// from. Rows with the same date may be stored in any order, so links are
+// matched against the whole statement rather than the row next to it. A single
+// break in the chain is as likely to be a row quarantined or dropped as a
+// duplicate on import as an edited one, so, as when a statement is parsed,
+// only a row cut off on both sides is flagged; at either end of the statement
+// the neighbour must carry on from the row after it. A row that starts higher
+// than the row before it ended is severe, as money appeared from nowhere.
+func detectEditedBalances(transactions []*models.Transaction) []Flag {
+	var flags []Flag
+
+	statements := make(map[uuid.UUID][]*models.Transaction)
+	for _, transaction := range transactions {
+		if transaction.StatementImportID != nil && hasBalances(transaction) {
+			id := *transaction.StatementImportID
+			statements[id] = append(statements[id], transaction)
+		}
+	}
+
+	for _, rows := range statements {
+		if len(rows) < 3 {
+			continue
+		}
+
+		starts := make(map[int64]int, len(rows))
+		endings := make(map[int64]int, len(rows))
+		for _, row := range rows {
+			starts[cents(row.BalanceBefore)]++
+			endings[cents(row.BalanceAfter)]++
+		}
+
+		// carriesOn reports whether a row's start is another row's ending,
+		// and carriedOn whether its ending is another row's start.
+		carriesOn := func(row *models.Transaction) bool {
+			return matchesOther(endings, cents(row.BalanceBefore), cents(row.BalanceAfter))
+		}
+		carriedOn := func(row *models.Transaction) bool {
+			return matchesOther(starts, cents(row.BalanceAfter), cents(row.BalanceBefore))
+		}
+
+		last := len(rows) - 1
+		for i, row := range rows {
+			if carriesOn(row) || carriedOn(row) {
+				continue
+			}
+			if (i == 0 && !carriedOn(rows[1])) || (i == last && !carriesOn(rows[last-1])) {
+				continue
+			}
+
+			previous := rows[max(i-1, 0)]
+			severity := SeverityMedium
+			if i > 0 && row.BalanceBefore > previous.BalanceAfter+balanceTolerance {
+				severity = SeverityHigh
+			}
+			flags = append(flags, Flag{
+				Kind:     KindEditedBalance,
+				Severity: severity,
+				Reason: fmt.Sprintf(
+					"balance before %.2f and after %.2f carry on from and into no row of the statement; the row before ended at %.2f",
+					row.BalanceBefore, row.BalanceAfter, previous.BalanceAfter,
+				),
+				Transaction: row,
+			})
+		}
+	}
+
+	return flags
+}
+
+// matchesOther reports whether balances holds value to within a cent, leaving
+// out the row's own entry when its other balance is the same one.
+func matchesOther(balances map[int64]int, value, own int64) bool {
+	for _, candidate := range []int64{value - 1, value, value + 1} {
+		count := balances[candidate]
+		if candidate == own {
+			count--
+		}
+		if count > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// detectChargeMismatches flags transactions whose fee or E-levy is not the
+// one the rate tables expected when they were imported. Charges that are well
+// off are medium severity, as they may mean the amount was edited; small
+// differences are more likely a table out of date. A row that prints no fee
+// or no levy is only flagged when its balances prove the expected charge was
+// not taken, as statements often take charges without printing them.
+func detectChargeMismatches(transactions []*models.Transaction) []Flag {
+	var flags []Flag
+
+	check := func(transaction *models.Transaction, kind, charge string, charged float64, expected *float64) {
+		if expected == nil || math.Abs(charged-*expected) <= balanceTolerance {
+			return
+		}
+		if charged == 0 && !fees.NothingTaken(transaction) {
+			return
+		}
+
+		difference := math.Abs(charged - *expected)
+		severity := SeverityLow
+		if difference > highChargeDifference && difference > *expected*highChargeShare {
+			severity = SeverityMedium
+		}
+		flags = append(flags, Flag{
+			Kind:        kind,
+			Severity:    severity,
+			Reason:      fmt.Sprintf("%s of %.2f where %.2f was expected", charge, charged, *expected),
+			Transaction: transaction,
+		})
+	}
+
+	for _, transaction := range transactions {
+		check(transaction, KindFeeMismatch, "fee", transaction.Fees, transaction.ExpectedFees)
+		check(transaction, KindELevyMismatch, "E-levy", transaction.ELevy, transaction.ExpectedELevy)
+	}
+
+	return flags
+}
+
+func cents(amount float64) int64 {
+	return int64(math.Round(amount * 100))
+}
+
+func hasBalances(transaction *models.Transaction) bool {
+	return transaction.BalanceBefore != 0 || transaction.BalanceAfter != 0
+}
+
+// hasTime reports whether a date carries a time of day. Statements that print
+// only dates are stored at midnight.
+func hasTime(date time.Time) bool {
+	date = date.UTC()
+	return date.Hour() != 0 || date.Minute() != 0 || date.Second() != 0
+}
+
+func isNight(date time.Time) bool {
+	hour := date.UTC().Hour()
+	return hour >= nightStart && hour < nightEnd
+}
+
+func median(values []float64) float64 {
+	if len(values) == 0 {
+		return 0
+	}
+
+	sorted := make([]float64, len(values))
+	copy(sorted, values)
+	sort.Float64s(sorted)
+
+	middle := len(sorted) / 2
+	if len(sorted)%2 == 0 {
+		return (sorted[middle-1] + sorted[middle]) / 2
+	}
+	return sorted[middle]
+}
@@ -0,0 +1,325 @@
+package anomaly
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"lumon-backend/internal/domain/models"
+	"lumon-backend/internal/statements"
+
+	"github.com/google/uuid"
+)
+
+func charge(amount float64) *float64 {
+	return &amount
+}
+
+func TestDetectChargeMismatches(t *testing.T) {
+	at := time.Date(2023, time.March, 1, 12, 0, 0, 0, time.UTC)
+	transfer := func(fee, levy, before, after float64) *models.Transaction {
+		return &models.Transaction{
+			TransactionDate: at,
+			TransactionType: statements.TypeTransfer,
+			Amount:          1000,
+			Fees:            fee,
+			ELevy:           levy,
+			BalanceBefore:   before,
+			BalanceAfter:    after,
+			ExpectedFees:    charge(7.5),
+			ExpectedELevy:   charge(10),
+		}
+	}
+
+	tests := []struct {
+		name        string
+		transaction *models.Transaction
+		want        map[string]string
+	}{
+		{
+			name:        "charges printed as expected",
+			transaction: transfer(7.5, 10, 2000, 982.5),
+			want:        map[string]string{},
+		},
+		{
+			name:        "no charges printed and no balances",
+			transaction: transfer(0, 0, 0, 0),
+			want:        map[string]string{},
+		},
+		{
+			name:        "no charges printed but taken from the balance",
+			transaction: transfer(0, 0, 2000, 982.5),
+			want:        map[string]string{},
+		},
+		{
+			name:        "no fee printed but levy and fee taken",
+			transaction: transfer(0, 10, 2000, 982.5),
+			want:        map[string]string{},
+		},
+		{
+			name:        "balances prove no charges were taken",
+			transaction: transfer(0, 0, 2000, 1000),
+			want:        map[string]string{KindFeeMismatch: SeverityMedium, KindELevyMismatch: SeverityMedium},
+		},
+		{
+			name:        "balances prove no fee was taken",
+			transaction: transfer(0, 10, 2000, 990),
+			want:        map[string]string{KindFeeMismatch: SeverityMedium},
+		},
+		{
+			name:        "printed fee slightly off",
+			transaction: transfer(7, 10, 2000, 983),
+			want:        map[string]string{KindFeeMismatch: SeverityLow},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := make(map[string]string)
+			for _, flag := range detectChargeMismatches([]*models.Transaction{test.transaction}) {
+				got[flag.Kind] = flag.Severity
+			}
+			if len(got) != len(test.want) {
+				t.Fatalf("flags = %v, want %v", got, test.want)
+			}
+			for kind, severity := range test.want {
+				if got[kind] != severity {
+					t.Errorf("%s severity = %q, want %q", kind, got[kind], severity)
+				}
+			}
+		})
+	}
+}
+
+// chain returns outgoing rows of one statement, all on the same date, moving
+// between each pair of balances in turn.
+func chain(statementID uuid.UUID, balances ...[2]float64) []models.Transaction {
+	var rows []models.Transaction
+	for _, balance := range balances {
+		rows = append(rows, models.Transaction{
+			StatementImportID: &statementID,
+			TransactionDate:   time.Date(2023, time.March, 1, 0, 0, 0, 0, time.UTC),
+			TransactionType:   statements.TypeTransfer,
+			Amount:            balance[0] - balance[1],
+			BalanceBefore:     balance[0],
+			BalanceAfter:      balance[1],
+			ToNumber:          "0244000001",
+		})
+	}
+	return rows
+}
+
+func TestDetectEditedBalances(t *testing.T) {
+	tests := []struct {
+		name     string
+		balances [][2]float64
+		// flagged maps the index of each flagged row to its severity.
+		flagged map[int]string
+	}{
+		{
+			name:     "unbroken chain",
+			balances: [][2]float64{{1000, 900}, {900, 800}, {800, 700}, {700, 600}, {600, 500}},
+		},
+		{
+			name:     "one row dropped",
+			balances: [][2]float64{{1000, 900}, {900, 800}, {700, 600}, {600, 500}},
+		},
+		{
+			name:     "rows with the same date stored out of order",
+			balances: [][2]float64{{1000, 900}, {800, 700}, {900, 800}, {700, 600}},
+		},
+		{
+			name:     "one row edited down",
+			balances: [][2]float64{{1000, 900}, {900, 800}, {780, 680}, {700, 600}, {600, 500}},
+			flagged:  map[int]string{2: SeverityMedium},
+		},
+		{
+			name:     "one row starting higher than the row before ended",
+			balances: [][2]float64{{1000, 900}, {900, 800}, {850, 750}, {700, 600}, {600, 500}},
+			flagged:  map[int]string{2: SeverityHigh},
+		},
+		{
+			name:     "first row edited",
+			balances: [][2]float64{{2000, 1900}, {900, 800}, {800, 700}, {700, 600}},
+			flagged:  map[int]string{0: SeverityMedium},
+		},
+		{
+			name:     "last row edited",
+			balances: [][2]float64{{1000, 900}, {900, 800}, {800, 700}, {500, 400}},
+			flagged:  map[int]string{3: SeverityMedium},
+		},
+		{
+			name:     "fewer than three rows",
+			balances: [][2]float64{{1000, 900}, {500, 400}},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			rows := chain(uuid.New(), test.balances...)
+
+			got := make(map[int]string)
+			for _, flag := range Detect(rows) {
+				if flag.Kind != KindEditedBalance {
+					continue
+				}
+				for i := range rows {
+					if flag.Transaction == &rows[i] {
+						got[i] = flag.Severity
+					}
+				}
+			}
+
+			if len(got) != len(test.flagged) {
+				t.Fatalf("flagged rows = %v, want %v", got, test.flagged)
+			}
+			for i, severity := range test.flagged {
+				if got[i] != severity {
+					t.Errorf("row %d severity = %q, want %q", i, got[i], severity)
+				}
+			}
+		})
+	}
+
+	t.Run("statements are checked apart", func(t *testing.T) {
+		rows := append(
+			chain(uuid.New(), [2]float64{1000, 900}, [2]float64{900, 800}, [2]float64{800, 700}),
+			chain(uuid.New(), [2]float64{300, 200}, [2]float64{200, 100}, [2]float64{100, 50})...,
+		)
+		if flags := Detect(rows); len(flags) != 0 {
+			t.Errorf("flags = %+v, want none", flags)
+		}
+	})
+}
+
+func TestDetectRoundTrips(t *testing.T) {
+	at := time.Date(2023, time.March, 1, 12, 0, 0, 0, time.UTC)
+	sent := func(hours int, amount float64, number string) models.Transaction {
+		return models.Transaction{
+			TransactionDate: at.Add(time.Duration(hours) * time.Hour),
+			TransactionType: statements.TypeTransfer,
+			Amount:          amount,
+			ToNumber:        number,
+		}
+	}
+	received := func(hours int, amount float64, number string) models.Transaction {
+		return models.Transaction{
+			TransactionDate: at.Add(time.Duration(hours) * time.Hour),
+			TransactionType: statements.TypeCashIn,
+			Amount:          amount,
+			FromNumber:      number,
+		}
+	}
+
+	tests := []struct {
+		name         string
+		transactions []models.Transaction
+		want         []string
+	}{
+		{
+			name:         "sent and received back",
+			transactions: []models.Transaction{sent(0, 500, "0244111111"), received(24, 480, "0244111111")},
+			want:         []string{SeverityMedium},
+		},
+		{
+			name:         "received and sent back",
+			transactions: []models.Transaction{received(0, 500, "0244111111"), sent(2, 500, "0244111111")},
+			want:         []string{SeverityMedium},
+		},
+		{
+			name:         "returned after the window",
+			transactions: []models.Transaction{sent(0, 500, "0244111111"), received(73, 500, "0244111111")},
+		},
+		{
+			name:         "different amount",
+			transactions: []models.Transaction{sent(0, 500, "0244111111"), received(24, 400, "0244111111")},
+		},
+		{
+			name:         "different number",
+			transactions: []models.Transaction{sent(0, 500, "0244111111"), received(24, 500, "0244222222")},
+		},
+		{
+			name: "repeated round trips",
+			transactions: []models.Transaction{
+				sent(0, 500, "0244111111"), received(1, 500, "0244111111"),
+				sent(2, 500, "0244111111"), received(3, 500, "0244111111"),
+			},
+			want: []string{SeverityMedium, SeverityMedium, SeverityHigh},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var got []string
+			for _, flag := range Detect(test.transactions) {
+				if flag.Kind == KindRoundTrip {
+					got = append(got, flag.Severity)
+				}
+			}
+			if fmt.Sprint(got) != fmt.Sprint(test.want) {
+				t.Errorf("round trip severities = %v, want %v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestDetectBursts(t *testing.T) {
+	at := time.Date(2023, time.March, 1, 12, 0, 0, 0, time.UTC)
+	payments := func(count int, every time.Duration) []models.Transaction {
+		var transactions []models.Transaction
+		for i := 0; i < count; i++ {
+			transactions = append(transactions, models.Transaction{
+				TransactionDate: at.Add(time.Duration(i) * every),
+				TransactionType: statements.TypeTransfer,
+				Amount:          50,
+				ToNumber:        fmt.Sprintf("02440000%02d", i),
+			})
+		}
+		return transactions
+	}
+
+	tests := []struct {
+		name         string
+		transactions []models.Transaction
+		want         []string
+		reason       string
+	}{
+		{name: "too few counterparties", transactions: payments(4, time.Hour)},
+		{name: "spread over more than a day", transactions: payments(5, 7*time.Hour)},
+		{
+			name:         "new counterparties within a day",
+			transactions: payments(5, time.Hour),
+			want:         []string{SeverityMedium},
+			reason:       "5 new counterparties paid within 24 hours",
+		},
+		{
+			name:         "a burst that keeps growing",
+			transactions: payments(10, time.Hour),
+			want:         []string{SeverityHigh},
+			reason:       "10 new counterparties paid within 24 hours",
+		},
+		{
+			name:         "paying the same counterparties again",
+			transactions: append(payments(4, time.Hour), payments(4, time.Hour)...),
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var got []string
+			var reason string
+			for _, flag := range Detect(test.transactions) {
+				if flag.Kind == KindCounterpartyBurst {
+					got = append(got, flag.Severity)
+					reason = flag.Reason
+				}
+			}
+			if fmt.Sprint(got) != fmt.Sprint(test.want) {
+				t.Fatalf("burst severities = %v, want %v", got, test.want)
+			}
+			if reason != test.reason {
+				t.Errorf("reason = %q, want %q", reason, test.reason)
+			}
+		})
+	}
+}
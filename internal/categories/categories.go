@@ -0,0 +1,246 @@
+// Package categories assigns spending categories to transactions from their
+// counterparty, reference and description, using built-in keyword rules and
+// the rules users teach it by correcting a category.
+package categories
+
+import (
+	"strings"
+
+	"lumon-backend/internal/domain/models"
+	"lumon-backend/internal/statements"
+)
+
+const (
+	Airtime        = "airtime"
+	Utilities      = "utilities"
+	Rent           = "rent"
+	Groceries      = "groceries"
+	Food           = "food"
+	Transport      = "transport"
+	Health         = "health"
+	Education      = "education"
+	Betting        = "betting"
+	Loan           = "loan"
+	Salary         = "salary"
+	Transfer       = "transfer"
+	CashWithdrawal = "cash_withdrawal"
+	// Savings is money moved to the user's own savings. It leaves the account
+	// but is not spent.
+	Savings = "savings"
+	Other   = "other"
+)
+
+// All lists every category in the order they are presented to users.
+var All = []string{
+	Airtime, Utilities, Rent, Groceries, Food, Transport, Health, Education,
+	Betting, Loan, Salary, Transfer, CashWithdrawal, Savings, Other,
+}
+
+// Valid reports whether category is one of All.
+func Valid(category string) bool {
+	for _, known := range All {
+		if category == known {
+			return true
+		}
+	}
+	return false
+}
+
+// Sources record how a transaction's category was assigned.
+const (
+	SourceRule     = "rule"
+	SourceLLM      = "llm"
+	SourceUser     = "user"
+	SourceFallback = "fallback"
+)
+
+// Rule kinds. A keyword rule matches a word or phrase anywhere in the
+// counterparty name, reference or description; a counterparty rule matches the
+// counterparty's number or name exactly.
+const (
+	MatchKeyword      = "keyword"
+	MatchCounterparty = "counterparty"
+)
+
+// Rule assigns Category to the transactions it matches. Incoming restricts a
+// rule to money received, so that a salary keyword does not categorise a
+// payment to a payroll provider.
+type Rule struct {
+	Match    string
+	Pattern  string
+	Category string
+	Incoming bool
+}
+
+// Categorizer matches transactions against the built-in rules.
+type Categorizer struct {
+	rules []Rule
+}
+
+// NewCategorizer returns a categorizer with the default rules followed by
+// any extra ones.
+func NewCategorizer(extra ...Rule) *Categorizer {
+	return &Categorizer{rules: append(DefaultRules(), extra...)}
+}
+
+// Match returns the category of the first rule that matches, trying the
+// user's own rules before the built-in ones.
+func (c *Categorizer) Match(transaction *models.Transaction, userRules []Rule) (string, bool) {
+	subject := newSubject(transaction)
+
+	for _, rules := range [][]Rule{userRules, c.rules} {
+		for _, rule := range rules {
+			if rule.matches(subject) {
+				return rule.Category, true
+			}
+		}
+	}
+
+	return "", false
+}
+
+// Matches reports whether the rule applies to the transaction.
+func (r Rule) Matches(transaction *models.Transaction) bool {
+	return r.matches(newSubject(transaction))
+}
+
+func (r Rule) matches(subject subject) bool {
+	if r.Incoming && !subject.incoming {
+		return false
+	}
+
+	pattern := normalizeRulePattern(r.Match, r.Pattern)
+	if pattern == "" {
+		return false
+	}
+
+	switch r.Match {
+	case MatchCounterparty:
+		return pattern == subject.number || pattern == subject.name
+	case MatchKeyword:
+		return strings.Contains(subject.text, " "+pattern+" ")
+	}
+	return false
+}
+
+// subject is the normalised view of a transaction that rules are matched
+// against.
+type subject struct {
+	text     string
+	name     string
+	number   string
+	incoming bool
+}
+
+func newSubject(transaction *models.Transaction) subject {
+	name, number := Counterparty(transaction)
+	return subject{
+		text:     Text(transaction),
+		name:     normalizeText(name),
+		number:   normalizeNumber(number),
+		incoming: IsIncoming(transaction),
+	}
+}
+
+// Fallback categorises a transaction no rule matched by which way the money
+// moved.
+func Fallback(transaction *models.Transaction) string {
+	switch {
+	case transaction.TransactionType == statements.TypeCashOut:
+		return CashWithdrawal
+	case IsIncoming(transaction), transaction.TransactionType == statements.TypeTransfer:
+		return Transfer
+	}
+	return Other
+}
+
+// CounterpartyRule is the rule learnt when a user moves a transaction into
+// category: every transaction with the same counterparty follows it. It
+// returns false when the transaction names no counterparty to learn from.
+func CounterpartyRule(transaction *models.Transaction, category string) (Rule, bool) {
+	pattern := CounterpartyKey(transaction)
+	if pattern == "" {
+		return Rule{}, false
+	}
+
+	return Rule{Match: MatchCounterparty, Pattern: pattern, Category: category}, true
+}
+
+// CounterpartyKey identifies the counterparty of a transaction by its
+// normalised number, or by its name when the statement prints no number. It
+// is empty when the transaction names no counterparty.
+func CounterpartyKey(transaction *models.Transaction) string {
+	name, number := Counterparty(transaction)
+
+	if key := normalizeNumber(number); key != "" {
+		return key
+	}
+	return normalizeText(name)
+}
+
+// IsIncoming reports whether the account holder received the money, going by
+// the type and, for types that go either way, the balance.
+func IsIncoming(transaction *models.Transaction) bool {
+	if transaction.TransactionType == statements.TypeCashIn {
+		return true
+	}
+	return transaction.BalanceAfter > transaction.BalanceBefore
+}
+
+// Counterparty returns the side of the transaction the account holder is not
+// on.
+func Counterparty(transaction *models.Transaction) (name, number string) {
+	if IsIncoming(transaction) {
+		return transaction.FromName, transaction.FromNumber
+	}
+	return transaction.ToName, transaction.ToNumber
+}
+
+// Text is what keyword rules are matched against: the counterparty name,
+// reference and description as upper-case words, padded with spaces so that
+// a pattern only matches whole words.
+func Text(transaction *models.Transaction) string {
+	name, _ := Counterparty(transaction)
+	return " " + normalizeText(name+" "+transaction.Reference+" "+transaction.Description) + " "
+}
+
+func normalizeRulePattern(match, pattern string) string {
+	if match == MatchCounterparty {
+		if number := normalizeNumber(pattern); number != "" {
+			return number
+		}
+	}
+	return normalizeText(pattern)
+}
+
+// normalizeText reduces text to upper-case words of letters and digits.
+func normalizeText(text string) string {
+	return strings.Join(strings.Fields(strings.Map(func(r rune) rune {
+		if (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') {
+			return r
+		}
+		return ' '
+	}, strings.ToUpper(text))), " ")
+}
+
+// normalizeNumber keeps the last nine digits of a phone number, so that the
+// local and international forms of a number compare equal. Values that are
+// not phone numbers give an empty string.
+func normalizeNumber(value string) string {
+	var digits strings.Builder
+	for _, r := range value {
+		switch {
+		case r >= '0' && r <= '9':
+			digits.WriteRune(r)
+		case r == '+' || r == ' ' || r == '-' || r == '(' || r == ')':
+		default:
+			return ""
+		}
+	}
+
+	number := digits.String()
+	if len(number) < 9 {
+		return ""
+	}
+	return number[len(number)-9:]
+}
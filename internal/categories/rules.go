@@ -0,0 +1,56 @@
+package categories
+
+// defaultKeywords maps the words that identify a category in Ghanaian mobile
+// money and bank statements, most specific first. Earlier entries win, so
+// merchant names come before generic words.
+var defaultKeywords = []struct {
+	category string
+	keywords []string
+}{
+	{Betting, []string{
+		"SPORTYBET", "BETWAY", "BETPAWA", "SOCCERBET", "1XBET", "MOZZARTBET", "BET9JA", "BETKING", "MELBET",
+		"NLA", "LOTTO", "BET",
+	}},
+	{Airtime, []string{"AIRTIME", "DATA BUNDLE", "BUNDLE", "TOP UP", "TOPUP", "RECHARGE"}},
+	{Utilities, []string{
+		"ECG", "ELECTRICITY", "PREPAID", "POWER", "GWCL", "GHANA WATER", "WATER", "DSTV", "GOTV", "STARTIMES",
+		"SURFLINE", "BROADBAND", "INTERNET", "UTILITY", "UTILITIES",
+	}},
+	{Rent, []string{"RENT", "RENTAL", "LANDLORD", "HOSTEL"}},
+	{Groceries, []string{
+		"SHOPRITE", "MELCOM", "MAXMART", "PALACE", "CHINA MALL", "GAME STORES", "SUPERMARKET", "GROCERY",
+		"GROCERIES", "MINI MART", "PROVISIONS",
+	}},
+	{Food, []string{"KFC", "PIZZA", "CHICKEN", "RESTAURANT", "CHOP BAR", "BOLT FOOD", "GLOVO", "JUMIA FOOD", "FOOD"}},
+	{Transport, []string{
+		"UBER", "BOLT", "YANGO", "TAXI", "TROTRO", "STC", "VIP BUS", "TRANSPORT", "FUEL", "GOIL", "STAR OIL",
+		"PETROL",
+	}},
+	{Health, []string{"PHARMACY", "CHEMIST", "HOSPITAL", "CLINIC", "NHIS", "MEDICAL", "HEALTH"}},
+	{Education, []string{"SCHOOL FEES", "SCHOOL", "UNIVERSITY", "COLLEGE", "TUITION", "WAEC", "ACADEMY"}},
+	{Loan, []string{"LOAN", "REPAYMENT", "QWIKLOAN", "XPRESSLOAN", "FIDO", "CREDIT FACILITY"}},
+}
+
+// incomeKeywords only categorise money received.
+var incomeKeywords = []struct {
+	category string
+	keywords []string
+}{
+	{Salary, []string{"SALARY", "SALARIES", "PAYROLL", "WAGES", "WAGE", "STIPEND", "ALLOWANCE"}},
+}
+
+// DefaultRules returns the built-in keyword rules.
+func DefaultRules() []Rule {
+	var rules []Rule
+	for _, entry := range incomeKeywords {
+		for _, keyword := range entry.keywords {
+			rules = append(rules, Rule{Match: MatchKeyword, Pattern: keyword, Category: entry.category, Incoming: true})
+		}
+	}
+	for _, entry := range defaultKeywords {
+		for _, keyword := range entry.keywords {
+			rules = append(rules, Rule{Match: MatchKeyword, Pattern: keyword, Category: entry.category})
+		}
+	}
+	return rules
+}
@@ -5,6 +5,7 @@ import (
 	"log"
 	"os"
 	"strconv"
+	"strings"
 	"time"
 )
 
@@ -18,6 +19,39 @@ type Config struct {
 	SecretKey     []byte
 	TokenDuration time.Duration
 	GeminiAPIKey  string
+
+	ScoringModel           string
+	ScoringModelPath       string
+	ShadowScoringModel     string
+	ShadowScoringModelPath string
+
+	CreditScoreWorkers        int
+	CreditScoreQueueSize      int
+	CreditScoreAlertThreshold float64
+	CreditScoreNightlyHour    int
+	CreditScoreMonitoringHour int
+
+	CreditReportSigningKey string
+
+	StatementMaxUploadSize int64
+	StatementStorageDir    string
+	StatementAllowedHosts  []string
+	StatementBankMappings  string
+	StatementImportWorkers int
+	StatementImportQueue   int
+
+	AttachmentMaxUploadSize int64
+
+	CategoryLLMFallback bool
+
+	AnalyticsCacheTTL        time.Duration
+	AnalyticsCacheMaxEntries int
+
+	PhoneCountryCode string
+
+	FeeTablesFile string
+
+	BudgetAlertThresholds []int
 }
 
 func LoadConfig() (*Config, error) {
@@ -31,6 +65,39 @@ func LoadConfig() (*Config, error) {
 		SecretKey:     []byte(os.Getenv("SECRET")),
 		TokenDuration: time.Duration(GetInt("TOKEN_EXPIRE_TIME", 24)) * time.Hour,
 		GeminiAPIKey:  os.Getenv("GEMINI_API_KEY"),
+
+		ScoringModel:           GetString("SCORING_MODEL", "heuristic"),
+		ScoringModelPath:       os.Getenv("SCORING_MODEL_PATH"),
+		ShadowScoringModel:     os.Getenv("SHADOW_SCORING_MODEL"),
+		ShadowScoringModelPath: os.Getenv("SHADOW_SCORING_MODEL_PATH"),
+
+		CreditScoreWorkers:        GetInt("CREDIT_SCORE_WORKERS", 4),
+		CreditScoreQueueSize:      GetInt("CREDIT_SCORE_QUEUE_SIZE", 100),
+		CreditScoreAlertThreshold: GetFloat("CREDIT_SCORE_ALERT_THRESHOLD", 50),
+		CreditScoreNightlyHour:    GetInt("CREDIT_SCORE_NIGHTLY_HOUR", 2),
+		CreditScoreMonitoringHour: GetInt("CREDIT_SCORE_MONITORING_HOUR", 4),
+
+		CreditReportSigningKey: os.Getenv("CREDIT_REPORT_SIGNING_KEY"),
+
+		StatementMaxUploadSize: int64(GetInt("STATEMENT_MAX_UPLOAD_MB", 10)) << 20,
+		StatementStorageDir:    GetString("STATEMENT_STORAGE_DIR", "storage"),
+		StatementAllowedHosts:  GetStrings("STATEMENT_URL_ALLOWED_HOSTS"),
+		StatementBankMappings:  GetString("STATEMENT_BANK_MAPPINGS_FILE", ""),
+		StatementImportWorkers: GetInt("STATEMENT_IMPORT_WORKERS", 2),
+		StatementImportQueue:   GetInt("STATEMENT_IMPORT_QUEUE_SIZE", 100),
+
+		AttachmentMaxUploadSize: int64(GetInt("ATTACHMENT_MAX_UPLOAD_MB", 5)) << 20,
+
+		CategoryLLMFallback: GetBool("CATEGORY_LLM_FALLBACK", false),
+
+		AnalyticsCacheTTL:        time.Duration(GetInt("ANALYTICS_CACHE_TTL_MINUTES", 15)) * time.Minute,
+		AnalyticsCacheMaxEntries: GetInt("ANALYTICS_CACHE_MAX_ENTRIES", 10000),
+
+		PhoneCountryCode: GetString("PHONE_COUNTRY_CODE", "233"),
+
+		FeeTablesFile: GetString("FEE_TABLES_FILE", ""),
+
+		BudgetAlertThresholds: GetInts("BUDGET_ALERT_THRESHOLDS", []int{80, 100}),
 	}, nil
 }
 
@@ -39,6 +106,54 @@ func (c *Config) GetDSN() string {
 		c.DBHost, c.DBUser, c.DBPassword, c.DBName, c.DBPort)
 }
 
+func GetString(key string, fallback string) string {
+	if v := os.Getenv(key); v != "" {
+		return v
+	}
+	return fallback
+}
+
+// GetStrings reads a comma-separated list, dropping empty entries.
+func GetStrings(key string) []string {
+	var values []string
+	for _, v := range strings.Split(os.Getenv(key), ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			values = append(values, v)
+		}
+	}
+	return values
+}
+
+// GetInts reads a comma-separated list of integers. The fallback is used when
+// the list is empty or holds anything but integers.
+func GetInts(key string, fallback []int) []int {
+	var values []int
+	for _, v := range GetStrings(key) {
+		i, err := strconv.Atoi(v)
+		if err != nil {
+			log.Printf("%s: %s", key, err)
+			return fallback
+		}
+		values = append(values, i)
+	}
+	if len(values) == 0 {
+		return fallback
+	}
+	return values
+}
+
+func GetFloat(key string, fallback float64) float64 {
+	if v := os.Getenv(key); v != "" {
+		f, err := strconv.ParseFloat(v, 64)
+		if err != nil {
+			log.Printf("%s: %s", key, err)
+			return fallback
+		}
+		return f
+	}
+	return fallback
+}
+
 func GetInt(key string, fallback int) int {
 	if v := os.Getenv(key); v != "" {
 		i, err := strconv.Atoi(v)
@@ -50,3 +165,15 @@ func GetInt(key string, fallback int) int {
 	}
 	return fallback
 }
+
+func GetBool(key string, fallback bool) bool {
+	if v := os.Getenv(key); v != "" {
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			log.Printf("%s: %s", key, err)
+			return fallback
+		}
+		return b
+	}
+	return fallback
+}
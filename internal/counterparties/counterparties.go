@@ -0,0 +1,355 @@
+// Package counterparties tells apart the people and businesses a user
+// transacts with. Statements print a counterparty's name and number as free
+// text, formatted and spelt differently from one provider and one row to the
+// next, so numbers are normalised to E.164 and names are compared loosely.
+package counterparties
+
+import (
+	"math"
+	"sort"
+	"strings"
+
+	"lumon-backend/internal/categories"
+	"lumon-backend/internal/domain/models"
+)
+
+// DefaultCountryCode is the calling code numbers without one are read in.
+const DefaultCountryCode = "233"
+
+const (
+	// MatchThreshold is the name similarity from which two names are taken
+	// for the same counterparty.
+	MatchThreshold = 0.92
+
+	// localNumberDigits is the length of a national number without its trunk
+	// prefix, as in 024 123 4567.
+	localNumberDigits = 9
+	minNumberDigits   = 8
+	maxNumberDigits   = 15
+)
+
+// titles are left out when names are compared.
+var titles = map[string]bool{
+	"MR": true, "MRS": true, "MS": true, "MISS": true, "DR": true, "REV": true, "PROF": true,
+}
+
+// NormalizeNumber returns a phone number in E.164 form, reading numbers
+// without a calling code as local to countryCode. It reports false for values
+// that are not phone numbers, such as account numbers or merchant codes.
+func NormalizeNumber(value, countryCode string) (string, bool) {
+	value = strings.TrimSpace(value)
+	international := strings.HasPrefix(value, "+")
+
+	var b strings.Builder
+	for i, r := range value {
+		switch {
+		case r >= '0' && r <= '9':
+			b.WriteRune(r)
+		case r == '+' && i == 0:
+		case r == ' ' || r == '-' || r == '(' || r == ')' || r == '.':
+		default:
+			return "", false
+		}
+	}
+
+	digits := b.String()
+	switch {
+	case international:
+	case strings.HasPrefix(digits, "00"):
+		digits = digits[2:]
+	case strings.HasPrefix(digits, "0") && len(digits) == localNumberDigits+1:
+		digits = countryCode + digits[1:]
+	case len(digits) == localNumberDigits:
+		digits = countryCode + digits
+	case strings.HasPrefix(digits, countryCode) && len(digits) == len(countryCode)+localNumberDigits:
+	default:
+		return "", false
+	}
+
+	if len(digits) < minNumberDigits || len(digits) > maxNumberDigits || digits[0] == '0' {
+		return "", false
+	}
+	return "+" + digits, true
+}
+
+// NormalizeIdentifier reduces a number that is not a phone number to
+// upper-case letters and digits.
+func NormalizeIdentifier(value string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z':
+			return r - 'a' + 'A'
+		case (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9'):
+			return r
+		}
+		return -1
+	}, value)
+}
+
+// NormalizeName reduces a name to upper-case words of letters and digits,
+// without titles.
+func NormalizeName(name string) string {
+	words := strings.Fields(strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z':
+			return r - 'a' + 'A'
+		case (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9'):
+			return r
+		}
+		return ' '
+	}, name))
+
+	kept := words[:0]
+	for _, word := range words {
+		if !titles[word] {
+			kept = append(kept, word)
+		}
+	}
+	return strings.Join(kept, " ")
+}
+
+// NameSimilarity compares two normalised names from 0 to 1. Word order does
+// not matter, and a name of at least two words found whole within the other,
+// such as a name printed without its middle name, counts as a match.
+func NameSimilarity(a, b string) float64 {
+	if a == "" || b == "" {
+		return 0
+	}
+	if a == b {
+		return 1
+	}
+
+	wordsA, wordsB := strings.Fields(a), strings.Fields(b)
+	if len(wordsA) > len(wordsB) {
+		wordsA, wordsB = wordsB, wordsA
+	}
+	subset := len(wordsA) >= 2 && containsAll(wordsB, wordsA)
+
+	sort.Strings(wordsA)
+	sort.Strings(wordsB)
+	similarity := jaroWinkler(strings.Join(wordsA, " "), strings.Join(wordsB, " "))
+	if subset {
+		similarity = math.Max(similarity, MatchThreshold)
+	}
+	return similarity
+}
+
+func containsAll(words, subset []string) bool {
+	set := make(map[string]bool, len(words))
+	for _, word := range words {
+		set[word] = true
+	}
+	for _, word := range subset {
+		if !set[word] {
+			return false
+		}
+	}
+	return true
+}
+
+// jaroWinkler is the Jaro-Winkler similarity of two strings, which favours
+// strings that share a prefix.
+func jaroWinkler(a, b string) float64 {
+	s, t := []rune(a), []rune(b)
+	if len(s) == 0 || len(t) == 0 {
+		return 0
+	}
+
+	window := max(len(s), len(t))/2 - 1
+	window = max(window, 0)
+
+	matchedS := make([]bool, len(s))
+	matchedT := make([]bool, len(t))
+	matches := 0
+	for i := range s {
+		for j := max(0, i-window); j < min(len(t), i+window+1); j++ {
+			if !matchedT[j] && s[i] == t[j] {
+				matchedS[i], matchedT[j] = true, true
+				matches++
+				break
+			}
+		}
+	}
+	if matches == 0 {
+		return 0
+	}
+
+	transpositions, j := 0, 0
+	for i := range s {
+		if !matchedS[i] {
+			continue
+		}
+		for !matchedT[j] {
+			j++
+		}
+		if s[i] != t[j] {
+			transpositions++
+		}
+		j++
+	}
+
+	m := float64(matches)
+	jaro := (m/float64(len(s)) + m/float64(len(t)) + (m-float64(transpositions)/2)/m) / 3
+
+	prefix := 0
+	for prefix < min(4, len(s), len(t)) && s[prefix] == t[prefix] {
+		prefix++
+	}
+	return jaro + float64(prefix)*0.1*(1-jaro)
+}
+
+// Directory resolves transactions to a user's counterparties, adding the ones
+// it has not seen before. A counterparty is recognised by its phone number,
+// by another identifier when the statement prints one that is not a phone
+// number, or by a similar name when it prints neither.
+type Directory struct {
+	countryCode  string
+	all          []*models.Counterparty
+	byNumber     map[string]*models.Counterparty
+	byIdentifier map[string]*models.Counterparty
+	created      map[*models.Counterparty]bool
+	changed      map[*models.Counterparty]bool
+}
+
+// NewDirectory starts a directory from the user's known counterparties.
+func NewDirectory(known []models.Counterparty, countryCode string) *Directory {
+	if countryCode == "" {
+		countryCode = DefaultCountryCode
+	}
+
+	d := &Directory{
+		countryCode:  countryCode,
+		byNumber:     make(map[string]*models.Counterparty),
+		byIdentifier: make(map[string]*models.Counterparty),
+		created:      make(map[*models.Counterparty]bool),
+		changed:      make(map[*models.Counterparty]bool),
+	}
+	for i := range known {
+		d.add(&known[i])
+	}
+	return d
+}
+
+// Resolve returns the counterparty of a transaction, or nil when the
+// transaction names none.
+func (d *Directory) Resolve(transaction *models.Transaction) *models.Counterparty {
+	name, number := categories.Counterparty(transaction)
+	name = strings.Join(strings.Fields(name), " ")
+	normalized := NormalizeName(name)
+
+	phone, isPhone := NormalizeNumber(number, d.countryCode)
+	identifier := ""
+	if !isPhone {
+		identifier = NormalizeIdentifier(number)
+	}
+
+	var counterparty *models.Counterparty
+	switch {
+	case isPhone:
+		counterparty = d.byNumber[phone]
+	case identifier != "":
+		counterparty = d.byIdentifier[identifier]
+	}
+
+	// A number seen for the first time may belong to a counterparty only
+	// known by name so far, but never to one known by another number.
+	if counterparty == nil && normalized != "" {
+		counterparty = d.matchName(normalized, isPhone || identifier != "")
+		if counterparty != nil {
+			if isPhone {
+				counterparty.PhoneNumber = phone
+				d.byNumber[phone] = counterparty
+				d.changed[counterparty] = true
+			} else if identifier != "" {
+				counterparty.Identifier = identifier
+				d.byIdentifier[identifier] = counterparty
+				d.changed[counterparty] = true
+			}
+		}
+	}
+
+	if counterparty == nil {
+		if normalized == "" && !isPhone && identifier == "" {
+			return nil
+		}
+
+		date := transaction.TransactionDate
+		counterparty = &models.Counterparty{
+			Name:           name,
+			NormalizedName: normalized,
+			PhoneNumber:    phone,
+			Identifier:     identifier,
+			LastSeenAt:     &date,
+			UserID:         transaction.UserID,
+		}
+		d.add(counterparty)
+		d.created[counterparty] = true
+		return counterparty
+	}
+
+	// The name printed most recently is kept, as it is the one the user
+	// will recognise.
+	if name != "" && name != counterparty.Name &&
+		(counterparty.LastSeenAt == nil || !transaction.TransactionDate.Before(*counterparty.LastSeenAt)) {
+		counterparty.Name = name
+		counterparty.NormalizedName = normalized
+		d.changed[counterparty] = true
+	}
+	if counterparty.LastSeenAt == nil || transaction.TransactionDate.After(*counterparty.LastSeenAt) {
+		date := transaction.TransactionDate
+		counterparty.LastSeenAt = &date
+		d.changed[counterparty] = true
+	}
+
+	return counterparty
+}
+
+// Created returns the counterparties added since the directory was started.
+func (d *Directory) Created() []*models.Counterparty {
+	return d.collect(d.created, nil)
+}
+
+// Changed returns the known counterparties that were given a new name or
+// number since the directory was started.
+func (d *Directory) Changed() []*models.Counterparty {
+	return d.collect(d.changed, d.created)
+}
+
+func (d *Directory) collect(set, except map[*models.Counterparty]bool) []*models.Counterparty {
+	var counterparties []*models.Counterparty
+	for _, counterparty := range d.all {
+		if set[counterparty] && !except[counterparty] {
+			counterparties = append(counterparties, counterparty)
+		}
+	}
+	return counterparties
+}
+
+// matchName returns the counterparty whose name is most similar to name, if
+// any is similar enough. When numbered is set, only counterparties without a
+// number are considered.
+func (d *Directory) matchName(name string, numbered bool) *models.Counterparty {
+	var best *models.Counterparty
+	bestScore := 0.0
+	for _, counterparty := range d.all {
+		if numbered && (counterparty.PhoneNumber != "" || counterparty.Identifier != "") {
+			continue
+		}
+
+		score := NameSimilarity(name, counterparty.NormalizedName)
+		if score >= MatchThreshold && score > bestScore {
+			best, bestScore = counterparty, score
+		}
+	}
+	return best
+}
+
+func (d *Directory) add(counterparty *models.Counterparty) {
+	d.all = append(d.all, counterparty)
+	if counterparty.PhoneNumber != "" {
+		d.byNumber[counterparty.PhoneNumber] = counterparty
+	}
+	if counterparty.Identifier != "" {
+		d.byIdentifier[counterparty.Identifier] = counterparty
+	}
+}
@@ -0,0 +1,79 @@
+package counterparties
+
+import "testing"
+
+func TestNormalizeNumber(t *testing.T) {
+	tests := []struct {
+		value string
+		want  string
+	}{
+		{value: "0244123456", want: "+233244123456"},
+		{value: "024 412 3456", want: "+233244123456"},
+		{value: "(024) 412-3456", want: "+233244123456"},
+		{value: "244123456", want: "+233244123456"},
+		{value: "233244123456", want: "+233244123456"},
+		{value: "+233244123456", want: "+233244123456"},
+		{value: " +233 24 412 3456 ", want: "+233244123456"},
+		{value: "00233244123456", want: "+233244123456"},
+		{value: "+44 20 7946 0958", want: "+442079460958"},
+		// Not phone numbers.
+		{value: ""},
+		{value: "12345"},
+		{value: "MERCHANT 4411"},
+		{value: "024+4123456"},
+		{value: "+0244123456"},
+		{value: "23324412345"},
+		{value: "1234567890123"},
+		{value: "+1234567890123456"},
+	}
+
+	for _, test := range tests {
+		got, ok := NormalizeNumber(test.value, DefaultCountryCode)
+		if got != test.want || ok != (test.want != "") {
+			t.Errorf("NormalizeNumber(%q) = %q, %v, want %q", test.value, got, ok, test.want)
+		}
+	}
+}
+
+func TestNameSimilarity(t *testing.T) {
+	tests := []struct {
+		a, b  string
+		match bool
+	}{
+		{a: "KWAME MENSAH", b: "KWAME MENSAH", match: true},
+		{a: "KWAME MENSAH", b: "MENSAH KWAME", match: true},
+		{a: "KWAME MENSAH", b: "KWAME MENSA", match: true},
+		// A name printed without its middle name.
+		{a: "KWAME OWUSU MENSAH", b: "KWAME MENSAH", match: true},
+		{a: "KWAME MENSAH", b: "AMA MENSAH"},
+		{a: "KWAME MENSAH", b: "KOFI MENSAH"},
+		// A single word is too little to match a longer name by.
+		{a: "KWAME", b: "KWAME MENSAH"},
+		{a: "", b: ""},
+		{a: "KWAME MENSAH", b: ""},
+	}
+
+	for _, test := range tests {
+		got := NameSimilarity(test.a, test.b)
+		if (got >= MatchThreshold) != test.match {
+			t.Errorf("NameSimilarity(%q, %q) = %.3f, want a match: %v", test.a, test.b, got, test.match)
+		}
+		if reversed := NameSimilarity(test.b, test.a); reversed != got {
+			t.Errorf("NameSimilarity(%q, %q) = %.3f, but %.3f the other way round", test.a, test.b, got, reversed)
+		}
+	}
+}
+
+func TestNormalizeName(t *testing.T) {
+	tests := map[string]string{
+		"Mr. Kwame  Mensah":  "KWAME MENSAH",
+		"DR KWAME-MENSAH":    "KWAME MENSAH",
+		"mrs ama o. boateng": "AMA O BOATENG",
+		"":                   "",
+	}
+	for name, want := range tests {
+		if got := NormalizeName(name); got != want {
+			t.Errorf("NormalizeName(%q) = %q, want %q", name, got, want)
+		}
+	}
+}
@@ -0,0 +1,53 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Budget is what a user means to spend on one category each month. The user
+// is alerted as their spending that month reaches each of Thresholds, given
+// as percentages of Amount.
+type Budget struct {
+	ID         uuid.UUID `gorm:"type:uuid;primary_key;default:uuid_generate_v4()" json:"id"`
+	Category   string    `gorm:"type:varchar(30);not null;uniqueIndex:idx_budgets_user_category,priority:2" json:"category"`
+	Amount     float64   `gorm:"type:decimal(20,2);not null" json:"amount"`
+	Thresholds []int     `gorm:"type:text;serializer:json;not null" json:"thresholds"`
+	CreatedAt  time.Time `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt  time.Time `gorm:"autoUpdateTime" json:"updated_at"`
+
+	UserID uuid.UUID `gorm:"type:uuid;not null;uniqueIndex:idx_budgets_user_category,priority:1" json:"user_id"`
+	User   User      `gorm:"foreignKey:UserID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;" json:"-"`
+}
+
+func (b *Budget) BeforeCreate(tx *gorm.DB) (err error) {
+	b.ID = uuid.New()
+	return
+}
+
+// BudgetAlert records that a budget reached one of its thresholds in a month,
+// so that the user is alerted about it only once.
+type BudgetAlert struct {
+	ID uuid.UUID `gorm:"type:uuid;primary_key;default:uuid_generate_v4()" json:"id"`
+	// Month is the first day of the month the spending was in.
+	Month     time.Time `gorm:"type:date;not null;uniqueIndex:idx_budget_alerts_threshold,priority:2" json:"month"`
+	Threshold int       `gorm:"not null;uniqueIndex:idx_budget_alerts_threshold,priority:3" json:"threshold"`
+	// Spent and Amount are the spending and the budget when the threshold
+	// was reached.
+	Spent     float64   `gorm:"type:decimal(20,2);not null" json:"spent"`
+	Amount    float64   `gorm:"type:decimal(20,2);not null" json:"amount"`
+	CreatedAt time.Time `gorm:"autoCreateTime" json:"created_at"`
+
+	BudgetID uuid.UUID `gorm:"type:uuid;not null;uniqueIndex:idx_budget_alerts_threshold,priority:1" json:"budget_id"`
+	Budget   *Budget   `gorm:"foreignKey:BudgetID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;" json:"-"`
+
+	UserID uuid.UUID `gorm:"type:uuid;not null;index" json:"user_id"`
+	User   User      `gorm:"foreignKey:UserID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;" json:"-"`
+}
+
+func (b *BudgetAlert) BeforeCreate(tx *gorm.DB) (err error) {
+	b.ID = uuid.New()
+	return
+}
@@ -0,0 +1,27 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// CategoryRule is a category a user taught by correcting a transaction. It
+// applies to the user's transactions before the built-in rules do.
+type CategoryRule struct {
+	ID        uuid.UUID `gorm:"type:uuid;primary_key;default:uuid_generate_v4()" json:"id"`
+	Match     string    `gorm:"type:varchar(20);not null;uniqueIndex:idx_category_rules_user_pattern" json:"match"`
+	Pattern   string    `gorm:"type:varchar(255);not null;uniqueIndex:idx_category_rules_user_pattern" json:"pattern"`
+	Category  string    `gorm:"type:varchar(30);not null" json:"category"`
+	CreatedAt time.Time `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt time.Time `gorm:"autoUpdateTime" json:"updated_at"`
+
+	UserID uuid.UUID `gorm:"type:uuid;not null;uniqueIndex:idx_category_rules_user_pattern" json:"user_id"`
+	User   User      `gorm:"foreignKey:UserID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;" json:"-"`
+}
+
+func (b *CategoryRule) BeforeCreate(tx *gorm.DB) (err error) {
+	b.ID = uuid.New()
+	return
+}
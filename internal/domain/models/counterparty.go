@@ -0,0 +1,46 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Counterparty is someone a user transacts with. It is told apart by its
+// phone number in E.164 form, by another identifier such as an account number
+// when the statements print one, or by its name when they print neither.
+type Counterparty struct {
+	ID             uuid.UUID `gorm:"type:uuid;primary_key;default:uuid_generate_v4()" json:"id"`
+	Name           string    `gorm:"type:varchar(255);not null" json:"name"`
+	NormalizedName string    `gorm:"type:varchar(255);not null;index:idx_counterparties_user_name,priority:2" json:"normalized_name"`
+	PhoneNumber    string    `gorm:"type:varchar(16);uniqueIndex:idx_counterparties_user_phone,where:phone_number <> ''" json:"phone_number"`
+	Identifier     string    `gorm:"type:varchar(255);uniqueIndex:idx_counterparties_user_identifier,where:identifier <> ''" json:"identifier"`
+	// LastSeenAt is the date of the latest transaction the name was read
+	// from.
+	LastSeenAt *time.Time `gorm:"type:timestamp" json:"last_seen_at"`
+	CreatedAt  time.Time  `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt  time.Time  `gorm:"autoUpdateTime" json:"updated_at"`
+
+	UserID uuid.UUID `gorm:"type:uuid;not null;uniqueIndex:idx_counterparties_user_phone;uniqueIndex:idx_counterparties_user_identifier;index:idx_counterparties_user_name,priority:1" json:"user_id"`
+	User   User      `gorm:"foreignKey:UserID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;" json:"-"`
+}
+
+func (b *Counterparty) BeforeCreate(tx *gorm.DB) (err error) {
+	b.ID = uuid.New()
+	return
+}
+
+// CounterpartySummary is what a user exchanged with one counterparty over
+// all of their transactions. It is read-only and not migrated.
+type CounterpartySummary struct {
+	ID               uuid.UUID
+	Name             string
+	PhoneNumber      string
+	Identifier       string
+	Inflow           float64
+	Outflow          float64
+	TransactionCount int
+	FirstSeen        time.Time
+	LastSeen         time.Time
+}
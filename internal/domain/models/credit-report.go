@@ -0,0 +1,32 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// CreditReport records every report issued so that third parties can check a
+// report they were handed against what the server actually signed.
+type CreditReport struct {
+	ID        uuid.UUID `gorm:"type:uuid;primary_key;default:uuid_generate_v4()" json:"id"`
+	Digest    string    `gorm:"type:varchar(64);not null" json:"digest"`
+	Signature string    `gorm:"type:text;not null" json:"signature"`
+	Score     float64   `gorm:"type:decimal(10,2);not null" json:"score"`
+	IssuedAt  time.Time `gorm:"not null" json:"issued_at"`
+	ExpiresAt time.Time `gorm:"not null" json:"expires_at"`
+
+	SnapshotID *uuid.UUID           `gorm:"type:uuid" json:"snapshot_id"`
+	Snapshot   *CreditScoreSnapshot `gorm:"foreignKey:SnapshotID;constraint:OnUpdate:CASCADE,OnDelete:SET NULL;" json:"-"`
+
+	UserID uuid.UUID `gorm:"type:uuid;not null;index" json:"user_id"`
+	User   User      `gorm:"foreignKey:UserID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;" json:"-"`
+}
+
+func (b *CreditReport) BeforeCreate(tx *gorm.DB) (err error) {
+	if b.ID == uuid.Nil {
+		b.ID = uuid.New()
+	}
+	return
+}
@@ -0,0 +1,42 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type CreditScoreSnapshot struct {
+	ID           uuid.UUID `gorm:"type:uuid;primary_key;default:uuid_generate_v4()" json:"id"`
+	Status       string    `gorm:"type:varchar(30);not null;default:'scored'" json:"status"`
+	Score        float64   `gorm:"type:decimal(10,2);not null" json:"score"`
+	ModelVersion string    `gorm:"type:varchar(50);not null" json:"model_version"`
+
+	ShadowModelVersion string   `gorm:"type:varchar(50)" json:"shadow_model_version,omitempty"`
+	ShadowScore        *float64 `gorm:"type:decimal(10,2)" json:"shadow_score,omitempty"`
+
+	ProfileID   *uuid.UUID      `gorm:"type:uuid" json:"profile_id"`
+	Profile     *ScoringProfile `gorm:"foreignKey:ProfileID;constraint:OnUpdate:CASCADE,OnDelete:SET NULL;" json:"-"`
+	ProfileName string          `gorm:"type:varchar(100);not null" json:"profile_name"`
+
+	PaymentBehavior   float64 `gorm:"type:decimal(10,4);not null" json:"payment_behavior"`
+	IncomeStability   float64 `gorm:"type:decimal(10,4);not null" json:"income_stability"`
+	CashFlow          float64 `gorm:"type:decimal(10,4);not null" json:"cash_flow"`
+	TransactionHabits float64 `gorm:"type:decimal(10,4);not null" json:"transaction_habits"`
+	CreditHistory     float64 `gorm:"type:decimal(10,4);not null" json:"credit_history"`
+
+	WindowStart      *time.Time `gorm:"type:timestamp" json:"window_start"`
+	WindowEnd        *time.Time `gorm:"type:timestamp" json:"window_end"`
+	TransactionCount int        `gorm:"not null" json:"transaction_count"`
+
+	CreatedAt time.Time `gorm:"autoCreateTime;index" json:"created_at"`
+
+	UserID uuid.UUID `gorm:"type:uuid;not null;index" json:"user_id"`
+	User   User      `gorm:"foreignKey:UserID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;" json:"-"`
+}
+
+func (b *CreditScoreSnapshot) BeforeCreate(tx *gorm.DB) (err error) {
+	b.ID = uuid.New()
+	return
+}
@@ -0,0 +1,10 @@
+package models
+
+import "time"
+
+// DataMigration records a one-off data migration that has been run, so that
+// it is not run again on the next start.
+type DataMigration struct {
+	Name      string    `gorm:"type:varchar(100);primaryKey" json:"name"`
+	CreatedAt time.Time `gorm:"autoCreateTime" json:"created_at"`
+}
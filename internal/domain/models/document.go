@@ -12,6 +12,12 @@ type Document struct {
 	ContentSummary string     `gorm:"type:text;unique;not null" json:"content_summary"`
 	Type           string     `gorm:"size:50;not null" json:"type"`
 	UploadedAt     *time.Time `json:"uploaded_at"`
+	// StorageKey, Filename, MIMEType and Size describe the stored file of a
+	// document that was uploaded, such as a transaction attachment.
+	StorageKey string `gorm:"type:varchar(255)" json:"-"`
+	Filename   string `gorm:"type:varchar(255)" json:"filename,omitempty"`
+	MIMEType   string `gorm:"type:varchar(100)" json:"mime_type,omitempty"`
+	Size       int64  `gorm:"not null;default:0" json:"size,omitempty"`
 
 	UserID uuid.UUID `gorm:"type:uuid;not null" json:"user_id"`
 	User   User      `gorm:"foreignKey:UserID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;" json:"user"`
@@ -0,0 +1,30 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Notification is a message for a user, kept in their inbox until they read
+// it. SubjectType and SubjectID name what it is about, such as a budget, so
+// that clients can link to it.
+type Notification struct {
+	ID          uuid.UUID  `gorm:"type:uuid;primary_key;default:uuid_generate_v4()" json:"id"`
+	Kind        string     `gorm:"type:varchar(30);not null" json:"kind"`
+	Title       string     `gorm:"type:varchar(255);not null" json:"title"`
+	Body        string     `gorm:"type:text;not null" json:"body"`
+	SubjectType string     `gorm:"type:varchar(30)" json:"subject_type,omitempty"`
+	SubjectID   *uuid.UUID `gorm:"type:uuid" json:"subject_id,omitempty"`
+	ReadAt      *time.Time `json:"read_at"`
+	CreatedAt   time.Time  `gorm:"autoCreateTime;index:idx_notifications_user_created,priority:2" json:"created_at"`
+
+	UserID uuid.UUID `gorm:"type:uuid;not null;index:idx_notifications_user_created,priority:1" json:"user_id"`
+	User   User      `gorm:"foreignKey:UserID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;" json:"-"`
+}
+
+func (b *Notification) BeforeCreate(tx *gorm.DB) (err error) {
+	b.ID = uuid.New()
+	return
+}
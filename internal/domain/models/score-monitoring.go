@@ -0,0 +1,53 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// ScoreMonitoringReport is a saved drift and fairness report. The full report
+// is kept as JSON in Payload; the headline figures are columns so reports can
+// be listed without decoding it.
+type ScoreMonitoringReport struct {
+	ID          uuid.UUID  `gorm:"type:uuid;primary_key;default:uuid_generate_v4()" json:"id"`
+	Trigger     string     `gorm:"type:varchar(30);not null" json:"trigger"`
+	Population  int        `gorm:"not null" json:"population"`
+	ScorePSI    float64    `gorm:"type:decimal(10,4);not null" json:"score_psi"`
+	DriftStatus string     `gorm:"type:varchar(30);not null" json:"drift_status"`
+	IsBaseline  bool       `gorm:"not null;default:false;index" json:"is_baseline"`
+	BaselineID  *uuid.UUID `gorm:"type:uuid" json:"baseline_id"`
+	Payload     string     `gorm:"type:text;not null" json:"-"`
+	CreatedAt   time.Time  `gorm:"autoCreateTime;index" json:"created_at"`
+}
+
+func (b *ScoreMonitoringReport) BeforeCreate(tx *gorm.DB) (err error) {
+	b.ID = uuid.New()
+	return
+}
+
+// ScoreMonitoringSubject is a user's latest scored snapshot together with the
+// attributes used to group users into cohorts. It is read-only and not
+// migrated.
+type ScoreMonitoringSubject struct {
+	UserID            uuid.UUID
+	Score             float64
+	PaymentBehavior   float64
+	IncomeStability   float64
+	CashFlow          float64
+	TransactionHabits float64
+	CreditHistory     float64
+	SignedUpAt        *time.Time
+	Region            string
+	DocumentType      string
+}
+
+// ScoreMonitoringDecision is a decided loan request with the borrower's score
+// at the time of application. Score is nil when the borrower had not been
+// scored yet.
+type ScoreMonitoringDecision struct {
+	LoanRequestID uuid.UUID
+	Status        string
+	Score         *float64
+}
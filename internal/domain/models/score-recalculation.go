@@ -0,0 +1,45 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type ScoreRecalculationRun struct {
+	ID         uuid.UUID  `gorm:"type:uuid;primary_key;default:uuid_generate_v4()" json:"id"`
+	Trigger    string     `gorm:"type:varchar(30);not null" json:"trigger"`
+	Status     string     `gorm:"type:varchar(30);not null" json:"status"`
+	TotalUsers int        `gorm:"not null" json:"total_users"`
+	Processed  int        `gorm:"not null;default:0" json:"processed"`
+	Failed     int        `gorm:"not null;default:0" json:"failed"`
+	Alerts     int        `gorm:"not null;default:0" json:"alerts"`
+	LastError  string     `gorm:"type:text" json:"last_error"`
+	StartedAt  time.Time  `gorm:"not null;index" json:"started_at"`
+	FinishedAt *time.Time `json:"finished_at"`
+}
+
+func (b *ScoreRecalculationRun) BeforeCreate(tx *gorm.DB) (err error) {
+	b.ID = uuid.New()
+	return
+}
+
+type CreditScoreAlert struct {
+	ID            uuid.UUID `gorm:"type:uuid;primary_key;default:uuid_generate_v4()" json:"id"`
+	PreviousScore float64   `gorm:"type:decimal(10,2);not null" json:"previous_score"`
+	NewScore      float64   `gorm:"type:decimal(10,2);not null" json:"new_score"`
+	Change        float64   `gorm:"type:decimal(10,2);not null" json:"change"`
+	CreatedAt     time.Time `gorm:"autoCreateTime;index" json:"created_at"`
+
+	SnapshotID uuid.UUID           `gorm:"type:uuid;not null" json:"snapshot_id"`
+	Snapshot   CreditScoreSnapshot `gorm:"foreignKey:SnapshotID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;" json:"-"`
+
+	UserID uuid.UUID `gorm:"type:uuid;not null;index" json:"user_id"`
+	User   User      `gorm:"foreignKey:UserID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;" json:"-"`
+}
+
+func (b *CreditScoreAlert) BeforeCreate(tx *gorm.DB) (err error) {
+	b.ID = uuid.New()
+	return
+}
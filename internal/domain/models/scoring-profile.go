@@ -0,0 +1,33 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type ScoringProfile struct {
+	ID   uuid.UUID `gorm:"type:uuid;primary_key;default:uuid_generate_v4()" json:"id"`
+	Name string    `gorm:"type:varchar(100);unique;not null" json:"name"`
+
+	PaymentHistoryWeight    float64 `gorm:"type:decimal(5,4);not null" json:"payment_history_weight"`
+	IncomeStabilityWeight   float64 `gorm:"type:decimal(5,4);not null" json:"income_stability_weight"`
+	CashFlowWeight          float64 `gorm:"type:decimal(5,4);not null" json:"cash_flow_weight"`
+	TransactionHabitsWeight float64 `gorm:"type:decimal(5,4);not null" json:"transaction_habits_weight"`
+	CreditHistoryWeight     float64 `gorm:"type:decimal(5,4);not null" json:"credit_history_weight"`
+
+	ScoreMin float64 `gorm:"type:decimal(10,2);not null" json:"score_min"`
+	ScoreMax float64 `gorm:"type:decimal(10,2);not null" json:"score_max"`
+
+	// IsActive is set on one profile at most, which a partial unique index
+	// created by the data migrations enforces.
+	IsActive  bool      `gorm:"default:false;not null" json:"is_active"`
+	CreatedAt time.Time `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt time.Time `gorm:"autoUpdateTime" json:"updated_at"`
+}
+
+func (b *ScoringProfile) BeforeCreate(tx *gorm.DB) (err error) {
+	b.ID = uuid.New()
+	return
+}
@@ -0,0 +1,59 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// StatementImport is a statement queued for ingestion. It holds everything a
+// worker needs to pick the job up again after a restart.
+type StatementImport struct {
+	ID               uuid.UUID  `gorm:"type:uuid;primary_key;default:uuid_generate_v4()" json:"id"`
+	Status           string     `gorm:"type:varchar(30);not null;index" json:"status"`
+	Stage            string     `gorm:"type:varchar(30);not null" json:"stage"`
+	SourceURL        string     `gorm:"type:text" json:"source_url,omitempty"`
+	StorageKey       string     `gorm:"type:varchar(255)" json:"-"`
+	Filename         string     `gorm:"type:varchar(255)" json:"filename"`
+	MIMEType         string     `gorm:"type:varchar(100)" json:"mime_type"`
+	Size             int64      `gorm:"not null;default:0" json:"size"`
+	Provider         string     `gorm:"type:varchar(50)" json:"provider"`
+	ExtractionSource string     `gorm:"type:varchar(20)" json:"extraction_source"`
+	TotalRows        int        `gorm:"not null;default:0" json:"total_rows"`
+	ImportedRows     int        `gorm:"not null;default:0" json:"imported_rows"`
+	RejectedRows     int        `gorm:"not null;default:0" json:"rejected_rows"`
+	DuplicateRows    int        `gorm:"not null;default:0" json:"duplicate_rows"`
+	Attempts         int        `gorm:"not null;default:0" json:"attempts"`
+	Error            string     `gorm:"type:text" json:"error,omitempty"`
+	CreatedAt        time.Time  `gorm:"autoCreateTime;index" json:"created_at"`
+	UpdatedAt        time.Time  `gorm:"autoUpdateTime" json:"updated_at"`
+	FinishedAt       *time.Time `json:"finished_at"`
+
+	UserID uuid.UUID `gorm:"type:uuid;not null;index" json:"user_id"`
+	User   User      `gorm:"foreignKey:UserID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;" json:"-"`
+}
+
+func (b *StatementImport) BeforeCreate(tx *gorm.DB) (err error) {
+	b.ID = uuid.New()
+	return
+}
+
+// StatementImportRejection is a row of an imported statement that was not
+// stored, with the reason it was turned away.
+type StatementImportRejection struct {
+	ID        uuid.UUID `gorm:"type:uuid;primary_key;default:uuid_generate_v4()" json:"id"`
+	RowNumber int       `gorm:"not null" json:"row"`
+	Reason    string    `gorm:"type:text;not null" json:"reason"`
+	// Payload is the row as it was extracted, encoded as JSON.
+	Payload   string    `gorm:"type:text" json:"payload"`
+	CreatedAt time.Time `gorm:"autoCreateTime" json:"created_at"`
+
+	ImportID uuid.UUID       `gorm:"type:uuid;not null;index" json:"import_id"`
+	Import   StatementImport `gorm:"foreignKey:ImportID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;" json:"-"`
+}
+
+func (b *StatementImportRejection) BeforeCreate(tx *gorm.DB) (err error) {
+	b.ID = uuid.New()
+	return
+}
@@ -0,0 +1,38 @@
+package models
+
+import "time"
+
+// CashFlowPeriod is the money a user moved in one week or month. It is
+// read-only and not migrated.
+type CashFlowPeriod struct {
+	Period           time.Time
+	Inflow           float64
+	Outflow          float64
+	Fees             float64
+	ELevy            float64
+	TransactionCount int
+	// AverageBalance is the mean balance after the transactions that printed
+	// one, of which there were BalanceCount. It is nil when none did.
+	AverageBalance *float64
+	BalanceCount   int
+}
+
+// CounterpartyTotal is the money a user exchanged with one counterparty. It
+// is read-only and not migrated.
+type CounterpartyTotal struct {
+	Name             string
+	Number           string
+	Inflow           float64
+	Outflow          float64
+	TransactionCount int
+}
+
+// CategoryTotal is the money a user moved in one category during a week or
+// month. It is read-only and not migrated.
+type CategoryTotal struct {
+	Period           time.Time
+	Category         string
+	Inflow           float64
+	Outflow          float64
+	TransactionCount int
+}
@@ -0,0 +1,78 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// TransactionTag is a label the user gave one of their transactions, such as
+// "business" or "family support". Names are kept in lower case so that the
+// same tag is not counted twice under different spellings.
+type TransactionTag struct {
+	ID        uuid.UUID `gorm:"type:uuid;primary_key;default:uuid_generate_v4()" json:"id"`
+	Name      string    `gorm:"type:varchar(50);not null;uniqueIndex:idx_transaction_tags_name,priority:2;index:idx_transaction_tags_user_name,priority:2" json:"name"`
+	CreatedAt time.Time `gorm:"autoCreateTime" json:"created_at"`
+
+	TransactionID uuid.UUID    `gorm:"type:uuid;not null;uniqueIndex:idx_transaction_tags_name,priority:1" json:"transaction_id"`
+	Transaction   *Transaction `gorm:"foreignKey:TransactionID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;" json:"-"`
+
+	UserID uuid.UUID `gorm:"type:uuid;not null;index:idx_transaction_tags_user_name,priority:1" json:"user_id"`
+	User   User      `gorm:"foreignKey:UserID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;" json:"-"`
+}
+
+func (b *TransactionTag) BeforeCreate(tx *gorm.DB) (err error) {
+	b.ID = uuid.New()
+	return
+}
+
+// TransactionNote is a free-text note the user wrote on one of their
+// transactions.
+type TransactionNote struct {
+	ID        uuid.UUID `gorm:"type:uuid;primary_key;default:uuid_generate_v4()" json:"id"`
+	Body      string    `gorm:"type:text;not null" json:"body"`
+	CreatedAt time.Time `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt time.Time `gorm:"autoUpdateTime" json:"updated_at"`
+
+	TransactionID uuid.UUID    `gorm:"type:uuid;not null;index" json:"transaction_id"`
+	Transaction   *Transaction `gorm:"foreignKey:TransactionID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;" json:"-"`
+
+	UserID uuid.UUID `gorm:"type:uuid;not null;index" json:"user_id"`
+	User   User      `gorm:"foreignKey:UserID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;" json:"-"`
+}
+
+func (b *TransactionNote) BeforeCreate(tx *gorm.DB) (err error) {
+	b.ID = uuid.New()
+	return
+}
+
+// TransactionAttachment links a file the user attached to a transaction,
+// such as a receipt, to the document it is stored as.
+type TransactionAttachment struct {
+	ID        uuid.UUID `gorm:"type:uuid;primary_key;default:uuid_generate_v4()" json:"id"`
+	CreatedAt time.Time `gorm:"autoCreateTime" json:"created_at"`
+
+	TransactionID uuid.UUID    `gorm:"type:uuid;not null;index" json:"transaction_id"`
+	Transaction   *Transaction `gorm:"foreignKey:TransactionID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;" json:"-"`
+
+	DocumentID uuid.UUID `gorm:"type:uuid;not null;uniqueIndex" json:"document_id"`
+	Document   Document  `gorm:"foreignKey:DocumentID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;" json:"document"`
+
+	UserID uuid.UUID `gorm:"type:uuid;not null;index" json:"user_id"`
+	User   User      `gorm:"foreignKey:UserID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;" json:"-"`
+}
+
+func (b *TransactionAttachment) BeforeCreate(tx *gorm.DB) (err error) {
+	b.ID = uuid.New()
+	return
+}
+
+// TagTotal is what moved through the transactions carrying one of a user's
+// tags. It is read-only and not migrated.
+type TagTotal struct {
+	Name             string
+	Inflow           float64
+	Outflow          float64
+	TransactionCount int
+}
@@ -0,0 +1,41 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// TransactionFlag is an anomaly found in a user's transactions, queued for an
+// admin to review. Flags raised on wallet movements have no transaction, so
+// the amount and time of the movement are kept on the flag.
+type TransactionFlag struct {
+	ID         uuid.UUID  `gorm:"type:uuid;primary_key;default:uuid_generate_v4()" json:"id"`
+	Kind       string     `gorm:"type:varchar(30);not null;uniqueIndex:idx_transaction_flags_kind,priority:1" json:"kind"`
+	Severity   string     `gorm:"type:varchar(10);not null;index" json:"severity"`
+	Reason     string     `gorm:"type:text;not null" json:"reason"`
+	Source     string     `gorm:"type:varchar(20);not null" json:"source"`
+	Amount     float64    `gorm:"type:decimal(20,2);not null" json:"amount"`
+	OccurredAt time.Time  `gorm:"type:timestamp;not null" json:"occurred_at"`
+	Status     string     `gorm:"type:varchar(20);not null;index" json:"status"`
+	ReviewNote string     `gorm:"type:text" json:"review_note,omitempty"`
+	ReviewedAt *time.Time `json:"reviewed_at"`
+	CreatedAt  time.Time  `gorm:"autoCreateTime;index" json:"created_at"`
+
+	// A transaction is flagged at most once for each kind of anomaly, so
+	// scanning the same transactions again raises no new flags.
+	TransactionID *uuid.UUID   `gorm:"type:uuid;uniqueIndex:idx_transaction_flags_kind,priority:2" json:"transaction_id"`
+	Transaction   *Transaction `gorm:"foreignKey:TransactionID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;" json:"-"`
+
+	ReviewedByID *uuid.UUID `gorm:"type:uuid" json:"reviewed_by_id"`
+	ReviewedBy   *User      `gorm:"foreignKey:ReviewedByID;constraint:OnUpdate:CASCADE,OnDelete:SET NULL;" json:"-"`
+
+	UserID uuid.UUID `gorm:"type:uuid;not null;index" json:"user_id"`
+	User   User      `gorm:"foreignKey:UserID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;" json:"-"`
+}
+
+func (b *TransactionFlag) BeforeCreate(tx *gorm.DB) (err error) {
+	b.ID = uuid.New()
+	return
+}
@@ -1,6 +1,11 @@
 package models
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
+	"math"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
@@ -9,7 +14,7 @@ import (
 
 type Transaction struct {
 	ID              uuid.UUID `gorm:"type:uuid;primaryKey;default:uuid_generate_v4()" json:"id"`
-	TransactionDate time.Time `gorm:"type:timestamp;not null" json:"transaction_date"`
+	TransactionDate time.Time `gorm:"type:timestamp;not null;index:idx_transactions_user_date,priority:2" json:"transaction_date"`
 	FromAccount     string    `gorm:"type:varchar(255);not null" json:"from_account"`
 	FromName        string    `gorm:"type:varchar(255);not null" json:"from_name"`
 	FromNumber      string    `gorm:"type:varchar(255);not null" json:"from_number"`
@@ -17,18 +22,72 @@ type Transaction struct {
 	Amount          float64   `gorm:"type:decimal(20,2);not null" json:"amount"`
 	Fees            float64   `gorm:"type:decimal(20,2);not null" json:"fees"`
 	ELevy           float64   `gorm:"type:decimal(20,2);not null" json:"e_levy"`
-	BalanceBefore   float64   `gorm:"type:decimal(20,2);not null" json:"balance_before"`
-	BalanceAfter    float64   `gorm:"type:decimal(20,2);not null" json:"balance_after"`
-	ToNumber        string    `gorm:"type:varchar(255);not null" json:"to_number"`
-	ToName          string    `gorm:"type:varchar(255);not null" json:"to_name"`
-	ToAccount       string    `gorm:"type:varchar(255);not null" json:"to_account"`
-	Reference       string    `gorm:"type:varchar(255)" json:"reference"`
-
-	UserID uuid.UUID `gorm:"type:uuid;not null" json:"user_id"`
+	// ExpectedFees and ExpectedELevy are the charges the rate tables give
+	// for the transaction, or nil when no table applied.
+	ExpectedFees   *float64  `gorm:"type:decimal(20,2)" json:"expected_fees"`
+	ExpectedELevy  *float64  `gorm:"type:decimal(20,2)" json:"expected_e_levy"`
+	BalanceBefore  float64   `gorm:"type:decimal(20,2);not null" json:"balance_before"`
+	BalanceAfter   float64   `gorm:"type:decimal(20,2);not null" json:"balance_after"`
+	ToNumber       string    `gorm:"type:varchar(255);not null" json:"to_number"`
+	ToName         string    `gorm:"type:varchar(255);not null" json:"to_name"`
+	ToAccount      string    `gorm:"type:varchar(255);not null" json:"to_account"`
+	Reference      string    `gorm:"type:varchar(255)" json:"reference"`
+	Description    string    `gorm:"type:varchar(500)" json:"description"`
+	Provider       string    `gorm:"type:varchar(50);index" json:"provider"`
+	Fingerprint    string    `gorm:"type:varchar(64);uniqueIndex:idx_transactions_user_fingerprint,where:fingerprint <> ''" json:"fingerprint"`
+	Category       string    `gorm:"type:varchar(30);index" json:"category"`
+	CategorySource string    `gorm:"type:varchar(10)" json:"category_source"`
+	CreatedAt      time.Time `gorm:"autoCreateTime;index" json:"created_at"`
+
+	UserID uuid.UUID `gorm:"type:uuid;not null;uniqueIndex:idx_transactions_user_fingerprint;index:idx_transactions_user_date,priority:1" json:"user_id"`
 	User   User      `gorm:"foreignKey:UserID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;" json:"user"`
+
+	StatementImportID *uuid.UUID `gorm:"type:uuid;index" json:"statement_import_id"`
+
+	CounterpartyID *uuid.UUID    `gorm:"type:uuid;index" json:"counterparty_id"`
+	Counterparty   *Counterparty `gorm:"foreignKey:CounterpartyID;constraint:OnUpdate:CASCADE,OnDelete:SET NULL;" json:"-"`
+
+	Tags []TransactionTag `gorm:"foreignKey:TransactionID" json:"tags,omitempty"`
 }
 
 func (b *Transaction) BeforeCreate(tx *gorm.DB) (err error) {
 	b.ID = uuid.New()
+	if b.Fingerprint == "" {
+		b.Fingerprint = b.ComputeFingerprint()
+	}
+	return
+}
+
+// BeforeUpdate fingerprints a transaction again when it is saved whole, as
+// any of the fields the fingerprint is made from may have been edited.
+// Updates of single columns leave it as it is.
+func (b *Transaction) BeforeUpdate(tx *gorm.DB) (err error) {
+	if _, ok := tx.Statement.Dest.(*Transaction); ok && b.ID != uuid.Nil {
+		b.Fingerprint = b.ComputeFingerprint()
+	}
 	return
 }
+
+// ComputeFingerprint identifies a transaction independently of the statement
+// it was read from, so that the same transaction in two overlapping
+// statements is only stored once. Names are compared without regard to case
+// or spacing, and amounts to the cent.
+func (b *Transaction) ComputeFingerprint() string {
+	fields := []string{
+		normalizeFingerprintText(b.Reference),
+		b.TransactionDate.UTC().Format(time.RFC3339),
+		strconv.FormatInt(int64(math.Round(b.Amount*100)), 10),
+		normalizeFingerprintText(b.FromNumber),
+		normalizeFingerprintText(b.FromName),
+		normalizeFingerprintText(b.ToNumber),
+		normalizeFingerprintText(b.ToName),
+		strconv.FormatInt(int64(math.Round(b.BalanceAfter*100)), 10),
+	}
+
+	sum := sha256.Sum256([]byte(strings.Join(fields, "\x1f")))
+	return hex.EncodeToString(sum[:])
+}
+
+func normalizeFingerprintText(value string) string {
+	return strings.ToUpper(strings.Join(strings.Fields(value), " "))
+}
@@ -1,6 +1,8 @@
 package models
 
 import (
+	"time"
+
 	"github.com/google/uuid"
 	"gorm.io/gorm"
 )
@@ -13,6 +15,7 @@ type User struct {
 	Email       string `gorm:"size:100;unique;not null" json:"email"`
 	UserRole    string `gorm:"type:varchar(20);default:'common'" json:"user_role"`
 	PhoneNumber string `gorm:"column:phone_number;unique;not null" json:"phone_number,omitempty"`
+	Region      string `gorm:"type:varchar(100)" json:"region,omitempty"`
 
 	// EmailVerified     bool   `gorm:"default:false" json:"is_verified"`
 	// VerificationToken string `gorm:"size:100" json:"verification_token"`
@@ -24,6 +27,7 @@ type User struct {
 	Accounts     []Account     `gorm:"foreignKey:UserID;constraint:OnUpdate:CASCADE,OnDelete:SET NULL;" json:"accounts"`
 	LoanRequests []LoanRequest `gorm:"foreignKey:BorrowerID;constraint:OnUpdate:CASCADE,OnDelete:SET NULL;" json:"loan_requests"`
 	CreditScore  int64         `gorm:"default:0;not null" json:"credit_score"`
+	CreatedAt    time.Time     `gorm:"autoCreateTime" json:"created_at"`
 }
 
 func (b *User) BeforeCreate(tx *gorm.DB) (err error) {
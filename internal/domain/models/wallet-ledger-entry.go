@@ -0,0 +1,28 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// WalletLedgerEntry records a movement of a wallet's balance: the amount
+// moved, the fee charged on it and the balance it left.
+type WalletLedgerEntry struct {
+	ID           uuid.UUID `gorm:"type:uuid;primaryKey;default:uuid_generate_v4()" json:"id"`
+	Type         string    `gorm:"type:varchar(20);not null" json:"type"`
+	Amount       int64     `gorm:"not null" json:"amount"`
+	Fee          int64     `gorm:"not null;default:0" json:"fee"`
+	BalanceAfter int64     `gorm:"not null" json:"balance_after"`
+	CreatedAt    time.Time `gorm:"autoCreateTime;index" json:"created_at"`
+
+	WalletID uuid.UUID `gorm:"type:uuid;not null;index" json:"wallet_id"`
+	Wallet   Wallet    `gorm:"foreignKey:WalletID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;" json:"-"`
+	UserID   uuid.UUID `gorm:"type:uuid;not null;index" json:"user_id"`
+}
+
+func (e *WalletLedgerEntry) BeforeCreate(tx *gorm.DB) (err error) {
+	e.ID = uuid.New()
+	return
+}
@@ -0,0 +1,68 @@
+package schemas
+
+import "time"
+
+// AnalyticsRequest is read from the query string of the analytics endpoints.
+// Period is week or month. From and To are RFC 3339 times or dates, both
+// inclusive, and default to the last twelve months.
+type AnalyticsRequest struct {
+	Period string `form:"period"`
+	From   string `form:"from"`
+	To     string `form:"to"`
+	Limit  int    `form:"limit"`
+}
+
+type CashFlowPeriod struct {
+	Period           string    `json:"period"`
+	Start            time.Time `json:"start"`
+	Inflow           float64   `json:"inflow"`
+	Outflow          float64   `json:"outflow"`
+	Fees             float64   `json:"fees"`
+	ELevy            float64   `json:"e_levy"`
+	NetCashFlow      float64   `json:"net_cash_flow"`
+	TransactionCount int       `json:"transaction_count"`
+	AverageBalance   *float64  `json:"average_balance"`
+}
+
+type CashFlowResponse struct {
+	Period  string           `json:"period"`
+	From    time.Time        `json:"from"`
+	To      time.Time        `json:"to"`
+	Periods []CashFlowPeriod `json:"periods"`
+	Totals  CashFlowPeriod   `json:"totals"`
+}
+
+type CounterpartyTotal struct {
+	Name             string  `json:"name"`
+	Number           string  `json:"number"`
+	Inflow           float64 `json:"inflow"`
+	Outflow          float64 `json:"outflow"`
+	TransactionCount int     `json:"transaction_count"`
+}
+
+type CounterpartiesResponse struct {
+	From           time.Time           `json:"from"`
+	To             time.Time           `json:"to"`
+	Counterparties []CounterpartyTotal `json:"counterparties"`
+}
+
+type CategoryTotal struct {
+	Category         string  `json:"category"`
+	Inflow           float64 `json:"inflow"`
+	Outflow          float64 `json:"outflow"`
+	TransactionCount int     `json:"transaction_count"`
+}
+
+type CategoryPeriod struct {
+	Period     string          `json:"period"`
+	Start      time.Time       `json:"start"`
+	Categories []CategoryTotal `json:"categories"`
+}
+
+type CategoryBreakdownResponse struct {
+	Period  string           `json:"period"`
+	From    time.Time        `json:"from"`
+	To      time.Time        `json:"to"`
+	Periods []CategoryPeriod `json:"periods"`
+	Totals  []CategoryTotal  `json:"totals"`
+}
@@ -0,0 +1,55 @@
+package schemas
+
+import "time"
+
+// CreateBudgetRequest sets a monthly budget for a category. Thresholds are
+// the percentages of Amount at which the user is alerted, 80 and 100 unless
+// others are given.
+type CreateBudgetRequest struct {
+	Category   string  `json:"category" binding:"required"`
+	Amount     float64 `json:"amount" binding:"required,gt=0"`
+	Thresholds []int   `json:"thresholds"`
+}
+
+// UpdateBudgetRequest changes the fields that are given.
+type UpdateBudgetRequest struct {
+	Amount     *float64 `json:"amount" binding:"omitempty,gt=0"`
+	Thresholds []int    `json:"thresholds"`
+}
+
+// BudgetProgressRequest picks the month, as YYYY-MM, to report budget
+// progress for. The current month is the default.
+type BudgetProgressRequest struct {
+	Month string `form:"month"`
+}
+
+const (
+	BudgetStatusOnTrack  = "on_track"
+	BudgetStatusAtRisk   = "at_risk"
+	BudgetStatusExceeded = "exceeded"
+)
+
+// BudgetResponse is a budget with the spending against it in one month.
+// Status is at_risk once a threshold below 100% is reached, and exceeded
+// once the spending is over the budget.
+type BudgetResponse struct {
+	ID          string                `json:"id"`
+	Category    string                `json:"category"`
+	Amount      float64               `json:"amount"`
+	Thresholds  []int                 `json:"thresholds"`
+	Month       string                `json:"month"`
+	Spent       float64               `json:"spent"`
+	Remaining   float64               `json:"remaining"`
+	PercentUsed float64               `json:"percent_used"`
+	Status      string                `json:"status"`
+	Alerts      []BudgetAlertResponse `json:"alerts"`
+	CreatedAt   time.Time             `json:"created_at"`
+	UpdatedAt   time.Time             `json:"updated_at"`
+}
+
+type BudgetAlertResponse struct {
+	Threshold int       `json:"threshold"`
+	Spent     float64   `json:"spent"`
+	Amount    float64   `json:"amount"`
+	CreatedAt time.Time `json:"created_at"`
+}
@@ -0,0 +1,29 @@
+package schemas
+
+import "time"
+
+type CategoryRuleResponse struct {
+	ID        string    `json:"id"`
+	Match     string    `json:"match"`
+	Pattern   string    `json:"pattern"`
+	Category  string    `json:"category"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+type CategoriesResponse struct {
+	Categories []string               `json:"categories"`
+	Rules      []CategoryRuleResponse `json:"rules"`
+}
+
+type UpdateCategoryRequest struct {
+	Category string `json:"category" binding:"required"`
+}
+
+// CategoryCorrectionResponse is the corrected transaction, the rule learnt
+// from it, if any, and how many of the user's other transactions the rule
+// moved into the same category.
+type CategoryCorrectionResponse struct {
+	Transaction   TransactionResponse   `json:"transaction"`
+	Rule          *CategoryRuleResponse `json:"rule"`
+	Recategorized int                   `json:"recategorized"`
+}
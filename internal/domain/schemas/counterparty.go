@@ -0,0 +1,29 @@
+package schemas
+
+import "time"
+
+// CounterpartyListRequest pages through a user's counterparties. Query
+// matches part of the name or number, and Sort is total, count, last_seen,
+// first_seen or name.
+type CounterpartyListRequest struct {
+	Query    string `form:"q"`
+	Sort     string `form:"sort"`
+	Page     int    `form:"page"`
+	PageSize int    `form:"page_size"`
+}
+
+type CounterpartyResponse struct {
+	ID               string  `json:"id"`
+	Name             string  `json:"name"`
+	PhoneNumber      string  `json:"phone_number,omitempty"`
+	Identifier       string  `json:"identifier,omitempty"`
+	Inflow           float64 `json:"inflow"`
+	Outflow          float64 `json:"outflow"`
+	Total            float64 `json:"total"`
+	TransactionCount int     `json:"transaction_count"`
+	// MonthlyFrequency is the number of transactions a month between the
+	// first and the last.
+	MonthlyFrequency float64   `json:"monthly_frequency"`
+	FirstSeen        time.Time `json:"first_seen"`
+	LastSeen         time.Time `json:"last_seen"`
+}
@@ -0,0 +1,83 @@
+package schemas
+
+import "time"
+
+type CreditReportSubject struct {
+	UserID      string `json:"user_id"`
+	Username    string `json:"username"`
+	Email       string `json:"email"`
+	PhoneNumber string `json:"phone_number"`
+}
+
+type CreditReportScoreComponent struct {
+	Feature    string  `json:"feature"`
+	ReasonCode string  `json:"reason_code"`
+	Value      float64 `json:"value"`
+	Points     float64 `json:"points"`
+	Adverse    bool    `json:"adverse"`
+}
+
+type CreditReportScore struct {
+	Status       string                       `json:"status"`
+	Score        float64                      `json:"score"`
+	ScoreMin     float64                      `json:"score_min"`
+	ScoreMax     float64                      `json:"score_max"`
+	ModelVersion string                       `json:"model_version"`
+	ProfileName  string                       `json:"profile_name"`
+	ScoredAt     time.Time                    `json:"scored_at"`
+	ReasonCodes  []string                     `json:"reason_codes"`
+	Breakdown    []CreditReportScoreComponent `json:"breakdown"`
+}
+
+type CreditReportMonth struct {
+	Month            string  `json:"month"`
+	Income           float64 `json:"income"`
+	Expenses         float64 `json:"expenses"`
+	Fees             float64 `json:"fees"`
+	TransactionCount int     `json:"transaction_count"`
+}
+
+type CreditReportLoan struct {
+	ID           string    `json:"id"`
+	Amount       int64     `json:"amount"`
+	InterestRate float64   `json:"interest_rate"`
+	LoanDuration int       `json:"loan_duration"`
+	Purpose      string    `json:"purpose"`
+	Status       string    `json:"status"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+type CreditReportPayload struct {
+	ReportID  string              `json:"report_id"`
+	IssuedAt  time.Time           `json:"issued_at"`
+	ExpiresAt time.Time           `json:"expires_at"`
+	Subject   CreditReportSubject `json:"subject"`
+	Score     CreditReportScore   `json:"score"`
+	Monthly   []CreditReportMonth `json:"monthly_summary"`
+	Loans     []CreditReportLoan  `json:"loan_history"`
+}
+
+// SignedCreditReport is the portable, machine-readable report. Payload is the
+// base64 encoding of the exact bytes that were signed, a JSON encoding of a
+// CreditReportPayload; the digest is their SHA-256 and the signature their
+// Ed25519 signature. Report is the same payload decoded for reading, and is
+// not what is verified.
+type SignedCreditReport struct {
+	Report    CreditReportPayload `json:"report"`
+	Payload   string              `json:"payload"`
+	Algorithm string              `json:"algorithm"`
+	Digest    string              `json:"digest"`
+	Signature string              `json:"signature"`
+	PublicKey string              `json:"public_key"`
+}
+
+type CreditReportVerification struct {
+	Valid     bool       `json:"valid"`
+	Reason    string     `json:"reason,omitempty"`
+	ReportID  string     `json:"report_id"`
+	Username  string     `json:"username,omitempty"`
+	Score     float64    `json:"score,omitempty"`
+	Digest    string     `json:"digest,omitempty"`
+	IssuedAt  *time.Time `json:"issued_at,omitempty"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+}
@@ -0,0 +1,46 @@
+package schemas
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type CreditScoreSnapshotResponse struct {
+	ID                string     `json:"id"`
+	Status            string     `json:"status"`
+	Score             float64    `json:"score"`
+	ModelVersion      string     `json:"model_version"`
+	ProfileID         *uuid.UUID `json:"profile_id"`
+	ProfileName       string     `json:"profile_name"`
+	PaymentBehavior   float64    `json:"payment_behavior"`
+	IncomeStability   float64    `json:"income_stability"`
+	CashFlow          float64    `json:"cash_flow"`
+	TransactionHabits float64    `json:"transaction_habits"`
+	CreditHistory     float64    `json:"credit_history"`
+	WindowStart       *time.Time `json:"window_start"`
+	WindowEnd         *time.Time `json:"window_end"`
+	TransactionCount  int        `json:"transaction_count"`
+	Change            float64    `json:"change"`
+	CreatedAt         time.Time  `json:"created_at"`
+}
+
+type CreditScoreTrend struct {
+	Direction   string  `json:"direction"`
+	FirstScore  float64 `json:"first_score"`
+	LatestScore float64 `json:"latest_score"`
+	Change      float64 `json:"change"`
+	MinScore    float64 `json:"min_score"`
+	MaxScore    float64 `json:"max_score"`
+}
+
+type CreditScoreHistoryResponse struct {
+	Snapshots []CreditScoreSnapshotResponse `json:"snapshots"`
+	Trend     *CreditScoreTrend             `json:"trend"`
+}
+
+type CreditScoreSimulationDetails struct {
+	ExtraMonthlyIncome     float64  `json:"extra_monthly_income" binding:"gte=0"`
+	MonthlySavingsTransfer float64  `json:"monthly_savings_transfer" binding:"gte=0"`
+	RemovePayees           []string `json:"remove_payees"`
+}
@@ -0,0 +1,7 @@
+package schemas
+
+type NotificationListRequest struct {
+	Unread   bool `form:"unread"`
+	Page     int  `form:"page"`
+	PageSize int  `form:"page_size"`
+}
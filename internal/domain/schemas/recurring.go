@@ -0,0 +1,29 @@
+package schemas
+
+import "time"
+
+type RecurringSeriesResponse struct {
+	Counterparty     string    `json:"counterparty"`
+	Number           string    `json:"number,omitempty"`
+	Category         string    `json:"category,omitempty"`
+	Period           string    `json:"period"`
+	ExpectedAmount   float64   `json:"expected_amount"`
+	MonthlyAmount    float64   `json:"monthly_amount"`
+	Occurrences      int       `json:"occurrences"`
+	Missed           int       `json:"missed"`
+	Punctuality      float64   `json:"punctuality"`
+	FirstDate        time.Time `json:"first_date"`
+	LastDate         time.Time `json:"last_date"`
+	NextExpectedDate time.Time `json:"next_expected_date"`
+	Confidence       float64   `json:"confidence"`
+	Active           bool      `json:"active"`
+}
+
+// RecurringResponse lists the user's recurring income and payments, and what
+// the active ones add up to in a month.
+type RecurringResponse struct {
+	Income          []RecurringSeriesResponse `json:"income"`
+	Payments        []RecurringSeriesResponse `json:"payments"`
+	MonthlyIncome   float64                   `json:"monthly_income"`
+	MonthlyPayments float64                   `json:"monthly_payments"`
+}
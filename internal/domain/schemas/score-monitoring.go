@@ -0,0 +1,60 @@
+package schemas
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type DistributionBin struct {
+	Label string  `json:"label"`
+	Lower float64 `json:"lower"`
+	Upper float64 `json:"upper"`
+	Count int     `json:"count"`
+	Share float64 `json:"share"`
+}
+
+// DistributionDrift is the current distribution of a score or feature and its
+// population stability index against the baseline report.
+type DistributionDrift struct {
+	Name   string            `json:"name"`
+	Mean   float64           `json:"mean"`
+	PSI    float64           `json:"psi"`
+	Status string            `json:"status"`
+	Bins   []DistributionBin `json:"bins"`
+}
+
+type CohortScore struct {
+	Cohort    string  `json:"cohort"`
+	Users     int     `json:"users"`
+	MeanScore float64 `json:"mean_score"`
+}
+
+type ScoreCohorts struct {
+	SignupMonth  []CohortScore `json:"signup_month"`
+	DocumentType []CohortScore `json:"document_type"`
+	Region       []CohortScore `json:"region"`
+}
+
+type BandApprovalRate struct {
+	Label        string  `json:"label"`
+	MinScore     float64 `json:"min_score"`
+	MaxScore     float64 `json:"max_score"`
+	Decided      int     `json:"decided"`
+	Approved     int     `json:"approved"`
+	ApprovalRate float64 `json:"approval_rate"`
+}
+
+type ScoreMonitoringReport struct {
+	ID                *uuid.UUID          `json:"id,omitempty"`
+	Trigger           string              `json:"trigger,omitempty"`
+	IsBaseline        bool                `json:"is_baseline"`
+	BaselineID        *uuid.UUID          `json:"baseline_id"`
+	GeneratedAt       time.Time           `json:"generated_at"`
+	Population        int                 `json:"population"`
+	Score             DistributionDrift   `json:"score"`
+	Features          []DistributionDrift `json:"features"`
+	Cohorts           ScoreCohorts        `json:"cohorts"`
+	ApprovalRates     []BandApprovalRate  `json:"approval_rates"`
+	UnscoredDecisions int                 `json:"unscored_decisions"`
+}
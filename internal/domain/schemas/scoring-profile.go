@@ -0,0 +1,12 @@
+package schemas
+
+type CreateScoringProfileDetails struct {
+	Name                    string  `json:"name" binding:"required,max=100"`
+	PaymentHistoryWeight    float64 `json:"payment_history_weight"`
+	IncomeStabilityWeight   float64 `json:"income_stability_weight"`
+	CashFlowWeight          float64 `json:"cash_flow_weight"`
+	TransactionHabitsWeight float64 `json:"transaction_habits_weight"`
+	CreditHistoryWeight     float64 `json:"credit_history_weight"`
+	ScoreMin                float64 `json:"score_min"`
+	ScoreMax                float64 `json:"score_max"`
+}
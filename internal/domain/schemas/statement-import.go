@@ -0,0 +1,29 @@
+package schemas
+
+import "time"
+
+type StatementImportRejection struct {
+	Row         int                  `json:"row"`
+	Reason      string               `json:"reason"`
+	Transaction *TransactionResponse `json:"transaction,omitempty"`
+}
+
+type StatementImportResponse struct {
+	ID               string                     `json:"id"`
+	Status           string                     `json:"status"`
+	Stage            string                     `json:"stage"`
+	Filename         string                     `json:"filename"`
+	MIMEType         string                     `json:"mime_type"`
+	SourceURL        string                     `json:"source_url,omitempty"`
+	Provider         string                     `json:"provider"`
+	ExtractionSource string                     `json:"extraction_source"`
+	TotalRows        int                        `json:"total_rows"`
+	ImportedRows     int                        `json:"imported_rows"`
+	RejectedRows     int                        `json:"rejected_rows"`
+	DuplicateRows    int                        `json:"duplicate_rows"`
+	Error            string                     `json:"error,omitempty"`
+	Rejections       []StatementImportRejection `json:"rejections"`
+	CreatedAt        time.Time                  `json:"created_at"`
+	UpdatedAt        time.Time                  `json:"updated_at"`
+	FinishedAt       *time.Time                 `json:"finished_at"`
+}
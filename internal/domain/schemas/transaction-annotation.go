@@ -0,0 +1,46 @@
+package schemas
+
+import "time"
+
+type AddTransactionTagsRequest struct {
+	Tags []string `json:"tags" binding:"required,min=1"`
+}
+
+type TransactionNoteRequest struct {
+	Body string `json:"body" binding:"required"`
+}
+
+type TransactionNoteResponse struct {
+	ID        string    `json:"id"`
+	Body      string    `json:"body"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+type TransactionAttachmentResponse struct {
+	ID         string    `json:"id"`
+	DocumentID string    `json:"document_id"`
+	Filename   string    `json:"filename"`
+	MIMEType   string    `json:"mime_type"`
+	Size       int64     `json:"size"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// TransactionAnnotationsResponse is everything the user added to one of their
+// transactions.
+type TransactionAnnotationsResponse struct {
+	TransactionID string                          `json:"transaction_id"`
+	Tags          []string                        `json:"tags"`
+	Notes         []TransactionNoteResponse       `json:"notes"`
+	Attachments   []TransactionAttachmentResponse `json:"attachments"`
+}
+
+// TagResponse is one of a user's tags with what moved through the
+// transactions carrying it, which tells apart, say, business income from
+// personal income.
+type TagResponse struct {
+	Name             string  `json:"name"`
+	TransactionCount int     `json:"transaction_count"`
+	Inflow           float64 `json:"inflow"`
+	Outflow          float64 `json:"outflow"`
+}
@@ -0,0 +1,36 @@
+package schemas
+
+import "time"
+
+type TransactionFlagResponse struct {
+	ID           string               `json:"id"`
+	Kind         string               `json:"kind"`
+	Severity     string               `json:"severity"`
+	Reason       string               `json:"reason"`
+	Source       string               `json:"source"`
+	Amount       float64              `json:"amount"`
+	OccurredAt   time.Time            `json:"occurred_at"`
+	Status       string               `json:"status"`
+	ReviewNote   string               `json:"review_note,omitempty"`
+	ReviewedAt   *time.Time           `json:"reviewed_at,omitempty"`
+	ReviewedByID string               `json:"reviewed_by_id,omitempty"`
+	UserID       string               `json:"user_id"`
+	Transaction  *TransactionResponse `json:"transaction,omitempty"`
+	CreatedAt    time.Time            `json:"created_at"`
+}
+
+// TransactionFlagListRequest filters the review queue. The queue holds the
+// open flags unless another status, or "all", is asked for.
+type TransactionFlagListRequest struct {
+	Status   string `form:"status"`
+	Severity string `form:"severity"`
+	Kind     string `form:"kind"`
+	UserID   string `form:"user_id"`
+	Page     int    `form:"page"`
+	PageSize int    `form:"page_size"`
+}
+
+type ReviewTransactionFlagRequest struct {
+	Status string `json:"status" binding:"required"`
+	Note   string `json:"note"`
+}
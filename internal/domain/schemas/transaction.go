@@ -3,17 +3,71 @@ package schemas
 import "time"
 
 type TransactionResponse struct {
+	ID              string    `json:"id,omitempty"`
 	TransactionDate time.Time `json:"transaction_date"`
+	Provider        string    `json:"provider"`
+	FromAccount     string    `json:"from_account"`
 	FromName        string    `json:"from_name"`
 	FromNumber      string    `json:"from_number"`
 	TransactionType string    `json:"transaction_type"`
 	BalanceBefore   float64   `json:"balance_before"`
 	BalanceAfter    float64   `json:"balance_after"`
 	Amount          float64   `json:"amount"`
+	Fees            float64   `json:"fees"`
+	ELevy           float64   `json:"e_levy"`
+	ExpectedFees    *float64  `json:"expected_fees,omitempty"`
+	ExpectedELevy   *float64  `json:"expected_e_levy,omitempty"`
+	ToAccount       string    `json:"to_account"`
 	ToNumber        string    `json:"to_number"`
 	ToName          string    `json:"to_name"`
 	Reference       string    `json:"reference"`
-	UserID          string    `json:"user_id"`
+	Description     string    `json:"description"`
+	Category        string    `json:"category,omitempty"`
+	CategorySource  string    `json:"category_source,omitempty"`
+	CounterpartyID  string    `json:"counterparty_id,omitempty"`
+	Tags            []string  `json:"tags,omitempty"`
+	UserID          string    `json:"user_id,omitempty"`
+}
+
+// TransactionSearchRequest is read from the query string of a transaction
+// search. Type and Tag are comma-separated lists of transaction types and of
+// tags, any of which a transaction may match. From and To
+// are RFC 3339 times or dates, both inclusive. Sort is date or amount,
+// prefixed with "-" for descending order, and Cursor is the next_cursor of
+// the previous page.
+type TransactionSearchRequest struct {
+	Type         string   `form:"type"`
+	From         string   `form:"from"`
+	To           string   `form:"to"`
+	MinAmount    *float64 `form:"min_amount"`
+	MaxAmount    *float64 `form:"max_amount"`
+	Counterparty string   `form:"counterparty"`
+	// CounterpartyID selects the transactions linked to one counterparty of
+	// the user's directory.
+	CounterpartyID string `form:"counterparty_id"`
+	Category       string `form:"category"`
+	Tag            string `form:"tag"`
+	Query          string `form:"q"`
+	Sort           string `form:"sort"`
+	Limit          int    `form:"limit"`
+	Cursor         string `form:"cursor"`
+}
+
+type TransactionPage struct {
+	Transactions []TransactionResponse `json:"transactions"`
+	NextCursor   string                `json:"next_cursor,omitempty"`
+	HasMore      bool                  `json:"has_more"`
+}
+
+// TransactionExportRequest takes the filters and sort of a transaction search
+// along with the format, csv, xlsx or ofx, a comma-separated list of columns
+// and an IANA time zone, such as Africa/Accra, that dates are written and
+// from and to dates are read in.
+type TransactionExportRequest struct {
+	TransactionSearchRequest
+	Format   string `form:"format"`
+	Columns  string `form:"columns"`
+	Timezone string `form:"tz"`
 }
 
 // type MoMoTimeFormat struct {
@@ -34,7 +88,8 @@ type TransactionResponse struct {
 // 	return nil
 // }
 
-type MTNMoMoTransactionScrape struct {
+// TransactionScrape is a transaction as the LLM extracts it from a statement.
+type TransactionScrape struct {
 	TransactionDate string  `json:"transaction_date"`
 	FromAccount     string  `json:"from_account"`
 	FromName        string  `json:"from_name"`
@@ -42,6 +97,7 @@ type MTNMoMoTransactionScrape struct {
 	TransactionType string  `json:"transaction_type"`
 	Amount          float64 `json:"amount"`
 	Fees            float64 `json:"fees"`
+	ELevy           float64 `json:"e_levy"`
 	BalanceBefore   float64 `json:"balance_before"`
 	BalanceAfter    float64 `json:"balance_after"`
 	ToNumber        string  `json:"to_number"`
@@ -17,6 +17,7 @@ type CreateAccountDetails struct {
 	Password    string `json:"password" binding:"required,min=8"`
 	Email       string `json:"email" binding:"required,email"`
 	PhoneNumber string `gorm:"column:phone_number;unique;not null" json:"phone_number"`
+	Region      string `json:"region" binding:"max=100"`
 }
 
 // type ForgotPasswordRequest struct {
@@ -3,3 +3,11 @@ package schemas
 type TopUpWalletDetails struct {
 	Amount int64 `gorm:"not null" json:"amount"`
 }
+
+// WalletOperationResponse is the outcome of a top-up or withdrawal. Fee is
+// charged on top of a withdrawal and taken out of a top-up.
+type WalletOperationResponse struct {
+	Amount  int64 `json:"amount"`
+	Fee     int64 `json:"fee"`
+	Balance int64 `json:"balance"`
+}
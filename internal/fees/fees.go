@@ -0,0 +1,313 @@
+// Package fees works out the charges a movement of money is expected to
+// carry: the provider's fee, from versioned rate tables for each provider and
+// transaction type, and the Ghana electronic transfer levy (E-levy), whose
+// rate and exemption changed over time.
+package fees
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"lumon-backend/internal/categories"
+	"lumon-backend/internal/domain/models"
+	"lumon-backend/internal/statements"
+)
+
+// ProviderWallet is the provider name of the tables for Lumon's own wallet.
+const ProviderWallet = "lumon_wallet"
+
+// Tolerance is how far a charge may be from the expected one, to allow for
+// providers rounding differently.
+const Tolerance = 0.011
+
+// Band is the charge for the amounts up to UpTo, or for any amount above the
+// previous band when UpTo is zero. The charge is Flat plus Rate of the amount,
+// kept between Min and Max when they are set.
+type Band struct {
+	UpTo float64 `json:"up_to"`
+	Flat float64 `json:"flat"`
+	Rate float64 `json:"rate"`
+	Min  float64 `json:"min"`
+	Max  float64 `json:"max"`
+}
+
+// Table is a provider's charges for one transaction type. It applies from
+// EffectiveFrom until the next table for the same provider and type.
+type Table struct {
+	Provider      string    `json:"provider"`
+	Type          string    `json:"type"`
+	EffectiveFrom time.Time `json:"effective_from"`
+	Bands         []Band    `json:"bands"`
+}
+
+// Levy is the E-levy as it stood from EffectiveFrom. It is borne by the
+// sender of a transfer; the first DailyExemption the sender transfers each
+// day is free of it.
+type Levy struct {
+	EffectiveFrom  time.Time
+	Rate           float64
+	DailyExemption float64
+}
+
+// ELevy is the history of the levy under the Electronic Transfer Levy Act,
+// 2022 (Act 1075) and its amendments.
+var ELevy = []Levy{
+	{EffectiveFrom: date(2022, time.May, 1), Rate: 0.015, DailyExemption: 100},
+	// The 2023 budget cut the rate and removed the daily exemption.
+	{EffectiveFrom: date(2023, time.January, 1), Rate: 0.01},
+	// Repealed by the Electronic Transfer Levy (Repeal) Act, 2025.
+	{EffectiveFrom: date(2025, time.April, 2), Rate: 0},
+}
+
+// levyTypes are the transaction types the levy is charged on. Payments to
+// merchants, deposits and withdrawals are exempt.
+var levyTypes = map[string]bool{
+	statements.TypeTransfer: true,
+}
+
+// WalletTables are the charges on wallet operations until others are
+// configured: top-ups are free and withdrawals cost 1%, up to 10 cedis.
+var WalletTables = []Table{
+	{
+		Provider:      ProviderWallet,
+		Type:          statements.TypeCashIn,
+		EffectiveFrom: date(2024, time.January, 1),
+		Bands:         []Band{{}},
+	},
+	{
+		Provider:      ProviderWallet,
+		Type:          statements.TypeCashOut,
+		EffectiveFrom: date(2024, time.January, 1),
+		Bands:         []Band{{Rate: 0.01, Max: 10}},
+	},
+}
+
+// LoadTables reads a JSON array of rate tables to add to or replace the
+// built-in ones. Providers' tariffs are not built in: fee-tables.example.json
+// lays out the mobile money tables and must be checked against each
+// provider's published tariff before it is deployed. An empty path means no
+// tables are configured, and fees are then only checked on wallet operations.
+func LoadTables(path string) ([]Table, error) {
+	if path == "" {
+		return nil, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var tables []Table
+	if err := json.Unmarshal(data, &tables); err != nil {
+		return nil, fmt.Errorf("fee tables %s: %w", path, err)
+	}
+	return tables, nil
+}
+
+// Charges are the fee and levy on a movement of money. FeeKnown and
+// LevyKnown report whether a table or levy applied at the time; unknown
+// charges are zero.
+type Charges struct {
+	Fee       float64
+	FeeKnown  bool
+	ELevy     float64
+	LevyKnown bool
+}
+
+type tableKey struct {
+	provider        string
+	transactionType string
+}
+
+// Engine holds the rate tables and works out charges from them.
+type Engine struct {
+	tables map[tableKey][]Table
+	levies []Levy
+}
+
+// NewEngine checks the tables and indexes them. Tables given later replace
+// earlier ones for the same provider, type and date, so configured tables can
+// override the built-in ones.
+func NewEngine(tables ...Table) (*Engine, error) {
+	e := &Engine{tables: make(map[tableKey][]Table), levies: ELevy}
+
+	for _, table := range tables {
+		if table.Provider == "" || table.Type == "" {
+			return nil, fmt.Errorf("fee table needs a provider and a type")
+		}
+		if err := checkBands(table.Bands); err != nil {
+			return nil, fmt.Errorf("fee table %s %s: %w", table.Provider, table.Type, err)
+		}
+
+		key := tableKey{provider: table.Provider, transactionType: strings.ToUpper(table.Type)}
+		versions := e.tables[key]
+		replaced := false
+		for i := range versions {
+			if versions[i].EffectiveFrom.Equal(table.EffectiveFrom) {
+				versions[i], replaced = table, true
+			}
+		}
+		if !replaced {
+			versions = append(versions, table)
+		}
+		e.tables[key] = versions
+	}
+
+	for _, versions := range e.tables {
+		sort.Slice(versions, func(i, j int) bool {
+			return versions[i].EffectiveFrom.Before(versions[j].EffectiveFrom)
+		})
+	}
+
+	return e, nil
+}
+
+func checkBands(bands []Band) error {
+	if len(bands) == 0 {
+		return fmt.Errorf("no bands")
+	}
+	for i, band := range bands {
+		if band.Rate < 0 || band.Flat < 0 || band.Min < 0 || band.Max < 0 {
+			return fmt.Errorf("band %d has a negative charge", i+1)
+		}
+		if band.Max > 0 && band.Max < band.Min {
+			return fmt.Errorf("band %d has a max below its min", i+1)
+		}
+		last := i == len(bands)-1
+		if !last && (band.UpTo <= 0 || (bands[i+1].UpTo > 0 && band.UpTo >= bands[i+1].UpTo)) {
+			return fmt.Errorf("band %d must end below the next one", i+1)
+		}
+	}
+	return nil
+}
+
+// Charge works out the charges on amount sent or received at a time. For
+// transfers sent, transferredToday is how much the sender had already
+// transferred that day, which counts against the levy exemption.
+func (e *Engine) Charge(
+	provider, transactionType string, amount float64, at time.Time, outgoing bool, transferredToday float64,
+) Charges {
+	var charges Charges
+
+	if table, ok := e.table(provider, transactionType, at); ok {
+		charges.Fee, charges.FeeKnown = table.charge(amount), true
+	}
+
+	if levy, ok := e.levy(at); ok {
+		charges.LevyKnown = true
+		if outgoing && levyTypes[strings.ToUpper(transactionType)] {
+			exempt := math.Max(levy.DailyExemption-transferredToday, 0)
+			charges.ELevy = round(math.Max(amount-exempt, 0) * levy.Rate)
+		}
+	}
+
+	return charges
+}
+
+// Apply works out the charges on statement rows, recording them as the
+// expected fee and levy. The charges the rows print are left as printed. Rows
+// are considered in date order so that each sender's daily exemption is used
+// up as it was.
+func (e *Engine) Apply(transactions []*models.Transaction) {
+	sorted := make([]*models.Transaction, len(transactions))
+	copy(sorted, transactions)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].TransactionDate.Before(sorted[j].TransactionDate)
+	})
+
+	transferred := make(map[string]float64)
+	for _, transaction := range sorted {
+		outgoing := !categories.IsIncoming(transaction)
+		day := transaction.TransactionDate.Format(time.DateOnly)
+
+		charges := e.Charge(
+			transaction.Provider, transaction.TransactionType, transaction.Amount,
+			transaction.TransactionDate, outgoing, transferred[day],
+		)
+		if outgoing && levyTypes[strings.ToUpper(transaction.TransactionType)] {
+			transferred[day] += transaction.Amount
+		}
+
+		transaction.ExpectedFees, transaction.ExpectedELevy = nil, nil
+		if charges.FeeKnown {
+			fee := charges.Fee
+			transaction.ExpectedFees = &fee
+		}
+		if charges.LevyKnown {
+			levy := charges.ELevy
+			transaction.ExpectedELevy = &levy
+		}
+	}
+}
+
+// NothingTaken reports whether the balances of a row prove that no more than
+// its amount and the charges it prints moved. Many statements leave the fee or
+// the levy out of their columns and only take it from the balance, so a charge
+// a row does not print can only be said to be missing when this holds. Rows
+// without balances prove nothing.
+func NothingTaken(transaction *models.Transaction) bool {
+	if transaction.BalanceBefore == 0 && transaction.BalanceAfter == 0 {
+		return false
+	}
+
+	moved := math.Abs(transaction.BalanceAfter - transaction.BalanceBefore)
+	var taken float64
+	if categories.IsIncoming(transaction) {
+		taken = transaction.Amount - transaction.Fees - transaction.ELevy - moved
+	} else {
+		taken = moved - transaction.Amount - transaction.Fees - transaction.ELevy
+	}
+	return math.Abs(taken) <= Tolerance
+}
+
+func (e *Engine) table(provider, transactionType string, at time.Time) (Table, bool) {
+	versions := e.tables[tableKey{provider: provider, transactionType: strings.ToUpper(transactionType)}]
+
+	for i := len(versions) - 1; i >= 0; i-- {
+		if !at.Before(versions[i].EffectiveFrom) {
+			return versions[i], true
+		}
+	}
+	return Table{}, false
+}
+
+func (e *Engine) levy(at time.Time) (Levy, bool) {
+	for i := len(e.levies) - 1; i >= 0; i-- {
+		if !at.Before(e.levies[i].EffectiveFrom) {
+			return e.levies[i], true
+		}
+	}
+	return Levy{}, false
+}
+
+func (t Table) charge(amount float64) float64 {
+	band := t.Bands[len(t.Bands)-1]
+	for _, b := range t.Bands {
+		if b.UpTo > 0 && amount <= b.UpTo {
+			band = b
+			break
+		}
+	}
+
+	charge := band.Flat + band.Rate*amount
+	if band.Min > 0 {
+		charge = math.Max(charge, band.Min)
+	}
+	if band.Max > 0 {
+		charge = math.Min(charge, band.Max)
+	}
+	return round(charge)
+}
+
+func round(amount float64) float64 {
+	return math.Round(amount*100) / 100
+}
+
+func date(year int, month time.Month, day int) time.Time {
+	return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+}
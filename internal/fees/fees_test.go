@@ -0,0 +1,235 @@
+package fees
+
+import (
+	"testing"
+	"time"
+
+	"lumon-backend/internal/domain/models"
+	"lumon-backend/internal/statements"
+)
+
+// transferTables are two versions of a transfer tariff, the second cutting
+// the fee, for the tests to check charges against.
+var transferTables = []Table{
+	{
+		Provider:      "test",
+		Type:          statements.TypeTransfer,
+		EffectiveFrom: date(2021, time.January, 1),
+		Bands:         []Band{{UpTo: 50, Flat: 0.5}, {Rate: 0.01, Max: 10}},
+	},
+	{
+		Provider:      "test",
+		Type:          statements.TypeTransfer,
+		EffectiveFrom: date(2022, time.May, 1),
+		Bands:         []Band{{UpTo: 50, Flat: 0.375}, {Rate: 0.0075, Max: 7.5}},
+	},
+}
+
+func TestTableCharge(t *testing.T) {
+	table := Table{Bands: []Band{
+		{UpTo: 50, Flat: 0.5},
+		{UpTo: 1000, Rate: 0.01, Min: 1},
+		{Flat: 2, Rate: 0.005, Max: 10},
+	}}
+
+	tests := []struct {
+		amount float64
+		want   float64
+	}{
+		{amount: 0, want: 0.5},
+		{amount: 50, want: 0.5},
+		// Just above the first band the rate applies, but not below the min.
+		{amount: 50.01, want: 1},
+		{amount: 100, want: 1},
+		{amount: 1000, want: 10},
+		{amount: 1000.01, want: 7},
+		{amount: 1600, want: 10},
+		{amount: 5000, want: 10},
+	}
+	for _, test := range tests {
+		if got := table.charge(test.amount); got != test.want {
+			t.Errorf("charge(%v) = %v, want %v", test.amount, got, test.want)
+		}
+	}
+}
+
+func TestEngineCharge(t *testing.T) {
+	override := Table{
+		Provider:      "test",
+		Type:          "transfer",
+		EffectiveFrom: date(2022, time.May, 1),
+		Bands:         []Band{{Flat: 1}},
+	}
+
+	tests := []struct {
+		name     string
+		tables   []Table
+		provider string
+		amount   float64
+		at       time.Time
+		want     Charges
+	}{
+		{
+			name:   "before any table or levy",
+			tables: transferTables,
+			amount: 1000,
+			at:     date(2020, time.June, 1),
+			want:   Charges{},
+		},
+		{
+			name:   "first table, before the levy",
+			tables: transferTables,
+			amount: 1000,
+			at:     date(2021, time.June, 1),
+			want:   Charges{Fee: 10, FeeKnown: true},
+		},
+		{
+			name:   "second table and levy above the exemption",
+			tables: transferTables,
+			amount: 1000,
+			at:     date(2022, time.May, 1),
+			want:   Charges{Fee: 7.5, FeeKnown: true, ELevy: 13.5, LevyKnown: true},
+		},
+		{
+			name:   "levy without the exemption",
+			tables: transferTables,
+			amount: 1000,
+			at:     date(2023, time.March, 1),
+			want:   Charges{Fee: 7.5, FeeKnown: true, ELevy: 10, LevyKnown: true},
+		},
+		{
+			name:   "levy repealed",
+			tables: transferTables,
+			amount: 1000,
+			at:     date(2025, time.April, 2),
+			want:   Charges{Fee: 7.5, FeeKnown: true, LevyKnown: true},
+		},
+		{
+			name:     "unknown provider",
+			tables:   transferTables,
+			provider: "other",
+			amount:   1000,
+			at:       date(2023, time.March, 1),
+			want:     Charges{ELevy: 10, LevyKnown: true},
+		},
+		{
+			name:   "later table replaces one of the same date",
+			tables: append(append([]Table{}, transferTables...), override),
+			amount: 1000,
+			at:     date(2023, time.March, 1),
+			want:   Charges{Fee: 1, FeeKnown: true, ELevy: 10, LevyKnown: true},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			engine, err := NewEngine(test.tables...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			provider := test.provider
+			if provider == "" {
+				provider = "test"
+			}
+			got := engine.Charge(provider, statements.TypeTransfer, test.amount, test.at, true, 0)
+			if got != test.want {
+				t.Errorf("Charge = %+v, want %+v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestEngineChargeDailyExemption(t *testing.T) {
+	engine, err := NewEngine()
+	if err != nil {
+		t.Fatal(err)
+	}
+	at := date(2022, time.June, 1)
+
+	tests := []struct {
+		name             string
+		amount           float64
+		transactionType  string
+		outgoing         bool
+		transferredToday float64
+		want             float64
+	}{
+		{name: "within the exemption", amount: 100, transactionType: statements.TypeTransfer, outgoing: true, want: 0},
+		{name: "above the exemption", amount: 150, transactionType: statements.TypeTransfer, outgoing: true, want: 0.75},
+		{
+			name: "exemption partly used", amount: 150, transactionType: statements.TypeTransfer, outgoing: true,
+			transferredToday: 60, want: 1.65,
+		},
+		{
+			name: "exemption used up", amount: 150, transactionType: statements.TypeTransfer, outgoing: true,
+			transferredToday: 500, want: 2.25,
+		},
+		{name: "received", amount: 150, transactionType: statements.TypeTransfer, want: 0},
+		{name: "withdrawal", amount: 150, transactionType: statements.TypeCashOut, outgoing: true, want: 0},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := engine.Charge("test", test.transactionType, test.amount, at, test.outgoing, test.transferredToday)
+			if !got.LevyKnown || got.ELevy != test.want {
+				t.Errorf("E-levy = %v (known %v), want %v", got.ELevy, got.LevyKnown, test.want)
+			}
+		})
+	}
+}
+
+func TestEngineApplyUsesExemptionInDateOrder(t *testing.T) {
+	engine, err := NewEngine(transferTables...)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	day := date(2022, time.June, 1)
+	transfer := func(hour int, amount float64) *models.Transaction {
+		return &models.Transaction{
+			Provider:        "test",
+			TransactionType: statements.TypeTransfer,
+			TransactionDate: day.Add(time.Duration(hour) * time.Hour),
+			Amount:          amount,
+			Fees:            0.4,
+		}
+	}
+	// Given out of order: the 09:00 transfer uses 80 of the exemption, so the
+	// 15:00 one is levied on all but 20 of its amount.
+	later, earlier := transfer(15, 120), transfer(9, 80)
+	nextDay := transfer(24+9, 120)
+
+	engine.Apply([]*models.Transaction{later, nextDay, earlier})
+
+	tests := []struct {
+		name        string
+		transaction *models.Transaction
+		levy        float64
+		fee         float64
+	}{
+		{name: "earlier", transaction: earlier, levy: 0, fee: 0.6},
+		{name: "later", transaction: later, levy: 1.5, fee: 0.9},
+		{name: "next day", transaction: nextDay, levy: 0.3, fee: 0.9},
+	}
+	for _, test := range tests {
+		if test.transaction.ExpectedELevy == nil || *test.transaction.ExpectedELevy != test.levy {
+			t.Errorf("%s: expected E-levy = %v, want %v", test.name, test.transaction.ExpectedELevy, test.levy)
+		}
+		if test.transaction.ExpectedFees == nil || *test.transaction.ExpectedFees != test.fee {
+			t.Errorf("%s: expected fee = %v, want %v", test.name, test.transaction.ExpectedFees, test.fee)
+		}
+		if test.transaction.Fees != 0.4 {
+			t.Errorf("%s: printed fee changed to %v", test.name, test.transaction.Fees)
+		}
+	}
+}
+
+func TestExampleTablesLoad(t *testing.T) {
+	tables, err := LoadTables("fee-tables.example.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := NewEngine(tables...); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,161 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"lumon-backend/internal/config"
+	"lumon-backend/internal/domain/schemas"
+	"lumon-backend/internal/middleware"
+	"lumon-backend/internal/service"
+	"lumon-backend/pkg/common/logger"
+	"lumon-backend/pkg/common/response"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/gin-gonic/gin"
+)
+
+type AnalyticsHandler struct {
+	analyticsService *service.AnalyticsService
+	cfg              *config.Config
+}
+
+func NewAnalyticsHandler(analyticsService *service.AnalyticsService, cfg *config.Config) *AnalyticsHandler {
+	return &AnalyticsHandler{
+		analyticsService: analyticsService,
+		cfg:              cfg,
+	}
+}
+
+func (h *AnalyticsHandler) RegisterRoutes(r *gin.RouterGroup) {
+	analytics := r.Group("/analytics")
+	analytics.Use(middleware.JWTMiddleware(h.cfg), middleware.RequireRoles("common"))
+	{
+		analytics.GET("/cash-flow", h.GetCashFlow)
+		analytics.GET("/counterparties", h.GetTopCounterparties)
+		analytics.GET("/categories", h.GetCategoryBreakdown)
+	}
+}
+
+func (h *AnalyticsHandler) GetCashFlow(c *gin.Context) {
+	userClaims, exists := c.Get("user")
+	if !exists {
+		logger.APILogger.Error("Unauthorized request in GetCashFlow")
+		c.JSON(http.StatusUnauthorized, response.NewFailureResponse("Unauthorized Request"))
+		return
+	}
+
+	claims, ok := userClaims.(jwt.MapClaims)
+	if !ok {
+		logger.APILogger.Error("Failed to parse user claims in GetCashFlow")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to parse user claims"})
+		return
+	}
+
+	userIDStr, ok := claims["user_id"].(string)
+	if !ok {
+		logger.APILogger.Error("User ID not found in token in GetCashFlow")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "User ID not found in token"})
+		return
+	}
+
+	var req schemas.AnalyticsRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, response.NewFailureResponse(err.Error()))
+		return
+	}
+
+	cashFlow, err := h.analyticsService.CashFlow(userIDStr, req)
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidAnalyticsRange) {
+			c.JSON(http.StatusBadRequest, response.NewFailureResponse(err.Error()))
+			return
+		}
+		c.JSON(http.StatusInternalServerError, response.NewServerResponse(err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, response.NewSuccessResponse(cashFlow))
+}
+
+func (h *AnalyticsHandler) GetTopCounterparties(c *gin.Context) {
+	userClaims, exists := c.Get("user")
+	if !exists {
+		logger.APILogger.Error("Unauthorized request in GetTopCounterparties")
+		c.JSON(http.StatusUnauthorized, response.NewFailureResponse("Unauthorized Request"))
+		return
+	}
+
+	claims, ok := userClaims.(jwt.MapClaims)
+	if !ok {
+		logger.APILogger.Error("Failed to parse user claims in GetTopCounterparties")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to parse user claims"})
+		return
+	}
+
+	userIDStr, ok := claims["user_id"].(string)
+	if !ok {
+		logger.APILogger.Error("User ID not found in token in GetTopCounterparties")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "User ID not found in token"})
+		return
+	}
+
+	var req schemas.AnalyticsRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, response.NewFailureResponse(err.Error()))
+		return
+	}
+
+	counterparties, err := h.analyticsService.TopCounterparties(userIDStr, req)
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidAnalyticsRange) {
+			c.JSON(http.StatusBadRequest, response.NewFailureResponse(err.Error()))
+			return
+		}
+		c.JSON(http.StatusInternalServerError, response.NewServerResponse(err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, response.NewSuccessResponse(counterparties))
+}
+
+func (h *AnalyticsHandler) GetCategoryBreakdown(c *gin.Context) {
+	userClaims, exists := c.Get("user")
+	if !exists {
+		logger.APILogger.Error("Unauthorized request in GetCategoryBreakdown")
+		c.JSON(http.StatusUnauthorized, response.NewFailureResponse("Unauthorized Request"))
+		return
+	}
+
+	claims, ok := userClaims.(jwt.MapClaims)
+	if !ok {
+		logger.APILogger.Error("Failed to parse user claims in GetCategoryBreakdown")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to parse user claims"})
+		return
+	}
+
+	userIDStr, ok := claims["user_id"].(string)
+	if !ok {
+		logger.APILogger.Error("User ID not found in token in GetCategoryBreakdown")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "User ID not found in token"})
+		return
+	}
+
+	var req schemas.AnalyticsRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, response.NewFailureResponse(err.Error()))
+		return
+	}
+
+	breakdown, err := h.analyticsService.CategoryBreakdown(userIDStr, req)
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidAnalyticsRange) {
+			c.JSON(http.StatusBadRequest, response.NewFailureResponse(err.Error()))
+			return
+		}
+		c.JSON(http.StatusInternalServerError, response.NewServerResponse(err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, response.NewSuccessResponse(breakdown))
+}
@@ -55,6 +55,7 @@ func (h *AuthHandler) Register(c *gin.Context) {
 		Password:    request.Password,
 		Email:       request.Email,
 		PhoneNumber: request.PhoneNumber,
+		Region:      request.Region,
 		UserRole:    "common",
 	}
 
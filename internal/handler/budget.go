@@ -0,0 +1,267 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"lumon-backend/internal/config"
+	"lumon-backend/internal/domain/schemas"
+	"lumon-backend/internal/middleware"
+	"lumon-backend/internal/service"
+	"lumon-backend/pkg/common/logger"
+	"lumon-backend/pkg/common/response"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type BudgetHandler struct {
+	budgetService *service.BudgetService
+	cfg           *config.Config
+}
+
+func NewBudgetHandler(budgetService *service.BudgetService, cfg *config.Config) *BudgetHandler {
+	return &BudgetHandler{
+		budgetService: budgetService,
+		cfg:           cfg,
+	}
+}
+
+func (h *BudgetHandler) RegisterRoutes(r *gin.RouterGroup) {
+	budgets := r.Group("/budgets")
+	budgets.Use(middleware.JWTMiddleware(h.cfg), middleware.RequireRoles("common"))
+	{
+		budgets.GET("", h.ListBudgets)
+		budgets.POST("", h.CreateBudget)
+		budgets.GET("/:id", h.GetBudget)
+		budgets.PATCH("/:id", h.UpdateBudget)
+		budgets.DELETE("/:id", h.DeleteBudget)
+	}
+}
+
+// ListBudgets returns the user's budgets with their progress in the month
+// given as ?month=YYYY-MM, the current month by default.
+func (h *BudgetHandler) ListBudgets(c *gin.Context) {
+	userClaims, exists := c.Get("user")
+	if !exists {
+		logger.APILogger.Error("Unauthorized request in ListBudgets")
+		c.JSON(http.StatusUnauthorized, response.NewFailureResponse("Unauthorized Request"))
+		return
+	}
+
+	claims, ok := userClaims.(jwt.MapClaims)
+	if !ok {
+		logger.APILogger.Error("Failed to parse user claims in ListBudgets")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to parse user claims"})
+		return
+	}
+
+	userIDStr, ok := claims["user_id"].(string)
+	if !ok {
+		logger.APILogger.Error("User ID not found in token in ListBudgets")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "User ID not found in token"})
+		return
+	}
+
+	var req schemas.BudgetProgressRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, response.NewFailureResponse(err.Error()))
+		return
+	}
+
+	budgets, err := h.budgetService.ListBudgets(userIDStr, req)
+	if err != nil {
+		c.JSON(budgetErrorStatus(err), budgetErrorResponse(err))
+		return
+	}
+
+	c.JSON(
+		http.StatusOK,
+		response.NewSuccessResponse(gin.H{
+			"budgets": budgets,
+			"meta": gin.H{
+				"total": len(budgets),
+			},
+		}),
+	)
+}
+
+func (h *BudgetHandler) CreateBudget(c *gin.Context) {
+	userClaims, exists := c.Get("user")
+	if !exists {
+		logger.APILogger.Error("Unauthorized request in CreateBudget")
+		c.JSON(http.StatusUnauthorized, response.NewFailureResponse("Unauthorized Request"))
+		return
+	}
+
+	claims, ok := userClaims.(jwt.MapClaims)
+	if !ok {
+		logger.APILogger.Error("Failed to parse user claims in CreateBudget")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to parse user claims"})
+		return
+	}
+
+	userIDStr, ok := claims["user_id"].(string)
+	if !ok {
+		logger.APILogger.Error("User ID not found in token in CreateBudget")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "User ID not found in token"})
+		return
+	}
+
+	var req schemas.CreateBudgetRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, response.NewFailureResponse(err.Error()))
+		return
+	}
+
+	budget, err := h.budgetService.CreateBudget(userIDStr, req)
+	if err != nil {
+		c.JSON(budgetErrorStatus(err), budgetErrorResponse(err))
+		return
+	}
+
+	c.JSON(http.StatusCreated, response.NewSuccessResponse(budget))
+}
+
+func (h *BudgetHandler) GetBudget(c *gin.Context) {
+	userClaims, exists := c.Get("user")
+	if !exists {
+		logger.APILogger.Error("Unauthorized request in GetBudget")
+		c.JSON(http.StatusUnauthorized, response.NewFailureResponse("Unauthorized Request"))
+		return
+	}
+
+	claims, ok := userClaims.(jwt.MapClaims)
+	if !ok {
+		logger.APILogger.Error("Failed to parse user claims in GetBudget")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to parse user claims"})
+		return
+	}
+
+	userIDStr, ok := claims["user_id"].(string)
+	if !ok {
+		logger.APILogger.Error("User ID not found in token in GetBudget")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "User ID not found in token"})
+		return
+	}
+
+	id := c.Param("id")
+	if _, err := uuid.Parse(id); err != nil {
+		c.JSON(http.StatusBadRequest, response.NewFailureResponse("ID is invalid"))
+		return
+	}
+
+	var req schemas.BudgetProgressRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, response.NewFailureResponse(err.Error()))
+		return
+	}
+
+	budget, err := h.budgetService.GetBudget(userIDStr, id, req)
+	if err != nil {
+		c.JSON(budgetErrorStatus(err), budgetErrorResponse(err))
+		return
+	}
+
+	c.JSON(http.StatusOK, response.NewSuccessResponse(budget))
+}
+
+func (h *BudgetHandler) UpdateBudget(c *gin.Context) {
+	userClaims, exists := c.Get("user")
+	if !exists {
+		logger.APILogger.Error("Unauthorized request in UpdateBudget")
+		c.JSON(http.StatusUnauthorized, response.NewFailureResponse("Unauthorized Request"))
+		return
+	}
+
+	claims, ok := userClaims.(jwt.MapClaims)
+	if !ok {
+		logger.APILogger.Error("Failed to parse user claims in UpdateBudget")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to parse user claims"})
+		return
+	}
+
+	userIDStr, ok := claims["user_id"].(string)
+	if !ok {
+		logger.APILogger.Error("User ID not found in token in UpdateBudget")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "User ID not found in token"})
+		return
+	}
+
+	id := c.Param("id")
+	if _, err := uuid.Parse(id); err != nil {
+		c.JSON(http.StatusBadRequest, response.NewFailureResponse("ID is invalid"))
+		return
+	}
+
+	var req schemas.UpdateBudgetRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, response.NewFailureResponse(err.Error()))
+		return
+	}
+
+	budget, err := h.budgetService.UpdateBudget(userIDStr, id, req)
+	if err != nil {
+		c.JSON(budgetErrorStatus(err), budgetErrorResponse(err))
+		return
+	}
+
+	c.JSON(http.StatusOK, response.NewSuccessResponse(budget))
+}
+
+func (h *BudgetHandler) DeleteBudget(c *gin.Context) {
+	userClaims, exists := c.Get("user")
+	if !exists {
+		logger.APILogger.Error("Unauthorized request in DeleteBudget")
+		c.JSON(http.StatusUnauthorized, response.NewFailureResponse("Unauthorized Request"))
+		return
+	}
+
+	claims, ok := userClaims.(jwt.MapClaims)
+	if !ok {
+		logger.APILogger.Error("Failed to parse user claims in DeleteBudget")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to parse user claims"})
+		return
+	}
+
+	userIDStr, ok := claims["user_id"].(string)
+	if !ok {
+		logger.APILogger.Error("User ID not found in token in DeleteBudget")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "User ID not found in token"})
+		return
+	}
+
+	id := c.Param("id")
+	if _, err := uuid.Parse(id); err != nil {
+		c.JSON(http.StatusBadRequest, response.NewFailureResponse("ID is invalid"))
+		return
+	}
+
+	if err := h.budgetService.DeleteBudget(userIDStr, id); err != nil {
+		c.JSON(budgetErrorStatus(err), budgetErrorResponse(err))
+		return
+	}
+
+	c.JSON(http.StatusOK, response.NewSuccessResponse("Budget deleted successfully"))
+}
+
+func budgetErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, service.ErrBudgetNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, service.ErrBudgetExists):
+		return http.StatusConflict
+	case errors.Is(err, service.ErrInvalidBudget):
+		return http.StatusBadRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+func budgetErrorResponse(err error) any {
+	if budgetErrorStatus(err) == http.StatusInternalServerError {
+		return response.NewServerResponse(err.Error())
+	}
+	return response.NewFailureResponse(err.Error())
+}
@@ -0,0 +1,131 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"lumon-backend/internal/config"
+	"lumon-backend/internal/domain/schemas"
+	"lumon-backend/internal/middleware"
+	"lumon-backend/internal/service"
+	"lumon-backend/pkg/common/logger"
+	"lumon-backend/pkg/common/response"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type CounterpartyHandler struct {
+	counterpartyService *service.CounterpartyService
+	cfg                 *config.Config
+}
+
+func NewCounterpartyHandler(counterpartyService *service.CounterpartyService, cfg *config.Config) *CounterpartyHandler {
+	return &CounterpartyHandler{
+		counterpartyService: counterpartyService,
+		cfg:                 cfg,
+	}
+}
+
+func (h *CounterpartyHandler) RegisterRoutes(r *gin.RouterGroup) {
+	counterparties := r.Group("/counterparties")
+	counterparties.Use(middleware.JWTMiddleware(h.cfg), middleware.RequireRoles("common"))
+	{
+		counterparties.GET("", h.ListCounterparties)
+		counterparties.GET("/:id", h.GetCounterparty)
+	}
+}
+
+// ListCounterparties pages through the people and businesses the user has
+// transacted with, with totals, frequency and first and last seen dates.
+func (h *CounterpartyHandler) ListCounterparties(c *gin.Context) {
+	userClaims, exists := c.Get("user")
+	if !exists {
+		logger.APILogger.Error("Unauthorized request in ListCounterparties")
+		c.JSON(http.StatusUnauthorized, response.NewFailureResponse("Unauthorized Request"))
+		return
+	}
+
+	claims, ok := userClaims.(jwt.MapClaims)
+	if !ok {
+		logger.APILogger.Error("Failed to parse user claims in ListCounterparties")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to parse user claims"})
+		return
+	}
+
+	userIDStr, ok := claims["user_id"].(string)
+	if !ok {
+		logger.APILogger.Error("User ID not found in token in ListCounterparties")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "User ID not found in token"})
+		return
+	}
+
+	var req schemas.CounterpartyListRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, response.NewFailureResponse(err.Error()))
+		return
+	}
+
+	counterparties, total, err := h.counterpartyService.ListCounterparties(userIDStr, req)
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidCounterpartyQuery) {
+			c.JSON(http.StatusBadRequest, response.NewFailureResponse(err.Error()))
+			return
+		}
+		c.JSON(http.StatusInternalServerError, response.NewServerResponse(err.Error()))
+		return
+	}
+
+	c.JSON(
+		http.StatusOK,
+		response.NewSuccessResponse(gin.H{
+			"counterparties": counterparties,
+			"meta": gin.H{
+				"total": total,
+			},
+		}),
+	)
+}
+
+func (h *CounterpartyHandler) GetCounterparty(c *gin.Context) {
+	userClaims, exists := c.Get("user")
+	if !exists {
+		logger.APILogger.Error("Unauthorized request in GetCounterparty")
+		c.JSON(http.StatusUnauthorized, response.NewFailureResponse("Unauthorized Request"))
+		return
+	}
+
+	claims, ok := userClaims.(jwt.MapClaims)
+	if !ok {
+		logger.APILogger.Error("Failed to parse user claims in GetCounterparty")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to parse user claims"})
+		return
+	}
+
+	userIDStr, ok := claims["user_id"].(string)
+	if !ok {
+		logger.APILogger.Error("User ID not found in token in GetCounterparty")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "User ID not found in token"})
+		return
+	}
+
+	id := c.Param("id")
+	if _, err := uuid.Parse(id); err != nil {
+		logger.APILogger.Error("Invalid counterparty ID in GetCounterparty:", err)
+		c.JSON(http.StatusBadRequest, response.NewFailureResponse("Invalid counterparty ID"))
+		return
+	}
+
+	counterparty, err := h.counterpartyService.GetCounterparty(userIDStr, id)
+	if err != nil {
+		if errors.Is(err, service.ErrCounterpartyNotFound) {
+			c.JSON(http.StatusNotFound, response.NewFailureResponse(err.Error()))
+			return
+		}
+		c.JSON(http.StatusInternalServerError, response.NewServerResponse(err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, response.NewSuccessResponse(counterparty))
+}
@@ -0,0 +1,120 @@
+package handler
+
+import (
+	"fmt"
+	"net/http"
+
+	"lumon-backend/internal/config"
+	"lumon-backend/internal/domain/schemas"
+	"lumon-backend/internal/middleware"
+	"lumon-backend/internal/service"
+	"lumon-backend/pkg/common/logger"
+	"lumon-backend/pkg/common/response"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/gin-gonic/gin"
+)
+
+type CreditReportHandler struct {
+	creditReportService *service.CreditReportService
+	cfg                 *config.Config
+}
+
+func NewCreditReportHandler(creditReportService *service.CreditReportService, cfg *config.Config) *CreditReportHandler {
+	return &CreditReportHandler{
+		creditReportService: creditReportService,
+		cfg:                 cfg,
+	}
+}
+
+func (h *CreditReportHandler) RegisterRoutes(r *gin.RouterGroup) {
+	reports := r.Group("/credit-reports")
+	{
+		reports.GET("/public-key", h.GetPublicKey)
+		reports.GET("/:id/verify", h.VerifyCreditReportByID)
+		reports.POST("/verify", h.VerifyCreditReport)
+	}
+
+	user := reports.Group("", middleware.JWTMiddleware(h.cfg), middleware.RequireRoles("common"))
+	{
+		user.POST("/me", h.CreateCreditReport)
+	}
+}
+
+func (h *CreditReportHandler) CreateCreditReport(c *gin.Context) {
+	format := c.DefaultQuery("format", "json")
+	if format != "json" && format != "pdf" {
+		c.JSON(http.StatusBadRequest, response.NewFailureResponse("format must be json or pdf"))
+		return
+	}
+
+	userClaims, exists := c.Get("user")
+	if !exists {
+		logger.APILogger.Error("Unauthorized request in CreateCreditReport")
+		c.JSON(http.StatusUnauthorized, response.NewFailureResponse("Unauthorized Request"))
+		return
+	}
+
+	claims, ok := userClaims.(jwt.MapClaims)
+	if !ok {
+		logger.APILogger.Error("Failed to parse user claims in CreateCreditReport")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to parse user claims"})
+		return
+	}
+
+	userIDStr, ok := claims["user_id"].(string)
+	if !ok {
+		logger.APILogger.Error("User ID not found in token in CreateCreditReport")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "User ID not found in token"})
+		return
+	}
+
+	report, err := h.creditReportService.GenerateCreditReport(userIDStr)
+	if err != nil {
+		logger.APILogger.Error("Failed to generate credit report in CreateCreditReport:", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate credit report"})
+		return
+	}
+
+	if format == "pdf" {
+		c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=credit-report-%s.pdf", report.Report.ReportID))
+		c.Data(http.StatusOK, "application/pdf", service.RenderCreditReportPDF(report))
+		return
+	}
+
+	c.JSON(http.StatusOK, response.NewSuccessResponse(report))
+}
+
+func (h *CreditReportHandler) GetPublicKey(c *gin.Context) {
+	c.JSON(http.StatusOK, response.NewSuccessResponse(gin.H{
+		"algorithm":  service.CreditReportAlgorithm,
+		"public_key": h.creditReportService.PublicKey(),
+	}))
+}
+
+func (h *CreditReportHandler) VerifyCreditReportByID(c *gin.Context) {
+	verification, err := h.creditReportService.VerifyCreditReportByID(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, response.NewFailureResponse(err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, response.NewSuccessResponse(verification))
+}
+
+func (h *CreditReportHandler) VerifyCreditReport(c *gin.Context) {
+	var report schemas.SignedCreditReport
+
+	if err := c.ShouldBindJSON(&report); err != nil {
+		c.JSON(http.StatusBadRequest, response.NewFailureResponse(err.Error()))
+		return
+	}
+
+	verification, err := h.creditReportService.VerifyCreditReport(&report)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, response.NewFailureResponse(err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, response.NewSuccessResponse(verification))
+}
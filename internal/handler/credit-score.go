@@ -0,0 +1,100 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"lumon-backend/internal/config"
+	"lumon-backend/internal/middleware"
+	"lumon-backend/internal/service"
+	"lumon-backend/pkg/common/logger"
+	"lumon-backend/pkg/common/response"
+
+	"github.com/gin-gonic/gin"
+)
+
+type CreditScoreHandler struct {
+	scheduler *service.CreditScoreScheduler
+	cfg       *config.Config
+}
+
+func NewCreditScoreHandler(scheduler *service.CreditScoreScheduler, cfg *config.Config) *CreditScoreHandler {
+	return &CreditScoreHandler{
+		scheduler: scheduler,
+		cfg:       cfg,
+	}
+}
+
+func (h *CreditScoreHandler) RegisterRoutes(r *gin.RouterGroup) {
+	creditScores := r.Group("/credit-scores")
+	creditScores.Use(middleware.JWTMiddleware(h.cfg))
+
+	admins := creditScores.Group("", middleware.RequireRoles("admin"))
+	{
+		admins.POST("/recalculations", h.TriggerRecalculation)
+		admins.GET("/recalculations", h.ListRecalculations)
+		admins.GET("/alerts", h.ListAlerts)
+	}
+}
+
+func (h *CreditScoreHandler) TriggerRecalculation(c *gin.Context) {
+	run, err := h.scheduler.RecalculateUsersWithNewData(service.RecalculationTriggerManual)
+	if errors.Is(err, service.ErrRecalculationQueueFull) {
+		c.JSON(http.StatusServiceUnavailable, response.NewFailureResponse(err.Error()))
+		return
+	}
+	if err != nil {
+		logger.APILogger.Error("Failed to schedule recalculation in TriggerRecalculation:", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to schedule recalculation"})
+		return
+	}
+
+	c.JSON(http.StatusAccepted, response.NewSuccessResponse(run))
+}
+
+func (h *CreditScoreHandler) ListRecalculations(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "10"))
+
+	runs, total, err := h.scheduler.ListRuns(page, pageSize)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(
+		http.StatusOK,
+		response.NewSuccessResponse(gin.H{
+			"recalculations": runs,
+			"meta": gin.H{
+				"total":     total,
+				"page":      page,
+				"page_size": pageSize,
+			},
+		}),
+	)
+}
+
+func (h *CreditScoreHandler) ListAlerts(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "10"))
+
+	alerts, total, err := h.scheduler.ListAlerts(page, pageSize)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(
+		http.StatusOK,
+		response.NewSuccessResponse(gin.H{
+			"alerts": alerts,
+			"meta": gin.H{
+				"total":     total,
+				"page":      page,
+				"page_size": pageSize,
+			},
+		}),
+	)
+}
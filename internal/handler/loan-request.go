@@ -1,6 +1,7 @@
 package handler
 
 import (
+	"errors"
 	"net/http"
 	"strconv"
 
@@ -252,6 +253,14 @@ func (h *LoanRequestHandler) UpdateLoanRequestStatus(c *gin.Context) {
 	}
 
 	if err := h.loanRequestService.UpdateLoanRequestStatus(id, statusReq.Status); err != nil {
+		if errors.Is(err, service.ErrLoanRequestFlagged) {
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			return
+		}
+		if errors.Is(err, service.ErrInvalidLoanRequestStatus) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
 		logger.APILogger.Error("Failed to update loan request status in UpdateLoanRequestStatus:", err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update loan request status"})
 		return
@@ -0,0 +1,158 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"lumon-backend/internal/config"
+	"lumon-backend/internal/domain/schemas"
+	"lumon-backend/internal/middleware"
+	"lumon-backend/internal/service"
+	"lumon-backend/pkg/common/logger"
+	"lumon-backend/pkg/common/response"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type NotificationHandler struct {
+	notificationService *service.NotificationService
+	cfg                 *config.Config
+}
+
+func NewNotificationHandler(notificationService *service.NotificationService, cfg *config.Config) *NotificationHandler {
+	return &NotificationHandler{
+		notificationService: notificationService,
+		cfg:                 cfg,
+	}
+}
+
+func (h *NotificationHandler) RegisterRoutes(r *gin.RouterGroup) {
+	notifications := r.Group("/notifications")
+	notifications.Use(middleware.JWTMiddleware(h.cfg))
+	{
+		notifications.GET("", h.ListNotifications)
+		notifications.PATCH("/:id/read", h.MarkRead)
+		notifications.POST("/read-all", h.MarkAllRead)
+	}
+}
+
+// ListNotifications pages through the user's notifications, newest first,
+// only the unread ones with ?unread=true.
+func (h *NotificationHandler) ListNotifications(c *gin.Context) {
+	userClaims, exists := c.Get("user")
+	if !exists {
+		logger.APILogger.Error("Unauthorized request in ListNotifications")
+		c.JSON(http.StatusUnauthorized, response.NewFailureResponse("Unauthorized Request"))
+		return
+	}
+
+	claims, ok := userClaims.(jwt.MapClaims)
+	if !ok {
+		logger.APILogger.Error("Failed to parse user claims in ListNotifications")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to parse user claims"})
+		return
+	}
+
+	userIDStr, ok := claims["user_id"].(string)
+	if !ok {
+		logger.APILogger.Error("User ID not found in token in ListNotifications")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "User ID not found in token"})
+		return
+	}
+
+	var req schemas.NotificationListRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, response.NewFailureResponse(err.Error()))
+		return
+	}
+
+	notifications, total, unread, err := h.notificationService.ListNotifications(userIDStr, req)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, response.NewServerResponse(err.Error()))
+		return
+	}
+
+	c.JSON(
+		http.StatusOK,
+		response.NewSuccessResponse(gin.H{
+			"notifications": notifications,
+			"meta": gin.H{
+				"total":  total,
+				"unread": unread,
+			},
+		}),
+	)
+}
+
+func (h *NotificationHandler) MarkRead(c *gin.Context) {
+	userClaims, exists := c.Get("user")
+	if !exists {
+		logger.APILogger.Error("Unauthorized request in MarkRead")
+		c.JSON(http.StatusUnauthorized, response.NewFailureResponse("Unauthorized Request"))
+		return
+	}
+
+	claims, ok := userClaims.(jwt.MapClaims)
+	if !ok {
+		logger.APILogger.Error("Failed to parse user claims in MarkRead")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to parse user claims"})
+		return
+	}
+
+	userIDStr, ok := claims["user_id"].(string)
+	if !ok {
+		logger.APILogger.Error("User ID not found in token in MarkRead")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "User ID not found in token"})
+		return
+	}
+
+	id := c.Param("id")
+	if _, err := uuid.Parse(id); err != nil {
+		c.JSON(http.StatusBadRequest, response.NewFailureResponse("ID is invalid"))
+		return
+	}
+
+	notification, err := h.notificationService.MarkRead(userIDStr, id)
+	if err != nil {
+		if errors.Is(err, service.ErrNotificationNotFound) {
+			c.JSON(http.StatusNotFound, response.NewFailureResponse(err.Error()))
+			return
+		}
+		c.JSON(http.StatusInternalServerError, response.NewServerResponse(err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, response.NewSuccessResponse(notification))
+}
+
+func (h *NotificationHandler) MarkAllRead(c *gin.Context) {
+	userClaims, exists := c.Get("user")
+	if !exists {
+		logger.APILogger.Error("Unauthorized request in MarkAllRead")
+		c.JSON(http.StatusUnauthorized, response.NewFailureResponse("Unauthorized Request"))
+		return
+	}
+
+	claims, ok := userClaims.(jwt.MapClaims)
+	if !ok {
+		logger.APILogger.Error("Failed to parse user claims in MarkAllRead")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to parse user claims"})
+		return
+	}
+
+	userIDStr, ok := claims["user_id"].(string)
+	if !ok {
+		logger.APILogger.Error("User ID not found in token in MarkAllRead")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "User ID not found in token"})
+		return
+	}
+
+	if err := h.notificationService.MarkAllRead(userIDStr); err != nil {
+		c.JSON(http.StatusInternalServerError, response.NewServerResponse(err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, response.NewSuccessResponse("Notifications marked as read"))
+}
@@ -0,0 +1,128 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"lumon-backend/internal/config"
+	"lumon-backend/internal/middleware"
+	"lumon-backend/internal/service"
+	"lumon-backend/pkg/common/logger"
+	"lumon-backend/pkg/common/response"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type ScoreMonitoringHandler struct {
+	monitoringService *service.ScoreMonitoringService
+	cfg               *config.Config
+}
+
+func NewScoreMonitoringHandler(monitoringService *service.ScoreMonitoringService, cfg *config.Config) *ScoreMonitoringHandler {
+	return &ScoreMonitoringHandler{
+		monitoringService: monitoringService,
+		cfg:               cfg,
+	}
+}
+
+func (h *ScoreMonitoringHandler) RegisterRoutes(r *gin.RouterGroup) {
+	monitoring := r.Group("/credit-scores/monitoring")
+	monitoring.Use(middleware.JWTMiddleware(h.cfg))
+
+	admins := monitoring.Group("", middleware.RequireRoles("admin"))
+	{
+		admins.GET("", h.GetCurrentReport)
+		admins.POST("/reports", h.SaveReport)
+		admins.GET("/reports", h.ListReports)
+		admins.GET("/reports/:id", h.GetReport)
+		admins.POST("/reports/:id/baseline", h.SetBaseline)
+	}
+}
+
+func (h *ScoreMonitoringHandler) GetCurrentReport(c *gin.Context) {
+	report, err := h.monitoringService.GenerateReport()
+	if err != nil {
+		logger.APILogger.Error("Failed to generate report in GetCurrentReport:", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate monitoring report"})
+		return
+	}
+
+	c.JSON(http.StatusOK, response.NewSuccessResponse(report))
+}
+
+func (h *ScoreMonitoringHandler) SaveReport(c *gin.Context) {
+	report, err := h.monitoringService.SaveReport(service.MonitoringTriggerManual)
+	if err != nil {
+		logger.APILogger.Error("Failed to save report in SaveReport:", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save monitoring report"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, response.NewSuccessResponse(report))
+}
+
+func (h *ScoreMonitoringHandler) ListReports(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "10"))
+
+	reports, total, err := h.monitoringService.ListReports(page, pageSize)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(
+		http.StatusOK,
+		response.NewSuccessResponse(gin.H{
+			"reports": reports,
+			"meta": gin.H{
+				"total":     total,
+				"page":      page,
+				"page_size": pageSize,
+			},
+		}),
+	)
+}
+
+func (h *ScoreMonitoringHandler) GetReport(c *gin.Context) {
+	id := c.Param("id")
+	if _, err := uuid.Parse(id); err != nil {
+		logger.APILogger.Error("Invalid monitoring report ID in GetReport:", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid monitoring report ID"})
+		return
+	}
+
+	report, err := h.monitoringService.GetReport(id)
+	if err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Monitoring report not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, response.NewSuccessResponse(report))
+}
+
+func (h *ScoreMonitoringHandler) SetBaseline(c *gin.Context) {
+	id := c.Param("id")
+	if _, err := uuid.Parse(id); err != nil {
+		logger.APILogger.Error("Invalid monitoring report ID in SetBaseline:", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid monitoring report ID"})
+		return
+	}
+
+	if err := h.monitoringService.SetBaseline(id); err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Monitoring report not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, response.NewSuccessResponse(gin.H{"message": "Baseline updated"}))
+}
@@ -0,0 +1,129 @@
+package handler
+
+import (
+	"net/http"
+
+	"lumon-backend/internal/config"
+	"lumon-backend/internal/domain/models"
+	"lumon-backend/internal/domain/schemas"
+	"lumon-backend/internal/middleware"
+	"lumon-backend/internal/service"
+	"lumon-backend/pkg/common/logger"
+	"lumon-backend/pkg/common/response"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type ScoringProfileHandler struct {
+	scoringProfileService *service.ScoringProfileService
+	cfg                   *config.Config
+}
+
+func NewScoringProfileHandler(scoringProfileService *service.ScoringProfileService, cfg *config.Config) *ScoringProfileHandler {
+	return &ScoringProfileHandler{
+		scoringProfileService: scoringProfileService,
+		cfg:                   cfg,
+	}
+}
+
+func (h *ScoringProfileHandler) RegisterRoutes(r *gin.RouterGroup) {
+	profiles := r.Group("/scoring-profiles")
+	profiles.Use(middleware.JWTMiddleware(h.cfg))
+
+	profiles = profiles.Group("", middleware.RequireRoles("admin"))
+	{
+		profiles.POST("", h.CreateScoringProfile)
+		profiles.GET("", h.ListScoringProfiles)
+		profiles.GET("/active", h.GetActiveScoringProfile)
+		profiles.GET("/:id", h.GetScoringProfile)
+		profiles.POST("/:id/activate", h.ActivateScoringProfile)
+	}
+}
+
+func (h *ScoringProfileHandler) CreateScoringProfile(c *gin.Context) {
+	var request schemas.CreateScoringProfileDetails
+
+	if err := c.ShouldBindJSON(&request); err != nil {
+		logger.APILogger.Error("Failed to bind JSON in CreateScoringProfile:", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	profile := &models.ScoringProfile{
+		Name:                    request.Name,
+		PaymentHistoryWeight:    request.PaymentHistoryWeight,
+		IncomeStabilityWeight:   request.IncomeStabilityWeight,
+		CashFlowWeight:          request.CashFlowWeight,
+		TransactionHabitsWeight: request.TransactionHabitsWeight,
+		CreditHistoryWeight:     request.CreditHistoryWeight,
+		ScoreMin:                request.ScoreMin,
+		ScoreMax:                request.ScoreMax,
+	}
+
+	if err := service.ValidateScoringProfile(profile); err != nil {
+		c.JSON(http.StatusBadRequest, response.NewFailureResponse(err.Error()))
+		return
+	}
+
+	if err := h.scoringProfileService.CreateScoringProfile(profile); err != nil {
+		logger.APILogger.Error("Failed to create scoring profile in CreateScoringProfile:", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create scoring profile"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, response.NewSuccessResponse(profile))
+}
+
+func (h *ScoringProfileHandler) GetScoringProfile(c *gin.Context) {
+	id := c.Param("id")
+	if _, err := uuid.Parse(id); err != nil {
+		logger.APILogger.Error("Invalid scoring profile ID in GetScoringProfile:", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid scoring profile ID"})
+		return
+	}
+
+	profile, err := h.scoringProfileService.GetScoringProfile(id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Scoring profile not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, response.NewSuccessResponse(profile))
+}
+
+func (h *ScoringProfileHandler) GetActiveScoringProfile(c *gin.Context) {
+	profile, err := h.scoringProfileService.GetActiveScoringProfile()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, response.NewSuccessResponse(profile))
+}
+
+func (h *ScoringProfileHandler) ListScoringProfiles(c *gin.Context) {
+	profiles, err := h.scoringProfileService.ListScoringProfiles()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, response.NewSuccessResponse(gin.H{"scoring_profiles": profiles}))
+}
+
+func (h *ScoringProfileHandler) ActivateScoringProfile(c *gin.Context) {
+	id := c.Param("id")
+	if _, err := uuid.Parse(id); err != nil {
+		logger.APILogger.Error("Invalid scoring profile ID in ActivateScoringProfile:", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid scoring profile ID"})
+		return
+	}
+
+	if err := h.scoringProfileService.ActivateScoringProfile(id); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, response.NewSuccessResponse("Scoring profile activated successfully"))
+}
@@ -0,0 +1,550 @@
+package handler
+
+import (
+	"errors"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+
+	"lumon-backend/internal/config"
+	"lumon-backend/internal/domain/schemas"
+	"lumon-backend/internal/middleware"
+	"lumon-backend/internal/service"
+	"lumon-backend/pkg/common/logger"
+	"lumon-backend/pkg/common/response"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type TransactionAnnotationHandler struct {
+	annotationService *service.TransactionAnnotationService
+	cfg               *config.Config
+}
+
+func NewTransactionAnnotationHandler(
+	annotationService *service.TransactionAnnotationService, cfg *config.Config,
+) *TransactionAnnotationHandler {
+	return &TransactionAnnotationHandler{
+		annotationService: annotationService,
+		cfg:               cfg,
+	}
+}
+
+func (h *TransactionAnnotationHandler) RegisterRoutes(r *gin.RouterGroup) {
+	transaction := r.Group("/transactions")
+	transaction.Use(middleware.JWTMiddleware(h.cfg))
+
+	users := transaction.Group("", middleware.RequireRoles("common"))
+	{
+		users.GET("/tags", h.ListTags)
+		users.GET("/item/:id/annotations", h.GetAnnotations)
+		users.POST("/item/:id/tags", h.AddTags)
+		users.DELETE("/item/:id/tags/:tag", h.RemoveTag)
+		users.POST("/item/:id/notes", h.AddNote)
+		users.PATCH("/item/:id/notes/:noteId", h.UpdateNote)
+		users.DELETE("/item/:id/notes/:noteId", h.DeleteNote)
+		users.POST("/item/:id/attachments", h.AddAttachment)
+		users.GET("/item/:id/attachments/:attachmentId", h.GetAttachment)
+		users.DELETE("/item/:id/attachments/:attachmentId", h.DeleteAttachment)
+	}
+
+	// Underwriters read a borrower's tags to tell business income from
+	// personal income.
+	admins := transaction.Group("", middleware.RequireRoles("admin"))
+	{
+		admins.GET("/users/:userId/tags", h.ListUserTags)
+	}
+}
+
+func (h *TransactionAnnotationHandler) ListTags(c *gin.Context) {
+	userClaims, exists := c.Get("user")
+	if !exists {
+		logger.APILogger.Error("Unauthorized request in ListTags")
+		c.JSON(http.StatusUnauthorized, response.NewFailureResponse("Unauthorized Request"))
+		return
+	}
+
+	claims, ok := userClaims.(jwt.MapClaims)
+	if !ok {
+		logger.APILogger.Error("Failed to parse user claims in ListTags")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to parse user claims"})
+		return
+	}
+
+	userIDStr, ok := claims["user_id"].(string)
+	if !ok {
+		logger.APILogger.Error("User ID not found in token in ListTags")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "User ID not found in token"})
+		return
+	}
+
+	tags, err := h.annotationService.ListTags(userIDStr)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, response.NewServerResponse(err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, response.NewSuccessResponse(gin.H{
+		"items": tags,
+		"meta": gin.H{
+			"total": len(tags),
+		},
+	}))
+}
+
+func (h *TransactionAnnotationHandler) ListUserTags(c *gin.Context) {
+	userID := c.Param("userId")
+	if _, err := uuid.Parse(userID); err != nil {
+		logger.APILogger.Error("Invalid user ID in ListUserTags:", err)
+		c.JSON(http.StatusBadRequest, response.NewFailureResponse("Invalid user ID"))
+		return
+	}
+
+	tags, err := h.annotationService.ListTags(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, response.NewServerResponse(err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, response.NewSuccessResponse(gin.H{
+		"items": tags,
+		"meta": gin.H{
+			"total": len(tags),
+		},
+	}))
+}
+
+func (h *TransactionAnnotationHandler) GetAnnotations(c *gin.Context) {
+	userClaims, exists := c.Get("user")
+	if !exists {
+		logger.APILogger.Error("Unauthorized request in GetAnnotations")
+		c.JSON(http.StatusUnauthorized, response.NewFailureResponse("Unauthorized Request"))
+		return
+	}
+
+	claims, ok := userClaims.(jwt.MapClaims)
+	if !ok {
+		logger.APILogger.Error("Failed to parse user claims in GetAnnotations")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to parse user claims"})
+		return
+	}
+
+	userIDStr, ok := claims["user_id"].(string)
+	if !ok {
+		logger.APILogger.Error("User ID not found in token in GetAnnotations")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "User ID not found in token"})
+		return
+	}
+
+	id := c.Param("id")
+	if _, err := uuid.Parse(id); err != nil {
+		c.JSON(http.StatusBadRequest, response.NewFailureResponse("ID is invalid"))
+		return
+	}
+
+	annotations, err := h.annotationService.GetAnnotations(userIDStr, id)
+	if err != nil {
+		c.JSON(annotationErrorStatus(err), annotationErrorResponse(err))
+		return
+	}
+
+	c.JSON(http.StatusOK, response.NewSuccessResponse(annotations))
+}
+
+func (h *TransactionAnnotationHandler) AddTags(c *gin.Context) {
+	userClaims, exists := c.Get("user")
+	if !exists {
+		logger.APILogger.Error("Unauthorized request in AddTags")
+		c.JSON(http.StatusUnauthorized, response.NewFailureResponse("Unauthorized Request"))
+		return
+	}
+
+	claims, ok := userClaims.(jwt.MapClaims)
+	if !ok {
+		logger.APILogger.Error("Failed to parse user claims in AddTags")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to parse user claims"})
+		return
+	}
+
+	userIDStr, ok := claims["user_id"].(string)
+	if !ok {
+		logger.APILogger.Error("User ID not found in token in AddTags")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "User ID not found in token"})
+		return
+	}
+
+	id := c.Param("id")
+	if _, err := uuid.Parse(id); err != nil {
+		c.JSON(http.StatusBadRequest, response.NewFailureResponse("ID is invalid"))
+		return
+	}
+
+	var req schemas.AddTransactionTagsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, response.NewFailureResponse(err.Error()))
+		return
+	}
+
+	tags, err := h.annotationService.AddTags(userIDStr, id, req.Tags)
+	if err != nil {
+		c.JSON(annotationErrorStatus(err), annotationErrorResponse(err))
+		return
+	}
+
+	c.JSON(http.StatusOK, response.NewSuccessResponse(gin.H{"tags": tags}))
+}
+
+func (h *TransactionAnnotationHandler) RemoveTag(c *gin.Context) {
+	userClaims, exists := c.Get("user")
+	if !exists {
+		logger.APILogger.Error("Unauthorized request in RemoveTag")
+		c.JSON(http.StatusUnauthorized, response.NewFailureResponse("Unauthorized Request"))
+		return
+	}
+
+	claims, ok := userClaims.(jwt.MapClaims)
+	if !ok {
+		logger.APILogger.Error("Failed to parse user claims in RemoveTag")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to parse user claims"})
+		return
+	}
+
+	userIDStr, ok := claims["user_id"].(string)
+	if !ok {
+		logger.APILogger.Error("User ID not found in token in RemoveTag")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "User ID not found in token"})
+		return
+	}
+
+	id := c.Param("id")
+	if _, err := uuid.Parse(id); err != nil {
+		c.JSON(http.StatusBadRequest, response.NewFailureResponse("ID is invalid"))
+		return
+	}
+
+	if err := h.annotationService.RemoveTag(userIDStr, id, c.Param("tag")); err != nil {
+		c.JSON(annotationErrorStatus(err), annotationErrorResponse(err))
+		return
+	}
+
+	c.JSON(http.StatusOK, response.NewSuccessResponse("Tag removed successfully"))
+}
+
+func (h *TransactionAnnotationHandler) AddNote(c *gin.Context) {
+	userClaims, exists := c.Get("user")
+	if !exists {
+		logger.APILogger.Error("Unauthorized request in AddNote")
+		c.JSON(http.StatusUnauthorized, response.NewFailureResponse("Unauthorized Request"))
+		return
+	}
+
+	claims, ok := userClaims.(jwt.MapClaims)
+	if !ok {
+		logger.APILogger.Error("Failed to parse user claims in AddNote")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to parse user claims"})
+		return
+	}
+
+	userIDStr, ok := claims["user_id"].(string)
+	if !ok {
+		logger.APILogger.Error("User ID not found in token in AddNote")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "User ID not found in token"})
+		return
+	}
+
+	id := c.Param("id")
+	if _, err := uuid.Parse(id); err != nil {
+		c.JSON(http.StatusBadRequest, response.NewFailureResponse("ID is invalid"))
+		return
+	}
+
+	var req schemas.TransactionNoteRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, response.NewFailureResponse(err.Error()))
+		return
+	}
+
+	note, err := h.annotationService.AddNote(userIDStr, id, req.Body)
+	if err != nil {
+		c.JSON(annotationErrorStatus(err), annotationErrorResponse(err))
+		return
+	}
+
+	c.JSON(http.StatusCreated, response.NewSuccessResponse(note))
+}
+
+func (h *TransactionAnnotationHandler) UpdateNote(c *gin.Context) {
+	userClaims, exists := c.Get("user")
+	if !exists {
+		logger.APILogger.Error("Unauthorized request in UpdateNote")
+		c.JSON(http.StatusUnauthorized, response.NewFailureResponse("Unauthorized Request"))
+		return
+	}
+
+	claims, ok := userClaims.(jwt.MapClaims)
+	if !ok {
+		logger.APILogger.Error("Failed to parse user claims in UpdateNote")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to parse user claims"})
+		return
+	}
+
+	userIDStr, ok := claims["user_id"].(string)
+	if !ok {
+		logger.APILogger.Error("User ID not found in token in UpdateNote")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "User ID not found in token"})
+		return
+	}
+
+	id, noteID := c.Param("id"), c.Param("noteId")
+	if _, err := uuid.Parse(id); err != nil {
+		c.JSON(http.StatusBadRequest, response.NewFailureResponse("ID is invalid"))
+		return
+	}
+	if _, err := uuid.Parse(noteID); err != nil {
+		c.JSON(http.StatusBadRequest, response.NewFailureResponse("Note ID is invalid"))
+		return
+	}
+
+	var req schemas.TransactionNoteRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, response.NewFailureResponse(err.Error()))
+		return
+	}
+
+	note, err := h.annotationService.UpdateNote(userIDStr, id, noteID, req.Body)
+	if err != nil {
+		c.JSON(annotationErrorStatus(err), annotationErrorResponse(err))
+		return
+	}
+
+	c.JSON(http.StatusOK, response.NewSuccessResponse(note))
+}
+
+func (h *TransactionAnnotationHandler) DeleteNote(c *gin.Context) {
+	userClaims, exists := c.Get("user")
+	if !exists {
+		logger.APILogger.Error("Unauthorized request in DeleteNote")
+		c.JSON(http.StatusUnauthorized, response.NewFailureResponse("Unauthorized Request"))
+		return
+	}
+
+	claims, ok := userClaims.(jwt.MapClaims)
+	if !ok {
+		logger.APILogger.Error("Failed to parse user claims in DeleteNote")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to parse user claims"})
+		return
+	}
+
+	userIDStr, ok := claims["user_id"].(string)
+	if !ok {
+		logger.APILogger.Error("User ID not found in token in DeleteNote")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "User ID not found in token"})
+		return
+	}
+
+	id, noteID := c.Param("id"), c.Param("noteId")
+	if _, err := uuid.Parse(id); err != nil {
+		c.JSON(http.StatusBadRequest, response.NewFailureResponse("ID is invalid"))
+		return
+	}
+	if _, err := uuid.Parse(noteID); err != nil {
+		c.JSON(http.StatusBadRequest, response.NewFailureResponse("Note ID is invalid"))
+		return
+	}
+
+	if err := h.annotationService.DeleteNote(userIDStr, id, noteID); err != nil {
+		c.JSON(annotationErrorStatus(err), annotationErrorResponse(err))
+		return
+	}
+
+	c.JSON(http.StatusOK, response.NewSuccessResponse("Note deleted successfully"))
+}
+
+// AddAttachment attaches a file sent as a multipart "file" upload.
+func (h *TransactionAnnotationHandler) AddAttachment(c *gin.Context) {
+	userClaims, exists := c.Get("user")
+	if !exists {
+		logger.APILogger.Error("Unauthorized request in AddAttachment")
+		c.JSON(http.StatusUnauthorized, response.NewFailureResponse("Unauthorized Request"))
+		return
+	}
+
+	claims, ok := userClaims.(jwt.MapClaims)
+	if !ok {
+		logger.APILogger.Error("Failed to parse user claims in AddAttachment")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to parse user claims"})
+		return
+	}
+
+	userIDStr, ok := claims["user_id"].(string)
+	if !ok {
+		logger.APILogger.Error("User ID not found in token in AddAttachment")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "User ID not found in token"})
+		return
+	}
+
+	id := c.Param("id")
+	if _, err := uuid.Parse(id); err != nil {
+		c.JSON(http.StatusBadRequest, response.NewFailureResponse("ID is invalid"))
+		return
+	}
+
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, h.cfg.AttachmentMaxUploadSize+multipartOverhead)
+
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			err = service.ErrAttachmentTooLarge
+			c.JSON(annotationErrorStatus(err), annotationErrorResponse(err))
+			return
+		}
+		c.JSON(http.StatusBadRequest, response.NewFailureResponse(err.Error()))
+		return
+	}
+
+	if fileHeader.Size > h.cfg.AttachmentMaxUploadSize {
+		err := service.ErrAttachmentTooLarge
+		c.JSON(annotationErrorStatus(err), annotationErrorResponse(err))
+		return
+	}
+
+	file, err := fileHeader.Open()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, response.NewFailureResponse(err.Error()))
+		return
+	}
+	defer file.Close()
+
+	attachment, err := h.annotationService.AddAttachment(c, userIDStr, id, fileHeader.Filename, file)
+	if err != nil {
+		c.JSON(annotationErrorStatus(err), annotationErrorResponse(err))
+		return
+	}
+
+	c.JSON(http.StatusCreated, response.NewSuccessResponse(attachment))
+}
+
+// GetAttachment downloads an attached file.
+func (h *TransactionAnnotationHandler) GetAttachment(c *gin.Context) {
+	userClaims, exists := c.Get("user")
+	if !exists {
+		logger.APILogger.Error("Unauthorized request in GetAttachment")
+		c.JSON(http.StatusUnauthorized, response.NewFailureResponse("Unauthorized Request"))
+		return
+	}
+
+	claims, ok := userClaims.(jwt.MapClaims)
+	if !ok {
+		logger.APILogger.Error("Failed to parse user claims in GetAttachment")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to parse user claims"})
+		return
+	}
+
+	userIDStr, ok := claims["user_id"].(string)
+	if !ok {
+		logger.APILogger.Error("User ID not found in token in GetAttachment")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "User ID not found in token"})
+		return
+	}
+
+	id, attachmentID := c.Param("id"), c.Param("attachmentId")
+	if _, err := uuid.Parse(id); err != nil {
+		c.JSON(http.StatusBadRequest, response.NewFailureResponse("ID is invalid"))
+		return
+	}
+	if _, err := uuid.Parse(attachmentID); err != nil {
+		c.JSON(http.StatusBadRequest, response.NewFailureResponse("Attachment ID is invalid"))
+		return
+	}
+
+	attachment, file, err := h.annotationService.OpenAttachment(c, userIDStr, id, attachmentID)
+	if err != nil {
+		c.JSON(annotationErrorStatus(err), annotationErrorResponse(err))
+		return
+	}
+	defer file.Close()
+
+	c.Header("Content-Type", attachment.MIMEType)
+	c.Header("Content-Length", strconv.FormatInt(attachment.Size, 10))
+	c.Header("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{
+		"filename": attachment.Filename,
+	}))
+	c.Status(http.StatusOK)
+
+	if _, err := io.Copy(c.Writer, file); err != nil {
+		logger.APILogger.Error("Failed to send attachment in GetAttachment:", err)
+	}
+}
+
+func (h *TransactionAnnotationHandler) DeleteAttachment(c *gin.Context) {
+	userClaims, exists := c.Get("user")
+	if !exists {
+		logger.APILogger.Error("Unauthorized request in DeleteAttachment")
+		c.JSON(http.StatusUnauthorized, response.NewFailureResponse("Unauthorized Request"))
+		return
+	}
+
+	claims, ok := userClaims.(jwt.MapClaims)
+	if !ok {
+		logger.APILogger.Error("Failed to parse user claims in DeleteAttachment")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to parse user claims"})
+		return
+	}
+
+	userIDStr, ok := claims["user_id"].(string)
+	if !ok {
+		logger.APILogger.Error("User ID not found in token in DeleteAttachment")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "User ID not found in token"})
+		return
+	}
+
+	id, attachmentID := c.Param("id"), c.Param("attachmentId")
+	if _, err := uuid.Parse(id); err != nil {
+		c.JSON(http.StatusBadRequest, response.NewFailureResponse("ID is invalid"))
+		return
+	}
+	if _, err := uuid.Parse(attachmentID); err != nil {
+		c.JSON(http.StatusBadRequest, response.NewFailureResponse("Attachment ID is invalid"))
+		return
+	}
+
+	if err := h.annotationService.DeleteAttachment(c, userIDStr, id, attachmentID); err != nil {
+		c.JSON(annotationErrorStatus(err), annotationErrorResponse(err))
+		return
+	}
+
+	c.JSON(http.StatusOK, response.NewSuccessResponse("Attachment deleted successfully"))
+}
+
+func annotationErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, service.ErrTransactionNotFound),
+		errors.Is(err, service.ErrTransactionTagNotFound),
+		errors.Is(err, service.ErrTransactionNoteNotFound),
+		errors.Is(err, service.ErrTransactionAttachmentNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, service.ErrAttachmentTooLarge):
+		return http.StatusRequestEntityTooLarge
+	case errors.Is(err, service.ErrUnsupportedAttachment):
+		return http.StatusUnsupportedMediaType
+	case errors.Is(err, service.ErrTooManyAttachments):
+		return http.StatusConflict
+	case errors.Is(err, service.ErrInvalidTag),
+		errors.Is(err, service.ErrInvalidTransactionNote),
+		errors.Is(err, service.ErrAttachmentEmpty):
+		return http.StatusBadRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+func annotationErrorResponse(err error) any {
+	if annotationErrorStatus(err) == http.StatusInternalServerError {
+		return response.NewServerResponse(err.Error())
+	}
+	return response.NewFailureResponse(err.Error())
+}
@@ -0,0 +1,141 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"lumon-backend/internal/config"
+	"lumon-backend/internal/domain/schemas"
+	"lumon-backend/internal/middleware"
+	"lumon-backend/internal/service"
+	"lumon-backend/pkg/common/logger"
+	"lumon-backend/pkg/common/response"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type TransactionFlagHandler struct {
+	anomalyService *service.AnomalyService
+	cfg            *config.Config
+}
+
+func NewTransactionFlagHandler(anomalyService *service.AnomalyService, cfg *config.Config) *TransactionFlagHandler {
+	return &TransactionFlagHandler{
+		anomalyService: anomalyService,
+		cfg:            cfg,
+	}
+}
+
+func (h *TransactionFlagHandler) RegisterRoutes(r *gin.RouterGroup) {
+	flags := r.Group("/transaction-flags")
+	flags.Use(middleware.JWTMiddleware(h.cfg))
+
+	admins := flags.Group("", middleware.RequireRoles("admin"))
+	{
+		admins.GET("", h.ListFlags)
+		admins.GET("/:id", h.GetFlag)
+		admins.PATCH("/:id/review", h.ReviewFlag)
+	}
+}
+
+func (h *TransactionFlagHandler) ListFlags(c *gin.Context) {
+	var req schemas.TransactionFlagListRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, response.NewFailureResponse(err.Error()))
+		return
+	}
+
+	flags, total, err := h.anomalyService.ListFlags(req)
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidTransactionFlagQuery) {
+			c.JSON(http.StatusBadRequest, response.NewFailureResponse(err.Error()))
+			return
+		}
+		c.JSON(http.StatusInternalServerError, response.NewServerResponse(err.Error()))
+		return
+	}
+
+	c.JSON(
+		http.StatusOK,
+		response.NewSuccessResponse(gin.H{
+			"flags": flags,
+			"meta": gin.H{
+				"total": total,
+			},
+		}),
+	)
+}
+
+func (h *TransactionFlagHandler) GetFlag(c *gin.Context) {
+	id := c.Param("id")
+	if _, err := uuid.Parse(id); err != nil {
+		logger.APILogger.Error("Invalid transaction flag ID in GetFlag:", err)
+		c.JSON(http.StatusBadRequest, response.NewFailureResponse("Invalid transaction flag ID"))
+		return
+	}
+
+	flag, err := h.anomalyService.GetFlag(id)
+	if err != nil {
+		if errors.Is(err, service.ErrTransactionFlagNotFound) {
+			c.JSON(http.StatusNotFound, response.NewFailureResponse(err.Error()))
+			return
+		}
+		c.JSON(http.StatusInternalServerError, response.NewServerResponse(err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, response.NewSuccessResponse(flag))
+}
+
+func (h *TransactionFlagHandler) ReviewFlag(c *gin.Context) {
+	userClaims, exists := c.Get("user")
+	if !exists {
+		logger.APILogger.Error("Unauthorized request in ReviewFlag")
+		c.JSON(http.StatusUnauthorized, response.NewFailureResponse("Unauthorized Request"))
+		return
+	}
+
+	claims, ok := userClaims.(jwt.MapClaims)
+	if !ok {
+		logger.APILogger.Error("Failed to parse user claims in ReviewFlag")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to parse user claims"})
+		return
+	}
+
+	userIDStr, ok := claims["user_id"].(string)
+	if !ok {
+		logger.APILogger.Error("User ID not found in token in ReviewFlag")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "User ID not found in token"})
+		return
+	}
+
+	id := c.Param("id")
+	if _, err := uuid.Parse(id); err != nil {
+		logger.APILogger.Error("Invalid transaction flag ID in ReviewFlag:", err)
+		c.JSON(http.StatusBadRequest, response.NewFailureResponse("Invalid transaction flag ID"))
+		return
+	}
+
+	var req schemas.ReviewTransactionFlagRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, response.NewFailureResponse(err.Error()))
+		return
+	}
+
+	flag, err := h.anomalyService.ReviewFlag(userIDStr, id, req)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrInvalidTransactionFlagReview):
+			c.JSON(http.StatusBadRequest, response.NewFailureResponse(err.Error()))
+		case errors.Is(err, service.ErrTransactionFlagNotFound):
+			c.JSON(http.StatusNotFound, response.NewFailureResponse(err.Error()))
+		default:
+			c.JSON(http.StatusInternalServerError, response.NewServerResponse(err.Error()))
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, response.NewSuccessResponse(flag))
+}
@@ -1,30 +1,50 @@
 package handler
 
 import (
+	"errors"
+	"fmt"
 	"net/http"
-	"strconv"
 
 	"lumon-backend/internal/config"
+	"lumon-backend/internal/domain/models"
+	"lumon-backend/internal/domain/schemas"
 	"lumon-backend/internal/middleware"
-	"lumon-backend/internal/ml"
 	"lumon-backend/internal/service"
 	"lumon-backend/pkg/common/logger"
 	"lumon-backend/pkg/common/response"
 
 	"github.com/dgrijalva/jwt-go"
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
 )
 
+// multipartOverhead is the room left for multipart headers and boundaries on
+// top of the maximum statement size.
+const multipartOverhead = 1 << 20
+
 type TransactionHandler struct {
 	transactionService *service.TransactionService
+	importService      *service.StatementImportService
+	categoryService    *service.CategoryService
 	userService        *service.UserService
+	creditScoreService *service.CreditScoreService
 	cfg                *config.Config
 }
 
-func NewTransactionHandler(transactionService *service.TransactionService, userService *service.UserService, cfg *config.Config) *TransactionHandler {
+func NewTransactionHandler(
+	transactionService *service.TransactionService,
+	importService *service.StatementImportService,
+	categoryService *service.CategoryService,
+	userService *service.UserService,
+	creditScoreService *service.CreditScoreService,
+	cfg *config.Config,
+) *TransactionHandler {
 	return &TransactionHandler{
 		transactionService: transactionService,
+		importService:      importService,
+		categoryService:    categoryService,
 		userService:        userService,
+		creditScoreService: creditScoreService,
 		cfg:                cfg,
 	}
 }
@@ -36,104 +56,174 @@ func (h *TransactionHandler) RegisterRoutes(r *gin.RouterGroup) {
 	transaction = transaction.Group("", middleware.RequireRoles("common"))
 	{
 		transaction.POST("", h.CreateTransactions)
+		transaction.GET("/imports/:id", h.GetImport)
 		transaction.GET("/credit", h.CreateCreditScore)
 		transaction.GET("/item/:id", h.GetTransaction)
-		transaction.GET("/type", h.ListTransactionsByType)
-		transaction.GET("/account", h.ListTransactionsByAccountNumber)
-		transaction.GET("/", h.ListDocuments)
+		transaction.PATCH("/item/:id/category", h.UpdateCategory)
+		transaction.GET("/categories", h.ListCategories)
+		transaction.GET("/recurring", h.ListRecurring)
+		transaction.GET("/export", h.ExportTransactions)
+		transaction.DELETE("/categories/rules/:id", h.DeleteCategoryRule)
+		transaction.GET("/", h.ListTransactions)
 	}
 }
 
 func (h *TransactionHandler) CreateTransactions(c *gin.Context) {
-	req := struct {
-		Url string `json:"url"`
-	}{}
-	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, response.NewFailureResponse(err.Error()))
-		return
-	}
-
-	transactions, batchSize, err := ml.GetMoMoTransactions(c, req.Url)
-	if err != nil {
-		c.JSON(http.StatusBadRequest, response.NewFailureResponse(err.Error()))
-		return
-	}
-
 	user, exists := c.Get("user")
 	if !exists {
-		logger.APILogger.Error("Unauthorized request in CreateLoanRequest")
+		logger.APILogger.Error("Unauthorized request in CreateTransactions")
 		c.JSON(http.StatusUnauthorized, response.NewFailureResponse("Unauthorized Request"))
 		return
 	}
 
 	claims, ok := user.(jwt.MapClaims)
 	if !ok {
-		logger.APILogger.Error("Failed to parse user claims in CreateLoanRequest")
+		logger.APILogger.Error("Failed to parse user claims in CreateTransactions")
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to parse user claims"})
 		return
 	}
 
 	userIDStr, ok := claims["user_id"].(string)
 	if !ok {
-		logger.APILogger.Error("User ID not found in token in CreateLoanRequest")
+		logger.APILogger.Error("User ID not found in token in CreateTransactions")
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "User ID not found in token"})
 		return
 	}
 
-	if err := h.transactionService.CreateTransactionBatch(transactions, batchSize, userIDStr); err != nil {
-		c.JSON(http.StatusInternalServerError, response.NewServerResponse(err.Error()))
+	statementImport, err := h.createImport(c, userIDStr)
+	if err != nil {
+		c.JSON(statementErrorStatus(err), response.NewFailureResponse(err.Error()))
 		return
 	}
 
 	c.JSON(
-		http.StatusOK,
+		http.StatusAccepted,
 		response.NewSuccessResponse(gin.H{
-			"created_transactions": transactions,
-			"batch_size":           batchSize,
+			"import_id": statementImport.ID,
+			"status":    statementImport.Status,
+			"import":    statementImport,
 		}),
 	)
 }
 
-func (h *TransactionHandler) CreateCreditScore(c *gin.Context) {
+// createImport queues the statement sent either as a multipart "file" upload
+// or as a JSON body with the URL to fetch it from. Either may name the
+// provider the statement came from; otherwise it is detected.
+func (h *TransactionHandler) createImport(c *gin.Context, userID string) (*models.StatementImport, error) {
+	if c.ContentType() != "multipart/form-data" {
+		req := struct {
+			Url      string `json:"url" binding:"required"`
+			Provider string `json:"provider"`
+		}{}
+		if err := c.ShouldBindJSON(&req); err != nil {
+			return nil, err
+		}
+
+		return h.importService.CreateFromURL(userID, req.Url, req.Provider)
+	}
+
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, h.cfg.StatementMaxUploadSize+multipartOverhead)
+
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			return nil, service.ErrStatementTooLarge
+		}
+		return nil, err
+	}
+
+	if fileHeader.Size > h.cfg.StatementMaxUploadSize {
+		return nil, service.ErrStatementTooLarge
+	}
+
+	file, err := fileHeader.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	return h.importService.CreateFromUpload(c, userID, fileHeader.Filename, file, c.PostForm("provider"))
+}
+
+func (h *TransactionHandler) GetImport(c *gin.Context) {
 	userClaims, exists := c.Get("user")
 	if !exists {
-		logger.APILogger.Error("Unauthorized request in CreateLoanRequest")
+		logger.APILogger.Error("Unauthorized request in GetImport")
 		c.JSON(http.StatusUnauthorized, response.NewFailureResponse("Unauthorized Request"))
 		return
 	}
 
 	claims, ok := userClaims.(jwt.MapClaims)
 	if !ok {
-		logger.APILogger.Error("Failed to parse user claims in CreateLoanRequest")
+		logger.APILogger.Error("Failed to parse user claims in GetImport")
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to parse user claims"})
 		return
 	}
 
 	userIDStr, ok := claims["user_id"].(string)
 	if !ok {
-		logger.APILogger.Error("User ID not found in token in CreateLoanRequest")
+		logger.APILogger.Error("User ID not found in token in GetImport")
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "User ID not found in token"})
 		return
 	}
 
-	transactions, err := h.transactionService.ListAllTransactions(userIDStr)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	id := c.Param("id")
+	if _, err := uuid.Parse(id); err != nil {
+		c.JSON(http.StatusBadRequest, response.NewFailureResponse("ID is invalid"))
 		return
 	}
 
-	dbUser, err := h.userService.GetUserModel(userIDStr)
+	statementImport, err := h.importService.GetImport(userIDStr, id)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		if errors.Is(err, service.ErrStatementImportNotFound) {
+			c.JSON(http.StatusNotFound, response.NewFailureResponse(err.Error()))
+			return
+		}
+		c.JSON(http.StatusInternalServerError, response.NewServerResponse(err.Error()))
 		return
 	}
 
-	var calculator *service.CreditScoreCalculator = service.NewCreditScoreCalculator(transactions)
-	creditScore := calculator.Calculate()
+	c.JSON(http.StatusOK, response.NewSuccessResponse(statementImport))
+}
+
+func statementErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, service.ErrStatementTooLarge):
+		return http.StatusRequestEntityTooLarge
+	case errors.Is(err, service.ErrUnsupportedStatement):
+		return http.StatusUnsupportedMediaType
+	case errors.Is(err, service.ErrStatementHostNotAllowed):
+		return http.StatusForbidden
+	default:
+		return http.StatusBadRequest
+	}
+}
 
-	dbUser.CreditScore = int64(creditScore)
+func (h *TransactionHandler) CreateCreditScore(c *gin.Context) {
+	userClaims, exists := c.Get("user")
+	if !exists {
+		logger.APILogger.Error("Unauthorized request in CreateLoanRequest")
+		c.JSON(http.StatusUnauthorized, response.NewFailureResponse("Unauthorized Request"))
+		return
+	}
+
+	claims, ok := userClaims.(jwt.MapClaims)
+	if !ok {
+		logger.APILogger.Error("Failed to parse user claims in CreateLoanRequest")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to parse user claims"})
+		return
+	}
 
-	if err := h.userService.UpdateUser(dbUser); err != nil {
+	userIDStr, ok := claims["user_id"].(string)
+	if !ok {
+		logger.APILogger.Error("User ID not found in token in CreateLoanRequest")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "User ID not found in token"})
+		return
+	}
+
+	snapshot, err := h.creditScoreService.CalculateCreditScore(userIDStr)
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
@@ -141,19 +231,42 @@ func (h *TransactionHandler) CreateCreditScore(c *gin.Context) {
 	c.JSON(
 		http.StatusOK,
 		response.NewSuccessResponse(gin.H{
-			"credit_score": creditScore,
+			"status":       snapshot.Status,
+			"credit_score": snapshot.Score,
+			"snapshot":     snapshot,
 		}),
 	)
 }
 
 func (h *TransactionHandler) GetTransaction(c *gin.Context) {
+	userClaims, exists := c.Get("user")
+	if !exists {
+		logger.APILogger.Error("Unauthorized request in GetTransaction")
+		c.JSON(http.StatusUnauthorized, response.NewFailureResponse("Unauthorized Request"))
+		return
+	}
+
+	claims, ok := userClaims.(jwt.MapClaims)
+	if !ok {
+		logger.APILogger.Error("Failed to parse user claims in GetTransaction")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to parse user claims"})
+		return
+	}
+
+	userIDStr, ok := claims["user_id"].(string)
+	if !ok {
+		logger.APILogger.Error("User ID not found in token in GetTransaction")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "User ID not found in token"})
+		return
+	}
+
 	id := c.Param("id")
-	if id == "" {
+	if _, err := uuid.Parse(id); err != nil {
 		c.JSON(http.StatusBadRequest, response.NewFailureResponse("ID is invalid"))
 		return
 	}
 
-	transaction, err := h.transactionService.GetTransaction(id)
+	transaction, err := h.transactionService.GetTransaction(userIDStr, id)
 	if err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Transaction not found"})
 		return
@@ -162,6 +275,127 @@ func (h *TransactionHandler) GetTransaction(c *gin.Context) {
 	c.JSON(http.StatusOK, response.NewSuccessResponse(transaction))
 }
 
+func (h *TransactionHandler) UpdateCategory(c *gin.Context) {
+	userClaims, exists := c.Get("user")
+	if !exists {
+		logger.APILogger.Error("Unauthorized request in UpdateCategory")
+		c.JSON(http.StatusUnauthorized, response.NewFailureResponse("Unauthorized Request"))
+		return
+	}
+
+	claims, ok := userClaims.(jwt.MapClaims)
+	if !ok {
+		logger.APILogger.Error("Failed to parse user claims in UpdateCategory")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to parse user claims"})
+		return
+	}
+
+	userIDStr, ok := claims["user_id"].(string)
+	if !ok {
+		logger.APILogger.Error("User ID not found in token in UpdateCategory")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "User ID not found in token"})
+		return
+	}
+
+	id := c.Param("id")
+	if _, err := uuid.Parse(id); err != nil {
+		c.JSON(http.StatusBadRequest, response.NewFailureResponse("ID is invalid"))
+		return
+	}
+
+	var req schemas.UpdateCategoryRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, response.NewFailureResponse(err.Error()))
+		return
+	}
+
+	correction, err := h.categoryService.CorrectCategory(userIDStr, id, req.Category)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrInvalidCategory):
+			c.JSON(http.StatusBadRequest, response.NewFailureResponse(err.Error()))
+		case errors.Is(err, service.ErrTransactionNotFound):
+			c.JSON(http.StatusNotFound, response.NewFailureResponse(err.Error()))
+		default:
+			c.JSON(http.StatusInternalServerError, response.NewServerResponse(err.Error()))
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, response.NewSuccessResponse(correction))
+}
+
+func (h *TransactionHandler) ListCategories(c *gin.Context) {
+	userClaims, exists := c.Get("user")
+	if !exists {
+		logger.APILogger.Error("Unauthorized request in ListCategories")
+		c.JSON(http.StatusUnauthorized, response.NewFailureResponse("Unauthorized Request"))
+		return
+	}
+
+	claims, ok := userClaims.(jwt.MapClaims)
+	if !ok {
+		logger.APILogger.Error("Failed to parse user claims in ListCategories")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to parse user claims"})
+		return
+	}
+
+	userIDStr, ok := claims["user_id"].(string)
+	if !ok {
+		logger.APILogger.Error("User ID not found in token in ListCategories")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "User ID not found in token"})
+		return
+	}
+
+	categories, err := h.categoryService.ListCategories(userIDStr)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, response.NewServerResponse(err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, response.NewSuccessResponse(categories))
+}
+
+func (h *TransactionHandler) DeleteCategoryRule(c *gin.Context) {
+	userClaims, exists := c.Get("user")
+	if !exists {
+		logger.APILogger.Error("Unauthorized request in DeleteCategoryRule")
+		c.JSON(http.StatusUnauthorized, response.NewFailureResponse("Unauthorized Request"))
+		return
+	}
+
+	claims, ok := userClaims.(jwt.MapClaims)
+	if !ok {
+		logger.APILogger.Error("Failed to parse user claims in DeleteCategoryRule")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to parse user claims"})
+		return
+	}
+
+	userIDStr, ok := claims["user_id"].(string)
+	if !ok {
+		logger.APILogger.Error("User ID not found in token in DeleteCategoryRule")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "User ID not found in token"})
+		return
+	}
+
+	id := c.Param("id")
+	if _, err := uuid.Parse(id); err != nil {
+		c.JSON(http.StatusBadRequest, response.NewFailureResponse("ID is invalid"))
+		return
+	}
+
+	if err := h.categoryService.DeleteRule(userIDStr, id); err != nil {
+		if errors.Is(err, service.ErrCategoryRuleNotFound) {
+			c.JSON(http.StatusNotFound, response.NewFailureResponse(err.Error()))
+			return
+		}
+		c.JSON(http.StatusInternalServerError, response.NewServerResponse(err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, response.NewSuccessResponse(gin.H{"message": "Category rule deleted successfully"}))
+}
+
 func (h *TransactionHandler) DeleteTransaction(c *gin.Context) {
 	id := c.Param("id")
 	if id == "" {
@@ -177,108 +411,135 @@ func (h *TransactionHandler) DeleteTransaction(c *gin.Context) {
 	c.JSON(http.StatusOK, response.NewSuccessResponse(gin.H{"message": "Transaction deleted successfully"}))
 }
 
-func (h *TransactionHandler) ListDocuments(c *gin.Context) {
-	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
-	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "10"))
-
+// ListRecurring returns the user's recurring income and payments.
+func (h *TransactionHandler) ListRecurring(c *gin.Context) {
 	userClaims, exists := c.Get("user")
 	if !exists {
-		logger.APILogger.Error("Unauthorized request in ListDocumnents")
+		logger.APILogger.Error("Unauthorized request in ListRecurring")
 		c.JSON(http.StatusUnauthorized, response.NewFailureResponse("Unauthorized Request"))
 		return
 	}
 
 	claims, ok := userClaims.(jwt.MapClaims)
 	if !ok {
-		logger.APILogger.Error("Failed to parse user claims in ListDocumnents")
+		logger.APILogger.Error("Failed to parse user claims in ListRecurring")
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to parse user claims"})
 		return
 	}
 
 	userIDStr, ok := claims["user_id"].(string)
 	if !ok {
-		logger.APILogger.Error("User ID not found in token in ListDocumnents")
+		logger.APILogger.Error("User ID not found in token in ListRecurring")
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "User ID not found in token"})
 		return
 	}
 
-	transactions, total, err := h.transactionService.ListTransactions(userIDStr, page, pageSize)
+	recurring, err := h.transactionService.ListRecurring(userIDStr)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.JSON(http.StatusInternalServerError, response.NewServerResponse(err.Error()))
 		return
 	}
 
-	c.JSON(
-		http.StatusOK,
-		response.NewSuccessResponse(gin.H{
-			"transactions": transactions,
-			"meta": gin.H{
-				"total":     total,
-				"page":      page,
-				"page_size": pageSize,
-			},
-		}),
-	)
+	c.JSON(http.StatusOK, response.NewSuccessResponse(recurring))
 }
 
-func (h *TransactionHandler) ListTransactionsByAccountNumber(c *gin.Context) {
-	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
-	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "10"))
+// ListTransactions searches the user's transactions with the filters, sort
+// and cursor given in the query string.
+func (h *TransactionHandler) ListTransactions(c *gin.Context) {
+	userClaims, exists := c.Get("user")
+	if !exists {
+		logger.APILogger.Error("Unauthorized request in ListTransactions")
+		c.JSON(http.StatusUnauthorized, response.NewFailureResponse("Unauthorized Request"))
+		return
+	}
 
-	req := struct {
-		AccountNumber string `json:"account_number"`
-	}{}
-	if err := c.ShouldBindJSON(&req); err != nil {
+	claims, ok := userClaims.(jwt.MapClaims)
+	if !ok {
+		logger.APILogger.Error("Failed to parse user claims in ListTransactions")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to parse user claims"})
+		return
+	}
+
+	userIDStr, ok := claims["user_id"].(string)
+	if !ok {
+		logger.APILogger.Error("User ID not found in token in ListTransactions")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "User ID not found in token"})
+		return
+	}
+
+	var req schemas.TransactionSearchRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
 		c.JSON(http.StatusBadRequest, response.NewFailureResponse(err.Error()))
 		return
 	}
 
-	transactions, total, err := h.transactionService.GetTransactionsByAccountNumber(req.AccountNumber, page, pageSize)
+	page, err := h.transactionService.SearchTransactions(userIDStr, req)
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Transactions not found"})
+		if errors.Is(err, service.ErrInvalidTransactionQuery) {
+			c.JSON(http.StatusBadRequest, response.NewFailureResponse(err.Error()))
+			return
+		}
+		c.JSON(http.StatusInternalServerError, response.NewServerResponse(err.Error()))
 		return
 	}
 
 	c.JSON(
 		http.StatusOK,
 		response.NewSuccessResponse(gin.H{
-			"transactions": transactions,
+			"transactions": page.Transactions,
 			"meta": gin.H{
-				"total":     total,
-				"page":      page,
-				"page_size": pageSize,
+				"next_cursor": page.NextCursor,
+				"has_more":    page.HasMore,
 			},
 		}),
 	)
 }
 
-func (h *TransactionHandler) ListTransactionsByType(c *gin.Context) {
-	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
-	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "10"))
+// ExportTransactions streams the transactions matching the search filters as
+// a CSV, XLSX or OFX file. Problems with the request are reported before the
+// file starts; an error while streaming can only cut the file short.
+func (h *TransactionHandler) ExportTransactions(c *gin.Context) {
+	userClaims, exists := c.Get("user")
+	if !exists {
+		logger.APILogger.Error("Unauthorized request in ExportTransactions")
+		c.JSON(http.StatusUnauthorized, response.NewFailureResponse("Unauthorized Request"))
+		return
+	}
 
-	req := struct {
-		Type string `json:"type"`
-	}{}
-	if err := c.ShouldBindJSON(&req); err != nil {
+	claims, ok := userClaims.(jwt.MapClaims)
+	if !ok {
+		logger.APILogger.Error("Failed to parse user claims in ExportTransactions")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to parse user claims"})
+		return
+	}
+
+	userIDStr, ok := claims["user_id"].(string)
+	if !ok {
+		logger.APILogger.Error("User ID not found in token in ExportTransactions")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "User ID not found in token"})
+		return
+	}
+
+	var req schemas.TransactionExportRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
 		c.JSON(http.StatusBadRequest, response.NewFailureResponse(err.Error()))
 		return
 	}
 
-	transactions, total, err := h.transactionService.GetTransactionsByType(req.Type, page, pageSize)
+	export, err := h.transactionService.NewExport(userIDStr, req)
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Transactions not found"})
+		if errors.Is(err, service.ErrInvalidTransactionExport) || errors.Is(err, service.ErrInvalidTransactionQuery) {
+			c.JSON(http.StatusBadRequest, response.NewFailureResponse(err.Error()))
+			return
+		}
+		c.JSON(http.StatusInternalServerError, response.NewServerResponse(err.Error()))
 		return
 	}
 
-	c.JSON(
-		http.StatusOK,
-		response.NewSuccessResponse(gin.H{
-			"transactions": transactions,
-			"meta": gin.H{
-				"total":     total,
-				"page":      page,
-				"page_size": pageSize,
-			},
-		}),
-	)
+	c.Header("Content-Type", export.ContentType)
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, export.Filename))
+	c.Status(http.StatusOK)
+
+	// WriteTo logs its own errors, and the status has already been sent.
+	_ = export.WriteTo(c.Request.Context(), c.Writer)
 }
@@ -9,22 +9,26 @@ import (
 	"lumon-backend/internal/domain/schemas"
 	"lumon-backend/internal/middleware"
 	"lumon-backend/internal/service"
+	"lumon-backend/pkg/common/logger"
 	"lumon-backend/pkg/common/response"
 
+	"github.com/dgrijalva/jwt-go"
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 	"golang.org/x/crypto/bcrypt"
 )
 
 type UserHandler struct {
-	userService *service.UserService
-	cfg         *config.Config
+	userService        *service.UserService
+	creditScoreService *service.CreditScoreService
+	cfg                *config.Config
 }
 
-func NewUserHandler(userService *service.UserService, cfg *config.Config) *UserHandler {
+func NewUserHandler(userService *service.UserService, creditScoreService *service.CreditScoreService, cfg *config.Config) *UserHandler {
 	return &UserHandler{
-		userService: userService,
-		cfg:         cfg,
+		userService:        userService,
+		creditScoreService: creditScoreService,
+		cfg:                cfg,
 	}
 }
 
@@ -39,6 +43,8 @@ func (h *UserHandler) RegisterRoutes(r *gin.RouterGroup) {
 
 	user := users.Group("", middleware.RequireRoles("common"))
 	{
+		user.GET("/me/credit-score/history", h.GetCreditScoreHistory)
+		user.POST("/me/credit-score/simulate", h.SimulateCreditScore)
 		user.GET("/:id", h.GetUser)
 		user.PUT("/:id", h.UpdateUser)
 		user.GET("/", h.ListUsers)
@@ -131,3 +137,73 @@ func (h *UserHandler) ListUsers(c *gin.Context) {
 		}),
 	)
 }
+
+func (h *UserHandler) GetCreditScoreHistory(c *gin.Context) {
+	userClaims, exists := c.Get("user")
+	if !exists {
+		logger.APILogger.Error("Unauthorized request in GetCreditScoreHistory")
+		c.JSON(http.StatusUnauthorized, response.NewFailureResponse("Unauthorized Request"))
+		return
+	}
+
+	claims, ok := userClaims.(jwt.MapClaims)
+	if !ok {
+		logger.APILogger.Error("Failed to parse user claims in GetCreditScoreHistory")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to parse user claims"})
+		return
+	}
+
+	userIDStr, ok := claims["user_id"].(string)
+	if !ok {
+		logger.APILogger.Error("User ID not found in token in GetCreditScoreHistory")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "User ID not found in token"})
+		return
+	}
+
+	history, err := h.creditScoreService.GetCreditScoreHistory(userIDStr)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, response.NewSuccessResponse(history))
+}
+
+func (h *UserHandler) SimulateCreditScore(c *gin.Context) {
+	var request schemas.CreditScoreSimulationDetails
+
+	if err := c.ShouldBindJSON(&request); err != nil {
+		logger.APILogger.Error("Failed to bind JSON in SimulateCreditScore:", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	userClaims, exists := c.Get("user")
+	if !exists {
+		logger.APILogger.Error("Unauthorized request in SimulateCreditScore")
+		c.JSON(http.StatusUnauthorized, response.NewFailureResponse("Unauthorized Request"))
+		return
+	}
+
+	claims, ok := userClaims.(jwt.MapClaims)
+	if !ok {
+		logger.APILogger.Error("Failed to parse user claims in SimulateCreditScore")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to parse user claims"})
+		return
+	}
+
+	userIDStr, ok := claims["user_id"].(string)
+	if !ok {
+		logger.APILogger.Error("User ID not found in token in SimulateCreditScore")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "User ID not found in token"})
+		return
+	}
+
+	simulation, err := h.creditScoreService.SimulateCreditScore(userIDStr, request)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, response.NewSuccessResponse(simulation))
+}
@@ -85,13 +85,14 @@ func (h *WalletsHandler) TopUpWallet(c *gin.Context) {
 		UserID:  userID,
 	}
 
-	if err := h.walletService.TopUpAccount(wallet); err != nil {
+	result, err := h.walletService.TopUpAccount(wallet)
+	if err != nil {
 		logger.APILogger.Errorf("Failed to top up wallet: %v", err)
 		c.JSON(http.StatusInternalServerError, response.NewFailureResponse(err.Error()))
 		return
 	}
 
-	c.JSON(http.StatusCreated, response.NewSuccessResponse("Wallet topped up successfully"))
+	c.JSON(http.StatusCreated, response.NewSuccessResponse(result))
 }
 
 func (h *WalletsHandler) WithdrawWallet(c *gin.Context) {
@@ -130,11 +131,12 @@ func (h *WalletsHandler) WithdrawWallet(c *gin.Context) {
 		return
 	}
 
-	if err := h.walletService.WithdrawAccount(userIDStr, request.Amount); err != nil {
+	result, err := h.walletService.WithdrawAccount(userIDStr, request.Amount)
+	if err != nil {
 		logger.APILogger.Errorf("Failed to withdraw from wallet: %v", err)
 		c.JSON(http.StatusBadRequest, response.NewFailureResponse(err.Error()))
 		return
 	}
 
-	c.JSON(http.StatusOK, response.NewSuccessResponse("Withdrawal processed successfully"))
+	c.JSON(http.StatusOK, response.NewSuccessResponse(result))
 }
\ No newline at end of file
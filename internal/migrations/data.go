@@ -0,0 +1,182 @@
+package migrations
+
+import (
+	"errors"
+	"fmt"
+
+	"lumon-backend/internal/counterparties"
+	"lumon-backend/internal/domain/models"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// dataMigrationBatchSize is how many rows a data migration loads at a time.
+const dataMigrationBatchSize = 500
+
+// RunDataMigrations fills in data that the schema migrations leave empty on
+// existing rows. Each step either only touches rows it has not seen before, or
+// is recorded as a DataMigration and run once, so it is cheap to run on every
+// start. Phone numbers without a country code are read as being in
+// phoneCountryCode.
+func RunDataMigrations(db *gorm.DB, phoneCountryCode string) error {
+	if err := backfillTransactionFingerprints(db); err != nil {
+		return fmt.Errorf("backfill transaction fingerprints: %w", err)
+	}
+
+	err := runOnce(db, "link-transaction-counterparties", func(tx *gorm.DB) error {
+		return linkTransactionCounterparties(tx, phoneCountryCode)
+	})
+	if err != nil {
+		return fmt.Errorf("link transaction counterparties: %w", err)
+	}
+
+	if err := runOnce(db, "single-active-scoring-profile", indexActiveScoringProfile); err != nil {
+		return fmt.Errorf("index active scoring profile: %w", err)
+	}
+
+	return nil
+}
+
+// runOnce runs the named migration in a database transaction unless it has
+// already been run, and records that it has.
+func runOnce(db *gorm.DB, name string, migrate func(tx *gorm.DB) error) error {
+	err := db.Where("name = ?", name).First(&models.DataMigration{}).Error
+	if err == nil {
+		return nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return err
+	}
+
+	return db.Transaction(func(tx *gorm.DB) error {
+		if err := migrate(tx); err != nil {
+			return err
+		}
+		return tx.Create(&models.DataMigration{Name: name}).Error
+	})
+}
+
+// backfillTransactionFingerprints fingerprints the transactions stored before
+// fingerprints were. A row whose fingerprint another of the user's rows
+// already has is a duplicate stored before they were caught; it is given an
+// empty fingerprint, which the unique index ignores, so that it is not looked
+// at again.
+func backfillTransactionFingerprints(db *gorm.DB) error {
+	var transactions []models.Transaction
+
+	return db.Where("fingerprint IS NULL").
+		FindInBatches(&transactions, dataMigrationBatchSize, func(_ *gorm.DB, _ int) error {
+			for _, transaction := range transactions {
+				fingerprint := transaction.ComputeFingerprint()
+
+				result := db.Exec(
+					`UPDATE transactions SET fingerprint = ? WHERE id = ? AND NOT EXISTS (
+						SELECT 1 FROM transactions WHERE user_id = ? AND fingerprint = ?
+					)`,
+					fingerprint, transaction.ID, transaction.UserID, fingerprint,
+				)
+				if result.Error != nil {
+					return result.Error
+				}
+				if result.RowsAffected > 0 {
+					continue
+				}
+
+				err := db.Model(&models.Transaction{}).
+					Where("id = ?", transaction.ID).
+					Update("fingerprint", "").Error
+				if err != nil {
+					return err
+				}
+			}
+			return nil
+		}).Error
+}
+
+// linkTransactionCounterparties links the transactions stored before the
+// counterparty directory existed to their counterparties, adding to each
+// user's directory the counterparties it does not have yet. Transactions
+// stored since are linked as they are imported.
+func linkTransactionCounterparties(db *gorm.DB, phoneCountryCode string) error {
+	var userIDs []uuid.UUID
+	err := db.Model(&models.Transaction{}).
+		Where("counterparty_id IS NULL").
+		Distinct().
+		Pluck("user_id", &userIDs).Error
+	if err != nil {
+		return err
+	}
+
+	for _, userID := range userIDs {
+		if err := linkUserCounterparties(db, userID, phoneCountryCode); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func linkUserCounterparties(db *gorm.DB, userID uuid.UUID, phoneCountryCode string) error {
+	var known []models.Counterparty
+	if err := db.Where("user_id = ?", userID).Order("created_at").Find(&known).Error; err != nil {
+		return err
+	}
+
+	var transactions []models.Transaction
+	err := db.Where("user_id = ? AND counterparty_id IS NULL", userID).
+		Order("transaction_date").
+		Find(&transactions).Error
+	if err != nil {
+		return err
+	}
+
+	directory := counterparties.NewDirectory(known, phoneCountryCode)
+	byCounterparty := make(map[*models.Counterparty][]uuid.UUID)
+	for i := range transactions {
+		if counterparty := directory.Resolve(&transactions[i]); counterparty != nil {
+			byCounterparty[counterparty] = append(byCounterparty[counterparty], transactions[i].ID)
+		}
+	}
+
+	if created := directory.Created(); len(created) > 0 {
+		if err := db.Create(created).Error; err != nil {
+			return err
+		}
+	}
+	for _, counterparty := range directory.Changed() {
+		if err := db.Omit(clause.Associations).Save(counterparty).Error; err != nil {
+			return err
+		}
+	}
+
+	for counterparty, ids := range byCounterparty {
+		err := db.Model(&models.Transaction{}).
+			Where("id IN ?", ids).
+			Update("counterparty_id", counterparty.ID).Error
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// indexActiveScoringProfile makes sure no more than one scoring profile can be
+// active. When several already are, the one most recently changed stays
+// active.
+func indexActiveScoringProfile(db *gorm.DB) error {
+	err := db.Exec(
+		`UPDATE scoring_profiles SET is_active = false WHERE is_active AND id <> (
+			SELECT id FROM scoring_profiles WHERE is_active ORDER BY updated_at DESC, id LIMIT 1
+		)`,
+	).Error
+	if err != nil {
+		return err
+	}
+
+	return db.Exec(
+		`CREATE UNIQUE INDEX IF NOT EXISTS idx_scoring_profiles_active ON scoring_profiles (is_active) WHERE is_active`,
+	).Error
+}
@@ -8,10 +8,29 @@ func GetMigrationModels() []interface{} {
 	mgrModel := []any{
 		&models.User{},
 		&models.Document{},
+		&models.Counterparty{},
 		&models.Transaction{},
 		&models.LoanRequest{},
 		&models.Account{},
 		&models.Wallet{},
+		&models.WalletLedgerEntry{},
+		&models.ScoringProfile{},
+		&models.CreditScoreSnapshot{},
+		&models.ScoreRecalculationRun{},
+		&models.CreditScoreAlert{},
+		&models.CreditReport{},
+		&models.ScoreMonitoringReport{},
+		&models.StatementImport{},
+		&models.StatementImportRejection{},
+		&models.CategoryRule{},
+		&models.TransactionFlag{},
+		&models.TransactionTag{},
+		&models.TransactionNote{},
+		&models.TransactionAttachment{},
+		&models.Notification{},
+		&models.Budget{},
+		&models.BudgetAlert{},
+		&models.DataMigration{},
 	}
 
 	return mgrModel
@@ -91,6 +91,26 @@ func GenerateContentText(
 	return result, nil
 }
 
+func GenerateContentJSON(
+	ctx context.Context,
+	client *genai.Client,
+	prompt string,
+	responseSchema *genai.Schema,
+) (*genai.GenerateContentResponse, error) {
+	parts := []*genai.Part{
+		{Text: prompt},
+	}
+	contents := []*genai.Content{{Parts: parts}}
+
+	config := getGenerateConfigJSON(responseSchema)
+	result, err := client.Models.GenerateContent(ctx, BASEMODEL, contents, config)
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
 func GenerateContentWithFilesText(
 	ctx context.Context,
 	client *genai.Client,
@@ -183,6 +203,25 @@ func GenerateContentStreamWithFilesJSON(
 	return result, nil
 }
 
+// GenerateContentStreamWithDataJSON is GenerateContentStreamWithFilesJSON for
+// a file that has already been loaded, such as a direct upload.
+func GenerateContentStreamWithDataJSON(
+	ctx context.Context,
+	client *genai.Client,
+	data []byte,
+	mimeType, prompt string,
+	responseSchema *genai.Schema,
+) iter.Seq2[*genai.GenerateContentResponse, error] {
+	parts := []*genai.Part{
+		{Text: prompt},
+		{InlineData: &genai.Blob{Data: data, MIMEType: mimeType}},
+	}
+	contents := []*genai.Content{{Parts: parts}}
+
+	config := getGenerateConfigJSON(responseSchema)
+	return client.Models.GenerateContentStream(ctx, BASEMODEL, contents, config)
+}
+
 func GenerateContentWithImagesText(
 	ctx context.Context,
 	client *genai.Client,
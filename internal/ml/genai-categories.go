@@ -0,0 +1,67 @@
+package ml
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"lumon-backend/internal/config"
+)
+
+// UnknownCategory is what the model answers for a transaction it cannot
+// place in any of the categories.
+const UnknownCategory = "unknown"
+
+type transactionCategory struct {
+	Row      int    `json:"row"`
+	Category string `json:"category"`
+}
+
+// GetTransactionCategories asks the model to place each transaction, described
+// in a line of its own, in one of the categories. The result has a category,
+// or UnknownCategory, for every line.
+func GetTransactionCategories(ctx context.Context, transactions []string, categories []string) ([]string, error) {
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	client, err := GetClientWithContext(ctx, *cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	allowed := append(append([]string{}, categories...), UnknownCategory)
+	schema := GetTransactionCategorySchema(allowed)
+
+	var prompt strings.Builder
+	fmt.Fprintf(&prompt, "These are mobile money and bank transactions from Ghana, one per row. "+
+		"Place each in one of these spending categories: %s. Answer %s when the counterparty and reference "+
+		"do not make the category clear, rather than guessing. Return one entry per row.\n\n",
+		strings.Join(categories, ", "), UnknownCategory)
+	for i, transaction := range transactions {
+		fmt.Fprintf(&prompt, "%d. %s\n", i+1, transaction)
+	}
+
+	response, err := GenerateContentJSON(ctx, client, prompt.String(), schema)
+	if err != nil {
+		return nil, err
+	}
+
+	var answers []transactionCategory
+	if err := ResponseToStructure(response, &answers); err != nil {
+		return nil, err
+	}
+
+	result := make([]string, len(transactions))
+	for i := range result {
+		result[i] = UnknownCategory
+	}
+	for _, answer := range answers {
+		if answer.Row >= 1 && answer.Row <= len(result) {
+			result[answer.Row-1] = answer.Category
+		}
+	}
+
+	return result, nil
+}
@@ -29,6 +29,9 @@ func GetTransactionSchema() *genai.Schema {
 				"fees": {
 					Type: genai.TypeNumber,
 				},
+				"e_levy": {
+					Type: genai.TypeNumber,
+				},
 				"balance_before": {
 					Type: genai.TypeNumber,
 				},
@@ -66,3 +69,25 @@ func GetTransactionSchema() *genai.Schema {
 		},
 	}
 }
+
+func GetTransactionCategorySchema(categories []string) *genai.Schema {
+	return &genai.Schema{
+		Type: genai.TypeArray,
+		Items: &genai.Schema{
+			Type: genai.TypeObject,
+			Properties: map[string]*genai.Schema{
+				"row": {
+					Type: genai.TypeInteger,
+				},
+				"category": {
+					Type: genai.TypeString,
+					Enum: categories,
+				},
+			},
+			Required: []string{
+				"row",
+				"category",
+			},
+		},
+	}
+}
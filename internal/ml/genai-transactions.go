@@ -1,14 +1,19 @@
 package ml
 
 import (
+	"bytes"
 	"context"
+	"encoding/csv"
 	"encoding/json"
+	"fmt"
+	"strings"
 
 	"lumon-backend/internal/config"
 	"lumon-backend/internal/domain/schemas"
+	"lumon-backend/internal/statements"
 )
 
-func GetMoMoTransactionData(ctx context.Context, fileUrl string) (*schemas.MTNMoMoTransactionScrape, error) {
+func GetMoMoTransactionData(ctx context.Context, fileUrl string) (*schemas.TransactionScrape, error) {
 	cfg, err := config.LoadConfig()
 	if err != nil {
 		return nil, err
@@ -26,7 +31,7 @@ func GetMoMoTransactionData(ctx context.Context, fileUrl string) (*schemas.MTNMo
 		return nil, err
 	}
 
-	var transaction schemas.MTNMoMoTransactionScrape
+	var transaction schemas.TransactionScrape
 	if err := ResponseToStructure(response, &transaction); err != nil {
 		return nil, err
 	}
@@ -34,10 +39,19 @@ func GetMoMoTransactionData(ctx context.Context, fileUrl string) (*schemas.MTNMo
 	return &transaction, nil
 }
 
-func GetMoMoTransactions(
+// GetStatementTransactions extracts the transactions from a statement file.
+// PDF and CSV statements are sent as they are; spreadsheets are converted to
+// CSV first since the model does not read them. provider is the name of the
+// provider the statement came from, or empty when it is not known. problems
+// lists what was wrong with a previous extraction of the same statement, for
+// the model to correct.
+func GetStatementTransactions(
 	ctx context.Context,
-	fileUrl string,
-) (data []*schemas.MTNMoMoTransactionScrape, batchSize int, err error) {
+	data []byte,
+	mimeType string,
+	provider string,
+	problems []string,
+) (transactions []*schemas.TransactionScrape, batchSize int, err error) {
 	cfg, err := config.LoadConfig()
 	if err != nil {
 		return nil, 0, err
@@ -48,19 +62,60 @@ func GetMoMoTransactions(
 		return nil, 0, err
 	}
 
+	if mimeType == statements.XLSXMIMEType {
+		data, err = spreadsheetToCSV(data)
+		if err != nil {
+			return nil, 0, err
+		}
+		mimeType = statements.CSVMIMEType
+	}
+
 	schema := GetTransactionSchema()
 
-	response, err := GenerateContentStreamWithFilesJSON(ctx, client, fileUrl, "Extract the first 20 transactions from the table and return them as a JSON array.", schema)
-	if err != nil {
-		return nil, 0, err
-	}
+	response := GenerateContentStreamWithDataJSON(ctx, client, data, mimeType, statementPrompt(provider, problems), schema)
 
 	blob := IterResponseToString(response)
 
-	var transactions []*schemas.MTNMoMoTransactionScrape
 	if err := json.Unmarshal([]byte(blob), &transactions); err != nil {
 		return nil, 0, err
 	}
 
 	return transactions, len(transactions), nil
 }
+
+func statementPrompt(provider string, problems []string) string {
+	source := "a mobile money or bank statement"
+	if provider != "" {
+		source = "a " + provider + " statement"
+	}
+
+	prompt := fmt.Sprintf("This is %s. Extract every transaction from the table and return them as a JSON array. "+
+		"Write transaction_date as YYYY-MM-DD HH:MM:SS. Set transaction_type to CASH_IN for money received, "+
+		"and to CASH_OUT, TRANSFER, PAYMENT or DEBIT for money sent, withdrawn or spent. "+
+		"Leave the account holder's side of the transaction empty when the statement does not show it. "+
+		"Each balance_after must equal balance_before plus the amount received, or minus the amount, fees and "+
+		"e_levy paid, and balance_before must equal the balance_after of the transaction before it.", source)
+
+	if len(problems) == 0 {
+		return prompt
+	}
+
+	return prompt + "\n\nA previous extraction of this statement had these problems, with rows numbered from 1 " +
+		"in statement order. Read those rows again from the statement and correct them, keeping every other " +
+		"transaction as it is:\n- " + strings.Join(problems, "\n- ")
+}
+
+func spreadsheetToCSV(data []byte) ([]byte, error) {
+	rows, err := statements.ReadRows(data, statements.XLSXMIMEType)
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	writer := csv.NewWriter(&buf)
+	if err := writer.WriteAll(rows); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,327 @@
+// Package recurrence finds the money a user pays or receives on a schedule,
+// such as a salary, rent, subscriptions or loan repayments, by looking for
+// counterparties that come back at a regular interval with a similar amount.
+package recurrence
+
+import (
+	"math"
+	"sort"
+	"time"
+
+	"lumon-backend/internal/categories"
+	"lumon-backend/internal/domain/models"
+)
+
+const (
+	PeriodWeekly    = "weekly"
+	PeriodBiweekly  = "biweekly"
+	PeriodMonthly   = "monthly"
+	PeriodQuarterly = "quarterly"
+)
+
+const (
+	// MinOccurrences is the fewest transactions a schedule is inferred from.
+	MinOccurrences = 3
+	// MinConfidence is the confidence below which a series is not reported.
+	MinConfidence = 0.5
+
+	// amountTolerance is how far, as a share of the expected amount, an
+	// occurrence may be off and still count as the same payment.
+	amountTolerance = 0.2
+	// recentOccurrences is how many of the latest occurrences the expected
+	// amount is taken from, so that a raise or a new rent is picked up.
+	recentOccurrences = 6
+	// fullCountOccurrences is the number of occurrences from which the count
+	// no longer adds to the confidence.
+	fullCountOccurrences = 7
+
+	daysPerMonth = 30.44
+)
+
+type period struct {
+	name string
+	days float64
+	// tolerance is how many days early or late an occurrence may be.
+	tolerance float64
+	months    int
+}
+
+var periods = []period{
+	{name: PeriodWeekly, days: 7, tolerance: 1.5},
+	{name: PeriodBiweekly, days: 14, tolerance: 2.5},
+	{name: PeriodMonthly, days: daysPerMonth, tolerance: 4, months: 1},
+	{name: PeriodQuarterly, days: 3 * daysPerMonth, tolerance: 10, months: 3},
+}
+
+// Series is money that moves to or from the same counterparty on a schedule.
+type Series struct {
+	Counterparty string
+	Number       string
+	// Category is the category most of the series' transactions are in.
+	Category string
+	Incoming bool
+	Period   string
+	// ExpectedAmount is the median of the latest occurrences.
+	ExpectedAmount float64
+	Occurrences    int
+	// Missed counts the occurrences the schedule expected but that never
+	// came, judged from the gaps between the ones that did.
+	Missed int
+	// Punctuality is the share of the expected occurrences that came on
+	// schedule, from 0 to 1.
+	Punctuality  float64
+	FirstDate    time.Time
+	LastDate     time.Time
+	NextExpected time.Time
+	// Confidence, from 0 to 1, weighs how punctual the series is, how
+	// steady its amount is and how many occurrences it is inferred from.
+	Confidence float64
+	// Active reports whether the series was still going at the time of
+	// detection: no more than one occurrence is overdue.
+	Active bool
+}
+
+// MonthlyAmount is the expected amount spread over a month.
+func (s Series) MonthlyAmount() float64 {
+	p, ok := periodNamed(s.Period)
+	if !ok {
+		return 0
+	}
+	return s.ExpectedAmount * daysPerMonth / p.days
+}
+
+type groupKey struct {
+	counterparty string
+	incoming     bool
+}
+
+// Detect returns the recurring series in the transactions as of asOf, most
+// confident first. Transactions are grouped by counterparty and direction;
+// those naming no counterparty are left out.
+func Detect(transactions []models.Transaction, asOf time.Time) []Series {
+	groups := make(map[groupKey][]*models.Transaction)
+	for i := range transactions {
+		transaction := &transactions[i]
+		if transaction.TransactionDate.IsZero() || transaction.Amount <= 0 {
+			continue
+		}
+
+		counterparty := categories.CounterpartyKey(transaction)
+		if counterparty == "" {
+			continue
+		}
+
+		key := groupKey{counterparty: counterparty, incoming: categories.IsIncoming(transaction)}
+		groups[key] = append(groups[key], transaction)
+	}
+
+	var found []Series
+	for _, group := range groups {
+		if series, ok := detect(group, asOf); ok {
+			found = append(found, series)
+		}
+	}
+
+	sort.Slice(found, func(i, j int) bool {
+		if found[i].Confidence != found[j].Confidence {
+			return found[i].Confidence > found[j].Confidence
+		}
+		if found[i].ExpectedAmount != found[j].ExpectedAmount {
+			return found[i].ExpectedAmount > found[j].ExpectedAmount
+		}
+		return found[i].Counterparty < found[j].Counterparty
+	})
+
+	return found
+}
+
+// occurrence is one day's worth of a counterparty's transactions, so that a
+// payment split in two is not taken for two payments.
+type occurrence struct {
+	date   time.Time
+	amount float64
+}
+
+func detect(transactions []*models.Transaction, asOf time.Time) (Series, bool) {
+	sort.SliceStable(transactions, func(i, j int) bool {
+		return transactions[i].TransactionDate.Before(transactions[j].TransactionDate)
+	})
+
+	occurrences := collapse(transactions)
+	if len(occurrences) < MinOccurrences {
+		return Series{}, false
+	}
+
+	intervals := make([]float64, len(occurrences)-1)
+	for i := range intervals {
+		intervals[i] = occurrences[i+1].date.Sub(occurrences[i].date).Hours() / 24
+	}
+
+	p, ok := matchPeriod(median(intervals))
+	if !ok {
+		return Series{}, false
+	}
+
+	// A gap of about two periods is one occurrence missed, not an irregular
+	// schedule.
+	onTime, missed := 0, 0
+	for _, interval := range intervals {
+		cycles := math.Round(interval / p.days)
+		if cycles >= 1 && math.Abs(interval-cycles*p.days) <= p.tolerance {
+			onTime++
+			missed += int(cycles) - 1
+		}
+	}
+	punctuality := float64(onTime) / float64(len(intervals)+missed)
+	if punctuality < 0.5 {
+		return Series{}, false
+	}
+
+	recent := occurrences
+	if len(recent) > recentOccurrences {
+		recent = recent[len(recent)-recentOccurrences:]
+	}
+	amounts := make([]float64, len(recent))
+	for i, o := range recent {
+		amounts[i] = o.amount
+	}
+	expected := median(amounts)
+
+	steady := 0
+	for _, o := range occurrences {
+		if math.Abs(o.amount-expected) <= expected*amountTolerance {
+			steady++
+		}
+	}
+	steadiness := float64(steady) / float64(len(occurrences))
+	count := math.Min(float64(len(occurrences)-1)/float64(fullCountOccurrences-1), 1)
+
+	confidence := 0.5*punctuality + 0.3*steadiness + 0.2*count
+	if confidence < MinConfidence {
+		return Series{}, false
+	}
+
+	last := transactions[len(transactions)-1]
+	name, number := categories.Counterparty(last)
+	first, lastDate := occurrences[0].date, occurrences[len(occurrences)-1].date
+	day := usualDay(occurrences)
+	next := p.after(lastDate, day)
+
+	return Series{
+		Counterparty:   name,
+		Number:         number,
+		Category:       mostCommonCategory(transactions),
+		Incoming:       categories.IsIncoming(last),
+		Period:         p.name,
+		ExpectedAmount: math.Round(expected*100) / 100,
+		Occurrences:    len(occurrences),
+		Missed:         missed,
+		Punctuality:    math.Round(punctuality*100) / 100,
+		FirstDate:      first,
+		LastDate:       lastDate,
+		NextExpected:   next,
+		Confidence:     math.Round(confidence*100) / 100,
+		Active:         !asOf.After(p.after(next, day).AddDate(0, 0, int(math.Ceil(p.tolerance)))),
+	}, true
+}
+
+func collapse(transactions []*models.Transaction) []occurrence {
+	var occurrences []occurrence
+	for _, transaction := range transactions {
+		date := transaction.TransactionDate.UTC()
+		day := time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, time.UTC)
+
+		if n := len(occurrences); n > 0 && occurrences[n-1].date.Equal(day) {
+			occurrences[n-1].amount += transaction.Amount
+			continue
+		}
+		occurrences = append(occurrences, occurrence{date: day, amount: transaction.Amount})
+	}
+	return occurrences
+}
+
+// matchPeriod returns the period the typical interval of a series falls in.
+func matchPeriod(interval float64) (period, bool) {
+	for _, p := range periods {
+		if math.Abs(interval-p.days) <= p.tolerance {
+			return p, true
+		}
+	}
+	return period{}, false
+}
+
+func periodNamed(name string) (period, bool) {
+	for _, p := range periods {
+		if p.name == name {
+			return p, true
+		}
+	}
+	return period{}, false
+}
+
+// after returns the date one period after date. Monthly schedules fall on
+// the given day of the month, moved back to the last day of shorter months, so
+// that a series paid on the 31st is expected on the 31st again after February.
+func (p period) after(date time.Time, day int) time.Time {
+	if p.months == 0 {
+		return date.AddDate(0, 0, int(p.days))
+	}
+
+	year, month, _ := date.Date()
+	first := time.Date(year, month+time.Month(p.months), 1, 0, 0, 0, 0, date.Location())
+	if last := first.AddDate(0, 1, -1).Day(); day > last {
+		day = last
+	}
+	return time.Date(first.Year(), first.Month(), day, 0, 0, 0, 0, date.Location())
+}
+
+// usualDay returns the day of the month a series most often falls on, the
+// later day on a tie. An occurrence on the last day of a month counts as the
+// 31st, as it is where a series at the end of the month lands in short months.
+func usualDay(occurrences []occurrence) int {
+	counts := make(map[int]int)
+	best := 0
+	for _, o := range occurrences {
+		day := o.date.Day()
+		if o.date.AddDate(0, 0, 1).Month() != o.date.Month() {
+			day = 31
+		}
+		counts[day]++
+		if counts[day] > counts[best] || (counts[day] == counts[best] && day > best) {
+			best = day
+		}
+	}
+	return best
+}
+
+func mostCommonCategory(transactions []*models.Transaction) string {
+	counts := make(map[string]int)
+	best := ""
+	for _, transaction := range transactions {
+		if transaction.Category == "" {
+			continue
+		}
+		counts[transaction.Category]++
+		if count := counts[transaction.Category]; count > counts[best] ||
+			(count == counts[best] && transaction.Category < best) {
+			best = transaction.Category
+		}
+	}
+	return best
+}
+
+func median(values []float64) float64 {
+	if len(values) == 0 {
+		return 0
+	}
+
+	sorted := make([]float64, len(values))
+	copy(sorted, values)
+	sort.Float64s(sorted)
+
+	middle := len(sorted) / 2
+	if len(sorted)%2 == 0 {
+		return (sorted[middle-1] + sorted[middle]) / 2
+	}
+	return sorted[middle]
+}
@@ -0,0 +1,218 @@
+package recurrence
+
+import (
+	"testing"
+	"time"
+
+	"lumon-backend/internal/domain/models"
+	"lumon-backend/internal/statements"
+)
+
+func date(year int, month time.Month, day int) time.Time {
+	return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+}
+
+// salary returns a payment received from the same employer on each date.
+func salary(amount float64, dates ...time.Time) []models.Transaction {
+	var transactions []models.Transaction
+	for _, at := range dates {
+		transactions = append(transactions, models.Transaction{
+			TransactionDate: at.Add(9 * time.Hour),
+			TransactionType: statements.TypeCashIn,
+			Amount:          amount,
+			FromName:        "EMPLOYER",
+			FromNumber:      "0244000001",
+		})
+	}
+	return transactions
+}
+
+// every returns count dates, days apart, from start.
+func every(start time.Time, days, count int) []time.Time {
+	var dates []time.Time
+	for i := 0; i < count; i++ {
+		dates = append(dates, start.AddDate(0, 0, i*days))
+	}
+	return dates
+}
+
+func TestDetect(t *testing.T) {
+	asOf := date(2024, time.July, 1)
+
+	tests := []struct {
+		name         string
+		transactions []models.Transaction
+		period       string
+		occurrences  int
+		missed       int
+		next         time.Time
+	}{
+		{
+			name:         "weekly",
+			transactions: salary(200, every(date(2024, time.May, 6), 7, 8)...),
+			period:       PeriodWeekly,
+			occurrences:  8,
+			next:         date(2024, time.July, 1),
+		},
+		{
+			name:         "biweekly",
+			transactions: salary(400, every(date(2024, time.March, 8), 14, 8)...),
+			period:       PeriodBiweekly,
+			occurrences:  8,
+			next:         date(2024, time.June, 28),
+		},
+		{
+			name: "monthly with a day's drift",
+			transactions: salary(1500, date(2024, time.January, 25), date(2024, time.February, 26),
+				date(2024, time.March, 25), date(2024, time.April, 24), date(2024, time.May, 25), date(2024, time.June, 25)),
+			period:      PeriodMonthly,
+			occurrences: 6,
+			next:        date(2024, time.July, 25),
+		},
+		{
+			name: "monthly with a month missed",
+			transactions: salary(1500, date(2024, time.January, 25), date(2024, time.February, 25),
+				date(2024, time.April, 25), date(2024, time.May, 25), date(2024, time.June, 25)),
+			period:      PeriodMonthly,
+			occurrences: 5,
+			missed:      1,
+			next:        date(2024, time.July, 25),
+		},
+		{
+			name: "monthly at the end of the month",
+			transactions: salary(1500, date(2024, time.January, 31), date(2024, time.February, 29),
+				date(2024, time.March, 31), date(2024, time.April, 30)),
+			period:      PeriodMonthly,
+			occurrences: 4,
+			next:        date(2024, time.May, 31),
+		},
+		{
+			name: "quarterly",
+			transactions: salary(300, date(2023, time.July, 10), date(2023, time.October, 10),
+				date(2024, time.January, 10), date(2024, time.April, 10)),
+			period:      PeriodQuarterly,
+			occurrences: 4,
+			next:        date(2024, time.July, 10),
+		},
+		{
+			name: "a payment split in two on one day",
+			transactions: append(salary(750, date(2024, time.April, 25)),
+				salary(1500, date(2024, time.March, 25), date(2024, time.April, 25), date(2024, time.May, 25))...),
+			period:      PeriodMonthly,
+			occurrences: 3,
+			next:        date(2024, time.June, 25),
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			found := Detect(test.transactions, asOf)
+			if len(found) != 1 {
+				t.Fatalf("found %d series, want 1", len(found))
+			}
+
+			series := found[0]
+			if series.Period != test.period {
+				t.Errorf("period = %s, want %s", series.Period, test.period)
+			}
+			if series.Occurrences != test.occurrences || series.Missed != test.missed {
+				t.Errorf("%d occurrences and %d missed, want %d and %d",
+					series.Occurrences, series.Missed, test.occurrences, test.missed)
+			}
+			if !series.NextExpected.Equal(test.next) {
+				t.Errorf("next expected %v, want %v", series.NextExpected.Format(time.DateOnly), test.next.Format(time.DateOnly))
+			}
+			if !series.Incoming || series.Number != "0244000001" {
+				t.Errorf("series = %+v, want the employer's payments in", series)
+			}
+		})
+	}
+}
+
+func TestDetectNothingRecurring(t *testing.T) {
+	tests := []struct {
+		name         string
+		transactions []models.Transaction
+	}{
+		{
+			name:         "too few occurrences",
+			transactions: salary(1500, date(2024, time.April, 25), date(2024, time.May, 25)),
+		},
+		{
+			name: "irregular intervals",
+			transactions: salary(1500, date(2024, time.January, 3), date(2024, time.January, 20),
+				date(2024, time.March, 1), date(2024, time.March, 11), date(2024, time.May, 30)),
+		},
+		{
+			name: "mostly missed",
+			transactions: salary(1500, date(2023, time.January, 25), date(2023, time.February, 25),
+				date(2023, time.March, 25), date(2023, time.September, 25), date(2024, time.April, 25)),
+		},
+		{
+			name: "no counterparty",
+			transactions: []models.Transaction{
+				{TransactionDate: date(2024, time.April, 1), TransactionType: statements.TypeCashIn, Amount: 100},
+				{TransactionDate: date(2024, time.May, 1), TransactionType: statements.TypeCashIn, Amount: 100},
+				{TransactionDate: date(2024, time.June, 1), TransactionType: statements.TypeCashIn, Amount: 100},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if found := Detect(test.transactions, date(2024, time.July, 1)); len(found) != 0 {
+				t.Errorf("found %+v, want nothing", found)
+			}
+		})
+	}
+}
+
+func TestDetectActive(t *testing.T) {
+	transactions := salary(1500, date(2024, time.January, 25), date(2024, time.February, 25),
+		date(2024, time.March, 25), date(2024, time.April, 25))
+
+	tests := []struct {
+		asOf   time.Time
+		active bool
+	}{
+		{asOf: date(2024, time.May, 10), active: true},
+		// One occurrence overdue is tolerated.
+		{asOf: date(2024, time.June, 20), active: true},
+		{asOf: date(2024, time.June, 30), active: false},
+	}
+
+	for _, test := range tests {
+		found := Detect(transactions, test.asOf)
+		if len(found) != 1 || found[0].Active != test.active {
+			t.Errorf("series as of %s = %+v, want active: %v", test.asOf.Format(time.DateOnly), found, test.active)
+		}
+	}
+}
+
+func TestPeriodAfter(t *testing.T) {
+	monthly, _ := periodNamed(PeriodMonthly)
+	quarterly, _ := periodNamed(PeriodQuarterly)
+
+	tests := []struct {
+		name string
+		p    period
+		date time.Time
+		day  int
+		want time.Time
+	}{
+		{name: "same day next month", p: monthly, date: date(2024, time.March, 15), day: 15, want: date(2024, time.April, 15)},
+		{name: "into a short month", p: monthly, date: date(2024, time.January, 31), day: 31, want: date(2024, time.February, 29)},
+		{name: "out of a short month", p: monthly, date: date(2023, time.February, 28), day: 31, want: date(2023, time.March, 31)},
+		{name: "into a 30-day month", p: monthly, date: date(2024, time.March, 31), day: 31, want: date(2024, time.April, 30)},
+		{name: "over the year end", p: monthly, date: date(2023, time.December, 31), day: 31, want: date(2024, time.January, 31)},
+		{name: "quarter", p: quarterly, date: date(2023, time.November, 30), day: 31, want: date(2024, time.February, 29)},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := test.p.after(test.date, test.day); !got.Equal(test.want) {
+				t.Errorf("after = %s, want %s", got.Format(time.DateOnly), test.want.Format(time.DateOnly))
+			}
+		})
+	}
+}
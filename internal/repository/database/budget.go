@@ -0,0 +1,119 @@
+package database
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"lumon-backend/internal/domain/models"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type BudgetRepositoryImpl struct {
+	db *gorm.DB
+}
+
+func NewBudgetRepository(db *gorm.DB) *BudgetRepositoryImpl {
+	return &BudgetRepositoryImpl{db: db}
+}
+
+func (r *BudgetRepositoryImpl) Create(budget *models.Budget) error {
+	if budget == nil {
+		return errors.New("budget cannot be nil")
+	}
+
+	return r.db.Omit(clause.Associations).Create(budget).Error
+}
+
+func (r *BudgetRepositoryImpl) GetByID(id uuid.UUID) (*models.Budget, error) {
+	var budget models.Budget
+
+	if err := r.db.First(&budget, "id = ?", id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("budget with ID %s not found", id)
+		}
+		return nil, err
+	}
+
+	return &budget, nil
+}
+
+func (r *BudgetRepositoryImpl) Update(budget *models.Budget) error {
+	if budget == nil {
+		return errors.New("budget cannot be nil")
+	}
+
+	return r.db.Omit(clause.Associations).Save(budget).Error
+}
+
+func (r *BudgetRepositoryImpl) Delete(id uuid.UUID) error {
+	result := r.db.Delete(&models.Budget{}, "id = ?", id)
+	if result.Error != nil {
+		return result.Error
+	}
+
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("budget with ID %s not found", id)
+	}
+
+	return nil
+}
+
+func (r *BudgetRepositoryImpl) ListByUser(userID uuid.UUID) ([]models.Budget, error) {
+	var budgets []models.Budget
+
+	if err := r.db.Where("user_id = ?", userID).Order("category").Find(&budgets).Error; err != nil {
+		return nil, err
+	}
+
+	return budgets, nil
+}
+
+func (r *BudgetRepositoryImpl) CreateAlerts(
+	alerts []*models.BudgetAlert, notify func(created []*models.BudgetAlert) []*models.Notification,
+) ([]*models.Notification, error) {
+	var notifications []*models.Notification
+
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		var created []*models.BudgetAlert
+		for _, alert := range alerts {
+			result := tx.Clauses(clause.OnConflict{
+				Columns:   []clause.Column{{Name: "budget_id"}, {Name: "month"}, {Name: "threshold"}},
+				DoNothing: true,
+			}).Omit(clause.Associations).Create(alert)
+			if result.Error != nil {
+				return result.Error
+			}
+			if result.RowsAffected > 0 {
+				created = append(created, alert)
+			}
+		}
+
+		notifications = notify(created)
+		if len(notifications) == 0 {
+			return nil
+		}
+		return tx.Omit(clause.Associations).Create(notifications).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return notifications, nil
+}
+
+func (r *BudgetRepositoryImpl) ListAlerts(userID uuid.UUID, month time.Time) ([]models.BudgetAlert, error) {
+	var alerts []models.BudgetAlert
+
+	err := r.db.Where("user_id = ? AND month = ?", userID, month).
+		Order("created_at").
+		Find(&alerts).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return alerts, nil
+}
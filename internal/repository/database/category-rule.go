@@ -0,0 +1,77 @@
+package database
+
+import (
+	"errors"
+	"fmt"
+
+	"lumon-backend/internal/domain/models"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type CategoryRuleRepositoryImpl struct {
+	db *gorm.DB
+}
+
+func NewCategoryRuleRepository(db *gorm.DB) *CategoryRuleRepositoryImpl {
+	return &CategoryRuleRepositoryImpl{db: db}
+}
+
+func (r *CategoryRuleRepositoryImpl) Save(rule *models.CategoryRule) error {
+	if rule == nil {
+		return errors.New("category rule cannot be nil")
+	}
+
+	var existing models.CategoryRule
+	err := r.db.Where(&models.CategoryRule{UserID: rule.UserID, Match: rule.Match, Pattern: rule.Pattern}).
+		First(&existing).Error
+	switch {
+	case err == nil:
+		rule.ID = existing.ID
+		rule.CreatedAt = existing.CreatedAt
+		return r.db.Save(rule).Error
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		return r.db.Create(rule).Error
+	}
+
+	return err
+}
+
+func (r *CategoryRuleRepositoryImpl) GetByID(id uuid.UUID) (*models.CategoryRule, error) {
+	var rule models.CategoryRule
+
+	if err := r.db.First(&rule, "id = ?", id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("category rule with ID %s not found", id)
+		}
+		return nil, err
+	}
+
+	return &rule, nil
+}
+
+// ListByUser returns the user's rules, most recently changed first, which is
+// the order they are applied in.
+func (r *CategoryRuleRepositoryImpl) ListByUser(userID uuid.UUID) ([]models.CategoryRule, error) {
+	var rules []models.CategoryRule
+
+	if err := r.db.Where("user_id = ?", userID).Order("updated_at DESC").Find(&rules).Error; err != nil {
+		return nil, err
+	}
+
+	return rules, nil
+}
+
+func (r *CategoryRuleRepositoryImpl) Delete(id uuid.UUID) error {
+	result := r.db.Delete(&models.CategoryRule{}, "id = ?", id)
+	if result.Error != nil {
+		return result.Error
+	}
+
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("category rule with ID %s not found", id)
+	}
+
+	return nil
+}
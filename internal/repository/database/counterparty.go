@@ -0,0 +1,137 @@
+package database
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"lumon-backend/internal/domain/models"
+	"lumon-backend/internal/repository/interfaces"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// counterpartySortSQL is the order of each sort, with the ID to break ties.
+var counterpartySortSQL = map[string]string{
+	interfaces.CounterpartySortTotal:     "SUM(transactions.amount) DESC",
+	interfaces.CounterpartySortCount:     "COUNT(transactions.id) DESC",
+	interfaces.CounterpartySortLastSeen:  "MAX(transactions.transaction_date) DESC",
+	interfaces.CounterpartySortFirstSeen: "MIN(transactions.transaction_date) DESC",
+	interfaces.CounterpartySortName:      "counterparties.normalized_name ASC",
+}
+
+type CounterpartyRepositoryImpl struct {
+	db *gorm.DB
+}
+
+func NewCounterpartyRepository(db *gorm.DB) *CounterpartyRepositoryImpl {
+	return &CounterpartyRepositoryImpl{db: db}
+}
+
+func (r *CounterpartyRepositoryImpl) Create(counterparties []*models.Counterparty) error {
+	if len(counterparties) == 0 {
+		return nil
+	}
+
+	return r.db.Create(counterparties).Error
+}
+
+func (r *CounterpartyRepositoryImpl) Update(counterparty *models.Counterparty) error {
+	if counterparty == nil {
+		return errors.New("counterparty cannot be nil")
+	}
+
+	return r.db.Omit(clause.Associations).Save(counterparty).Error
+}
+
+func (r *CounterpartyRepositoryImpl) ListByUser(userID uuid.UUID) ([]models.Counterparty, error) {
+	var counterparties []models.Counterparty
+
+	if err := r.db.Where("user_id = ?", userID).Order("created_at").Find(&counterparties).Error; err != nil {
+		return nil, err
+	}
+
+	return counterparties, nil
+}
+
+func (r *CounterpartyRepositoryImpl) Summaries(
+	query interfaces.CounterpartyQuery,
+) ([]models.CounterpartySummary, int64, error) {
+	var summaries []models.CounterpartySummary
+	var total int64
+
+	db := r.summaries(query.UserID)
+	if query.Text != "" {
+		pattern := containsPattern(query.Text)
+		db = db.Where(
+			"counterparties.name ILIKE ? OR counterparties.phone_number ILIKE ? OR counterparties.identifier ILIKE ?",
+			pattern, pattern, pattern,
+		)
+	}
+	db = db.Session(&gorm.Session{})
+
+	if err := r.db.Table("(?) AS matched", db.Select("counterparties.id")).Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	order, ok := counterpartySortSQL[query.SortBy]
+	if !ok {
+		order = counterpartySortSQL[interfaces.CounterpartySortTotal]
+	}
+
+	offset := (query.Page - 1) * query.PageSize
+	err := db.Select(summarySelectSQL).
+		Order(order).
+		Order("counterparties.id").
+		Offset(offset).
+		Limit(query.PageSize).
+		Scan(&summaries).Error
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return summaries, total, nil
+}
+
+func (r *CounterpartyRepositoryImpl) Summary(userID, id uuid.UUID) (*models.CounterpartySummary, error) {
+	var summaries []models.CounterpartySummary
+
+	err := r.summaries(userID).
+		Where("counterparties.id = ?", id).
+		Select(summarySelectSQL).
+		Scan(&summaries).Error
+	if err != nil {
+		return nil, err
+	}
+
+	if len(summaries) == 0 {
+		return nil, fmt.Errorf("counterparty with ID %s not found", id)
+	}
+
+	return &summaries[0], nil
+}
+
+// summarySelectSQL reads a CounterpartySummary from the counterparties joined
+// to their transactions.
+var summarySelectSQL = strings.Join([]string{
+	"counterparties.id",
+	"counterparties.name",
+	"counterparties.phone_number",
+	"counterparties.identifier",
+	fmt.Sprintf("COALESCE(SUM(CASE WHEN %s THEN transactions.amount ELSE 0 END), 0) AS inflow", incomingSQL),
+	fmt.Sprintf("COALESCE(SUM(CASE WHEN %s THEN 0 ELSE transactions.amount END), 0) AS outflow", incomingSQL),
+	"COUNT(transactions.id) AS transaction_count",
+	"MIN(transactions.transaction_date) AS first_seen",
+	"MAX(transactions.transaction_date) AS last_seen",
+}, ", ")
+
+// summaries joins the user's counterparties to their transactions, leaving
+// out those no transaction is linked to any more.
+func (r *CounterpartyRepositoryImpl) summaries(userID uuid.UUID) *gorm.DB {
+	return r.db.Model(&models.Counterparty{}).
+		Joins("JOIN transactions ON transactions.counterparty_id = counterparties.id").
+		Where("counterparties.user_id = ?", userID).
+		Group("counterparties.id")
+}
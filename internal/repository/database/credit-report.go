@@ -0,0 +1,41 @@
+package database
+
+import (
+	"errors"
+	"fmt"
+
+	"lumon-backend/internal/domain/models"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type CreditReportRepositoryImpl struct {
+	db *gorm.DB
+}
+
+func NewCreditReportRepository(db *gorm.DB) *CreditReportRepositoryImpl {
+	return &CreditReportRepositoryImpl{db: db}
+}
+
+func (r *CreditReportRepositoryImpl) Create(report *models.CreditReport) error {
+	if report == nil {
+		return errors.New("credit report cannot be nil")
+	}
+
+	return r.db.Create(report).Error
+}
+
+func (r *CreditReportRepositoryImpl) GetByID(id uuid.UUID) (*models.CreditReport, error) {
+	var report models.CreditReport
+
+	err := r.db.Preload("User").First(&report, "id = ?", id).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("credit report with ID %s not found", id)
+		}
+		return nil, err
+	}
+
+	return &report, nil
+}
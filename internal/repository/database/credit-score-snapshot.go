@@ -0,0 +1,90 @@
+package database
+
+import (
+	"errors"
+	"fmt"
+
+	"lumon-backend/internal/domain/models"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type CreditScoreSnapshotRepositoryImpl struct {
+	db *gorm.DB
+}
+
+func NewCreditScoreSnapshotRepository(db *gorm.DB) *CreditScoreSnapshotRepositoryImpl {
+	return &CreditScoreSnapshotRepositoryImpl{db: db}
+}
+
+func (r *CreditScoreSnapshotRepositoryImpl) Create(snapshot *models.CreditScoreSnapshot) error {
+	if snapshot == nil {
+		return errors.New("credit score snapshot cannot be nil")
+	}
+
+	return r.db.Create(snapshot).Error
+}
+
+func (r *CreditScoreSnapshotRepositoryImpl) GetLatestByUser(userID uuid.UUID) (*models.CreditScoreSnapshot, error) {
+	var snapshot models.CreditScoreSnapshot
+
+	err := r.db.Where("user_id = ?", userID).Order("created_at DESC").First(&snapshot).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("no credit score snapshot found for user %s", userID)
+		}
+		return nil, err
+	}
+
+	return &snapshot, nil
+}
+
+// GetLatestScoredByUser skips insufficient-data snapshots and returns nil when
+// the user has never been scored.
+func (r *CreditScoreSnapshotRepositoryImpl) GetLatestScoredByUser(userID uuid.UUID) (*models.CreditScoreSnapshot, error) {
+	var snapshot models.CreditScoreSnapshot
+
+	err := r.db.Where("user_id = ? AND status = ?", userID, "scored").Order("created_at DESC").First(&snapshot).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return &snapshot, nil
+}
+
+// ListUsersWithNewTransactions returns the users who have transactions
+// ingested after their most recent snapshot, including users never scored.
+func (r *CreditScoreSnapshotRepositoryImpl) ListUsersWithNewTransactions() ([]uuid.UUID, error) {
+	var userIDs []uuid.UUID
+
+	latestSnapshots := r.db.Model(&models.CreditScoreSnapshot{}).
+		Select("user_id, MAX(created_at) AS last_scored_at").
+		Group("user_id")
+
+	err := r.db.Model(&models.Transaction{}).
+		Select("transactions.user_id").
+		Joins("LEFT JOIN (?) AS latest ON latest.user_id = transactions.user_id", latestSnapshots).
+		Group("transactions.user_id, latest.last_scored_at").
+		Having("latest.last_scored_at IS NULL OR MAX(transactions.created_at) > latest.last_scored_at").
+		Pluck("transactions.user_id", &userIDs).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return userIDs, nil
+}
+
+func (r *CreditScoreSnapshotRepositoryImpl) ListByUser(userID uuid.UUID) ([]models.CreditScoreSnapshot, error) {
+	var snapshots []models.CreditScoreSnapshot
+
+	err := r.db.Where("user_id = ?", userID).Order("created_at ASC").Find(&snapshots).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return snapshots, nil
+}
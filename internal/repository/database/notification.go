@@ -0,0 +1,91 @@
+package database
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"lumon-backend/internal/domain/models"
+	"lumon-backend/internal/repository/interfaces"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type NotificationRepositoryImpl struct {
+	db *gorm.DB
+}
+
+func NewNotificationRepository(db *gorm.DB) *NotificationRepositoryImpl {
+	return &NotificationRepositoryImpl{db: db}
+}
+
+func (r *NotificationRepositoryImpl) Create(notifications []*models.Notification) error {
+	if len(notifications) == 0 {
+		return nil
+	}
+
+	return r.db.Omit(clause.Associations).Create(notifications).Error
+}
+
+func (r *NotificationRepositoryImpl) GetByID(id uuid.UUID) (*models.Notification, error) {
+	var notification models.Notification
+
+	if err := r.db.First(&notification, "id = ?", id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("notification with ID %s not found", id)
+		}
+		return nil, err
+	}
+
+	return &notification, nil
+}
+
+func (r *NotificationRepositoryImpl) List(query interfaces.NotificationQuery) ([]models.Notification, int64, error) {
+	var notifications []models.Notification
+	var total int64
+
+	db := r.db.Model(&models.Notification{}).Where("user_id = ?", query.UserID)
+	if query.UnreadOnly {
+		db = db.Where("read_at IS NULL")
+	}
+	db = db.Session(&gorm.Session{})
+
+	if err := db.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	offset := (query.Page - 1) * query.PageSize
+	err := db.Order("created_at DESC").
+		Offset(offset).
+		Limit(query.PageSize).
+		Find(&notifications).Error
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return notifications, total, nil
+}
+
+func (r *NotificationRepositoryImpl) CountUnread(userID uuid.UUID) (int64, error) {
+	var count int64
+
+	err := r.db.Model(&models.Notification{}).
+		Where("user_id = ? AND read_at IS NULL", userID).
+		Count(&count).Error
+	if err != nil {
+		return 0, err
+	}
+
+	return count, nil
+}
+
+func (r *NotificationRepositoryImpl) MarkRead(userID uuid.UUID, ids []uuid.UUID, at time.Time) error {
+	db := r.db.Model(&models.Notification{}).Where("user_id = ? AND read_at IS NULL", userID)
+	if len(ids) > 0 {
+		db = db.Where("id IN ?", ids)
+	}
+
+	return db.Update("read_at", at).Error
+}
@@ -0,0 +1,144 @@
+package database
+
+import (
+	"errors"
+	"fmt"
+
+	"lumon-backend/internal/domain/models"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type ScoreMonitoringRepositoryImpl struct {
+	db *gorm.DB
+}
+
+func NewScoreMonitoringRepository(db *gorm.DB) *ScoreMonitoringRepositoryImpl {
+	return &ScoreMonitoringRepositoryImpl{db: db}
+}
+
+func (r *ScoreMonitoringRepositoryImpl) Create(report *models.ScoreMonitoringReport) error {
+	if report == nil {
+		return errors.New("monitoring report cannot be nil")
+	}
+
+	return r.db.Create(report).Error
+}
+
+func (r *ScoreMonitoringRepositoryImpl) GetByID(id uuid.UUID) (*models.ScoreMonitoringReport, error) {
+	var report models.ScoreMonitoringReport
+
+	if err := r.db.First(&report, "id = ?", id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("monitoring report with ID %s not found", id)
+		}
+		return nil, err
+	}
+
+	return &report, nil
+}
+
+// GetBaseline returns nil when no report has been marked as the baseline yet.
+func (r *ScoreMonitoringRepositoryImpl) GetBaseline() (*models.ScoreMonitoringReport, error) {
+	var report models.ScoreMonitoringReport
+
+	if err := r.db.Where("is_baseline = ?", true).First(&report).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return &report, nil
+}
+
+func (r *ScoreMonitoringRepositoryImpl) SetBaseline(id uuid.UUID) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.ScoreMonitoringReport{}).
+			Where("is_baseline = ?", true).
+			Update("is_baseline", false).Error; err != nil {
+			return err
+		}
+
+		result := tx.Model(&models.ScoreMonitoringReport{}).Where("id = ?", id).Update("is_baseline", true)
+		if result.Error != nil {
+			return result.Error
+		}
+
+		if result.RowsAffected == 0 {
+			return fmt.Errorf("monitoring report with ID %s not found", id)
+		}
+
+		return nil
+	})
+}
+
+func (r *ScoreMonitoringRepositoryImpl) List(page, pageSize int) ([]models.ScoreMonitoringReport, int64, error) {
+	var reports []models.ScoreMonitoringReport
+	var total int64
+
+	offset := (page - 1) * pageSize
+
+	if err := r.db.Model(&models.ScoreMonitoringReport{}).Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	if err := r.db.Order("created_at DESC").Offset(offset).Limit(pageSize).Find(&reports).Error; err != nil {
+		return nil, 0, err
+	}
+
+	return reports, total, nil
+}
+
+// ListSubjects returns every scored user's latest scored snapshot joined with
+// their signup date, region and most recently uploaded document type.
+func (r *ScoreMonitoringRepositoryImpl) ListSubjects() ([]models.ScoreMonitoringSubject, error) {
+	var subjects []models.ScoreMonitoringSubject
+
+	latestSnapshots := r.db.Model(&models.CreditScoreSnapshot{}).
+		Select("DISTINCT ON (user_id) *").
+		Where("status = ?", "scored").
+		Order("user_id, created_at DESC")
+
+	latestDocuments := r.db.Model(&models.Document{}).
+		Select("DISTINCT ON (user_id) user_id, type").
+		Order("user_id, uploaded_at DESC NULLS LAST")
+
+	err := r.db.Table("(?) AS snapshots", latestSnapshots).
+		Select(`snapshots.user_id, snapshots.score, snapshots.payment_behavior, snapshots.income_stability,
+			snapshots.cash_flow, snapshots.transaction_habits, snapshots.credit_history,
+			users.created_at AS signed_up_at, users.region, documents.type AS document_type`).
+		Joins("JOIN users ON users.id = snapshots.user_id").
+		Joins("LEFT JOIN (?) AS documents ON documents.user_id = snapshots.user_id", latestDocuments).
+		Scan(&subjects).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return subjects, nil
+}
+
+// ListDecisions returns the loan requests in the given statuses with the
+// borrower's latest score recorded on or before the request was made.
+func (r *ScoreMonitoringRepositoryImpl) ListDecisions(statuses []string) ([]models.ScoreMonitoringDecision, error) {
+	var decisions []models.ScoreMonitoringDecision
+
+	err := r.db.Model(&models.LoanRequest{}).
+		Select("loan_requests.id AS loan_request_id, loan_requests.status, snapshots.score").
+		Joins(`LEFT JOIN LATERAL (
+			SELECT score FROM credit_score_snapshots
+			WHERE credit_score_snapshots.user_id = loan_requests.borrower_id
+				AND credit_score_snapshots.status = 'scored'
+				AND credit_score_snapshots.created_at <= loan_requests.created_at
+			ORDER BY credit_score_snapshots.created_at DESC
+			LIMIT 1
+		) AS snapshots ON true`).
+		Where("loan_requests.status IN ?", statuses).
+		Scan(&decisions).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return decisions, nil
+}
@@ -0,0 +1,94 @@
+package database
+
+import (
+	"errors"
+	"time"
+
+	"lumon-backend/internal/domain/models"
+
+	"gorm.io/gorm"
+)
+
+type ScoreRecalculationRunRepositoryImpl struct {
+	db *gorm.DB
+}
+
+func NewScoreRecalculationRunRepository(db *gorm.DB) *ScoreRecalculationRunRepositoryImpl {
+	return &ScoreRecalculationRunRepositoryImpl{db: db}
+}
+
+func (r *ScoreRecalculationRunRepositoryImpl) Create(run *models.ScoreRecalculationRun) error {
+	if run == nil {
+		return errors.New("recalculation run cannot be nil")
+	}
+
+	return r.db.Create(run).Error
+}
+
+func (r *ScoreRecalculationRunRepositoryImpl) Update(run *models.ScoreRecalculationRun) error {
+	if run == nil {
+		return errors.New("recalculation run cannot be nil")
+	}
+
+	return r.db.Save(run).Error
+}
+
+func (r *ScoreRecalculationRunRepositoryImpl) List(page, pageSize int) ([]models.ScoreRecalculationRun, int64, error) {
+	var runs []models.ScoreRecalculationRun
+	var total int64
+
+	offset := (page - 1) * pageSize
+
+	if err := r.db.Model(&models.ScoreRecalculationRun{}).Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	if err := r.db.Order("started_at DESC").Offset(offset).Limit(pageSize).Find(&runs).Error; err != nil {
+		return nil, 0, err
+	}
+
+	return runs, total, nil
+}
+
+func (r *ScoreRecalculationRunRepositoryImpl) FinishUnfinished(
+	status, lastError string, finishedAt time.Time,
+) (int64, error) {
+	result := r.db.Model(&models.ScoreRecalculationRun{}).
+		Where("finished_at IS NULL").
+		Updates(map[string]any{"status": status, "last_error": lastError, "finished_at": finishedAt})
+
+	return result.RowsAffected, result.Error
+}
+
+type CreditScoreAlertRepositoryImpl struct {
+	db *gorm.DB
+}
+
+func NewCreditScoreAlertRepository(db *gorm.DB) *CreditScoreAlertRepositoryImpl {
+	return &CreditScoreAlertRepositoryImpl{db: db}
+}
+
+func (r *CreditScoreAlertRepositoryImpl) Create(alert *models.CreditScoreAlert) error {
+	if alert == nil {
+		return errors.New("credit score alert cannot be nil")
+	}
+
+	return r.db.Create(alert).Error
+}
+
+func (r *CreditScoreAlertRepositoryImpl) List(page, pageSize int) ([]models.CreditScoreAlert, int64, error) {
+	var alerts []models.CreditScoreAlert
+	var total int64
+
+	offset := (page - 1) * pageSize
+
+	if err := r.db.Model(&models.CreditScoreAlert{}).Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	if err := r.db.Order("created_at DESC").Offset(offset).Limit(pageSize).Find(&alerts).Error; err != nil {
+		return nil, 0, err
+	}
+
+	return alerts, total, nil
+}
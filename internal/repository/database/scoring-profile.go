@@ -0,0 +1,88 @@
+package database
+
+import (
+	"errors"
+	"fmt"
+
+	"lumon-backend/internal/domain/models"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type ScoringProfileRepositoryImpl struct {
+	db *gorm.DB
+}
+
+func NewScoringProfileRepository(db *gorm.DB) *ScoringProfileRepositoryImpl {
+	return &ScoringProfileRepositoryImpl{db: db}
+}
+
+func (r *ScoringProfileRepositoryImpl) Create(profile *models.ScoringProfile) error {
+	if profile == nil {
+		return errors.New("scoring profile cannot be nil")
+	}
+
+	return r.db.Create(profile).Error
+}
+
+func (r *ScoringProfileRepositoryImpl) GetByID(id uuid.UUID) (*models.ScoringProfile, error) {
+	var profile models.ScoringProfile
+
+	err := r.db.First(&profile, "id = ?", id).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("scoring profile with ID %s not found", id)
+		}
+		return nil, err
+	}
+
+	return &profile, nil
+}
+
+// GetActive returns the active scoring profile, or nil when none has been
+// activated yet.
+func (r *ScoringProfileRepositoryImpl) GetActive() (*models.ScoringProfile, error) {
+	var profile models.ScoringProfile
+
+	err := r.db.Where("is_active = ?", true).First(&profile).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return &profile, nil
+}
+
+func (r *ScoringProfileRepositoryImpl) List() ([]models.ScoringProfile, error) {
+	var profiles []models.ScoringProfile
+
+	if err := r.db.Order("created_at DESC").Find(&profiles).Error; err != nil {
+		return nil, err
+	}
+
+	return profiles, nil
+}
+
+func (r *ScoringProfileRepositoryImpl) Activate(id uuid.UUID) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.ScoringProfile{}).
+			Where("is_active = ?", true).
+			Update("is_active", false).Error; err != nil {
+			return err
+		}
+
+		result := tx.Model(&models.ScoringProfile{}).Where("id = ?", id).Update("is_active", true)
+		if result.Error != nil {
+			return result.Error
+		}
+
+		if result.RowsAffected == 0 {
+			return fmt.Errorf("scoring profile with ID %s not found", id)
+		}
+
+		return nil
+	})
+}
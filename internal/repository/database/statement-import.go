@@ -0,0 +1,103 @@
+package database
+
+import (
+	"errors"
+	"fmt"
+
+	"lumon-backend/internal/domain/models"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type StatementImportRepositoryImpl struct {
+	db *gorm.DB
+}
+
+func NewStatementImportRepository(db *gorm.DB) *StatementImportRepositoryImpl {
+	return &StatementImportRepositoryImpl{db: db}
+}
+
+func (r *StatementImportRepositoryImpl) Create(statementImport *models.StatementImport) error {
+	if statementImport == nil {
+		return errors.New("statement import cannot be nil")
+	}
+
+	return r.db.Create(statementImport).Error
+}
+
+func (r *StatementImportRepositoryImpl) Update(statementImport *models.StatementImport) error {
+	if statementImport == nil {
+		return errors.New("statement import cannot be nil")
+	}
+
+	return r.db.Save(statementImport).Error
+}
+
+func (r *StatementImportRepositoryImpl) GetByID(id uuid.UUID) (*models.StatementImport, error) {
+	var statementImport models.StatementImport
+
+	if err := r.db.First(&statementImport, "id = ?", id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("statement import with ID %s not found", id)
+		}
+		return nil, err
+	}
+
+	return &statementImport, nil
+}
+
+// ListByStatus returns the imports in any of the statuses, oldest first.
+func (r *StatementImportRepositoryImpl) ListByStatus(statuses ...string) ([]models.StatementImport, error) {
+	var imports []models.StatementImport
+
+	err := r.db.Where("status IN ?", statuses).
+		Order("created_at ASC").
+		Find(&imports).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return imports, nil
+}
+
+func (r *StatementImportRepositoryImpl) ListRejections(importID uuid.UUID) ([]models.StatementImportRejection, error) {
+	var rejections []models.StatementImportRejection
+
+	if err := r.db.Where("import_id = ?", importID).Order("row_number ASC").Find(&rejections).Error; err != nil {
+		return nil, err
+	}
+
+	return rejections, nil
+}
+
+func (r *StatementImportRepositoryImpl) Complete(
+	statementImport *models.StatementImport,
+	transactions []*models.Transaction,
+	rejections []models.StatementImportRejection,
+	batchSize int,
+) error {
+	if statementImport == nil {
+		return errors.New("statement import cannot be nil")
+	}
+
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if len(transactions) > 0 {
+			duplicates, err := createTransactions(tx, transactions, batchSize)
+			if err != nil {
+				return err
+			}
+
+			statementImport.DuplicateRows = duplicates
+			statementImport.ImportedRows = len(transactions) - duplicates
+		}
+
+		if len(rejections) > 0 {
+			if err := tx.CreateInBatches(rejections, batchSize).Error; err != nil {
+				return err
+			}
+		}
+
+		return tx.Save(statementImport).Error
+	})
+}
@@ -0,0 +1,114 @@
+package database
+
+import (
+	"fmt"
+
+	"lumon-backend/internal/domain/models"
+	"lumon-backend/internal/repository/interfaces"
+
+	"gorm.io/gorm"
+)
+
+// incomingSQL tells money received from money paid, the same way the
+// categories package does: by type, or by the balance for types that go
+// either way.
+const incomingSQL = "(transaction_type = 'CASH_IN' OR balance_after > balance_before)"
+
+type TransactionAnalyticsRepositoryImpl struct {
+	db *gorm.DB
+}
+
+func NewTransactionAnalyticsRepository(db *gorm.DB) *TransactionAnalyticsRepositoryImpl {
+	return &TransactionAnalyticsRepositoryImpl{db: db}
+}
+
+func (r *TransactionAnalyticsRepositoryImpl) CashFlow(rng interfaces.AnalyticsRange) ([]models.CashFlowPeriod, error) {
+	var periods []models.CashFlowPeriod
+
+	err := r.inRange(rng).
+		Select(fmt.Sprintf(`%s AS period,
+			COALESCE(SUM(CASE WHEN %s THEN amount ELSE 0 END), 0) AS inflow,
+			COALESCE(SUM(CASE WHEN %s THEN 0 ELSE amount END), 0) AS outflow,
+			COALESCE(SUM(fees), 0) AS fees,
+			COALESCE(SUM(e_levy), 0) AS e_levy,
+			COUNT(*) AS transaction_count,
+			AVG(CASE WHEN balance_before <> 0 OR balance_after <> 0 THEN balance_after END) AS average_balance,
+			COUNT(CASE WHEN balance_before <> 0 OR balance_after <> 0 THEN 1 END) AS balance_count`,
+			periodSQL(rng.Period), incomingSQL, incomingSQL)).
+		Group("period").
+		Order("period").
+		Scan(&periods).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return periods, nil
+}
+
+func (r *TransactionAnalyticsRepositoryImpl) TopCounterparties(
+	rng interfaces.AnalyticsRange, limit int,
+) ([]models.CounterpartyTotal, error) {
+	var totals []models.CounterpartyTotal
+
+	name := fmt.Sprintf("CASE WHEN %s THEN from_name ELSE to_name END", incomingSQL)
+	number := fmt.Sprintf("CASE WHEN %s THEN from_number ELSE to_number END", incomingSQL)
+	// Counterparties are told apart by number, or by name when the statement
+	// prints none.
+	key := fmt.Sprintf("COALESCE(NULLIF(%s, ''), UPPER(%s))", number, name)
+
+	err := r.inRange(rng).
+		Select(fmt.Sprintf(`MAX(%s) AS name,
+			MAX(%s) AS number,
+			COALESCE(SUM(CASE WHEN %s THEN amount ELSE 0 END), 0) AS inflow,
+			COALESCE(SUM(CASE WHEN %s THEN 0 ELSE amount END), 0) AS outflow,
+			COUNT(*) AS transaction_count`,
+			name, number, incomingSQL, incomingSQL)).
+		Group(key).
+		Having(key + " <> ''").
+		Order("SUM(amount) DESC").
+		Limit(limit).
+		Scan(&totals).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return totals, nil
+}
+
+func (r *TransactionAnalyticsRepositoryImpl) CategoryBreakdown(
+	rng interfaces.AnalyticsRange,
+) ([]models.CategoryTotal, error) {
+	var totals []models.CategoryTotal
+
+	err := r.inRange(rng).
+		Select(fmt.Sprintf(`%s AS period,
+			COALESCE(NULLIF(category, ''), 'uncategorized') AS category,
+			COALESCE(SUM(CASE WHEN %s THEN amount ELSE 0 END), 0) AS inflow,
+			COALESCE(SUM(CASE WHEN %s THEN 0 ELSE amount END), 0) AS outflow,
+			COUNT(*) AS transaction_count`,
+			periodSQL(rng.Period), incomingSQL, incomingSQL)).
+		// The category is grouped by position, as its name would refer to the
+		// column rather than to the defaulted value.
+		Group("period, 2").
+		Order("period, 2").
+		Scan(&totals).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return totals, nil
+}
+
+func (r *TransactionAnalyticsRepositoryImpl) inRange(rng interfaces.AnalyticsRange) *gorm.DB {
+	return r.db.Model(&models.Transaction{}).
+		Where("user_id = ? AND transaction_date >= ? AND transaction_date < ?", rng.UserID, rng.From, rng.To)
+}
+
+// periodSQL truncates the transaction date to the start of its week, which
+// begins on a Monday, or month. Anything but a week is grouped by month.
+func periodSQL(period string) string {
+	if period == interfaces.AnalyticsPeriodWeek {
+		return "date_trunc('week', transaction_date)"
+	}
+	return "date_trunc('month', transaction_date)"
+}
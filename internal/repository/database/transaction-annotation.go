@@ -0,0 +1,197 @@
+package database
+
+import (
+	"errors"
+	"fmt"
+
+	"lumon-backend/internal/domain/models"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type TransactionTagRepositoryImpl struct {
+	db *gorm.DB
+}
+
+func NewTransactionTagRepository(db *gorm.DB) *TransactionTagRepositoryImpl {
+	return &TransactionTagRepositoryImpl{db: db}
+}
+
+func (r *TransactionTagRepositoryImpl) Create(tags []*models.TransactionTag) error {
+	if len(tags) == 0 {
+		return nil
+	}
+
+	return r.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "transaction_id"}, {Name: "name"}},
+		DoNothing: true,
+	}).Omit(clause.Associations).Create(tags).Error
+}
+
+func (r *TransactionTagRepositoryImpl) ListByTransaction(transactionID uuid.UUID) ([]models.TransactionTag, error) {
+	var tags []models.TransactionTag
+
+	if err := r.db.Where("transaction_id = ?", transactionID).Order("name").Find(&tags).Error; err != nil {
+		return nil, err
+	}
+
+	return tags, nil
+}
+
+func (r *TransactionTagRepositoryImpl) Delete(transactionID uuid.UUID, name string) (bool, error) {
+	result := r.db.Delete(&models.TransactionTag{}, "transaction_id = ? AND name = ?", transactionID, name)
+	if result.Error != nil {
+		return false, result.Error
+	}
+
+	return result.RowsAffected > 0, nil
+}
+
+func (r *TransactionTagRepositoryImpl) Totals(userID uuid.UUID) ([]models.TagTotal, error) {
+	var totals []models.TagTotal
+
+	err := r.db.Table("transaction_tags").
+		Joins("JOIN transactions ON transactions.id = transaction_tags.transaction_id").
+		Where("transaction_tags.user_id = ?", userID).
+		Select(fmt.Sprintf(`transaction_tags.name AS name,
+			COALESCE(SUM(CASE WHEN %s THEN amount ELSE 0 END), 0) AS inflow,
+			COALESCE(SUM(CASE WHEN %s THEN 0 ELSE amount END), 0) AS outflow,
+			COUNT(*) AS transaction_count`,
+			incomingSQL, incomingSQL)).
+		Group("transaction_tags.name").
+		Order("transaction_tags.name").
+		Scan(&totals).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return totals, nil
+}
+
+type TransactionNoteRepositoryImpl struct {
+	db *gorm.DB
+}
+
+func NewTransactionNoteRepository(db *gorm.DB) *TransactionNoteRepositoryImpl {
+	return &TransactionNoteRepositoryImpl{db: db}
+}
+
+func (r *TransactionNoteRepositoryImpl) Create(note *models.TransactionNote) error {
+	if note == nil {
+		return errors.New("transaction note cannot be nil")
+	}
+
+	return r.db.Omit(clause.Associations).Create(note).Error
+}
+
+func (r *TransactionNoteRepositoryImpl) GetByID(id uuid.UUID) (*models.TransactionNote, error) {
+	var note models.TransactionNote
+
+	if err := r.db.First(&note, "id = ?", id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("transaction note with ID %s not found", id)
+		}
+		return nil, err
+	}
+
+	return &note, nil
+}
+
+func (r *TransactionNoteRepositoryImpl) Update(note *models.TransactionNote) error {
+	if note == nil {
+		return errors.New("transaction note cannot be nil")
+	}
+
+	return r.db.Omit(clause.Associations).Save(note).Error
+}
+
+func (r *TransactionNoteRepositoryImpl) Delete(id uuid.UUID) error {
+	result := r.db.Delete(&models.TransactionNote{}, "id = ?", id)
+	if result.Error != nil {
+		return result.Error
+	}
+
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("transaction note with ID %s not found", id)
+	}
+
+	return nil
+}
+
+func (r *TransactionNoteRepositoryImpl) ListByTransaction(transactionID uuid.UUID) ([]models.TransactionNote, error) {
+	var notes []models.TransactionNote
+
+	if err := r.db.Where("transaction_id = ?", transactionID).Order("created_at").Find(&notes).Error; err != nil {
+		return nil, err
+	}
+
+	return notes, nil
+}
+
+type TransactionAttachmentRepositoryImpl struct {
+	db *gorm.DB
+}
+
+func NewTransactionAttachmentRepository(db *gorm.DB) *TransactionAttachmentRepositoryImpl {
+	return &TransactionAttachmentRepositoryImpl{db: db}
+}
+
+func (r *TransactionAttachmentRepositoryImpl) Create(attachment *models.TransactionAttachment) error {
+	if attachment == nil {
+		return errors.New("transaction attachment cannot be nil")
+	}
+
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Omit(clause.Associations).Create(&attachment.Document).Error; err != nil {
+			return err
+		}
+
+		attachment.DocumentID = attachment.Document.ID
+		return tx.Omit(clause.Associations).Create(attachment).Error
+	})
+}
+
+func (r *TransactionAttachmentRepositoryImpl) GetByID(id uuid.UUID) (*models.TransactionAttachment, error) {
+	var attachment models.TransactionAttachment
+
+	if err := r.db.Preload("Document").First(&attachment, "id = ?", id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("transaction attachment with ID %s not found", id)
+		}
+		return nil, err
+	}
+
+	return &attachment, nil
+}
+
+func (r *TransactionAttachmentRepositoryImpl) Delete(attachment *models.TransactionAttachment) error {
+	if attachment == nil {
+		return errors.New("transaction attachment cannot be nil")
+	}
+
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Delete(&models.TransactionAttachment{}, "id = ?", attachment.ID).Error; err != nil {
+			return err
+		}
+
+		return tx.Delete(&models.Document{}, "id = ?", attachment.DocumentID).Error
+	})
+}
+
+func (r *TransactionAttachmentRepositoryImpl) ListByTransaction(
+	transactionID uuid.UUID,
+) ([]models.TransactionAttachment, error) {
+	var attachments []models.TransactionAttachment
+
+	err := r.db.Preload("Document").
+		Where("transaction_id = ?", transactionID).
+		Order("created_at").
+		Find(&attachments).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return attachments, nil
+}
@@ -0,0 +1,114 @@
+package database
+
+import (
+	"errors"
+	"fmt"
+
+	"lumon-backend/internal/domain/models"
+	"lumon-backend/internal/repository/interfaces"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type TransactionFlagRepositoryImpl struct {
+	db *gorm.DB
+}
+
+func NewTransactionFlagRepository(db *gorm.DB) *TransactionFlagRepositoryImpl {
+	return &TransactionFlagRepositoryImpl{db: db}
+}
+
+func (r *TransactionFlagRepositoryImpl) Create(flags []*models.TransactionFlag) error {
+	if len(flags) == 0 {
+		return nil
+	}
+
+	return r.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "kind"}, {Name: "transaction_id"}},
+		DoNothing: true,
+	}).Create(flags).Error
+}
+
+func (r *TransactionFlagRepositoryImpl) GetByID(id uuid.UUID) (*models.TransactionFlag, error) {
+	var flag models.TransactionFlag
+
+	if err := r.db.Preload("Transaction").First(&flag, "id = ?", id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("transaction flag with ID %s not found", id)
+		}
+		return nil, err
+	}
+
+	return &flag, nil
+}
+
+func (r *TransactionFlagRepositoryImpl) Update(flag *models.TransactionFlag) error {
+	if flag == nil {
+		return errors.New("transaction flag cannot be nil")
+	}
+
+	return r.db.Omit(clause.Associations).Save(flag).Error
+}
+
+func (r *TransactionFlagRepositoryImpl) ListByUser(userID uuid.UUID) ([]models.TransactionFlag, error) {
+	var flags []models.TransactionFlag
+
+	if err := r.db.Where("user_id = ?", userID).Order("created_at DESC").Find(&flags).Error; err != nil {
+		return nil, err
+	}
+
+	return flags, nil
+}
+
+func (r *TransactionFlagRepositoryImpl) List(
+	query interfaces.TransactionFlagQuery,
+) ([]models.TransactionFlag, int64, error) {
+	var flags []models.TransactionFlag
+	var total int64
+
+	db := r.db.Model(&models.TransactionFlag{})
+	if query.Status != "" {
+		db = db.Where("status = ?", query.Status)
+	}
+	if query.Severity != "" {
+		db = db.Where("severity = ?", query.Severity)
+	}
+	if query.Kind != "" {
+		db = db.Where("kind = ?", query.Kind)
+	}
+	if query.UserID != nil {
+		db = db.Where("user_id = ?", *query.UserID)
+	}
+
+	if err := db.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	offset := (query.Page - 1) * query.PageSize
+	err := db.Preload("Transaction").
+		Order("CASE severity WHEN 'high' THEN 0 WHEN 'medium' THEN 1 ELSE 2 END").
+		Order("created_at DESC").
+		Offset(offset).
+		Limit(query.PageSize).
+		Find(&flags).Error
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return flags, total, nil
+}
+
+func (r *TransactionFlagRepositoryImpl) Count(userID uuid.UUID, status string, severities []string) (int64, error) {
+	var count int64
+
+	err := r.db.Model(&models.TransactionFlag{}).
+		Where("user_id = ? AND status = ? AND severity IN ?", userID, status, severities).
+		Count(&count).Error
+	if err != nil {
+		return 0, err
+	}
+
+	return count, nil
+}
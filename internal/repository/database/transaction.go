@@ -3,12 +3,14 @@ package database
 import (
 	"errors"
 	"fmt"
-	"time"
+	"strings"
 
 	"lumon-backend/internal/domain/models"
+	"lumon-backend/internal/repository/interfaces"
 
 	"github.com/google/uuid"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type TransactionRepositoryImpl struct {
@@ -27,12 +29,50 @@ func (r *TransactionRepositoryImpl) Create(transaction *models.Transaction) erro
 	return r.db.Create(transaction).Error
 }
 
-func (r *TransactionRepositoryImpl) CreateBatch(transactions []*models.Transaction, batchSize int) error {
+func (r *TransactionRepositoryImpl) CreateBatch(transactions []*models.Transaction, batchSize int) (int, error) {
 	if len(transactions) == 0 {
-		return errors.New("no transactions provided for batch creation")
+		return 0, errors.New("no transactions provided for batch creation")
 	}
 
-	return r.db.CreateInBatches(transactions, batchSize).Error
+	return createTransactions(r.db, transactions, batchSize)
+}
+
+// transactionConflict skips a transaction whose fingerprint the user already
+// has. It matches the partial unique index on the fingerprint.
+var transactionConflict = clause.OnConflict{
+	Columns:     []clause.Column{{Name: "fingerprint"}, {Name: "user_id"}},
+	TargetWhere: clause.Where{Exprs: []clause.Expression{clause.Expr{SQL: "fingerprint <> ''"}}},
+	DoNothing:   true,
+}
+
+// createTransactions inserts the transactions that are not already stored and
+// returns how many were skipped as duplicates, including repeats within the
+// batch itself.
+func createTransactions(db *gorm.DB, transactions []*models.Transaction, batchSize int) (int, error) {
+	if err := db.Clauses(transactionConflict).CreateInBatches(transactions, batchSize).Error; err != nil {
+		return 0, err
+	}
+
+	// RowsAffected cannot be relied on here: the IDs are assigned before the
+	// insert, so gorm cannot tell skipped rows from stored ones when it reads
+	// back the returned IDs. The stored rows are counted by ID instead.
+	ids := make([]uuid.UUID, len(transactions))
+	for i, transaction := range transactions {
+		ids[i] = transaction.ID
+	}
+
+	var stored int64
+	for start := 0; start < len(ids); start += batchSize {
+		end := min(start+batchSize, len(ids))
+
+		var count int64
+		if err := db.Model(&models.Transaction{}).Where("id IN ?", ids[start:end]).Count(&count).Error; err != nil {
+			return 0, err
+		}
+		stored += count
+	}
+
+	return len(transactions) - int(stored), nil
 }
 
 func (r *TransactionRepositoryImpl) GetByID(id uuid.UUID) (*models.Transaction, error) {
@@ -49,108 +89,113 @@ func (r *TransactionRepositoryImpl) GetByID(id uuid.UUID) (*models.Transaction,
 	return &transaction, nil
 }
 
-func (r *TransactionRepositoryImpl) GetByType(
-	transactionType string,
-	page, pageSize int,
-) ([]models.Transaction, int64, error) {
+func (r *TransactionRepositoryImpl) Search(query interfaces.TransactionQuery) ([]models.Transaction, error) {
 	var transactions []models.Transaction
-	var total int64
 
-	offset := (page - 1) * pageSize
+	db := r.db.Where("user_id = ?", query.UserID)
 
-	if err := r.db.Model(&models.Transaction{}).Count(&total).Error; err != nil {
-		return nil, 0, err
+	if len(query.Types) > 0 {
+		db = db.Where("transaction_type IN ?", query.Types)
 	}
-
-	err := r.db.Where(
-		"transaction_type = ?",
-		transactionType,
-	).Offset(offset).Limit(pageSize).Preload("User").Find(&transactions).Error
-	if err != nil {
-		return nil, 0, err
+	if query.From != nil {
+		db = db.Where("transaction_date >= ?", *query.From)
 	}
-
-	return transactions, total, nil
-}
-
-func (r *TransactionRepositoryImpl) GetByAccountNumber(
-	accountNumber string,
-	page, pageSize int,
-) ([]models.Transaction, int64, error) {
-	var transactions []models.Transaction
-	var total int64
-
-	offset := (page - 1) * pageSize
-
-	if err := r.db.Model(&models.Transaction{}).Count(&total).Error; err != nil {
-		return nil, 0, err
+	if query.To != nil {
+		db = db.Where("transaction_date <= ?", *query.To)
 	}
-
-	err := r.db.Where(
-		"to_number = ?",
-		accountNumber,
-	).Offset(offset).Limit(pageSize).Preload("User").Find(&transactions).Error
-	if err != nil {
-		return nil, 0, err
+	if query.MinAmount != nil {
+		db = db.Where("amount >= ?", *query.MinAmount)
+	}
+	if query.MaxAmount != nil {
+		db = db.Where("amount <= ?", *query.MaxAmount)
+	}
+	if query.Category != "" {
+		db = db.Where("category = ?", query.Category)
+	}
+	if query.CounterpartyID != nil {
+		db = db.Where("counterparty_id = ?", *query.CounterpartyID)
+	}
+	if len(query.Tags) > 0 {
+		db = db.Where("id IN (SELECT transaction_id FROM transaction_tags WHERE name IN ?)", query.Tags)
+	}
+	if query.Counterparty != "" {
+		pattern := containsPattern(query.Counterparty)
+		db = db.Where(
+			"from_name ILIKE ? OR from_number ILIKE ? OR to_name ILIKE ? OR to_number ILIKE ?",
+			pattern, pattern, pattern, pattern,
+		)
+	}
+	if query.Text != "" {
+		pattern := containsPattern(query.Text)
+		db = db.Where(
+			"from_name ILIKE ? OR from_number ILIKE ? OR to_name ILIKE ? OR to_number ILIKE ? "+
+				"OR reference ILIKE ? OR description ILIKE ?",
+			pattern, pattern, pattern, pattern, pattern, pattern,
+		)
 	}
 
-	return transactions, total, nil
-}
-
-func (r *TransactionRepositoryImpl) GetByDateRange(
-	start, end time.Time,
-	page, pageSize int,
-) ([]models.Transaction, int64, error) {
-	var transactions []models.Transaction
-	var total int64
+	column := interfaces.TransactionSortDate
+	if query.SortBy == interfaces.TransactionSortAmount {
+		column = interfaces.TransactionSortAmount
+	}
 
-	offset := (page - 1) * pageSize
+	direction, comparison := "ASC", ">"
+	if query.Descending {
+		direction, comparison = "DESC", "<"
+	}
 
-	if err := r.db.Model(&models.Transaction{}).Count(&total).Error; err != nil {
-		return nil, 0, err
+	if query.After != nil {
+		var value any = query.After.Date
+		if column == interfaces.TransactionSortAmount {
+			value = query.After.Amount
+		}
+		db = db.Where(fmt.Sprintf("(%s, id) %s (?, ?)", column, comparison), value, query.After.ID)
 	}
 
-	err := r.db.Where(
-		"transaction_date BETWEEN ? AND ?",
-		start, end,
-	).Offset(offset).Limit(pageSize).Preload("User").Find(&transactions).Error
+	err := db.Preload("Tags", func(db *gorm.DB) *gorm.DB { return db.Order("name") }).
+		Order(column + " " + direction).
+		Order("id " + direction).
+		Limit(query.Limit).
+		Find(&transactions).Error
 	if err != nil {
-		return nil, 0, err
+		return nil, err
 	}
 
-	return transactions, total, nil
+	return transactions, nil
 }
 
-func (r *TransactionRepositoryImpl) GetByAmountRange(
-	min, max float64,
-	page, pageSize int,
-) ([]models.Transaction, int64, error) {
-	var transactions []models.Transaction
-	var total int64
-
-	offset := (page - 1) * pageSize
+// containsPattern is an ILIKE pattern matching value anywhere, with the
+// wildcards in value itself taken literally.
+func containsPattern(value string) string {
+	return "%" + strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(value) + "%"
+}
 
-	if err := r.db.Model(&models.Transaction{}).Count(&total).Error; err != nil {
-		return nil, 0, err
+func (r *TransactionRepositoryImpl) Update(transaction *models.Transaction) error {
+	if transaction == nil {
+		return errors.New("transaction cannot be nil")
 	}
 
-	err := r.db.Where(
-		"amount BETWEEN ? AND ?",
-		min, max,
-	).Offset(offset).Limit(pageSize).Preload("User").Find(&transactions).Error
-	if err != nil {
-		return nil, 0, err
+	return r.db.Save(transaction).Error
+}
+
+func (r *TransactionRepositoryImpl) UpdateCategory(ids []uuid.UUID, category, source string) error {
+	if len(ids) == 0 {
+		return nil
 	}
 
-	return transactions, total, nil
+	return r.db.Model(&models.Transaction{}).
+		Where("id IN ?", ids).
+		Updates(map[string]any{"category": category, "category_source": source}).Error
 }
 
-func (r *TransactionRepositoryImpl) Update(transaction *models.Transaction) error {
-	if transaction == nil {
-		return errors.New("transaction cannot be nil")
+func (r *TransactionRepositoryImpl) UpdateCounterparty(ids []uuid.UUID, counterpartyID uuid.UUID) error {
+	if len(ids) == 0 {
+		return nil
 	}
 
-	return r.db.Save(transaction).Error
+	return r.db.Model(&models.Transaction{}).
+		Where("id IN ?", ids).
+		Update("counterparty_id", counterpartyID).Error
 }
 
 func (r *TransactionRepositoryImpl) Delete(id uuid.UUID) error {
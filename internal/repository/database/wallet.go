@@ -4,7 +4,9 @@ import (
 	"lumon-backend/internal/domain/models"
 	"lumon-backend/internal/repository/interfaces"
 
+	"github.com/google/uuid"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type walletRepository struct {
@@ -30,3 +32,39 @@ func (w *walletRepository) Create(wallet *models.Wallet) error {
 func (w *walletRepository) Update(wallet *models.Wallet) error {
 	return w.db.Save(&wallet).Error
 }
+
+func (w *walletRepository) CreateWithEntry(wallet *models.Wallet, entry *models.WalletLedgerEntry) error {
+	return w.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(wallet).Error; err != nil {
+			return err
+		}
+		entry.WalletID, entry.UserID = wallet.ID, wallet.UserID
+		return tx.Create(entry).Error
+	})
+}
+
+func (w *walletRepository) MoveBalance(id uuid.UUID, delta int64, entry *models.WalletLedgerEntry) (*models.Wallet, error) {
+	var wallet models.Wallet
+	err := w.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&wallet).
+			Clauses(clause.Returning{}).
+			Where("id = ? AND balance + ? >= 0", id, delta).
+			Update("balance", gorm.Expr("balance + ?", delta))
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			if err := tx.Select("id").First(&models.Wallet{}, "id = ?", id).Error; err != nil {
+				return err
+			}
+			return interfaces.ErrInsufficientBalance
+		}
+
+		entry.WalletID, entry.UserID, entry.BalanceAfter = wallet.ID, wallet.UserID, wallet.Balance
+		return tx.Create(entry).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &wallet, nil
+}
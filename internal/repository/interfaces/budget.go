@@ -0,0 +1,27 @@
+package interfaces
+
+import (
+	"time"
+
+	"lumon-backend/internal/domain/models"
+
+	"github.com/google/uuid"
+)
+
+type BudgetRepository interface {
+	Create(budget *models.Budget) error
+	GetByID(id uuid.UUID) (*models.Budget, error)
+	Update(budget *models.Budget) error
+	Delete(id uuid.UUID) error
+	// ListByUser returns the user's budgets by category.
+	ListByUser(userID uuid.UUID) ([]models.Budget, error)
+	// CreateAlerts stores the alerts, skipping any a budget already had for
+	// the same month and threshold, together with the notifications notify
+	// gives for the ones stored, in one transaction. It returns the
+	// notifications stored.
+	CreateAlerts(
+		alerts []*models.BudgetAlert, notify func(created []*models.BudgetAlert) []*models.Notification,
+	) ([]*models.Notification, error)
+	// ListAlerts returns the alerts of the user's budgets for a month.
+	ListAlerts(userID uuid.UUID, month time.Time) ([]models.BudgetAlert, error)
+}
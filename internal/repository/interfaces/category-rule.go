@@ -0,0 +1,16 @@
+package interfaces
+
+import (
+	"lumon-backend/internal/domain/models"
+
+	"github.com/google/uuid"
+)
+
+type CategoryRuleRepository interface {
+	// Save creates the rule, or changes the category of the user's existing
+	// rule for the same pattern.
+	Save(rule *models.CategoryRule) error
+	GetByID(id uuid.UUID) (*models.CategoryRule, error)
+	ListByUser(userID uuid.UUID) ([]models.CategoryRule, error)
+	Delete(id uuid.UUID) error
+}
@@ -0,0 +1,38 @@
+package interfaces
+
+import (
+	"lumon-backend/internal/domain/models"
+
+	"github.com/google/uuid"
+)
+
+const (
+	CounterpartySortTotal     = "total"
+	CounterpartySortCount     = "count"
+	CounterpartySortLastSeen  = "last_seen"
+	CounterpartySortFirstSeen = "first_seen"
+	CounterpartySortName      = "name"
+)
+
+// CounterpartyQuery selects a page of one user's counterparties. Text matches
+// part of the name, phone number or identifier.
+type CounterpartyQuery struct {
+	UserID   uuid.UUID
+	Text     string
+	SortBy   string
+	Page     int
+	PageSize int
+}
+
+type CounterpartyRepository interface {
+	Create(counterparties []*models.Counterparty) error
+	Update(counterparty *models.Counterparty) error
+	ListByUser(userID uuid.UUID) ([]models.Counterparty, error)
+	// Summaries returns a page of the user's counterparties that have
+	// transactions, with what was exchanged with each, and the number of
+	// counterparties matching the query. Names are sorted in ascending order
+	// and everything else in descending order.
+	Summaries(query CounterpartyQuery) ([]models.CounterpartySummary, int64, error)
+	// Summary returns what the user exchanged with one counterparty.
+	Summary(userID, id uuid.UUID) (*models.CounterpartySummary, error)
+}
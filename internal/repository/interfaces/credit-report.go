@@ -0,0 +1,12 @@
+package interfaces
+
+import (
+	"lumon-backend/internal/domain/models"
+
+	"github.com/google/uuid"
+)
+
+type CreditReportRepository interface {
+	Create(report *models.CreditReport) error
+	GetByID(id uuid.UUID) (*models.CreditReport, error)
+}
@@ -0,0 +1,15 @@
+package interfaces
+
+import (
+	"lumon-backend/internal/domain/models"
+
+	"github.com/google/uuid"
+)
+
+type CreditScoreSnapshotRepository interface {
+	Create(snapshot *models.CreditScoreSnapshot) error
+	GetLatestByUser(userID uuid.UUID) (*models.CreditScoreSnapshot, error)
+	GetLatestScoredByUser(userID uuid.UUID) (*models.CreditScoreSnapshot, error)
+	ListUsersWithNewTransactions() ([]uuid.UUID, error)
+	ListByUser(userID uuid.UUID) ([]models.CreditScoreSnapshot, error)
+}
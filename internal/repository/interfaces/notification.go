@@ -0,0 +1,29 @@
+package interfaces
+
+import (
+	"time"
+
+	"lumon-backend/internal/domain/models"
+
+	"github.com/google/uuid"
+)
+
+// NotificationQuery selects a page of one user's notifications.
+type NotificationQuery struct {
+	UserID     uuid.UUID
+	UnreadOnly bool
+	Page       int
+	PageSize   int
+}
+
+type NotificationRepository interface {
+	Create(notifications []*models.Notification) error
+	GetByID(id uuid.UUID) (*models.Notification, error)
+	// List returns a page of notifications, newest first, with the number
+	// of notifications matching the query.
+	List(query NotificationQuery) ([]models.Notification, int64, error)
+	CountUnread(userID uuid.UUID) (int64, error)
+	// MarkRead marks the user's unread notifications as read at a time,
+	// only those with the IDs when any are given.
+	MarkRead(userID uuid.UUID, ids []uuid.UUID, at time.Time) error
+}
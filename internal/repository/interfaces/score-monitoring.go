@@ -0,0 +1,17 @@
+package interfaces
+
+import (
+	"lumon-backend/internal/domain/models"
+
+	"github.com/google/uuid"
+)
+
+type ScoreMonitoringRepository interface {
+	Create(report *models.ScoreMonitoringReport) error
+	GetByID(id uuid.UUID) (*models.ScoreMonitoringReport, error)
+	GetBaseline() (*models.ScoreMonitoringReport, error)
+	SetBaseline(id uuid.UUID) error
+	List(page, pageSize int) ([]models.ScoreMonitoringReport, int64, error)
+	ListSubjects() ([]models.ScoreMonitoringSubject, error)
+	ListDecisions(statuses []string) ([]models.ScoreMonitoringDecision, error)
+}
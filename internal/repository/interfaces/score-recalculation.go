@@ -0,0 +1,21 @@
+package interfaces
+
+import (
+	"time"
+
+	"lumon-backend/internal/domain/models"
+)
+
+type ScoreRecalculationRunRepository interface {
+	Create(run *models.ScoreRecalculationRun) error
+	Update(run *models.ScoreRecalculationRun) error
+	List(page, pageSize int) ([]models.ScoreRecalculationRun, int64, error)
+	// FinishUnfinished finishes every run that has not finished with the
+	// status and error and returns how many there were.
+	FinishUnfinished(status, lastError string, finishedAt time.Time) (int64, error)
+}
+
+type CreditScoreAlertRepository interface {
+	Create(alert *models.CreditScoreAlert) error
+	List(page, pageSize int) ([]models.CreditScoreAlert, int64, error)
+}
@@ -0,0 +1,15 @@
+package interfaces
+
+import (
+	"lumon-backend/internal/domain/models"
+
+	"github.com/google/uuid"
+)
+
+type ScoringProfileRepository interface {
+	Create(profile *models.ScoringProfile) error
+	GetByID(id uuid.UUID) (*models.ScoringProfile, error)
+	GetActive() (*models.ScoringProfile, error)
+	List() ([]models.ScoringProfile, error)
+	Activate(id uuid.UUID) error
+}
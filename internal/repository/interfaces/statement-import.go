@@ -0,0 +1,24 @@
+package interfaces
+
+import (
+	"lumon-backend/internal/domain/models"
+
+	"github.com/google/uuid"
+)
+
+type StatementImportRepository interface {
+	Create(statementImport *models.StatementImport) error
+	Update(statementImport *models.StatementImport) error
+	GetByID(id uuid.UUID) (*models.StatementImport, error)
+	ListByStatus(statuses ...string) ([]models.StatementImport, error)
+	ListRejections(importID uuid.UUID) ([]models.StatementImportRejection, error)
+	// Complete stores the accepted transactions and the rejected rows and
+	// marks the import finished, all or nothing. Transactions the user
+	// already has are skipped and counted in the import's DuplicateRows.
+	Complete(
+		statementImport *models.StatementImport,
+		transactions []*models.Transaction,
+		rejections []models.StatementImportRejection,
+		batchSize int,
+	) error
+}
@@ -0,0 +1,31 @@
+package interfaces
+
+import (
+	"time"
+
+	"lumon-backend/internal/domain/models"
+
+	"github.com/google/uuid"
+)
+
+const (
+	AnalyticsPeriodWeek  = "week"
+	AnalyticsPeriodMonth = "month"
+)
+
+// AnalyticsRange selects one user's transactions from From up to but not
+// including To, grouped by Period.
+type AnalyticsRange struct {
+	UserID uuid.UUID
+	Period string
+	From   time.Time
+	To     time.Time
+}
+
+type TransactionAnalyticsRepository interface {
+	CashFlow(r AnalyticsRange) ([]models.CashFlowPeriod, error)
+	// TopCounterparties returns the counterparties the most money was
+	// exchanged with over the whole range, largest first.
+	TopCounterparties(r AnalyticsRange, limit int) ([]models.CounterpartyTotal, error)
+	CategoryBreakdown(r AnalyticsRange) ([]models.CategoryTotal, error)
+}
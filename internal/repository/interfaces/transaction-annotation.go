@@ -0,0 +1,36 @@
+package interfaces
+
+import (
+	"lumon-backend/internal/domain/models"
+
+	"github.com/google/uuid"
+)
+
+type TransactionTagRepository interface {
+	// Create stores the tags, skipping any the transaction already has.
+	Create(tags []*models.TransactionTag) error
+	ListByTransaction(transactionID uuid.UUID) ([]models.TransactionTag, error)
+	// Delete removes a tag from a transaction and reports whether it had
+	// it.
+	Delete(transactionID uuid.UUID, name string) (bool, error)
+	// Totals returns each of the user's tags with what moved through the
+	// transactions carrying it, by name.
+	Totals(userID uuid.UUID) ([]models.TagTotal, error)
+}
+
+type TransactionNoteRepository interface {
+	Create(note *models.TransactionNote) error
+	GetByID(id uuid.UUID) (*models.TransactionNote, error)
+	Update(note *models.TransactionNote) error
+	Delete(id uuid.UUID) error
+	ListByTransaction(transactionID uuid.UUID) ([]models.TransactionNote, error)
+}
+
+type TransactionAttachmentRepository interface {
+	// Create stores the attachment along with its document.
+	Create(attachment *models.TransactionAttachment) error
+	GetByID(id uuid.UUID) (*models.TransactionAttachment, error)
+	// Delete removes the attachment along with its document.
+	Delete(attachment *models.TransactionAttachment) error
+	ListByTransaction(transactionID uuid.UUID) ([]models.TransactionAttachment, error)
+}
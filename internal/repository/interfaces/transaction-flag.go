@@ -0,0 +1,33 @@
+package interfaces
+
+import (
+	"lumon-backend/internal/domain/models"
+
+	"github.com/google/uuid"
+)
+
+// TransactionFlagQuery selects flags for the review queue. Empty fields do not
+// filter.
+type TransactionFlagQuery struct {
+	Status   string
+	Severity string
+	Kind     string
+	UserID   *uuid.UUID
+	Page     int
+	PageSize int
+}
+
+type TransactionFlagRepository interface {
+	// Create stores the flags, skipping any raised before on the same
+	// transaction for the same kind of anomaly.
+	Create(flags []*models.TransactionFlag) error
+	GetByID(id uuid.UUID) (*models.TransactionFlag, error)
+	Update(flag *models.TransactionFlag) error
+	ListByUser(userID uuid.UUID) ([]models.TransactionFlag, error)
+	// List returns a page of flags, most severe and then newest first, with
+	// the number of flags matching the query.
+	List(query TransactionFlagQuery) ([]models.TransactionFlag, int64, error)
+	// Count counts the user's flags in the status with one of the
+	// severities.
+	Count(userID uuid.UUID, status string, severities []string) (int64, error)
+}
@@ -10,14 +10,58 @@ import (
 
 type TransactionRepository interface {
 	Create(transaction *models.Transaction) error
-	CreateBatch(transactions []*models.Transaction, batchSize int) error
+	// CreateBatch skips transactions the user already has and returns how
+	// many were skipped.
+	CreateBatch(transactions []*models.Transaction, batchSize int) (int, error)
 	GetByID(id uuid.UUID) (*models.Transaction, error)
-	GetByType(transactionType string, page, pageSize int) ([]models.Transaction, int64, error)
-	GetByAccountNumber(accountNumber string, page, pageSize int) ([]models.Transaction, int64, error)
-	GetByDateRange(start, end time.Time, page, pageSize int) ([]models.Transaction, int64, error)
-	GetByAmountRange(min, max float64, page, pageSize int) ([]models.Transaction, int64, error)
+	// Search returns a page of one user's transactions matching the query.
+	Search(query TransactionQuery) ([]models.Transaction, error)
 	Update(transaction *models.Transaction) error
+	// UpdateCategory sets the category of the transactions, recording how it
+	// was assigned.
+	UpdateCategory(ids []uuid.UUID, category, source string) error
+	// UpdateCounterparty links the transactions to a counterparty.
+	UpdateCounterparty(ids []uuid.UUID, counterpartyID uuid.UUID) error
 	Delete(id uuid.UUID) error
 	List(userId uuid.UUID, page, pageSize int) ([]models.Transaction, int64, error)
 	ListAll(userId uuid.UUID) ([]models.Transaction, error)
 }
+
+const (
+	TransactionSortDate   = "transaction_date"
+	TransactionSortAmount = "amount"
+)
+
+// TransactionQuery filters and orders one user's transactions. Filters left
+// at their zero value are not applied.
+type TransactionQuery struct {
+	UserID uuid.UUID
+	Types  []string
+	// From and To bound the transaction date, both inclusive.
+	From      *time.Time
+	To        *time.Time
+	MinAmount *float64
+	MaxAmount *float64
+	// Counterparty matches part of the name or number on either side.
+	Counterparty   string
+	CounterpartyID *uuid.UUID
+	Category       string
+	// Tags selects the transactions carrying any of the tags.
+	Tags []string
+	// Text matches part of the names, numbers, reference or description.
+	Text string
+
+	SortBy     string
+	Descending bool
+	Limit      int
+	// After continues from the last transaction of the previous page.
+	After *TransactionCursor
+}
+
+// TransactionCursor is the position of a transaction in a sorted search: its
+// value of the sort column, with the ID to break ties.
+type TransactionCursor struct {
+	Date   time.Time
+	Amount float64
+	ID     uuid.UUID
+}
@@ -1,11 +1,28 @@
 package interfaces
 
 import (
+	"errors"
+
 	"lumon-backend/internal/domain/models"
+
+	"github.com/google/uuid"
 )
 
+// ErrInsufficientBalance is returned by MoveBalance when a movement would take
+// a wallet's balance below zero.
+var ErrInsufficientBalance = errors.New("insufficient funds")
+
 type WalletRepository interface {
 	Create(wallet *models.Wallet) error
 	Update(wallet *models.Wallet) error
 	FindByID(id string) (*models.Wallet, error)
+	// CreateWithEntry stores a new wallet together with the ledger entry of
+	// the movement that brought it to its balance.
+	CreateWithEntry(wallet *models.Wallet, entry *models.WalletLedgerEntry) error
+	// MoveBalance adds delta to the wallet's balance in a single update, so
+	// that concurrent movements cannot overwrite each other, and records the
+	// entry with the balance the update left. It fails with
+	// ErrInsufficientBalance rather than take the balance below zero, and
+	// returns the wallet as updated.
+	MoveBalance(id uuid.UUID, delta int64, entry *models.WalletLedgerEntry) (*models.Wallet, error)
 }
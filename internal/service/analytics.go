@@ -0,0 +1,337 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"lumon-backend/internal/domain/models"
+	"lumon-backend/internal/domain/schemas"
+	"lumon-backend/internal/repository/interfaces"
+	"lumon-backend/pkg/common/cache"
+	"lumon-backend/pkg/common/logger"
+
+	"github.com/google/uuid"
+)
+
+var ErrInvalidAnalyticsRange = errors.New("invalid analytics range")
+
+const (
+	// defaultAnalyticsMonths is how far back the analytics look when no range
+	// is given, including the current month.
+	defaultAnalyticsMonths = 12
+	// maxAnalyticsPeriods bounds the number of weeks or months in one request.
+	maxAnalyticsPeriods = 160
+
+	defaultCounterpartyLimit = 10
+	maxCounterpartyLimit     = 100
+)
+
+// AnalyticsService aggregates a user's transactions for the dashboard. Results
+// are cached per user until the user's transactions change.
+type AnalyticsService struct {
+	repo  interfaces.TransactionAnalyticsRepository
+	cache *cache.Cache[any]
+}
+
+func NewAnalyticsService(
+	repo interfaces.TransactionAnalyticsRepository, cacheTTL time.Duration, cacheMaxEntries int,
+) *AnalyticsService {
+	return &AnalyticsService{
+		repo:  repo,
+		cache: cache.New[any](cacheTTL, cacheMaxEntries),
+	}
+}
+
+// Invalidate drops the cached analytics of a user whose transactions were
+// added to or changed.
+func (s *AnalyticsService) Invalidate(userID uuid.UUID) {
+	s.cache.Invalidate(userID.String())
+}
+
+// CashFlow returns the money in and out, the charges paid and the average
+// balance for every week or month of the range, including those without any
+// transactions.
+func (s *AnalyticsService) CashFlow(userID string, req schemas.AnalyticsRequest) (*schemas.CashFlowResponse, error) {
+	rng, err := newAnalyticsRange(uuid.MustParse(userID), req)
+	if err != nil {
+		return nil, err
+	}
+
+	key := analyticsCacheKey("cash-flow", rng, 0)
+	if cached, ok := s.cache.Get(userID, key); ok {
+		return cached.(*schemas.CashFlowResponse), nil
+	}
+
+	rows, err := s.repo.CashFlow(rng)
+	if err != nil {
+		logger.APILogger.Error(err)
+		return nil, err
+	}
+
+	byStart := make(map[time.Time]models.CashFlowPeriod, len(rows))
+	for _, row := range rows {
+		byStart[row.Period.UTC()] = row
+	}
+
+	response := &schemas.CashFlowResponse{
+		Period:  rng.Period,
+		From:    rng.From,
+		To:      rng.To,
+		Periods: []schemas.CashFlowPeriod{},
+		Totals:  schemas.CashFlowPeriod{Period: "total", Start: rng.From},
+	}
+
+	balanceSum, balanceCount := 0.0, 0
+	for start := periodStart(rng.From, rng.Period); start.Before(rng.To); start = nextPeriod(start, rng.Period) {
+		row := byStart[start]
+		period := schemas.CashFlowPeriod{
+			Period:           periodLabel(start, rng.Period),
+			Start:            start,
+			Inflow:           roundAmount(row.Inflow),
+			Outflow:          roundAmount(row.Outflow),
+			Fees:             roundAmount(row.Fees),
+			ELevy:            roundAmount(row.ELevy),
+			NetCashFlow:      roundAmount(row.Inflow - row.Outflow - row.Fees - row.ELevy),
+			TransactionCount: row.TransactionCount,
+		}
+		if row.AverageBalance != nil {
+			average := roundAmount(*row.AverageBalance)
+			period.AverageBalance = &average
+			balanceSum += *row.AverageBalance * float64(row.BalanceCount)
+			balanceCount += row.BalanceCount
+		}
+		response.Periods = append(response.Periods, period)
+
+		response.Totals.Inflow += row.Inflow
+		response.Totals.Outflow += row.Outflow
+		response.Totals.Fees += row.Fees
+		response.Totals.ELevy += row.ELevy
+		response.Totals.TransactionCount += row.TransactionCount
+	}
+
+	totals := &response.Totals
+	totals.NetCashFlow = roundAmount(totals.Inflow - totals.Outflow - totals.Fees - totals.ELevy)
+	totals.Inflow, totals.Outflow = roundAmount(totals.Inflow), roundAmount(totals.Outflow)
+	totals.Fees, totals.ELevy = roundAmount(totals.Fees), roundAmount(totals.ELevy)
+	if balanceCount > 0 {
+		average := roundAmount(balanceSum / float64(balanceCount))
+		totals.AverageBalance = &average
+	}
+
+	s.cache.Set(userID, key, response)
+	return response, nil
+}
+
+// TopCounterparties returns the counterparties the user exchanged the most
+// money with over the range.
+func (s *AnalyticsService) TopCounterparties(
+	userID string, req schemas.AnalyticsRequest,
+) (*schemas.CounterpartiesResponse, error) {
+	rng, err := newAnalyticsRange(uuid.MustParse(userID), req)
+	if err != nil {
+		return nil, err
+	}
+
+	limit := req.Limit
+	switch {
+	case limit <= 0:
+		limit = defaultCounterpartyLimit
+	case limit > maxCounterpartyLimit:
+		limit = maxCounterpartyLimit
+	}
+
+	key := analyticsCacheKey("counterparties", rng, limit)
+	if cached, ok := s.cache.Get(userID, key); ok {
+		return cached.(*schemas.CounterpartiesResponse), nil
+	}
+
+	rows, err := s.repo.TopCounterparties(rng, limit)
+	if err != nil {
+		logger.APILogger.Error(err)
+		return nil, err
+	}
+
+	response := &schemas.CounterpartiesResponse{
+		From:           rng.From,
+		To:             rng.To,
+		Counterparties: make([]schemas.CounterpartyTotal, len(rows)),
+	}
+	for i, row := range rows {
+		response.Counterparties[i] = schemas.CounterpartyTotal{
+			Name:             row.Name,
+			Number:           row.Number,
+			Inflow:           roundAmount(row.Inflow),
+			Outflow:          roundAmount(row.Outflow),
+			TransactionCount: row.TransactionCount,
+		}
+	}
+
+	s.cache.Set(userID, key, response)
+	return response, nil
+}
+
+// CategoryBreakdown returns the money moved in each category for every week
+// or month of the range that had transactions, and over the whole range.
+func (s *AnalyticsService) CategoryBreakdown(
+	userID string, req schemas.AnalyticsRequest,
+) (*schemas.CategoryBreakdownResponse, error) {
+	rng, err := newAnalyticsRange(uuid.MustParse(userID), req)
+	if err != nil {
+		return nil, err
+	}
+
+	key := analyticsCacheKey("categories", rng, 0)
+	if cached, ok := s.cache.Get(userID, key); ok {
+		return cached.(*schemas.CategoryBreakdownResponse), nil
+	}
+
+	rows, err := s.repo.CategoryBreakdown(rng)
+	if err != nil {
+		logger.APILogger.Error(err)
+		return nil, err
+	}
+
+	response := &schemas.CategoryBreakdownResponse{
+		Period:  rng.Period,
+		From:    rng.From,
+		To:      rng.To,
+		Periods: []schemas.CategoryPeriod{},
+		Totals:  []schemas.CategoryTotal{},
+	}
+
+	totals := make(map[string]*schemas.CategoryTotal)
+	for _, row := range rows {
+		start := row.Period.UTC()
+		if n := len(response.Periods); n == 0 || !response.Periods[n-1].Start.Equal(start) {
+			response.Periods = append(response.Periods, schemas.CategoryPeriod{
+				Period: periodLabel(start, rng.Period),
+				Start:  start,
+			})
+		}
+
+		period := &response.Periods[len(response.Periods)-1]
+		period.Categories = append(period.Categories, schemas.CategoryTotal{
+			Category:         row.Category,
+			Inflow:           roundAmount(row.Inflow),
+			Outflow:          roundAmount(row.Outflow),
+			TransactionCount: row.TransactionCount,
+		})
+
+		total, ok := totals[row.Category]
+		if !ok {
+			total = &schemas.CategoryTotal{Category: row.Category}
+			totals[row.Category] = total
+		}
+		total.Inflow += row.Inflow
+		total.Outflow += row.Outflow
+		total.TransactionCount += row.TransactionCount
+	}
+
+	for _, total := range totals {
+		total.Inflow, total.Outflow = roundAmount(total.Inflow), roundAmount(total.Outflow)
+		response.Totals = append(response.Totals, *total)
+	}
+	sort.Slice(response.Totals, func(i, j int) bool {
+		if response.Totals[i].Outflow != response.Totals[j].Outflow {
+			return response.Totals[i].Outflow > response.Totals[j].Outflow
+		}
+		return response.Totals[i].Category < response.Totals[j].Category
+	})
+
+	s.cache.Set(userID, key, response)
+	return response, nil
+}
+
+func newAnalyticsRange(userID uuid.UUID, req schemas.AnalyticsRequest) (interfaces.AnalyticsRange, error) {
+	rng := interfaces.AnalyticsRange{UserID: userID, Period: strings.ToLower(req.Period)}
+
+	switch rng.Period {
+	case "":
+		rng.Period = interfaces.AnalyticsPeriodMonth
+	case interfaces.AnalyticsPeriodWeek, interfaces.AnalyticsPeriodMonth:
+	default:
+		return rng, fmt.Errorf("%w: period must be week or month", ErrInvalidAnalyticsRange)
+	}
+
+	now := time.Now().UTC()
+	rng.From = YearMonthOf(now).AddMonths(-(defaultAnalyticsMonths - 1)).Start()
+	rng.To = YearMonthOf(now).AddMonths(1).Start()
+
+	if req.From != "" {
+		from, _, err := parseQueryTime(req.From, time.UTC)
+		if err != nil {
+			return rng, fmt.Errorf("%w: from: %v", ErrInvalidAnalyticsRange, err)
+		}
+		rng.From = from
+	}
+	if req.To != "" {
+		to, dateOnly, err := parseQueryTime(req.To, time.UTC)
+		if err != nil {
+			return rng, fmt.Errorf("%w: to: %v", ErrInvalidAnalyticsRange, err)
+		}
+		// The range excludes its end, so a date on its own is moved to the
+		// start of the next day to include all of it.
+		if dateOnly {
+			to = to.AddDate(0, 0, 1)
+		} else {
+			to = to.Add(time.Microsecond)
+		}
+		rng.To = to
+	}
+
+	if !rng.From.Before(rng.To) {
+		return rng, fmt.Errorf("%w: to must be after from", ErrInvalidAnalyticsRange)
+	}
+
+	periods := 0
+	for start := periodStart(rng.From, rng.Period); start.Before(rng.To); start = nextPeriod(start, rng.Period) {
+		if periods++; periods > maxAnalyticsPeriods {
+			return rng, fmt.Errorf("%w: the range spans more than %d %ss",
+				ErrInvalidAnalyticsRange, maxAnalyticsPeriods, rng.Period)
+		}
+	}
+
+	return rng, nil
+}
+
+func analyticsCacheKey(kind string, rng interfaces.AnalyticsRange, limit int) string {
+	return fmt.Sprintf("%s|%s|%s|%s|%d",
+		kind, rng.Period, rng.From.Format(time.RFC3339Nano), rng.To.Format(time.RFC3339Nano), limit)
+}
+
+// periodStart truncates t the way Postgres date_trunc does: to midnight on the
+// Monday of its week, or to the first of its month.
+func periodStart(t time.Time, period string) time.Time {
+	t = t.UTC()
+	if period == interfaces.AnalyticsPeriodWeek {
+		day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+		return day.AddDate(0, 0, -((int(day.Weekday()) + 6) % 7))
+	}
+	return YearMonthOf(t).Start()
+}
+
+func nextPeriod(start time.Time, period string) time.Time {
+	if period == interfaces.AnalyticsPeriodWeek {
+		return start.AddDate(0, 0, 7)
+	}
+	return start.AddDate(0, 1, 0)
+}
+
+// periodLabel names a month as 2024-01 and a week by its ISO week, 2024-W05.
+func periodLabel(start time.Time, period string) string {
+	if period == interfaces.AnalyticsPeriodWeek {
+		year, week := start.ISOWeek()
+		return fmt.Sprintf("%04d-W%02d", year, week)
+	}
+	return YearMonthOf(start).String()
+}
+
+// roundAmount rounds money to the cent, so that sums of many floating point
+// amounts read as they would on a statement.
+func roundAmount(amount float64) float64 {
+	return math.Round(amount*100) / 100
+}
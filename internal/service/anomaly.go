@@ -0,0 +1,284 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"lumon-backend/internal/anomaly"
+	"lumon-backend/internal/domain/models"
+	"lumon-backend/internal/domain/schemas"
+	"lumon-backend/internal/repository/interfaces"
+	"lumon-backend/internal/statements"
+	"lumon-backend/pkg/common/logger"
+
+	"github.com/google/uuid"
+)
+
+// A flag is open until an admin reviews it, and is then either cleared as
+// legitimate or confirmed as suspicious.
+const (
+	TransactionFlagStatusOpen      = "open"
+	TransactionFlagStatusCleared   = "cleared"
+	TransactionFlagStatusConfirmed = "confirmed"
+)
+
+const (
+	TransactionFlagSourceStatement = "statement"
+	TransactionFlagSourceWallet    = "wallet"
+)
+
+var (
+	ErrTransactionFlagNotFound      = errors.New("transaction flag not found")
+	ErrInvalidTransactionFlagQuery  = errors.New("invalid transaction flag query")
+	ErrInvalidTransactionFlagReview = errors.New("review status must be cleared or confirmed")
+)
+
+const (
+	defaultTransactionFlagPageSize = 20
+	maxTransactionFlagPageSize     = 100
+)
+
+// AnomalyService scans users' transactions and wallet movements for anomalies
+// and keeps the flags raised for admins to review.
+type AnomalyService struct {
+	flagRepo        interfaces.TransactionFlagRepository
+	transactionRepo interfaces.TransactionRepository
+}
+
+func NewAnomalyService(
+	flagRepo interfaces.TransactionFlagRepository, transactionRepo interfaces.TransactionRepository,
+) *AnomalyService {
+	return &AnomalyService{
+		flagRepo:        flagRepo,
+		transactionRepo: transactionRepo,
+	}
+}
+
+type transactionFlagKey struct {
+	kind          string
+	transactionID uuid.UUID
+}
+
+// ScanTransactions looks for anomalies across all of the user's transactions
+// and returns how many new flags were raised. Transactions already flagged
+// for an anomaly are not flagged for it again, whatever the review decided.
+func (s *AnomalyService) ScanTransactions(userID uuid.UUID) (int, error) {
+	transactions, err := s.transactionRepo.ListAll(userID)
+	if err != nil {
+		logger.APILogger.Error(err)
+		return 0, err
+	}
+
+	existing, err := s.flagRepo.ListByUser(userID)
+	if err != nil {
+		logger.APILogger.Error(err)
+		return 0, err
+	}
+
+	raised := make(map[transactionFlagKey]bool, len(existing))
+	for _, flag := range existing {
+		if flag.TransactionID != nil {
+			raised[transactionFlagKey{kind: flag.Kind, transactionID: *flag.TransactionID}] = true
+		}
+	}
+
+	var flags []*models.TransactionFlag
+	for _, found := range anomaly.Detect(transactions) {
+		key := transactionFlagKey{kind: found.Kind, transactionID: found.Transaction.ID}
+		if raised[key] {
+			continue
+		}
+		raised[key] = true
+
+		flag := newTransactionFlag(found, TransactionFlagSourceStatement, userID)
+		flag.TransactionID = &found.Transaction.ID
+		flags = append(flags, flag)
+	}
+
+	if err := s.flagRepo.Create(flags); err != nil {
+		logger.APILogger.Error(err)
+		return 0, err
+	}
+
+	return len(flags), nil
+}
+
+// CheckWalletMovement flags a wallet top-up or withdrawal that stands out from
+// the user's transactions. It is best effort: a failure is logged and never
+// holds up the movement.
+func (s *AnomalyService) CheckWalletMovement(userID uuid.UUID, amount float64, incoming bool) {
+	history, err := s.transactionRepo.ListAll(userID)
+	if err != nil {
+		logger.APILogger.Errorf("Failed to load transactions to check wallet movement: %v", err)
+		return
+	}
+
+	movement := &models.Transaction{
+		TransactionDate: time.Now().UTC(),
+		TransactionType: statements.TypeCashOut,
+		Amount:          amount,
+	}
+	if incoming {
+		movement.TransactionType = statements.TypeCashIn
+	}
+
+	var flags []*models.TransactionFlag
+	for _, found := range anomaly.CheckMovement(history, movement) {
+		flags = append(flags, newTransactionFlag(found, TransactionFlagSourceWallet, userID))
+	}
+
+	if err := s.flagRepo.Create(flags); err != nil {
+		logger.APILogger.Errorf("Failed to flag wallet movement: %v", err)
+	}
+}
+
+// HasBlockingFlags reports whether any of the user's medium or high severity
+// flags still await review. Low severity flags are left for the queue and do
+// not hold anything up.
+func (s *AnomalyService) HasBlockingFlags(userID uuid.UUID) (bool, error) {
+	count, err := s.flagRepo.Count(
+		userID, TransactionFlagStatusOpen, []string{anomaly.SeverityMedium, anomaly.SeverityHigh},
+	)
+	if err != nil {
+		logger.APILogger.Error(err)
+		return false, err
+	}
+
+	return count > 0, nil
+}
+
+// ListFlags returns a page of the review queue with the number of flags in
+// it.
+func (s *AnomalyService) ListFlags(
+	req schemas.TransactionFlagListRequest,
+) ([]schemas.TransactionFlagResponse, int64, error) {
+	query := interfaces.TransactionFlagQuery{
+		Status:   req.Status,
+		Severity: req.Severity,
+		Kind:     req.Kind,
+		Page:     req.Page,
+		PageSize: req.PageSize,
+	}
+
+	switch query.Status {
+	case "":
+		query.Status = TransactionFlagStatusOpen
+	case "all":
+		query.Status = ""
+	case TransactionFlagStatusOpen, TransactionFlagStatusCleared, TransactionFlagStatusConfirmed:
+	default:
+		return nil, 0, fmt.Errorf("%w: unknown status %q", ErrInvalidTransactionFlagQuery, query.Status)
+	}
+
+	if req.UserID != "" {
+		userID, err := uuid.Parse(req.UserID)
+		if err != nil {
+			return nil, 0, fmt.Errorf("%w: user_id must be a UUID", ErrInvalidTransactionFlagQuery)
+		}
+		query.UserID = &userID
+	}
+
+	if query.Page < 1 {
+		query.Page = 1
+	}
+	switch {
+	case query.PageSize <= 0:
+		query.PageSize = defaultTransactionFlagPageSize
+	case query.PageSize > maxTransactionFlagPageSize:
+		query.PageSize = maxTransactionFlagPageSize
+	}
+
+	flags, total, err := s.flagRepo.List(query)
+	if err != nil {
+		logger.APILogger.Error(err)
+		return nil, 0, err
+	}
+
+	responses := make([]schemas.TransactionFlagResponse, len(flags))
+	for i, flag := range flags {
+		responses[i] = newTransactionFlagResponse(flag)
+	}
+
+	return responses, total, nil
+}
+
+func (s *AnomalyService) GetFlag(id string) (*schemas.TransactionFlagResponse, error) {
+	flag, err := s.flagRepo.GetByID(uuid.MustParse(id))
+	if err != nil {
+		logger.APILogger.Error(err)
+		return nil, ErrTransactionFlagNotFound
+	}
+
+	response := newTransactionFlagResponse(*flag)
+	return &response, nil
+}
+
+// ReviewFlag records an admin's decision on a flag. A decision can be changed
+// by reviewing the flag again, but a flag cannot be reopened.
+func (s *AnomalyService) ReviewFlag(
+	reviewerID, id string, req schemas.ReviewTransactionFlagRequest,
+) (*schemas.TransactionFlagResponse, error) {
+	if req.Status != TransactionFlagStatusCleared && req.Status != TransactionFlagStatusConfirmed {
+		return nil, ErrInvalidTransactionFlagReview
+	}
+
+	flag, err := s.flagRepo.GetByID(uuid.MustParse(id))
+	if err != nil {
+		logger.APILogger.Error(err)
+		return nil, ErrTransactionFlagNotFound
+	}
+
+	reviewedAt := time.Now().UTC()
+	reviewer := uuid.MustParse(reviewerID)
+	flag.Status = req.Status
+	flag.ReviewNote = req.Note
+	flag.ReviewedAt = &reviewedAt
+	flag.ReviewedByID = &reviewer
+
+	if err := s.flagRepo.Update(flag); err != nil {
+		logger.APILogger.Error(err)
+		return nil, err
+	}
+
+	response := newTransactionFlagResponse(*flag)
+	return &response, nil
+}
+
+func newTransactionFlag(found anomaly.Flag, source string, userID uuid.UUID) *models.TransactionFlag {
+	return &models.TransactionFlag{
+		Kind:       found.Kind,
+		Severity:   found.Severity,
+		Reason:     found.Reason,
+		Source:     source,
+		Amount:     found.Transaction.Amount,
+		OccurredAt: found.Transaction.TransactionDate,
+		Status:     TransactionFlagStatusOpen,
+		UserID:     userID,
+	}
+}
+
+func newTransactionFlagResponse(flag models.TransactionFlag) schemas.TransactionFlagResponse {
+	response := schemas.TransactionFlagResponse{
+		ID:         flag.ID.String(),
+		Kind:       flag.Kind,
+		Severity:   flag.Severity,
+		Reason:     flag.Reason,
+		Source:     flag.Source,
+		Amount:     flag.Amount,
+		OccurredAt: flag.OccurredAt,
+		Status:     flag.Status,
+		ReviewNote: flag.ReviewNote,
+		ReviewedAt: flag.ReviewedAt,
+		UserID:     flag.UserID.String(),
+		CreatedAt:  flag.CreatedAt,
+	}
+	if flag.ReviewedByID != nil {
+		response.ReviewedByID = flag.ReviewedByID.String()
+	}
+	if flag.Transaction != nil {
+		transaction := NewTransactionResponse(*flag.Transaction)
+		response.Transaction = &transaction
+	}
+	return response
+}
@@ -0,0 +1,266 @@
+package service
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"lumon-backend/internal/domain/models"
+)
+
+const (
+	LoanOutcomeRepaid    = "repaid"
+	LoanOutcomeDefaulted = "defaulted"
+)
+
+const (
+	defaultBacktestBandCount        = 5
+	defaultBacktestCalibrationCount = 10
+)
+
+type BacktestLoan struct {
+	ID              string    `json:"id"`
+	ApplicationDate time.Time `json:"application_date"`
+	Outcome         string    `json:"outcome"`
+}
+
+type BacktestBorrower struct {
+	ID           string               `json:"id"`
+	Transactions []models.Transaction `json:"transactions"`
+	Loans        []BacktestLoan       `json:"loans"`
+}
+
+type BacktestDataset struct {
+	Borrowers []BacktestBorrower `json:"borrowers"`
+}
+
+type BacktestObservation struct {
+	BorrowerID string  `json:"borrower_id"`
+	LoanID     string  `json:"loan_id"`
+	Score      float64 `json:"score"`
+	Defaulted  bool    `json:"defaulted"`
+}
+
+type BacktestBand struct {
+	Label       string  `json:"label"`
+	MinScore    float64 `json:"min_score"`
+	MaxScore    float64 `json:"max_score"`
+	Loans       int     `json:"loans"`
+	Defaults    int     `json:"defaults"`
+	DefaultRate float64 `json:"default_rate"`
+}
+
+type BacktestCalibrationBucket struct {
+	Bucket              int     `json:"bucket"`
+	Loans               int     `json:"loans"`
+	MeanScore           float64 `json:"mean_score"`
+	PredictedDefaultPct float64 `json:"predicted_default_rate"`
+	ObservedDefaultPct  float64 `json:"observed_default_rate"`
+}
+
+type BacktestReport struct {
+	Profile      string                      `json:"profile"`
+	ModelVersion string                      `json:"model_version"`
+	Loans        int                         `json:"loans"`
+	Scored       int                         `json:"scored"`
+	Unscored     int                         `json:"unscored"`
+	Defaults     int                         `json:"defaults"`
+	AUC          float64                     `json:"auc"`
+	KS           float64                     `json:"ks"`
+	Gini         float64                     `json:"gini"`
+	Bands        []BacktestBand              `json:"bands"`
+	Calibration  []BacktestCalibrationBucket `json:"calibration"`
+	Observations []BacktestObservation       `json:"-"`
+}
+
+// RunBacktest replays every loan in the dataset through the model and profile, scoring
+// only the transactions the borrower had before the loan's application date.
+// Loans whose history is too thin to score are counted but left out of the
+// metrics.
+func RunBacktest(
+	dataset BacktestDataset, profile *models.ScoringProfile, model ScoringModel,
+) (*BacktestReport, error) {
+	report := &BacktestReport{Profile: profile.Name, ModelVersion: model.Version()}
+
+	for _, borrower := range dataset.Borrowers {
+		for _, loan := range borrower.Loans {
+			if loan.Outcome != LoanOutcomeRepaid && loan.Outcome != LoanOutcomeDefaulted {
+				return nil, fmt.Errorf("loan %s has unknown outcome %q", loan.ID, loan.Outcome)
+			}
+
+			report.Loans++
+
+			var history []models.Transaction
+			for _, tx := range borrower.Transactions {
+				if tx.TransactionDate.Before(loan.ApplicationDate) {
+					history = append(history, tx)
+				}
+			}
+
+			result := NewCreditScoreCalculatorWithModel(history, profile, model).Evaluate()
+			if !result.Scored() {
+				report.Unscored++
+				continue
+			}
+
+			observation := BacktestObservation{
+				BorrowerID: borrower.ID,
+				LoanID:     loan.ID,
+				Score:      result.Score,
+				Defaulted:  loan.Outcome == LoanOutcomeDefaulted,
+			}
+			report.Observations = append(report.Observations, observation)
+			report.Scored++
+			if observation.Defaulted {
+				report.Defaults++
+			}
+		}
+	}
+
+	report.AUC = backtestAUC(report.Observations)
+	report.Gini = 2*report.AUC - 1
+	report.KS = backtestKS(report.Observations)
+	report.Bands = backtestBands(report.Observations, profile, defaultBacktestBandCount)
+	report.Calibration = backtestCalibration(report.Observations, profile, defaultBacktestCalibrationCount)
+
+	return report, nil
+}
+
+// backtestAUC is the probability that a randomly chosen repaid loan scored
+// higher than a randomly chosen defaulted one, with ties counting half. With
+// only one outcome present it reports 0.5, i.e. no discriminating power.
+func backtestAUC(observations []BacktestObservation) float64 {
+	var good, bad []float64
+	for _, o := range observations {
+		if o.Defaulted {
+			bad = append(bad, o.Score)
+		} else {
+			good = append(good, o.Score)
+		}
+	}
+
+	if len(good) == 0 || len(bad) == 0 {
+		return 0.5
+	}
+
+	wins := 0.0
+	for _, g := range good {
+		for _, b := range bad {
+			switch {
+			case g > b:
+				wins++
+			case g == b:
+				wins += 0.5
+			}
+		}
+	}
+
+	return wins / float64(len(good)*len(bad))
+}
+
+// backtestKS is the largest gap between the cumulative score distributions of
+// defaulted and repaid loans.
+func backtestKS(observations []BacktestObservation) float64 {
+	sorted := sortedObservations(observations)
+
+	var totalGood, totalBad int
+	for _, o := range sorted {
+		if o.Defaulted {
+			totalBad++
+		} else {
+			totalGood++
+		}
+	}
+
+	if totalGood == 0 || totalBad == 0 {
+		return 0
+	}
+
+	ks, good, bad := 0.0, 0, 0
+	for i, o := range sorted {
+		if o.Defaulted {
+			bad++
+		} else {
+			good++
+		}
+
+		if i+1 < len(sorted) && sorted[i+1].Score == o.Score {
+			continue
+		}
+
+		gap := math.Abs(float64(bad)/float64(totalBad) - float64(good)/float64(totalGood))
+		ks = math.Max(ks, gap)
+	}
+
+	return ks
+}
+
+// backtestBands splits the profile's score range into equal-width bands.
+func backtestBands(observations []BacktestObservation, profile *models.ScoringProfile, count int) []BacktestBand {
+	width := (profile.ScoreMax - profile.ScoreMin) / float64(count)
+
+	bands := make([]BacktestBand, count)
+	for i := range bands {
+		bands[i].MinScore = profile.ScoreMin + float64(i)*width
+		bands[i].MaxScore = bands[i].MinScore + width
+		bands[i].Label = fmt.Sprintf("%.0f-%.0f", bands[i].MinScore, bands[i].MaxScore)
+	}
+
+	for _, o := range observations {
+		i := int((o.Score - profile.ScoreMin) / width)
+		i = max(0, min(i, count-1))
+
+		bands[i].Loans++
+		if o.Defaulted {
+			bands[i].Defaults++
+		}
+	}
+
+	for i := range bands {
+		if bands[i].Loans > 0 {
+			bands[i].DefaultRate = float64(bands[i].Defaults) / float64(bands[i].Loans)
+		}
+	}
+
+	return bands
+}
+
+// backtestCalibration groups loans into equal-count buckets by score and
+// compares the default rate implied by the score with the observed one. The
+// implied rate maps the bottom of the score range to 100% and the top to 0%.
+func backtestCalibration(observations []BacktestObservation, profile *models.ScoringProfile, count int) []BacktestCalibrationBucket {
+	sorted := sortedObservations(observations)
+	if len(sorted) == 0 {
+		return []BacktestCalibrationBucket{}
+	}
+	count = min(count, len(sorted))
+
+	buckets := make([]BacktestCalibrationBucket, count)
+	for i, o := range sorted {
+		b := i * count / len(sorted)
+		buckets[b].Bucket = b + 1
+		buckets[b].Loans++
+		buckets[b].MeanScore += o.Score
+		buckets[b].PredictedDefaultPct += 1 - (o.Score-profile.ScoreMin)/(profile.ScoreMax-profile.ScoreMin)
+		if o.Defaulted {
+			buckets[b].ObservedDefaultPct++
+		}
+	}
+
+	for i := range buckets {
+		n := float64(buckets[i].Loans)
+		buckets[i].MeanScore /= n
+		buckets[i].PredictedDefaultPct /= n
+		buckets[i].ObservedDefaultPct /= n
+	}
+
+	return buckets
+}
+
+func sortedObservations(observations []BacktestObservation) []BacktestObservation {
+	sorted := make([]BacktestObservation, len(observations))
+	copy(sorted, observations)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].Score < sorted[j].Score })
+	return sorted
+}
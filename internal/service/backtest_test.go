@@ -0,0 +1,128 @@
+package service
+
+import (
+	"math"
+	"testing"
+
+	"lumon-backend/internal/domain/models"
+)
+
+func repaid(scores ...float64) []BacktestObservation {
+	var observations []BacktestObservation
+	for _, score := range scores {
+		observations = append(observations, BacktestObservation{Score: score})
+	}
+	return observations
+}
+
+func defaulted(scores ...float64) []BacktestObservation {
+	observations := repaid(scores...)
+	for i := range observations {
+		observations[i].Defaulted = true
+	}
+	return observations
+}
+
+func TestBacktestAUCAndKS(t *testing.T) {
+	tests := []struct {
+		name         string
+		observations []BacktestObservation
+		auc          float64
+		ks           float64
+	}{
+		{name: "no loans", auc: 0.5},
+		{name: "all repaid", observations: repaid(500, 600), auc: 0.5},
+		{name: "all defaulted", observations: defaulted(500, 600), auc: 0.5},
+		{
+			name:         "repaid loans all scored higher",
+			observations: append(defaulted(400, 500), repaid(600, 700)...),
+			auc:          1,
+			ks:           1,
+		},
+		{
+			name:         "repaid loans all scored lower",
+			observations: append(defaulted(600, 700), repaid(400, 500)...),
+			auc:          0,
+			ks:           1,
+		},
+		{
+			// Of the four pairs 500/400, 600/400 and 600/500 are won and
+			// 500/500 is tied. The KS gap is measured after both loans
+			// scored 500, not between them.
+			name:         "tied scores",
+			observations: append(defaulted(400, 500), repaid(500, 600)...),
+			auc:          3.5 / 4,
+			ks:           0.5,
+		},
+		{
+			name:         "every score tied",
+			observations: append(defaulted(500, 500), repaid(500)...),
+			auc:          0.5,
+			ks:           0,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := backtestAUC(test.observations); math.Abs(got-test.auc) > 1e-9 {
+				t.Errorf("AUC = %v, want %v", got, test.auc)
+			}
+			if got := backtestKS(test.observations); math.Abs(got-test.ks) > 1e-9 {
+				t.Errorf("KS = %v, want %v", got, test.ks)
+			}
+		})
+	}
+}
+
+func TestBacktestBands(t *testing.T) {
+	profile := &models.ScoringProfile{ScoreMin: 300, ScoreMax: 850}
+	observations := append(defaulted(300, 409.99, 620), repaid(410, 850, 900)...)
+
+	bands := backtestBands(observations, profile, 5)
+
+	want := []BacktestBand{
+		{Label: "300-410", MinScore: 300, MaxScore: 410, Loans: 2, Defaults: 2, DefaultRate: 1},
+		{Label: "410-520", MinScore: 410, MaxScore: 520, Loans: 1},
+		{Label: "520-630", MinScore: 520, MaxScore: 630, Loans: 1, Defaults: 1, DefaultRate: 1},
+		{Label: "630-740", MinScore: 630, MaxScore: 740},
+		// The top of the range and scores above it fall in the last band.
+		{Label: "740-850", MinScore: 740, MaxScore: 850, Loans: 2},
+	}
+	if len(bands) != len(want) {
+		t.Fatalf("%d bands, want %d", len(bands), len(want))
+	}
+	for i := range want {
+		if bands[i] != want[i] {
+			t.Errorf("band %d = %+v, want %+v", i, bands[i], want[i])
+		}
+	}
+}
+
+func TestBacktestCalibration(t *testing.T) {
+	profile := &models.ScoringProfile{ScoreMin: 300, ScoreMax: 850}
+
+	if buckets := backtestCalibration(nil, profile, 10); len(buckets) != 0 {
+		t.Errorf("buckets without loans = %+v, want none", buckets)
+	}
+
+	observations := append(defaulted(300, 575), repaid(850, 575)...)
+	buckets := backtestCalibration(observations, profile, 2)
+
+	want := []BacktestCalibrationBucket{
+		{Bucket: 1, Loans: 2, MeanScore: 437.5, PredictedDefaultPct: 0.75, ObservedDefaultPct: 1},
+		{Bucket: 2, Loans: 2, MeanScore: 712.5, PredictedDefaultPct: 0.25},
+	}
+	if len(buckets) != len(want) {
+		t.Fatalf("%d buckets, want %d", len(buckets), len(want))
+	}
+	for i := range want {
+		if buckets[i] != want[i] {
+			t.Errorf("bucket %d = %+v, want %+v", i, buckets[i], want[i])
+		}
+	}
+
+	// Fewer loans than buckets gives one bucket per loan.
+	if buckets := backtestCalibration(repaid(500, 600, 700), profile, 10); len(buckets) != 3 {
+		t.Errorf("%d buckets for three loans, want 3", len(buckets))
+	}
+}
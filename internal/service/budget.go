@@ -0,0 +1,513 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"lumon-backend/internal/categories"
+	"lumon-backend/internal/domain/models"
+	"lumon-backend/internal/domain/schemas"
+	"lumon-backend/internal/repository/interfaces"
+	"lumon-backend/pkg/common/logger"
+
+	"github.com/google/uuid"
+)
+
+var (
+	ErrBudgetNotFound = errors.New("budget not found")
+	ErrInvalidBudget  = errors.New("invalid budget")
+	ErrBudgetExists   = errors.New("a budget already exists for this category")
+)
+
+const (
+	NotificationKindBudgetAlert = "budget_alert"
+	NotificationSubjectBudget   = "budget"
+)
+
+const (
+	budgetMonthLayout  = "2006-01"
+	budgetCurrency     = "GHS"
+	maxBudgetThreshold = 500
+	maxBudgetAlerts    = 5
+)
+
+// DefaultBudgetThresholds are the percentages of a budget at which the user
+// is alerted when none are configured.
+var DefaultBudgetThresholds = []int{80, 100}
+
+// BudgetService keeps users' monthly budgets per category, works out their
+// spending against them from their transactions and alerts them as it reaches
+// each threshold.
+type BudgetService struct {
+	repo          interfaces.BudgetRepository
+	analyticsRepo interfaces.TransactionAnalyticsRepository
+	notifications *NotificationService
+	thresholds    []int
+
+	// mu serialises evaluation, so that two imports for the same user
+	// finishing together cannot send the same alert twice.
+	mu sync.Mutex
+}
+
+// NewBudgetService creates the service. thresholds are the alert thresholds
+// given to budgets created without any.
+func NewBudgetService(
+	repo interfaces.BudgetRepository,
+	analyticsRepo interfaces.TransactionAnalyticsRepository,
+	notifications *NotificationService,
+	thresholds []int,
+) *BudgetService {
+	checked, err := checkBudgetThresholds(thresholds)
+	if err != nil || len(checked) == 0 {
+		if err != nil {
+			logger.APILogger.Warnw("Ignoring configured budget thresholds", "error", err)
+		}
+		checked = DefaultBudgetThresholds
+	}
+
+	return &BudgetService{
+		repo:          repo,
+		analyticsRepo: analyticsRepo,
+		notifications: notifications,
+		thresholds:    checked,
+	}
+}
+
+func (s *BudgetService) CreateBudget(userID string, req schemas.CreateBudgetRequest) (*schemas.BudgetResponse, error) {
+	category := strings.ToLower(strings.TrimSpace(req.Category))
+	if !categories.Valid(category) {
+		return nil, fmt.Errorf("%w: category must be one of %s", ErrInvalidBudget, strings.Join(categories.All, ", "))
+	}
+
+	thresholds, err := checkBudgetThresholds(req.Thresholds)
+	if err != nil {
+		return nil, err
+	}
+	if len(thresholds) == 0 {
+		thresholds = append([]int(nil), s.thresholds...)
+	}
+
+	budgets, err := s.repo.ListByUser(uuid.MustParse(userID))
+	if err != nil {
+		logger.APILogger.Error(err)
+		return nil, err
+	}
+	for _, budget := range budgets {
+		if budget.Category == category {
+			return nil, ErrBudgetExists
+		}
+	}
+
+	budget := &models.Budget{
+		Category:   category,
+		Amount:     roundAmount(req.Amount),
+		Thresholds: thresholds,
+		UserID:     uuid.MustParse(userID),
+	}
+	if err := s.repo.Create(budget); err != nil {
+		logger.APILogger.Error(err)
+		return nil, err
+	}
+
+	return s.changed(budget)
+}
+
+func (s *BudgetService) UpdateBudget(
+	userID, id string, req schemas.UpdateBudgetRequest,
+) (*schemas.BudgetResponse, error) {
+	budget, err := s.budget(userID, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Amount != nil {
+		budget.Amount = roundAmount(*req.Amount)
+	}
+	if req.Thresholds != nil {
+		thresholds, err := checkBudgetThresholds(req.Thresholds)
+		if err != nil {
+			return nil, err
+		}
+		if len(thresholds) == 0 {
+			return nil, fmt.Errorf("%w: a budget needs at least one threshold", ErrInvalidBudget)
+		}
+		budget.Thresholds = thresholds
+	}
+
+	if err := s.repo.Update(budget); err != nil {
+		logger.APILogger.Error(err)
+		return nil, err
+	}
+
+	return s.changed(budget)
+}
+
+func (s *BudgetService) DeleteBudget(userID, id string) error {
+	budget, err := s.budget(userID, id)
+	if err != nil {
+		return err
+	}
+
+	if err := s.repo.Delete(budget.ID); err != nil {
+		logger.APILogger.Error(err)
+		return err
+	}
+
+	return nil
+}
+
+// ListBudgets returns the user's budgets with their progress in a month.
+func (s *BudgetService) ListBudgets(
+	userID string, req schemas.BudgetProgressRequest,
+) ([]schemas.BudgetResponse, error) {
+	month, err := parseBudgetMonth(req.Month)
+	if err != nil {
+		return nil, err
+	}
+
+	budgets, err := s.repo.ListByUser(uuid.MustParse(userID))
+	if err != nil {
+		logger.APILogger.Error(err)
+		return nil, err
+	}
+
+	return s.progress(uuid.MustParse(userID), month, budgets)
+}
+
+// GetBudget returns one of the user's budgets with its progress in a month.
+func (s *BudgetService) GetBudget(
+	userID, id string, req schemas.BudgetProgressRequest,
+) (*schemas.BudgetResponse, error) {
+	month, err := parseBudgetMonth(req.Month)
+	if err != nil {
+		return nil, err
+	}
+
+	budget, err := s.budget(userID, id)
+	if err != nil {
+		return nil, err
+	}
+
+	responses, err := s.progress(budget.UserID, month, []models.Budget{*budget})
+	if err != nil {
+		return nil, err
+	}
+
+	return &responses[0], nil
+}
+
+// Evaluate works out the user's spending in each of the months, this month
+// when none are given, against each of their budgets and alerts them about
+// every threshold newly reached. Months before a budget was created are not
+// held against it, so importing older statements does not alert about them.
+// When a budget reaches several thresholds in a month at once, only the
+// highest is notified. The alerts and their
+// notifications are stored together, so an alert is never recorded without
+// its notification.
+func (s *BudgetService) Evaluate(userID uuid.UUID, months ...time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	budgets, err := s.repo.ListByUser(userID)
+	if err != nil {
+		logger.APILogger.Error(err)
+		return err
+	}
+	if len(budgets) == 0 {
+		return nil
+	}
+
+	if len(months) == 0 {
+		months = []time.Time{budgetMonth(time.Now())}
+	}
+
+	earliest := budgetMonth(budgets[0].CreatedAt)
+	for _, budget := range budgets[1:] {
+		if created := budgetMonth(budget.CreatedAt); created.Before(earliest) {
+			earliest = created
+		}
+	}
+
+	var reached []*models.BudgetAlert
+	seen := make(map[time.Time]bool)
+	for _, month := range months {
+		month = budgetMonth(month)
+		if seen[month] || month.Before(earliest) {
+			continue
+		}
+		seen[month] = true
+
+		spending, err := s.spending(userID, month)
+		if err != nil {
+			return err
+		}
+
+		for _, budget := range budgets {
+			if month.Before(budgetMonth(budget.CreatedAt)) {
+				continue
+			}
+			spent := spending[budget.Category]
+			for _, threshold := range budget.Thresholds {
+				if thresholdReached(spent, budget.Amount, threshold) {
+					reached = append(reached, &models.BudgetAlert{
+						Month:     month,
+						Threshold: threshold,
+						Spent:     spent,
+						Amount:    budget.Amount,
+						BudgetID:  budget.ID,
+						UserID:    userID,
+					})
+				}
+			}
+		}
+	}
+	if len(reached) == 0 {
+		return nil
+	}
+
+	notifications, err := s.repo.CreateAlerts(reached, func(created []*models.BudgetAlert) []*models.Notification {
+		return newBudgetNotifications(budgets, created)
+	})
+	if err != nil {
+		logger.APILogger.Error(err)
+		return err
+	}
+
+	logNotifications(notifications)
+	return nil
+}
+
+// newBudgetNotifications gives a notification for the highest alert created
+// for each budget and month.
+func newBudgetNotifications(budgets []models.Budget, created []*models.BudgetAlert) []*models.Notification {
+	type budgetMonthKey struct {
+		budgetID uuid.UUID
+		month    time.Time
+	}
+
+	highest := make(map[budgetMonthKey]*models.BudgetAlert)
+	var keys []budgetMonthKey
+	for _, alert := range created {
+		key := budgetMonthKey{budgetID: alert.BudgetID, month: alert.Month}
+		current, ok := highest[key]
+		if !ok {
+			keys = append(keys, key)
+		}
+		if !ok || alert.Threshold > current.Threshold {
+			highest[key] = alert
+		}
+	}
+
+	byID := make(map[uuid.UUID]models.Budget, len(budgets))
+	for _, budget := range budgets {
+		byID[budget.ID] = budget
+	}
+
+	var notifications []*models.Notification
+	for _, key := range keys {
+		notifications = append(notifications, newBudgetNotification(byID[key.budgetID], highest[key]))
+	}
+	return notifications
+}
+
+// changed evaluates the alerts of a created or updated budget and returns its
+// progress this month. A failed evaluation is retried with the next import,
+// so it is logged rather than failing the change.
+func (s *BudgetService) changed(budget *models.Budget) (*schemas.BudgetResponse, error) {
+	if err := s.Evaluate(budget.UserID); err != nil {
+		logger.APILogger.Errorf("Failed to evaluate budgets: %v", err)
+	}
+
+	responses, err := s.progress(budget.UserID, budgetMonth(time.Now()), []models.Budget{*budget})
+	if err != nil {
+		return nil, err
+	}
+
+	return &responses[0], nil
+}
+
+func (s *BudgetService) budget(userID, id string) (*models.Budget, error) {
+	budget, err := s.repo.GetByID(uuid.MustParse(id))
+	if err != nil {
+		logger.APILogger.Error(err)
+		return nil, ErrBudgetNotFound
+	}
+
+	if budget.UserID != uuid.MustParse(userID) {
+		return nil, ErrBudgetNotFound
+	}
+
+	return budget, nil
+}
+
+func (s *BudgetService) progress(
+	userID uuid.UUID, month time.Time, budgets []models.Budget,
+) ([]schemas.BudgetResponse, error) {
+	responses := []schemas.BudgetResponse{}
+	if len(budgets) == 0 {
+		return responses, nil
+	}
+
+	spending, err := s.spending(userID, month)
+	if err != nil {
+		return nil, err
+	}
+
+	alerts, err := s.repo.ListAlerts(userID, month)
+	if err != nil {
+		logger.APILogger.Error(err)
+		return nil, err
+	}
+	alertsByBudget := make(map[uuid.UUID][]schemas.BudgetAlertResponse)
+	for _, alert := range alerts {
+		alertsByBudget[alert.BudgetID] = append(alertsByBudget[alert.BudgetID], schemas.BudgetAlertResponse{
+			Threshold: alert.Threshold,
+			Spent:     alert.Spent,
+			Amount:    alert.Amount,
+			CreatedAt: alert.CreatedAt,
+		})
+	}
+
+	for _, budget := range budgets {
+		spent := spending[budget.Category]
+		response := schemas.BudgetResponse{
+			ID:         budget.ID.String(),
+			Category:   budget.Category,
+			Amount:     budget.Amount,
+			Thresholds: budget.Thresholds,
+			Month:      month.Format(budgetMonthLayout),
+			Spent:      spent,
+			Remaining:  math.Max(roundAmount(budget.Amount-spent), 0),
+			Status:     schemas.BudgetStatusOnTrack,
+			Alerts:     alertsByBudget[budget.ID],
+			CreatedAt:  budget.CreatedAt,
+			UpdatedAt:  budget.UpdatedAt,
+		}
+		if response.Alerts == nil {
+			response.Alerts = []schemas.BudgetAlertResponse{}
+		}
+		if budget.Amount > 0 {
+			response.PercentUsed = math.Round(spent/budget.Amount*1000) / 10
+		}
+
+		switch {
+		case spent > budget.Amount:
+			response.Status = schemas.BudgetStatusExceeded
+		case len(budget.Thresholds) > 0 && thresholdReached(spent, budget.Amount, budget.Thresholds[0]):
+			response.Status = schemas.BudgetStatusAtRisk
+		}
+
+		responses = append(responses, response)
+	}
+
+	return responses, nil
+}
+
+// spending returns what the user paid out in each category during a month.
+func (s *BudgetService) spending(userID uuid.UUID, month time.Time) (map[string]float64, error) {
+	totals, err := s.analyticsRepo.CategoryBreakdown(interfaces.AnalyticsRange{
+		UserID: userID,
+		Period: interfaces.AnalyticsPeriodMonth,
+		From:   month,
+		To:     month.AddDate(0, 1, 0),
+	})
+	if err != nil {
+		logger.APILogger.Error(err)
+		return nil, err
+	}
+
+	spending := make(map[string]float64)
+	for _, total := range totals {
+		spending[total.Category] = roundAmount(spending[total.Category] + total.Outflow)
+	}
+
+	return spending, nil
+}
+
+// checkBudgetThresholds sorts the thresholds and drops repeats. No thresholds
+// are returned as none, for the caller to default.
+func checkBudgetThresholds(thresholds []int) ([]int, error) {
+	seen := make(map[int]bool)
+	var checked []int
+	for _, threshold := range thresholds {
+		if threshold < 1 || threshold > maxBudgetThreshold {
+			return nil, fmt.Errorf("%w: thresholds must be between 1 and %d percent", ErrInvalidBudget, maxBudgetThreshold)
+		}
+		if !seen[threshold] {
+			seen[threshold] = true
+			checked = append(checked, threshold)
+		}
+	}
+
+	if len(checked) > maxBudgetAlerts {
+		return nil, fmt.Errorf("%w: a budget can have at most %d thresholds", ErrInvalidBudget, maxBudgetAlerts)
+	}
+
+	sort.Ints(checked)
+	return checked, nil
+}
+
+func parseBudgetMonth(value string) (time.Time, error) {
+	if value == "" {
+		return budgetMonth(time.Now()), nil
+	}
+
+	month, err := time.Parse(budgetMonthLayout, value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%w: month must be formatted as YYYY-MM", ErrInvalidBudget)
+	}
+
+	return month, nil
+}
+
+// budgetMonths returns the months the transactions fall in.
+func budgetMonths(transactions []*models.Transaction) []time.Time {
+	seen := make(map[time.Time]bool)
+	var months []time.Time
+	for _, transaction := range transactions {
+		month := budgetMonth(transaction.TransactionDate)
+		if !seen[month] {
+			seen[month] = true
+			months = append(months, month)
+		}
+	}
+	return months
+}
+
+// budgetMonth is the first day of the month of t. Transaction dates are
+// stored as printed on statements, in Ghana time, which is UTC.
+func budgetMonth(t time.Time) time.Time {
+	t = t.UTC()
+	return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+}
+
+func thresholdReached(spent, amount float64, threshold int) bool {
+	return amount > 0 && spent >= roundAmount(amount*float64(threshold)/100)
+}
+
+func newBudgetNotification(budget models.Budget, alert *models.BudgetAlert) *models.Notification {
+	label := strings.ReplaceAll(budget.Category, "_", " ")
+
+	body := fmt.Sprintf(
+		"You have spent %s %.2f of your %s %.2f %s budget for %s.",
+		budgetCurrency, alert.Spent, budgetCurrency, alert.Amount, label, alert.Month.Format("January 2006"),
+	)
+	if alert.Spent > alert.Amount {
+		body += fmt.Sprintf(" That is %s %.2f over budget.", budgetCurrency, roundAmount(alert.Spent-alert.Amount))
+	}
+
+	budgetID := budget.ID
+	return &models.Notification{
+		Kind:        NotificationKindBudgetAlert,
+		Title:       fmt.Sprintf("You've reached %d%% of your %s budget", alert.Threshold, label),
+		Body:        body,
+		SubjectType: NotificationSubjectBudget,
+		SubjectID:   &budgetID,
+		UserID:      budget.UserID,
+	}
+}
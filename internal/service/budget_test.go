@@ -0,0 +1,137 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"lumon-backend/internal/categories"
+	"lumon-backend/internal/domain/models"
+	"lumon-backend/internal/repository/interfaces"
+
+	"github.com/google/uuid"
+)
+
+// fakeBudgetRepository keeps budgets and their alerts in memory, skipping
+// alerts a budget already has for the month and threshold as the database
+// does.
+type fakeBudgetRepository struct {
+	interfaces.BudgetRepository
+	budgets       []models.Budget
+	alerts        []*models.BudgetAlert
+	notifications []*models.Notification
+}
+
+func (r *fakeBudgetRepository) ListByUser(uuid.UUID) ([]models.Budget, error) {
+	return r.budgets, nil
+}
+
+func (r *fakeBudgetRepository) CreateAlerts(
+	alerts []*models.BudgetAlert, notify func(created []*models.BudgetAlert) []*models.Notification,
+) ([]*models.Notification, error) {
+	var created []*models.BudgetAlert
+	for _, alert := range alerts {
+		duplicate := false
+		for _, stored := range r.alerts {
+			if stored.BudgetID == alert.BudgetID && stored.Month.Equal(alert.Month) && stored.Threshold == alert.Threshold {
+				duplicate = true
+			}
+		}
+		if !duplicate {
+			r.alerts = append(r.alerts, alert)
+			created = append(created, alert)
+		}
+	}
+
+	notifications := notify(created)
+	r.notifications = append(r.notifications, notifications...)
+	return notifications, nil
+}
+
+// fakeSpendingRepository returns the spending of each month by category.
+type fakeSpendingRepository struct {
+	interfaces.TransactionAnalyticsRepository
+	spending map[time.Time]map[string]float64
+}
+
+func (r *fakeSpendingRepository) CategoryBreakdown(rng interfaces.AnalyticsRange) ([]models.CategoryTotal, error) {
+	var totals []models.CategoryTotal
+	for category, spent := range r.spending[rng.From] {
+		totals = append(totals, models.CategoryTotal{Period: rng.From, Category: category, Outflow: spent})
+	}
+	return totals, nil
+}
+
+func TestBudgetEvaluate(t *testing.T) {
+	userID := uuid.New()
+	jan := time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC)
+	feb, mar := jan.AddDate(0, 1, 0), jan.AddDate(0, 2, 0)
+
+	newService := func(spending map[time.Time]map[string]float64) (*BudgetService, *fakeBudgetRepository) {
+		repo := &fakeBudgetRepository{budgets: []models.Budget{{
+			ID:         uuid.New(),
+			Category:   categories.Groceries,
+			Amount:     500,
+			Thresholds: []int{80, 100},
+			CreatedAt:  feb.AddDate(0, 0, 14),
+			UserID:     userID,
+		}}}
+		return NewBudgetService(repo, &fakeSpendingRepository{spending: spending}, nil, nil), repo
+	}
+
+	t.Run("months before the budget was created", func(t *testing.T) {
+		service, repo := newService(map[time.Time]map[string]float64{
+			jan: {categories.Groceries: 900},
+			feb: {categories.Groceries: 450},
+			mar: {categories.Groceries: 100},
+		})
+
+		if err := service.Evaluate(userID, jan, feb, mar); err != nil {
+			t.Fatal(err)
+		}
+		if len(repo.alerts) != 1 || !repo.alerts[0].Month.Equal(feb) || repo.alerts[0].Threshold != 80 {
+			t.Fatalf("alerts = %+v, want the 80%% alert for February only", repo.alerts)
+		}
+	})
+
+	t.Run("one alert per threshold", func(t *testing.T) {
+		spending := map[time.Time]map[string]float64{mar: {categories.Groceries: 420}}
+		service, repo := newService(spending)
+
+		if err := service.Evaluate(userID, mar); err != nil {
+			t.Fatal(err)
+		}
+		if err := service.Evaluate(userID, mar); err != nil {
+			t.Fatal(err)
+		}
+		if len(repo.alerts) != 1 || len(repo.notifications) != 1 {
+			t.Fatalf("%d alerts and %d notifications after evaluating twice, want 1 and 1",
+				len(repo.alerts), len(repo.notifications))
+		}
+
+		// Going over the budget alerts at 100% only, once.
+		spending[mar][categories.Groceries] = 510
+		if err := service.Evaluate(userID, mar, mar); err != nil {
+			t.Fatal(err)
+		}
+		if err := service.Evaluate(userID, mar); err != nil {
+			t.Fatal(err)
+		}
+		if len(repo.alerts) != 2 || repo.alerts[1].Threshold != 100 {
+			t.Fatalf("alerts = %+v, want the 80%% and 100%% alerts", repo.alerts)
+		}
+		if len(repo.notifications) != 2 {
+			t.Fatalf("%d notifications, want 2", len(repo.notifications))
+		}
+	})
+
+	t.Run("thresholds reached at once notify the highest", func(t *testing.T) {
+		service, repo := newService(map[time.Time]map[string]float64{mar: {categories.Groceries: 600}})
+
+		if err := service.Evaluate(userID, mar); err != nil {
+			t.Fatal(err)
+		}
+		if len(repo.alerts) != 2 || len(repo.notifications) != 1 {
+			t.Fatalf("%d alerts and %d notifications, want 2 and 1", len(repo.alerts), len(repo.notifications))
+		}
+	})
+}
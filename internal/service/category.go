@@ -0,0 +1,265 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"lumon-backend/internal/categories"
+	"lumon-backend/internal/domain/models"
+	"lumon-backend/internal/domain/schemas"
+	"lumon-backend/internal/ml"
+	"lumon-backend/internal/repository/interfaces"
+	"lumon-backend/pkg/common/logger"
+
+	"github.com/google/uuid"
+)
+
+var (
+	ErrInvalidCategory      = errors.New("category is not valid")
+	ErrCategoryRuleNotFound = errors.New("category rule not found")
+)
+
+// categoryLLMBatchSize bounds how many transactions are sent to the LLM in
+// one request.
+const categoryLLMBatchSize = 100
+
+type CategoryServiceConfig struct {
+	// LLMFallback asks the LLM for the category of transactions no rule
+	// matches before falling back to the transaction type.
+	LLMFallback bool
+}
+
+// CategoryService assigns spending categories to transactions and learns from
+// the corrections users make.
+type CategoryService struct {
+	ruleRepo        interfaces.CategoryRuleRepository
+	transactionRepo interfaces.TransactionRepository
+	categorizer     *categories.Categorizer
+	analytics       *AnalyticsService
+	cfg             CategoryServiceConfig
+}
+
+func NewCategoryService(
+	ruleRepo interfaces.CategoryRuleRepository,
+	transactionRepo interfaces.TransactionRepository,
+	analytics *AnalyticsService,
+	cfg CategoryServiceConfig,
+) *CategoryService {
+	return &CategoryService{
+		ruleRepo:        ruleRepo,
+		transactionRepo: transactionRepo,
+		categorizer:     categories.NewCategorizer(),
+		analytics:       analytics,
+		cfg:             cfg,
+	}
+}
+
+// Categorize assigns a category to each of the user's new transactions in
+// place. The user's rules are tried first, then the built-in ones, then the
+// LLM when it is enabled, and whatever is left is categorised by type.
+func (s *CategoryService) Categorize(ctx context.Context, userID uuid.UUID, transactions []*models.Transaction) error {
+	userRules, err := s.userRules(userID)
+	if err != nil {
+		logger.APILogger.Error(err)
+		return err
+	}
+
+	var unmatched []*models.Transaction
+	for _, transaction := range transactions {
+		if category, ok := s.categorizer.Match(transaction, userRules); ok {
+			transaction.Category = category
+			transaction.CategorySource = categories.SourceRule
+			continue
+		}
+		unmatched = append(unmatched, transaction)
+	}
+
+	if s.cfg.LLMFallback {
+		s.categorizeWithLLM(ctx, unmatched)
+	}
+
+	for _, transaction := range unmatched {
+		if transaction.Category == "" {
+			transaction.Category = categories.Fallback(transaction)
+			transaction.CategorySource = categories.SourceFallback
+		}
+	}
+
+	return nil
+}
+
+// categorizeWithLLM fills in the categories the LLM is sure of. A failed
+// request only leaves its transactions to the fallback.
+func (s *CategoryService) categorizeWithLLM(ctx context.Context, transactions []*models.Transaction) {
+	for start := 0; start < len(transactions); start += categoryLLMBatchSize {
+		batch := transactions[start:min(start+categoryLLMBatchSize, len(transactions))]
+
+		lines := make([]string, len(batch))
+		for i, transaction := range batch {
+			lines[i] = describeTransaction(transaction)
+		}
+
+		answers, err := ml.GetTransactionCategories(ctx, lines, categories.All)
+		if err != nil {
+			logger.APILogger.Warnw("LLM categorisation failed", "transactions", len(batch), "error", err)
+			continue
+		}
+
+		for i, category := range answers {
+			if categories.Valid(category) {
+				batch[i].Category = category
+				batch[i].CategorySource = categories.SourceLLM
+			}
+		}
+	}
+}
+
+func describeTransaction(transaction *models.Transaction) string {
+	direction := "paid"
+	if categories.IsIncoming(transaction) {
+		direction = "received"
+	}
+
+	name, number := categories.Counterparty(transaction)
+	return fmt.Sprintf("%s %.2f, type %s, counterparty %q %s, reference %q, description %q",
+		direction, transaction.Amount, transaction.TransactionType,
+		name, number, transaction.Reference, transaction.Description)
+}
+
+// CorrectCategory moves one of the user's transactions into category and
+// remembers the choice for the counterparty, recategorising the user's other
+// transactions with it that the user has not corrected themselves.
+func (s *CategoryService) CorrectCategory(
+	userID, transactionID, category string,
+) (*schemas.CategoryCorrectionResponse, error) {
+	category = strings.ToLower(strings.TrimSpace(category))
+	if !categories.Valid(category) {
+		return nil, ErrInvalidCategory
+	}
+
+	transaction, err := s.transactionRepo.GetByID(uuid.MustParse(transactionID))
+	if err != nil {
+		logger.APILogger.Error(err)
+		return nil, ErrTransactionNotFound
+	}
+	if transaction.UserID != uuid.MustParse(userID) {
+		return nil, ErrTransactionNotFound
+	}
+
+	if err := s.transactionRepo.UpdateCategory([]uuid.UUID{transaction.ID}, category, categories.SourceUser); err != nil {
+		logger.APILogger.Error(err)
+		return nil, err
+	}
+	s.analytics.Invalidate(transaction.UserID)
+	transaction.Category = category
+	transaction.CategorySource = categories.SourceUser
+
+	result := &schemas.CategoryCorrectionResponse{Transaction: NewTransactionResponse(*transaction)}
+
+	rule, ok := categories.CounterpartyRule(transaction, category)
+	if !ok {
+		return result, nil
+	}
+
+	model := &models.CategoryRule{
+		Match:    rule.Match,
+		Pattern:  rule.Pattern,
+		Category: rule.Category,
+		UserID:   transaction.UserID,
+	}
+	if err := s.ruleRepo.Save(model); err != nil {
+		logger.APILogger.Error(err)
+		return nil, err
+	}
+	response := newCategoryRuleResponse(*model)
+	result.Rule = &response
+
+	others, err := s.transactionRepo.ListAll(transaction.UserID)
+	if err != nil {
+		logger.APILogger.Error(err)
+		return nil, err
+	}
+
+	var ids []uuid.UUID
+	for i := range others {
+		other := &others[i]
+		if other.ID != transaction.ID && other.CategorySource != categories.SourceUser &&
+			other.Category != category && rule.Matches(other) {
+			ids = append(ids, other.ID)
+		}
+	}
+
+	if err := s.transactionRepo.UpdateCategory(ids, category, categories.SourceRule); err != nil {
+		logger.APILogger.Error(err)
+		return nil, err
+	}
+	result.Recategorized = len(ids)
+	s.analytics.Invalidate(transaction.UserID)
+
+	return result, nil
+}
+
+// ListCategories returns the categories transactions can be placed in and
+// the rules the user has taught.
+func (s *CategoryService) ListCategories(userID string) (*schemas.CategoriesResponse, error) {
+	rules, err := s.ruleRepo.ListByUser(uuid.MustParse(userID))
+	if err != nil {
+		logger.APILogger.Error(err)
+		return nil, err
+	}
+
+	response := &schemas.CategoriesResponse{
+		Categories: categories.All,
+		Rules:      make([]schemas.CategoryRuleResponse, len(rules)),
+	}
+	for i, rule := range rules {
+		response.Rules[i] = newCategoryRuleResponse(rule)
+	}
+
+	return response, nil
+}
+
+// DeleteRule forgets one of the user's rules. Transactions it categorised
+// keep their category.
+func (s *CategoryService) DeleteRule(userID, ruleID string) error {
+	rule, err := s.ruleRepo.GetByID(uuid.MustParse(ruleID))
+	if err != nil {
+		logger.APILogger.Error(err)
+		return ErrCategoryRuleNotFound
+	}
+	if rule.UserID != uuid.MustParse(userID) {
+		return ErrCategoryRuleNotFound
+	}
+
+	if err := s.ruleRepo.Delete(rule.ID); err != nil {
+		logger.APILogger.Error(err)
+		return err
+	}
+
+	return nil
+}
+
+func (s *CategoryService) userRules(userID uuid.UUID) ([]categories.Rule, error) {
+	saved, err := s.ruleRepo.ListByUser(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	rules := make([]categories.Rule, len(saved))
+	for i, rule := range saved {
+		rules[i] = categories.Rule{Match: rule.Match, Pattern: rule.Pattern, Category: rule.Category}
+	}
+	return rules, nil
+}
+
+func newCategoryRuleResponse(rule models.CategoryRule) schemas.CategoryRuleResponse {
+	return schemas.CategoryRuleResponse{
+		ID:        rule.ID.String(),
+		Match:     rule.Match,
+		Pattern:   rule.Pattern,
+		Category:  rule.Category,
+		UpdatedAt: rule.UpdatedAt,
+	}
+}
@@ -0,0 +1,175 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sync"
+
+	"lumon-backend/internal/counterparties"
+	"lumon-backend/internal/domain/models"
+	"lumon-backend/internal/domain/schemas"
+	"lumon-backend/internal/repository/interfaces"
+	"lumon-backend/pkg/common/logger"
+
+	"github.com/google/uuid"
+)
+
+var (
+	ErrCounterpartyNotFound     = errors.New("counterparty not found")
+	ErrInvalidCounterpartyQuery = errors.New("invalid counterparty query")
+)
+
+const (
+	defaultCounterpartyPageSize = 20
+	maxCounterpartyPageSize     = 100
+)
+
+// CounterpartyService keeps each user's directory of counterparties and
+// links their transactions to it.
+type CounterpartyService struct {
+	repo            interfaces.CounterpartyRepository
+	transactionRepo interfaces.TransactionRepository
+	countryCode     string
+	// mu serialises linking, so that two imports for the same user cannot
+	// both add the same counterparty.
+	mu sync.Mutex
+}
+
+func NewCounterpartyService(
+	repo interfaces.CounterpartyRepository,
+	transactionRepo interfaces.TransactionRepository,
+	countryCode string,
+) *CounterpartyService {
+	return &CounterpartyService{
+		repo:            repo,
+		transactionRepo: transactionRepo,
+		countryCode:     countryCode,
+	}
+}
+
+// Link links each of the user's new transactions to its counterparty in
+// place, adding the counterparties the user has not transacted with before.
+func (s *CounterpartyService) Link(userID uuid.UUID, transactions []*models.Transaction) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	_, err := s.link(userID, transactions)
+	return err
+}
+
+// link resolves the transactions and stores the directory's changes, returning
+// the counterparty of each transaction, or nil for those that name none.
+func (s *CounterpartyService) link(
+	userID uuid.UUID, transactions []*models.Transaction,
+) ([]*models.Counterparty, error) {
+	known, err := s.repo.ListByUser(userID)
+	if err != nil {
+		logger.APILogger.Error(err)
+		return nil, err
+	}
+
+	directory := counterparties.NewDirectory(known, s.countryCode)
+	resolved := make([]*models.Counterparty, len(transactions))
+	for i, transaction := range transactions {
+		resolved[i] = directory.Resolve(transaction)
+	}
+
+	if err := s.repo.Create(directory.Created()); err != nil {
+		logger.APILogger.Error(err)
+		return nil, err
+	}
+	for _, counterparty := range directory.Changed() {
+		if err := s.repo.Update(counterparty); err != nil {
+			logger.APILogger.Error(err)
+			return nil, err
+		}
+	}
+
+	for i, transaction := range transactions {
+		if resolved[i] != nil {
+			transaction.CounterpartyID = &resolved[i].ID
+		}
+	}
+
+	return resolved, nil
+}
+
+// ListCounterparties returns a page of the counterparties the user has
+// transactions with, and how many there are.
+func (s *CounterpartyService) ListCounterparties(
+	userID string, req schemas.CounterpartyListRequest,
+) ([]schemas.CounterpartyResponse, int64, error) {
+	query := interfaces.CounterpartyQuery{
+		UserID:   uuid.MustParse(userID),
+		Text:     req.Query,
+		SortBy:   req.Sort,
+		Page:     req.Page,
+		PageSize: req.PageSize,
+	}
+
+	switch query.SortBy {
+	case "":
+		query.SortBy = interfaces.CounterpartySortTotal
+	case interfaces.CounterpartySortTotal, interfaces.CounterpartySortCount, interfaces.CounterpartySortLastSeen,
+		interfaces.CounterpartySortFirstSeen, interfaces.CounterpartySortName:
+	default:
+		return nil, 0, fmt.Errorf(
+			"%w: sort must be total, count, last_seen, first_seen or name", ErrInvalidCounterpartyQuery,
+		)
+	}
+
+	if query.Page < 1 {
+		query.Page = 1
+	}
+	switch {
+	case query.PageSize <= 0:
+		query.PageSize = defaultCounterpartyPageSize
+	case query.PageSize > maxCounterpartyPageSize:
+		query.PageSize = maxCounterpartyPageSize
+	}
+
+	summaries, total, err := s.repo.Summaries(query)
+	if err != nil {
+		logger.APILogger.Error(err)
+		return nil, 0, err
+	}
+
+	responses := make([]schemas.CounterpartyResponse, len(summaries))
+	for i, summary := range summaries {
+		responses[i] = newCounterpartyResponse(summary)
+	}
+
+	return responses, total, nil
+}
+
+// GetCounterparty returns what the user exchanged with one of their
+// counterparties.
+func (s *CounterpartyService) GetCounterparty(userID, id string) (*schemas.CounterpartyResponse, error) {
+	summary, err := s.repo.Summary(uuid.MustParse(userID), uuid.MustParse(id))
+	if err != nil {
+		logger.APILogger.Error(err)
+		return nil, ErrCounterpartyNotFound
+	}
+
+	response := newCounterpartyResponse(*summary)
+	return &response, nil
+}
+
+func newCounterpartyResponse(summary models.CounterpartySummary) schemas.CounterpartyResponse {
+	months := math.Max(summary.LastSeen.Sub(summary.FirstSeen).Hours()/24/30.44, 1)
+
+	return schemas.CounterpartyResponse{
+		ID:               summary.ID.String(),
+		Name:             summary.Name,
+		PhoneNumber:      summary.PhoneNumber,
+		Identifier:       summary.Identifier,
+		Inflow:           roundAmount(summary.Inflow),
+		Outflow:          roundAmount(summary.Outflow),
+		Total:            roundAmount(summary.Inflow + summary.Outflow),
+		TransactionCount: summary.TransactionCount,
+		MonthlyFrequency: math.Round(float64(summary.TransactionCount)/months*100) / 100,
+		FirstSeen:        summary.FirstSeen,
+		LastSeen:         summary.LastSeen,
+	}
+}
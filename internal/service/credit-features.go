@@ -0,0 +1,169 @@
+package service
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"lumon-backend/internal/categories"
+	"lumon-backend/internal/domain/models"
+	"lumon-backend/internal/statements"
+)
+
+// YearMonth identifies a calendar month. Grouping by time.Month alone merges
+// the same month of different years, so every monthly aggregate is keyed by
+// YearMonth instead.
+type YearMonth struct {
+	Year  int
+	Month time.Month
+}
+
+func YearMonthOf(t time.Time) YearMonth {
+	return YearMonth{Year: t.Year(), Month: t.Month()}
+}
+
+func (ym YearMonth) String() string {
+	return fmt.Sprintf("%04d-%02d", ym.Year, int(ym.Month))
+}
+
+func (ym YearMonth) Start() time.Time {
+	return time.Date(ym.Year, ym.Month, 1, 0, 0, 0, 0, time.UTC)
+}
+
+func (ym YearMonth) End() time.Time {
+	return ym.AddMonths(1).Start().Add(-time.Nanosecond)
+}
+
+func (ym YearMonth) AddMonths(n int) YearMonth {
+	return YearMonthOf(ym.Start().AddDate(0, n, 0))
+}
+
+func (ym YearMonth) Before(other YearMonth) bool {
+	if ym.Year != other.Year {
+		return ym.Year < other.Year
+	}
+	return ym.Month < other.Month
+}
+
+type MonthlyActivity struct {
+	Period   YearMonth `json:"-"`
+	Month    string    `json:"month"`
+	Income   float64   `json:"income"`
+	Expenses float64   `json:"expenses"`
+	Fees     float64   `json:"fees"`
+	// Savings is the money put aside, which is not counted in the expenses.
+	Savings          float64 `json:"savings"`
+	TransactionCount int     `json:"transaction_count"`
+	// Spending breaks the expenses down by category. Transactions stored
+	// before categorisation are left out.
+	Spending map[string]float64 `json:"spending,omitempty"`
+}
+
+// MonthlySeries is a contiguous run of months, oldest first. Months without any
+// transactions are present with zero totals so that gaps count against
+// stability instead of silently disappearing.
+type MonthlySeries []MonthlyActivity
+
+// BuildMonthlySeries aggregates transactions into every month from start to
+// end inclusive. Transactions outside the range are ignored.
+func BuildMonthlySeries(transactions []models.Transaction, start, end YearMonth) MonthlySeries {
+	if end.Before(start) {
+		return nil
+	}
+
+	index := make(map[YearMonth]int)
+	var series MonthlySeries
+	for ym := start; !end.Before(ym); ym = ym.AddMonths(1) {
+		index[ym] = len(series)
+		series = append(series, MonthlyActivity{Period: ym, Month: ym.String()})
+	}
+
+	for _, tx := range transactions {
+		i, ok := index[YearMonthOf(tx.TransactionDate)]
+		if !ok {
+			continue
+		}
+
+		series[i].TransactionCount++
+		series[i].Fees += tx.Fees
+		if isIncomeTransaction(tx) {
+			series[i].Income += tx.Amount
+			continue
+		}
+		if tx.Category == categories.Savings {
+			series[i].Savings += tx.Amount
+			series[i].Expenses += tx.Fees
+			continue
+		}
+
+		series[i].Expenses += tx.Amount + tx.Fees
+		if tx.Category != "" {
+			if series[i].Spending == nil {
+				series[i].Spending = make(map[string]float64)
+			}
+			series[i].Spending[tx.Category] += tx.Amount + tx.Fees
+		}
+	}
+
+	return series
+}
+
+func (s MonthlySeries) Income() []float64 {
+	amounts := make([]float64, len(s))
+	for i, month := range s {
+		amounts[i] = month.Income
+	}
+	return amounts
+}
+
+func (s MonthlySeries) TotalIncome() float64 {
+	total := 0.0
+	for _, month := range s {
+		total += month.Income
+	}
+	return total
+}
+
+func (s MonthlySeries) TotalExpenses() float64 {
+	total := 0.0
+	for _, month := range s {
+		total += month.Expenses
+	}
+	return total
+}
+
+// TotalSpending returns the expenses in category over the whole series.
+func (s MonthlySeries) TotalSpending(category string) float64 {
+	total := 0.0
+	for _, month := range s {
+		total += month.Spending[category]
+	}
+	return total
+}
+
+// ActiveMonths counts the months that have at least one transaction.
+func (s MonthlySeries) ActiveMonths() int {
+	active := 0
+	for _, month := range s {
+		if month.TransactionCount > 0 {
+			active++
+		}
+	}
+	return active
+}
+
+// isIncomeTransaction reports whether a transaction counts as income. Loan
+// disbursements are received like income but have to be paid back, so they
+// are left out once they have been categorised.
+func isIncomeTransaction(tx models.Transaction) bool {
+	return tx.TransactionType == statements.TypeCashIn && tx.Category != categories.Loan
+}
+
+func sortTransactionsByDate(transactions []models.Transaction) []models.Transaction {
+	sorted := make([]models.Transaction, len(transactions))
+	copy(sorted, transactions)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].TransactionDate.Before(sorted[j].TransactionDate)
+	})
+	return sorted
+}
@@ -0,0 +1,44 @@
+package service
+
+import "math"
+
+const (
+	ReasonCodeLowPaymentBehavior = "LOW_PAYMENT_BEHAVIOR"
+	ReasonCodeUnstableIncome     = "UNSTABLE_INCOME"
+	ReasonCodeWeakCashFlow       = "WEAK_CASH_FLOW"
+	ReasonCodeNarrowActivity     = "NARROW_TRANSACTION_ACTIVITY"
+	ReasonCodeShortHistory       = "SHORT_CREDIT_HISTORY"
+	ReasonCodeInsufficientData   = "INSUFFICIENT_DATA"
+)
+
+// adverseFeatureThreshold is the feature value below which a feature is
+// reported as a reason holding the score back.
+const adverseFeatureThreshold = 50.0
+
+// ScoreContribution is the number of points a single feature adds to the
+// score, together with the reason code reported when the feature is weak.
+type ScoreContribution struct {
+	ReasonCode string  `json:"reason_code"`
+	Feature    string  `json:"feature"`
+	Value      float64 `json:"value"`
+	Points     float64 `json:"points"`
+	Adverse    bool    `json:"adverse"`
+}
+
+// Contributions breaks a score down per feature, weakest contribution first.
+func (c *CreditScoreCalculator) Contributions(features CreditScoreFeatures) []ScoreContribution {
+	return c.Explain(features).Contributions
+}
+
+// ReasonCodes lists the adverse reason codes for a result, weakest first.
+func (c *CreditScoreCalculator) ReasonCodes(result CreditScoreResult) []string {
+	if !result.Scored() {
+		return []string{ReasonCodeInsufficientData}
+	}
+
+	return c.Explain(result.Features).ReasonCodes()
+}
+
+func roundPoints(points float64) float64 {
+	return math.Round(points*100) / 100
+}
@@ -0,0 +1,390 @@
+package service
+
+import (
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"lumon-backend/internal/domain/models"
+	"lumon-backend/internal/domain/schemas"
+	"lumon-backend/internal/repository/interfaces"
+	"lumon-backend/pkg/common/logger"
+	"lumon-backend/pkg/common/pdf"
+
+	"github.com/google/uuid"
+)
+
+const (
+	CreditReportAlgorithm = "Ed25519"
+	CreditReportValidity  = 30 * 24 * time.Hour
+)
+
+// CreditReportSigner signs reports with the server's Ed25519 key.
+type CreditReportSigner struct {
+	privateKey ed25519.PrivateKey
+}
+
+// NewCreditReportSigner loads the signing key from a base64 encoded 32 byte
+// seed. The key is required, so that reports are never signed with a key
+// that changes with, or can be recovered from, another secret.
+func NewCreditReportSigner(encodedSeed string) (*CreditReportSigner, error) {
+	if encodedSeed == "" {
+		return nil, errors.New("credit report signing key is not set")
+	}
+
+	seed, err := base64.StdEncoding.DecodeString(encodedSeed)
+	if err != nil {
+		return nil, fmt.Errorf("invalid credit report signing key: %w", err)
+	}
+	if len(seed) != ed25519.SeedSize {
+		return nil, fmt.Errorf("credit report signing key must be %d bytes", ed25519.SeedSize)
+	}
+
+	return &CreditReportSigner{privateKey: ed25519.NewKeyFromSeed(seed)}, nil
+}
+
+func (s *CreditReportSigner) PublicKey() string {
+	return base64.StdEncoding.EncodeToString(s.privateKey.Public().(ed25519.PublicKey))
+}
+
+func (s *CreditReportSigner) Sign(message []byte) string {
+	return base64.StdEncoding.EncodeToString(ed25519.Sign(s.privateKey, message))
+}
+
+func (s *CreditReportSigner) Verify(message []byte, signature string) bool {
+	decoded, err := base64.StdEncoding.DecodeString(signature)
+	if err != nil {
+		return false
+	}
+	return ed25519.Verify(s.privateKey.Public().(ed25519.PublicKey), message, decoded)
+}
+
+type CreditReportService struct {
+	userRepo           interfaces.UserRepository
+	transactionRepo    interfaces.TransactionRepository
+	loanRequestRepo    interfaces.LoanRequestRepository
+	reportRepo         interfaces.CreditReportRepository
+	creditScoreService *CreditScoreService
+	signer             *CreditReportSigner
+}
+
+func NewCreditReportService(
+	userRepo interfaces.UserRepository,
+	transactionRepo interfaces.TransactionRepository,
+	loanRequestRepo interfaces.LoanRequestRepository,
+	reportRepo interfaces.CreditReportRepository,
+	creditScoreService *CreditScoreService,
+	signer *CreditReportSigner,
+) *CreditReportService {
+	return &CreditReportService{
+		userRepo:           userRepo,
+		transactionRepo:    transactionRepo,
+		loanRequestRepo:    loanRequestRepo,
+		reportRepo:         reportRepo,
+		creditScoreService: creditScoreService,
+		signer:             signer,
+	}
+}
+
+func (s *CreditReportService) PublicKey() string {
+	return s.signer.PublicKey()
+}
+
+// GenerateCreditReport builds, signs and records a report for the user from
+// their latest score snapshot, transactions and loan history.
+func (s *CreditReportService) GenerateCreditReport(userID string) (*schemas.SignedCreditReport, error) {
+	id := uuid.MustParse(userID)
+
+	dbUser, err := s.userRepo.GetByID(id)
+	if err != nil {
+		logger.APILogger.Error(err)
+		return nil, err
+	}
+
+	snapshot, err := s.creditScoreService.LatestScoredSnapshot(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	transactions, err := s.transactionRepo.ListAll(id)
+	if err != nil {
+		logger.APILogger.Error(err)
+		return nil, err
+	}
+
+	loans, err := s.loanRequestRepo.GetByBorrower(id)
+	if err != nil {
+		logger.APILogger.Error(err)
+		return nil, err
+	}
+
+	issuedAt := time.Now().UTC().Truncate(time.Second)
+	payload := schemas.CreditReportPayload{
+		ReportID:  uuid.New().String(),
+		IssuedAt:  issuedAt,
+		ExpiresAt: issuedAt.Add(CreditReportValidity),
+		Subject: schemas.CreditReportSubject{
+			UserID:      dbUser.ID.String(),
+			Username:    dbUser.Username,
+			Email:       dbUser.Email,
+			PhoneNumber: dbUser.PhoneNumber,
+		},
+		Monthly: creditReportMonths(transactions),
+		Loans:   creditReportLoans(loans),
+	}
+
+	payload.Score, err = s.creditReportScore(snapshot)
+	if err != nil {
+		return nil, err
+	}
+
+	message, err := json.Marshal(payload)
+	if err != nil {
+		return nil, err
+	}
+
+	digest := sha256.Sum256(message)
+	signed := &schemas.SignedCreditReport{
+		Report:    payload,
+		Payload:   base64.StdEncoding.EncodeToString(message),
+		Algorithm: CreditReportAlgorithm,
+		Digest:    hex.EncodeToString(digest[:]),
+		Signature: s.signer.Sign(message),
+		PublicKey: s.signer.PublicKey(),
+	}
+
+	record := &models.CreditReport{
+		ID:        uuid.MustParse(payload.ReportID),
+		Digest:    signed.Digest,
+		Signature: signed.Signature,
+		Score:     payload.Score.Score,
+		IssuedAt:  payload.IssuedAt,
+		ExpiresAt: payload.ExpiresAt,
+		UserID:    id,
+	}
+	if snapshot.ID != uuid.Nil {
+		record.SnapshotID = &snapshot.ID
+	}
+
+	if err := s.reportRepo.Create(record); err != nil {
+		logger.APILogger.Error(err)
+		return nil, err
+	}
+
+	return signed, nil
+}
+
+// VerifyCreditReportByID checks that a report ID was issued by this server and
+// has not expired.
+func (s *CreditReportService) VerifyCreditReportByID(reportID string) (*schemas.CreditReportVerification, error) {
+	id, err := uuid.Parse(reportID)
+	if err != nil {
+		return nil, errors.New("invalid report ID")
+	}
+
+	verification := &schemas.CreditReportVerification{ReportID: reportID}
+
+	record, err := s.reportRepo.GetByID(id)
+	if err != nil {
+		verification.Reason = "report was not issued by this server"
+		return verification, nil
+	}
+
+	verification.Username = record.User.Username
+	verification.Score = record.Score
+	verification.Digest = record.Digest
+	verification.IssuedAt = &record.IssuedAt
+	verification.ExpiresAt = &record.ExpiresAt
+
+	if time.Now().After(record.ExpiresAt) {
+		verification.Reason = "report has expired"
+		return verification, nil
+	}
+
+	verification.Valid = true
+	return verification, nil
+}
+
+// VerifyCreditReport checks the signature of a machine-readable report's
+// payload and that it matches the report the server recorded when issuing it.
+func (s *CreditReportService) VerifyCreditReport(report *schemas.SignedCreditReport) (*schemas.CreditReportVerification, error) {
+	message, err := base64.StdEncoding.DecodeString(report.Payload)
+	if err != nil || len(message) == 0 {
+		return nil, errors.New("payload must be the base64 encoded report that was signed")
+	}
+
+	var payload schemas.CreditReportPayload
+	if err := json.Unmarshal(message, &payload); err != nil {
+		return nil, fmt.Errorf("invalid report payload: %w", err)
+	}
+
+	if !s.signer.Verify(message, report.Signature) {
+		return &schemas.CreditReportVerification{
+			ReportID: payload.ReportID,
+			Reason:   "signature does not match report contents",
+		}, nil
+	}
+
+	verification, err := s.VerifyCreditReportByID(payload.ReportID)
+	if err != nil {
+		return nil, err
+	}
+
+	digest := sha256.Sum256(message)
+	if verification.Valid && verification.Digest != hex.EncodeToString(digest[:]) {
+		verification.Valid = false
+		verification.Reason = "report contents differ from the issued report"
+	}
+
+	return verification, nil
+}
+
+func (s *CreditReportService) creditReportScore(snapshot *models.CreditScoreSnapshot) (schemas.CreditReportScore, error) {
+	score := schemas.CreditReportScore{
+		Status:       snapshot.Status,
+		Score:        snapshot.Score,
+		ModelVersion: snapshot.ModelVersion,
+		ProfileName:  snapshot.ProfileName,
+		ScoredAt:     snapshot.CreatedAt.UTC().Truncate(time.Second),
+		ReasonCodes:  []string{ReasonCodeInsufficientData},
+		Breakdown:    []schemas.CreditReportScoreComponent{},
+	}
+
+	explanation, profile, err := s.creditScoreService.ExplainSnapshot(snapshot)
+	if err != nil {
+		return score, err
+	}
+
+	score.ScoreMin = profile.ScoreMin
+	score.ScoreMax = profile.ScoreMax
+
+	if snapshot.Status != CreditScoreStatusScored {
+		return score, nil
+	}
+
+	score.ReasonCodes = explanation.ReasonCodes()
+	for _, contribution := range explanation.Contributions {
+		score.Breakdown = append(score.Breakdown, schemas.CreditReportScoreComponent{
+			Feature:    contribution.Feature,
+			ReasonCode: contribution.ReasonCode,
+			Value:      contribution.Value,
+			Points:     contribution.Points,
+			Adverse:    contribution.Adverse,
+		})
+	}
+
+	return score, nil
+}
+
+func creditReportMonths(transactions []models.Transaction) []schemas.CreditReportMonth {
+	months := []schemas.CreditReportMonth{}
+	if len(transactions) == 0 {
+		return months
+	}
+
+	sorted := sortTransactionsByDate(transactions)
+	series := BuildMonthlySeries(
+		sorted,
+		YearMonthOf(sorted[0].TransactionDate),
+		YearMonthOf(sorted[len(sorted)-1].TransactionDate),
+	)
+
+	for _, month := range series {
+		months = append(months, schemas.CreditReportMonth{
+			Month:            month.Month,
+			Income:           roundPoints(month.Income),
+			Expenses:         roundPoints(month.Expenses),
+			Fees:             roundPoints(month.Fees),
+			TransactionCount: month.TransactionCount,
+		})
+	}
+
+	return months
+}
+
+func creditReportLoans(loans []models.LoanRequest) []schemas.CreditReportLoan {
+	history := []schemas.CreditReportLoan{}
+	for _, loan := range loans {
+		history = append(history, schemas.CreditReportLoan{
+			ID:           loan.ID.String(),
+			Amount:       loan.Amount,
+			InterestRate: loan.InterestRate,
+			LoanDuration: loan.LoanDuration,
+			Purpose:      loan.Purpose,
+			Status:       loan.Status,
+			CreatedAt:    loan.CreatedAt.UTC().Truncate(time.Second),
+		})
+	}
+	return history
+}
+
+// RenderCreditReportPDF lays out a signed report for printing. The PDF carries
+// the report ID, digest and signature so it can be checked against the
+// public verification endpoint.
+func RenderCreditReportPDF(signed *schemas.SignedCreditReport) []byte {
+	report := signed.Report
+	doc := pdf.New()
+
+	doc.Heading("Lumon Credit Report")
+	doc.Text(fmt.Sprintf("Report ID: %s", report.ReportID))
+	doc.Text(fmt.Sprintf("Issued: %s    Valid until: %s",
+		report.IssuedAt.Format(time.RFC1123), report.ExpiresAt.Format(time.RFC1123)))
+	doc.Blank()
+
+	doc.Heading("Borrower")
+	doc.Text(fmt.Sprintf("Name: %s", report.Subject.Username))
+	doc.Text(fmt.Sprintf("Email: %s", report.Subject.Email))
+	doc.Text(fmt.Sprintf("Phone: %s", report.Subject.PhoneNumber))
+	doc.Blank()
+
+	doc.Heading("Credit Score")
+	if report.Score.Status == CreditScoreStatusScored {
+		doc.Text(fmt.Sprintf("Score: %.0f (range %.0f-%.0f)", report.Score.Score, report.Score.ScoreMin, report.Score.ScoreMax))
+	} else {
+		doc.Text("Score: not enough transaction history to produce a score")
+	}
+	doc.Text(fmt.Sprintf("Model: %s    Profile: %s    Scored: %s",
+		report.Score.ModelVersion, report.Score.ProfileName, report.Score.ScoredAt.Format("2006-01-02")))
+	for _, component := range report.Score.Breakdown {
+		marker := ""
+		if component.Adverse {
+			marker = "  [" + component.ReasonCode + "]"
+		}
+		doc.Text(fmt.Sprintf("  %-20s value %6.1f   points %7.2f%s", component.Feature, component.Value, component.Points, marker))
+	}
+	doc.Blank()
+
+	doc.Heading("Monthly Transaction Summary")
+	doc.Text(fmt.Sprintf("  %-8s %14s %14s %10s %6s", "Month", "Income", "Expenses", "Fees", "Count"))
+	for _, month := range report.Monthly {
+		doc.Text(fmt.Sprintf("  %-8s %14.2f %14.2f %10.2f %6d",
+			month.Month, month.Income, month.Expenses, month.Fees, month.TransactionCount))
+	}
+	doc.Blank()
+
+	doc.Heading("Loan History")
+	if len(report.Loans) == 0 {
+		doc.Text("  No loan requests on record")
+	}
+	for _, loan := range report.Loans {
+		doc.Text(fmt.Sprintf("  %s  amount %d  rate %.2f%%  %d months  %s",
+			loan.CreatedAt.Format("2006-01-02"), loan.Amount, loan.InterestRate, loan.LoanDuration, loan.Status))
+	}
+	doc.Blank()
+
+	doc.Heading("Verification")
+	doc.Text(fmt.Sprintf("Algorithm: %s", signed.Algorithm))
+	doc.Text(fmt.Sprintf("SHA-256 digest: %s", signed.Digest))
+	doc.Text("Signature:")
+	for i := 0; i < len(signed.Signature); i += 64 {
+		doc.Text("  " + signed.Signature[i:min(i+64, len(signed.Signature))])
+	}
+	doc.Text(fmt.Sprintf("Verify this report at /api/credit-reports/%s/verify", report.ReportID))
+
+	return doc.Bytes()
+}
@@ -0,0 +1,419 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"runtime/debug"
+	"sync"
+	"time"
+
+	"lumon-backend/internal/domain/models"
+	"lumon-backend/internal/repository/interfaces"
+	"lumon-backend/pkg/common/logger"
+
+	"github.com/google/uuid"
+)
+
+const (
+	RecalculationTriggerIngestion = "ingestion"
+	RecalculationTriggerNightly   = "nightly"
+	RecalculationTriggerManual    = "manual"
+)
+
+const (
+	RecalculationStatusRunning   = "running"
+	RecalculationStatusCompleted = "completed"
+	RecalculationStatusFailed    = "failed"
+)
+
+var (
+	// ErrRecalculationQueueFull is returned when a run is scheduled while
+	// QueueSize runs are already waiting. Users with new transactions are
+	// picked up by the next nightly run.
+	ErrRecalculationQueueFull = errors.New("credit score recalculation queue is full")
+	ErrSchedulerStopped       = errors.New("credit score scheduler is stopped")
+)
+
+type CreditScoreSchedulerConfig struct {
+	Workers int
+	// QueueSize bounds both the runs waiting to be dispatched and the users
+	// waiting for a worker.
+	QueueSize      int
+	AlertThreshold float64
+	// NightlyHour is the UTC hour at which users with new transactions are
+	// rescored.
+	NightlyHour int
+}
+
+// CreditScoreScheduler recalculates credit scores in the background on a
+// bounded pool of workers, after statement ingestion and once a night. Runs
+// wait in a bounded queue and a single dispatcher hands their users to the
+// workers one run at a time.
+type CreditScoreScheduler struct {
+	creditScoreService *CreditScoreService
+	snapshotRepo       interfaces.CreditScoreSnapshotRepository
+	runRepo            interfaces.ScoreRecalculationRunRepository
+	alertRepo          interfaces.CreditScoreAlertRepository
+	cfg                CreditScoreSchedulerConfig
+	runs               chan queuedRun
+	jobs               chan recalculationJob
+	// ctx is cancelled when the scheduler is stopped, so that runs stop
+	// queueing users no worker will take.
+	ctx context.Context
+}
+
+type recalculationJob struct {
+	run    *recalculationRun
+	userID uuid.UUID
+}
+
+// queuedRun is a run waiting for the dispatcher with the users it rescores.
+type queuedRun struct {
+	run     *recalculationRun
+	userIDs []uuid.UUID
+}
+
+// recalculationRun tracks the progress of a persisted run while its jobs are
+// being worked on. The run finishes when every user has been recorded.
+type recalculationRun struct {
+	mu      sync.Mutex
+	model   *models.ScoreRecalculationRun
+	runRepo interfaces.ScoreRecalculationRunRepository
+}
+
+func NewCreditScoreScheduler(
+	creditScoreService *CreditScoreService,
+	snapshotRepo interfaces.CreditScoreSnapshotRepository,
+	runRepo interfaces.ScoreRecalculationRunRepository,
+	alertRepo interfaces.CreditScoreAlertRepository,
+	cfg CreditScoreSchedulerConfig,
+) *CreditScoreScheduler {
+	if cfg.Workers <= 0 {
+		cfg.Workers = 1
+	}
+	if cfg.QueueSize <= 0 {
+		cfg.QueueSize = 100
+	}
+
+	return &CreditScoreScheduler{
+		creditScoreService: creditScoreService,
+		snapshotRepo:       snapshotRepo,
+		runRepo:            runRepo,
+		alertRepo:          alertRepo,
+		cfg:                cfg,
+		runs:               make(chan queuedRun, cfg.QueueSize),
+		jobs:               make(chan recalculationJob, cfg.QueueSize),
+		ctx:                context.Background(),
+	}
+}
+
+// Start fails the runs the previous process left running, then launches the
+// dispatcher, the worker pool and the nightly schedule. All stop when ctx is
+// cancelled.
+func (s *CreditScoreScheduler) Start(ctx context.Context) {
+	s.ctx = ctx
+
+	stale, err := s.runRepo.FinishUnfinished(
+		RecalculationStatusFailed, "interrupted by a restart", time.Now().UTC(),
+	)
+	if err != nil {
+		logger.APILogger.Errorf("Failed to fail interrupted recalculation runs: %v", err)
+	} else if stale > 0 {
+		logger.APILogger.Warnw("failed recalculation runs interrupted by a restart", "runs", stale)
+	}
+
+	go s.dispatch(ctx)
+	for i := 0; i < s.cfg.Workers; i++ {
+		go s.work(ctx)
+	}
+
+	go s.runNightly(ctx)
+}
+
+// RecalculateAfterIngestion queues a rescore of a user whose statement was
+// just ingested.
+func (s *CreditScoreScheduler) RecalculateAfterIngestion(userID string) {
+	id, err := uuid.Parse(userID)
+	if err != nil {
+		logger.APILogger.Errorf("Invalid user ID for credit score recalculation: %v", err)
+		return
+	}
+
+	if _, err := s.Schedule(RecalculationTriggerIngestion, []uuid.UUID{id}); err != nil {
+		logger.APILogger.Errorf("Failed to schedule credit score recalculation: %v", err)
+	}
+}
+
+// RecalculateUsersWithNewData queues every user with transactions ingested
+// since their last snapshot.
+func (s *CreditScoreScheduler) RecalculateUsersWithNewData(trigger string) (*models.ScoreRecalculationRun, error) {
+	userIDs, err := s.snapshotRepo.ListUsersWithNewTransactions()
+	if err != nil {
+		logger.APILogger.Error(err)
+		return nil, err
+	}
+
+	return s.Schedule(trigger, userIDs)
+}
+
+// Schedule records a run for the users and queues it for the worker pool. It
+// returns as soon as the run is queued; progress is written to the run as
+// each user finishes. A run that cannot be queued because the queue is full
+// or the scheduler has stopped is recorded as failed, and so is a run still
+// waiting when the scheduler stops.
+func (s *CreditScoreScheduler) Schedule(trigger string, userIDs []uuid.UUID) (*models.ScoreRecalculationRun, error) {
+	run := &recalculationRun{
+		model: &models.ScoreRecalculationRun{
+			Trigger:    trigger,
+			Status:     RecalculationStatusRunning,
+			TotalUsers: len(userIDs),
+			StartedAt:  time.Now().UTC(),
+		},
+		runRepo: s.runRepo,
+	}
+
+	if err := s.runRepo.Create(run.model); err != nil {
+		logger.APILogger.Error(err)
+		return nil, err
+	}
+
+	snapshot := *run.model
+
+	if len(userIDs) == 0 {
+		run.finish(RecalculationStatusCompleted)
+		return &snapshot, nil
+	}
+
+	if s.ctx.Err() != nil {
+		run.fail(ErrSchedulerStopped.Error())
+		return nil, ErrSchedulerStopped
+	}
+
+	select {
+	case s.runs <- queuedRun{run: run, userIDs: userIDs}:
+	default:
+		run.fail(ErrRecalculationQueueFull.Error())
+		return nil, ErrRecalculationQueueFull
+	}
+
+	return &snapshot, nil
+}
+
+// dispatch hands the users of each queued run to the workers, waiting for a
+// free place in the job queue. When the scheduler stops, the run being
+// dispatched and those still queued are failed.
+func (s *CreditScoreScheduler) dispatch(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			s.interruptQueued()
+			return
+		case queued := <-s.runs:
+			for _, userID := range queued.userIDs {
+				if ctx.Err() != nil {
+					queued.run.interrupt()
+					s.interruptQueued()
+					return
+				}
+				select {
+				case s.jobs <- recalculationJob{run: queued.run, userID: userID}:
+				case <-ctx.Done():
+					queued.run.interrupt()
+					s.interruptQueued()
+					return
+				}
+			}
+		}
+	}
+}
+
+func (s *CreditScoreScheduler) interruptQueued() {
+	for {
+		select {
+		case queued := <-s.runs:
+			queued.run.interrupt()
+		default:
+			return
+		}
+	}
+}
+
+func (s *CreditScoreScheduler) work(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case job := <-s.jobs:
+			s.process(job)
+		}
+	}
+}
+
+// process rescores a user of a run. A rescore that panics is recorded as
+// failed rather than taking the worker down with it.
+func (s *CreditScoreScheduler) process(job recalculationJob) {
+	defer func() {
+		if r := recover(); r != nil {
+			logger.APILogger.Errorf("Credit score recalculation panicked: %v\n%s", r, debug.Stack())
+			job.run.record(fmt.Errorf("recalculation panicked: %v", r), false)
+		}
+	}()
+
+	alerted, err := s.recalculate(job.userID)
+	job.run.record(err, alerted)
+}
+
+func (s *CreditScoreScheduler) recalculate(userID uuid.UUID) (bool, error) {
+	previous, err := s.snapshotRepo.GetLatestScoredByUser(userID)
+	if err != nil {
+		return false, err
+	}
+
+	current, err := s.creditScoreService.CalculateCreditScore(userID.String())
+	if err != nil {
+		return false, err
+	}
+
+	alert := scoreAlert(previous, current, s.cfg.AlertThreshold)
+	if alert == nil {
+		return false, nil
+	}
+	if err := s.alertRepo.Create(alert); err != nil {
+		return false, err
+	}
+
+	logger.APILogger.Warnw("credit score moved beyond alert threshold",
+		"user_id", userID,
+		"previous_score", previous.Score,
+		"new_score", current.Score,
+		"change", alert.Change,
+	)
+
+	return true, nil
+}
+
+// scoreAlert returns the alert for a rescore that moved the score by more
+// than threshold either way, or nil. There is nothing to compare when the user
+// had no score before or could not be scored now.
+func scoreAlert(previous, current *models.CreditScoreSnapshot, threshold float64) *models.CreditScoreAlert {
+	if previous == nil || current.Status != CreditScoreStatusScored {
+		return nil
+	}
+
+	change := current.Score - previous.Score
+	if math.Abs(change) <= threshold {
+		return nil
+	}
+
+	return &models.CreditScoreAlert{
+		PreviousScore: previous.Score,
+		NewScore:      current.Score,
+		Change:        change,
+		SnapshotID:    current.ID,
+		UserID:        current.UserID,
+	}
+}
+
+func (s *CreditScoreScheduler) runNightly(ctx context.Context) {
+	for {
+		timer := time.NewTimer(time.Until(nextNightlyRun(time.Now().UTC(), s.cfg.NightlyHour)))
+
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+			if _, err := s.RecalculateUsersWithNewData(RecalculationTriggerNightly); err != nil {
+				logger.APILogger.Errorf("Nightly credit score recalculation failed: %v", err)
+			}
+		}
+	}
+}
+
+func nextNightlyRun(now time.Time, hour int) time.Time {
+	next := time.Date(now.Year(), now.Month(), now.Day(), hour, 0, 0, 0, time.UTC)
+	if !next.After(now) {
+		next = next.AddDate(0, 0, 1)
+	}
+	return next
+}
+
+func (r *recalculationRun) record(err error, alerted bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if err != nil {
+		r.model.Failed++
+		r.model.LastError = err.Error()
+		logger.APILogger.Errorf("Credit score recalculation failed: %v", err)
+	} else {
+		r.model.Processed++
+	}
+	if alerted {
+		r.model.Alerts++
+	}
+
+	if r.model.FinishedAt == nil && r.model.Processed+r.model.Failed >= r.model.TotalUsers {
+		r.finishLocked(RecalculationStatusCompleted)
+		return
+	}
+
+	if err := r.runRepo.Update(r.model); err != nil {
+		logger.APILogger.Errorf("Failed to record recalculation progress: %v", err)
+	}
+}
+
+// interrupt fails a run whose remaining users will not be rescored.
+func (r *recalculationRun) interrupt() {
+	r.fail("interrupted by shutdown")
+}
+
+// fail finishes a run as failed with the reason, unless it already finished.
+func (r *recalculationRun) fail(reason string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.model.FinishedAt == nil {
+		r.model.LastError = reason
+		r.finishLocked(RecalculationStatusFailed)
+	}
+}
+
+func (r *recalculationRun) finish(status string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.finishLocked(status)
+}
+
+func (r *recalculationRun) finishLocked(status string) {
+	finishedAt := time.Now().UTC()
+	r.model.Status = status
+	r.model.FinishedAt = &finishedAt
+
+	if err := r.runRepo.Update(r.model); err != nil {
+		logger.APILogger.Errorf("Failed to record recalculation completion: %v", err)
+	}
+}
+
+func (s *CreditScoreScheduler) ListRuns(page, pageSize int) ([]models.ScoreRecalculationRun, int64, error) {
+	runs, total, err := s.runRepo.List(page, pageSize)
+	if err != nil {
+		logger.APILogger.Error(err)
+		return nil, 0, err
+	}
+
+	return runs, total, nil
+}
+
+func (s *CreditScoreScheduler) ListAlerts(page, pageSize int) ([]models.CreditScoreAlert, int64, error) {
+	alerts, total, err := s.alertRepo.List(page, pageSize)
+	if err != nil {
+		logger.APILogger.Error(err)
+		return nil, 0, err
+	}
+
+	return alerts, total, nil
+}
@@ -0,0 +1,183 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"lumon-backend/internal/domain/models"
+
+	"github.com/google/uuid"
+)
+
+// fakeRunRepository keeps recalculation runs in memory.
+type fakeRunRepository struct {
+	mu         sync.Mutex
+	runs       []*models.ScoreRecalculationRun
+	unfinished int64
+	finished   []string
+}
+
+func (r *fakeRunRepository) Create(run *models.ScoreRecalculationRun) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	run.ID = uuid.New()
+	r.runs = append(r.runs, run)
+	return nil
+}
+
+func (r *fakeRunRepository) Update(*models.ScoreRecalculationRun) error {
+	return nil
+}
+
+func (r *fakeRunRepository) List(int, int) ([]models.ScoreRecalculationRun, int64, error) {
+	return nil, 0, nil
+}
+
+func (r *fakeRunRepository) FinishUnfinished(status, lastError string, _ time.Time) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.finished = append(r.finished, status+": "+lastError)
+	return r.unfinished, nil
+}
+
+func TestNextNightlyRun(t *testing.T) {
+	tests := []struct {
+		name string
+		now  time.Time
+		hour int
+		want time.Time
+	}{
+		{
+			name: "before the hour",
+			now:  time.Date(2024, time.March, 10, 1, 30, 0, 0, time.UTC),
+			hour: 2,
+			want: time.Date(2024, time.March, 10, 2, 0, 0, 0, time.UTC),
+		},
+		{
+			name: "at the hour",
+			now:  time.Date(2024, time.March, 10, 2, 0, 0, 0, time.UTC),
+			hour: 2,
+			want: time.Date(2024, time.March, 11, 2, 0, 0, 0, time.UTC),
+		},
+		{
+			name: "after the hour",
+			now:  time.Date(2024, time.March, 10, 13, 0, 0, 0, time.UTC),
+			hour: 2,
+			want: time.Date(2024, time.March, 11, 2, 0, 0, 0, time.UTC),
+		},
+		{
+			name: "end of the month",
+			now:  time.Date(2024, time.February, 29, 23, 0, 0, 0, time.UTC),
+			hour: 0,
+			want: time.Date(2024, time.March, 1, 0, 0, 0, 0, time.UTC),
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := nextNightlyRun(test.now, test.hour); !got.Equal(test.want) {
+				t.Errorf("nextNightlyRun = %v, want %v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestScoreAlert(t *testing.T) {
+	scored := func(score float64) *models.CreditScoreSnapshot {
+		return &models.CreditScoreSnapshot{Status: CreditScoreStatusScored, Score: score, UserID: uuid.New()}
+	}
+
+	tests := []struct {
+		name     string
+		previous *models.CreditScoreSnapshot
+		current  *models.CreditScoreSnapshot
+		want     float64
+		alert    bool
+	}{
+		{name: "first score", current: scored(700)},
+		{
+			name:     "not scored now",
+			previous: scored(700),
+			current:  &models.CreditScoreSnapshot{Status: CreditScoreStatusInsufficientData},
+		},
+		{name: "within the threshold", previous: scored(700), current: scored(740)},
+		{name: "at the threshold", previous: scored(700), current: scored(650)},
+		{name: "rose beyond the threshold", previous: scored(600), current: scored(651), want: 51, alert: true},
+		{name: "fell beyond the threshold", previous: scored(700), current: scored(600), want: -100, alert: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			alert := scoreAlert(test.previous, test.current, 50)
+			if (alert != nil) != test.alert {
+				t.Fatalf("alert = %+v, want one: %v", alert, test.alert)
+			}
+			if alert != nil && (alert.Change != test.want || alert.UserID != test.current.UserID) {
+				t.Errorf("alert = %+v, want a change of %v for the user", alert, test.want)
+			}
+		})
+	}
+}
+
+func TestCreditScoreSchedulerStartFailsStaleRuns(t *testing.T) {
+	runRepo := &fakeRunRepository{unfinished: 2}
+	scheduler := NewCreditScoreScheduler(nil, nil, runRepo, nil, CreditScoreSchedulerConfig{NightlyHour: 2})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	scheduler.Start(ctx)
+
+	runRepo.mu.Lock()
+	defer runRepo.mu.Unlock()
+	want := RecalculationStatusFailed + ": interrupted by a restart"
+	if len(runRepo.finished) != 1 || runRepo.finished[0] != want {
+		t.Errorf("unfinished runs finished with %v, want [%s]", runRepo.finished, want)
+	}
+}
+
+func TestCreditScoreSchedulerRejectsRunsWhenQueueIsFull(t *testing.T) {
+	runRepo := &fakeRunRepository{}
+	// Not started, so nothing takes runs off the queue.
+	scheduler := NewCreditScoreScheduler(nil, nil, runRepo, nil, CreditScoreSchedulerConfig{QueueSize: 1})
+
+	if _, err := scheduler.Schedule(RecalculationTriggerIngestion, []uuid.UUID{uuid.New()}); err != nil {
+		t.Fatalf("first run: %v", err)
+	}
+	_, err := scheduler.Schedule(RecalculationTriggerIngestion, []uuid.UUID{uuid.New()})
+	if !errors.Is(err, ErrRecalculationQueueFull) {
+		t.Fatalf("second run error = %v, want %v", err, ErrRecalculationQueueFull)
+	}
+
+	rejected := runRepo.runs[1]
+	if rejected.Status != RecalculationStatusFailed || rejected.FinishedAt == nil {
+		t.Errorf("rejected run = %s, finished %v, want failed", rejected.Status, rejected.FinishedAt)
+	}
+	if runRepo.runs[0].Status != RecalculationStatusRunning {
+		t.Errorf("queued run = %s, want running", runRepo.runs[0].Status)
+	}
+}
+
+func TestCreditScoreSchedulerFailsQueuedRunsWhenStopped(t *testing.T) {
+	runRepo := &fakeRunRepository{}
+	scheduler := NewCreditScoreScheduler(nil, nil, runRepo, nil, CreditScoreSchedulerConfig{QueueSize: 1})
+
+	if _, err := scheduler.Schedule(RecalculationTriggerManual, []uuid.UUID{uuid.New()}); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	scheduler.dispatch(ctx)
+
+	if run := runRepo.runs[0]; run.Status != RecalculationStatusFailed {
+		t.Errorf("queued run = %s, want failed", run.Status)
+	}
+	if _, err := scheduler.Schedule(RecalculationTriggerManual, []uuid.UUID{uuid.New()}); err != nil {
+		t.Errorf("run after the queue drained: %v", err)
+	}
+}
@@ -0,0 +1,288 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"math"
+
+	"lumon-backend/internal/domain/models"
+	"lumon-backend/internal/domain/schemas"
+	"lumon-backend/internal/repository/interfaces"
+	"lumon-backend/pkg/common/logger"
+
+	"github.com/google/uuid"
+)
+
+const (
+	CreditScoreTrendImproving = "improving"
+	CreditScoreTrendDeclining = "declining"
+	CreditScoreTrendStable    = "stable"
+)
+
+// ErrSnapshotModelMismatch is returned when a snapshot is explained that was
+// scored by a model version other than the live one.
+var ErrSnapshotModelMismatch = errors.New("snapshot was scored by another model version")
+
+// creditScoreTrendTolerance is the score movement below which a borrower is
+// considered stable rather than improving or declining.
+const creditScoreTrendTolerance = 5.0
+
+type CreditScoreService struct {
+	transactionRepo interfaces.TransactionRepository
+	userRepo        interfaces.UserRepository
+	snapshotRepo    interfaces.CreditScoreSnapshotRepository
+	profileRepo     interfaces.ScoringProfileRepository
+	model           ScoringModel
+	shadowModel     ScoringModel
+}
+
+func NewCreditScoreService(
+	transactionRepo interfaces.TransactionRepository,
+	userRepo interfaces.UserRepository,
+	snapshotRepo interfaces.CreditScoreSnapshotRepository,
+	profileRepo interfaces.ScoringProfileRepository,
+	model ScoringModel,
+	shadowModel ScoringModel,
+) *CreditScoreService {
+	return &CreditScoreService{
+		transactionRepo: transactionRepo,
+		userRepo:        userRepo,
+		snapshotRepo:    snapshotRepo,
+		profileRepo:     profileRepo,
+		model:           model,
+		shadowModel:     shadowModel,
+	}
+}
+
+// CalculateCreditScore scores the user's transaction history and records the
+// result as a snapshot. The user's current credit score is only updated when
+// there was enough data to produce a score.
+func (s *CreditScoreService) CalculateCreditScore(userID string) (*models.CreditScoreSnapshot, error) {
+	id := uuid.MustParse(userID)
+
+	transactions, err := s.transactionRepo.ListAll(id)
+	if err != nil {
+		logger.APILogger.Error(err)
+		return nil, err
+	}
+
+	dbUser, err := s.userRepo.GetByID(id)
+	if err != nil {
+		logger.APILogger.Error(err)
+		return nil, err
+	}
+
+	profile, err := loadActiveScoringProfile(s.profileRepo)
+	if err != nil {
+		return nil, err
+	}
+
+	calculator := NewCreditScoreCalculatorWithModel(transactions, profile, s.model)
+	result := calculator.Evaluate()
+
+	snapshot := &models.CreditScoreSnapshot{
+		Status:            result.Status,
+		Score:             result.Score,
+		ModelVersion:      result.ModelVersion,
+		ProfileName:       profile.Name,
+		PaymentBehavior:   result.Features.PaymentBehavior,
+		IncomeStability:   result.Features.IncomeStability,
+		CashFlow:          result.Features.CashFlow,
+		TransactionHabits: result.Features.TransactionHabits,
+		CreditHistory:     result.Features.CreditHistory,
+		WindowStart:       result.WindowStart,
+		WindowEnd:         result.WindowEnd,
+		TransactionCount:  result.TransactionCount,
+		UserID:            id,
+	}
+	if profile.ID != uuid.Nil {
+		snapshot.ProfileID = &profile.ID
+	}
+	if s.shadowModel != nil && result.Scored() {
+		s.recordShadowScore(snapshot, result, profile)
+	}
+
+	if err := s.snapshotRepo.Create(snapshot); err != nil {
+		logger.APILogger.Error(err)
+		return nil, err
+	}
+
+	if !result.Scored() {
+		return snapshot, nil
+	}
+
+	dbUser.CreditScore = int64(snapshot.Score)
+	if err := s.userRepo.Update(dbUser); err != nil {
+		logger.APILogger.Error(err)
+		return nil, err
+	}
+
+	return snapshot, nil
+}
+
+// LatestScoredSnapshot returns the user's most recent scored snapshot,
+// scoring the user first if they have never been scored by the live model. It
+// returns the insufficient-data snapshot when there is still not enough data.
+func (s *CreditScoreService) LatestScoredSnapshot(userID string) (*models.CreditScoreSnapshot, error) {
+	snapshot, err := s.snapshotRepo.GetLatestScoredByUser(uuid.MustParse(userID))
+	if err != nil {
+		logger.APILogger.Error(err)
+		return nil, err
+	}
+
+	if snapshot != nil && snapshot.ModelVersion == s.model.Version() {
+		return snapshot, nil
+	}
+
+	return s.CalculateCreditScore(userID)
+}
+
+// ExplainSnapshot rebuilds the score breakdown of a stored snapshot using the
+// profile it was scored with. Snapshots from another model version cannot be
+// explained, as the breakdown would not add up to their score.
+func (s *CreditScoreService) ExplainSnapshot(
+	snapshot *models.CreditScoreSnapshot,
+) (ScoreExplanation, *models.ScoringProfile, error) {
+	if snapshot.ModelVersion != s.model.Version() {
+		return ScoreExplanation{}, nil, fmt.Errorf(
+			"%w: %s, live model is %s", ErrSnapshotModelMismatch, snapshot.ModelVersion, s.model.Version(),
+		)
+	}
+
+	profile := DefaultScoringProfile()
+	if snapshot.ProfileID != nil {
+		stored, err := s.profileRepo.GetByID(*snapshot.ProfileID)
+		if err != nil {
+			logger.APILogger.Error(err)
+			return ScoreExplanation{}, nil, err
+		}
+		profile = stored
+	}
+
+	features := CreditScoreFeatures{
+		PaymentBehavior:   snapshot.PaymentBehavior,
+		IncomeStability:   snapshot.IncomeStability,
+		CashFlow:          snapshot.CashFlow,
+		TransactionHabits: snapshot.TransactionHabits,
+		CreditHistory:     snapshot.CreditHistory,
+	}
+
+	return s.model.Score(features, profile), profile, nil
+}
+
+// recordShadowScore scores the same features with the shadow model so that a
+// candidate model can be compared against the live one on real traffic. The
+// shadow score is stored on the snapshot but never shown to the user.
+func (s *CreditScoreService) recordShadowScore(
+	snapshot *models.CreditScoreSnapshot, result CreditScoreResult, profile *models.ScoringProfile,
+) {
+	shadow := s.shadowModel.Score(result.Features, profile)
+
+	snapshot.ShadowModelVersion = shadow.ModelVersion
+	snapshot.ShadowScore = &shadow.Score
+
+	logger.APILogger.Infow("shadow credit score",
+		"user_id", snapshot.UserID,
+		"model_version", result.ModelVersion,
+		"score", result.Score,
+		"shadow_model_version", shadow.ModelVersion,
+		"shadow_score", shadow.Score,
+		"difference", shadow.Score-result.Score,
+	)
+}
+
+// SimulateCreditScore projects the user's score under a hypothetical scenario
+// using the active scoring profile without persisting anything.
+func (s *CreditScoreService) SimulateCreditScore(
+	userID string, scenario schemas.CreditScoreSimulationDetails,
+) (*CreditScoreSimulation, error) {
+	transactions, err := s.transactionRepo.ListAll(uuid.MustParse(userID))
+	if err != nil {
+		logger.APILogger.Error(err)
+		return nil, err
+	}
+
+	profile, err := loadActiveScoringProfile(s.profileRepo)
+	if err != nil {
+		return nil, err
+	}
+
+	simulation := SimulateCreditScore(transactions, profile, s.model, scenario)
+	return &simulation, nil
+}
+
+func (s *CreditScoreService) GetCreditScoreHistory(userID string) (*schemas.CreditScoreHistoryResponse, error) {
+	snapshots, err := s.snapshotRepo.ListByUser(uuid.MustParse(userID))
+	if err != nil {
+		logger.APILogger.Error(err)
+		return nil, err
+	}
+
+	history := &schemas.CreditScoreHistoryResponse{
+		Snapshots: make([]schemas.CreditScoreSnapshotResponse, len(snapshots)),
+	}
+
+	var scored []models.CreditScoreSnapshot
+	for i, snapshot := range snapshots {
+		change := 0.0
+		if snapshot.Status == CreditScoreStatusScored {
+			if len(scored) > 0 {
+				change = snapshot.Score - scored[len(scored)-1].Score
+			}
+			scored = append(scored, snapshot)
+		}
+
+		history.Snapshots[i] = schemas.CreditScoreSnapshotResponse{
+			ID:                snapshot.ID.String(),
+			Status:            snapshot.Status,
+			Score:             snapshot.Score,
+			ModelVersion:      snapshot.ModelVersion,
+			ProfileID:         snapshot.ProfileID,
+			ProfileName:       snapshot.ProfileName,
+			PaymentBehavior:   snapshot.PaymentBehavior,
+			IncomeStability:   snapshot.IncomeStability,
+			CashFlow:          snapshot.CashFlow,
+			TransactionHabits: snapshot.TransactionHabits,
+			CreditHistory:     snapshot.CreditHistory,
+			WindowStart:       snapshot.WindowStart,
+			WindowEnd:         snapshot.WindowEnd,
+			TransactionCount:  snapshot.TransactionCount,
+			Change:            change,
+			CreatedAt:         snapshot.CreatedAt,
+		}
+	}
+
+	if len(scored) > 0 {
+		history.Trend = calculateCreditScoreTrend(scored)
+	}
+
+	return history, nil
+}
+
+func calculateCreditScoreTrend(snapshots []models.CreditScoreSnapshot) *schemas.CreditScoreTrend {
+	first, latest := snapshots[0].Score, snapshots[len(snapshots)-1].Score
+
+	trend := &schemas.CreditScoreTrend{
+		FirstScore:  first,
+		LatestScore: latest,
+		Change:      latest - first,
+		MinScore:    math.Inf(1),
+		MaxScore:    math.Inf(-1),
+	}
+
+	for _, snapshot := range snapshots {
+		trend.MinScore = math.Min(trend.MinScore, snapshot.Score)
+		trend.MaxScore = math.Max(trend.MaxScore, snapshot.Score)
+	}
+
+	switch {
+	case trend.Change >= creditScoreTrendTolerance:
+		trend.Direction = CreditScoreTrendImproving
+	case trend.Change <= -creditScoreTrendTolerance:
+		trend.Direction = CreditScoreTrendDeclining
+	default:
+		trend.Direction = CreditScoreTrendStable
+	}
+
+	return trend
+}
@@ -2,160 +2,299 @@ package service
 
 import (
 	"math"
-	"sort"
 	"time"
 
+	"lumon-backend/internal/categories"
 	"lumon-backend/internal/domain/models"
+	"lumon-backend/internal/recurrence"
+)
+
+const CreditScoreModelVersion = "heuristic-v4"
+
+const (
+	CreditScoreStatusScored           = "scored"
+	CreditScoreStatusInsufficientData = "insufficient_data"
+)
+
+const (
+	// DefaultScoringWindowMonths is how many months, ending with the month of
+	// the most recent transaction, feed the monthly features.
+	DefaultScoringWindowMonths = 12
+	// MinScoringTransactions and MinScoringActiveMonths define a thin file.
+	// Below either threshold the calculator refuses to produce a score.
+	MinScoringTransactions = 10
+	MinScoringActiveMonths = 2
 )
 
 type CreditScoreCalculator struct {
 	Transactions []models.Transaction
-	ScoreRange   struct {
-		Min, Max float64
-	}
-	Weights struct {
-		PaymentHistory, IncomeStability, CashFlow,
-		TransactionHabits, CreditHistory float64
+	WindowMonths int
+	Profile      *models.ScoringProfile
+	Model        ScoringModel
+}
+
+// DefaultScoringProfile is used whenever no scoring profile has been
+// activated. It is never persisted.
+func DefaultScoringProfile() *models.ScoringProfile {
+	return &models.ScoringProfile{
+		Name:                    "default",
+		PaymentHistoryWeight:    0.35,
+		IncomeStabilityWeight:   0.25,
+		CashFlowWeight:          0.20,
+		TransactionHabitsWeight: 0.10,
+		CreditHistoryWeight:     0.10,
+		ScoreMin:                300,
+		ScoreMax:                850,
 	}
 }
 
 func NewCreditScoreCalculator(tx []models.Transaction) *CreditScoreCalculator {
+	return NewCreditScoreCalculatorWithProfile(tx, DefaultScoringProfile())
+}
+
+func NewCreditScoreCalculatorWithProfile(tx []models.Transaction, profile *models.ScoringProfile) *CreditScoreCalculator {
+	return NewCreditScoreCalculatorWithModel(tx, profile, HeuristicScoringModel{})
+}
+
+func NewCreditScoreCalculatorWithModel(
+	tx []models.Transaction, profile *models.ScoringProfile, model ScoringModel,
+) *CreditScoreCalculator {
 	return &CreditScoreCalculator{
-		Transactions: tx,
-		ScoreRange:   struct{ Min, Max float64 }{300, 850},
-		Weights: struct {
-			PaymentHistory, IncomeStability, CashFlow,
-			TransactionHabits, CreditHistory float64
-		}{
-			PaymentHistory:    0.35,
-			IncomeStability:   0.25,
-			CashFlow:          0.20,
-			TransactionHabits: 0.10,
-			CreditHistory:     0.10,
-		},
+		Transactions: sortTransactionsByDate(tx),
+		WindowMonths: DefaultScoringWindowMonths,
+		Profile:      profile,
+		Model:        model,
 	}
 }
 
+type CreditScoreFeatures struct {
+	PaymentBehavior   float64 `json:"payment_behavior"`
+	IncomeStability   float64 `json:"income_stability"`
+	CashFlow          float64 `json:"cash_flow"`
+	TransactionHabits float64 `json:"transaction_habits"`
+	CreditHistory     float64 `json:"credit_history"`
+}
+
+type CreditScoreResult struct {
+	Status           string              `json:"status"`
+	Score            float64             `json:"score"`
+	ModelVersion     string              `json:"model_version"`
+	Features         CreditScoreFeatures `json:"features"`
+	Monthly          MonthlySeries       `json:"monthly"`
+	WindowStart      *time.Time          `json:"window_start"`
+	WindowEnd        *time.Time          `json:"window_end"`
+	TransactionCount int                 `json:"transaction_count"`
+	ActiveMonths     int                 `json:"active_months"`
+}
+
+func (r CreditScoreResult) Scored() bool {
+	return r.Status == CreditScoreStatusScored
+}
+
+// Calculate returns the score for the transactions, or zero when there is not
+// enough data to score them. Use Evaluate to tell the two apart.
 func (c *CreditScoreCalculator) Calculate() float64 {
-	income, expenses := c.categorizeTransactions()
-	firstTx, lastTx := c.getTimeBounds()
-
-	features := struct {
-		IncomeStability   float64
-		CashFlow          float64
-		PaymentBehavior   float64
-		TransactionHabits float64
-		CreditHistory     float64
-	}{
-		IncomeStability:   c.calculateIncomeStability(income),
-		CashFlow:          c.calculateCashFlow(income, expenses),
-		PaymentBehavior:   c.calculatePaymentBehavior(expenses),
-		TransactionHabits: c.calculateTransactionHabits(),
-		CreditHistory:     c.calculateCreditHistory(firstTx, lastTx),
-	}
-
-	rawScore := (features.PaymentBehavior * c.Weights.PaymentHistory) +
-		(features.IncomeStability * c.Weights.IncomeStability) +
-		(features.CashFlow * c.Weights.CashFlow) +
-		(features.TransactionHabits * c.Weights.TransactionHabits) +
-		(features.CreditHistory * c.Weights.CreditHistory)
-
-	return c.normalizeScore(rawScore)
-}
-
-func (c *CreditScoreCalculator) categorizeTransactions() ([]models.Transaction, []models.Transaction) {
-	var income, expenses []models.Transaction
-	for _, tx := range c.Transactions {
-		if tx.TransactionType == "CASH_IN" {
-			income = append(income, tx)
-		} else {
-			expenses = append(expenses, tx)
-		}
+	return c.Evaluate().Score
+}
+
+func (c *CreditScoreCalculator) Evaluate() CreditScoreResult {
+	result := CreditScoreResult{
+		Status:       CreditScoreStatusInsufficientData,
+		ModelVersion: c.Model.Version(),
+	}
+	if len(c.Transactions) == 0 {
+		return result
+	}
+
+	start, end := c.window()
+	windowStart, windowEnd := start.Start(), end.End()
+	windowed := c.transactionsBetween(windowStart, windowEnd)
+	monthly := BuildMonthlySeries(windowed, start, end)
+
+	result.Monthly = monthly
+	result.WindowStart = &windowStart
+	result.WindowEnd = &windowEnd
+	result.TransactionCount = len(windowed)
+	result.ActiveMonths = monthly.ActiveMonths()
+
+	if result.TransactionCount < MinScoringTransactions || result.ActiveMonths < MinScoringActiveMonths {
+		return result
 	}
-	return income, expenses
+
+	result.Status = CreditScoreStatusScored
+	result.Features = c.calculateFeatures(windowed, monthly)
+	result.Score = c.Score(result.Features)
+
+	return result
+}
+
+func (c *CreditScoreCalculator) Score(features CreditScoreFeatures) float64 {
+	return c.Explain(features).Score
+}
+
+func (c *CreditScoreCalculator) Explain(features CreditScoreFeatures) ScoreExplanation {
+	return c.Model.Score(features, c.Profile)
 }
 
-func (c *CreditScoreCalculator) getTimeBounds() (time.Time, time.Time) {
-	dates := make([]time.Time, len(c.Transactions))
-	for i, tx := range c.Transactions {
-		dates[i] = tx.TransactionDate
+func (c *CreditScoreCalculator) calculateFeatures(
+	windowed []models.Transaction, monthly MonthlySeries,
+) CreditScoreFeatures {
+	// Recurring series are judged as of the last transaction of the window,
+	// so that an old statement is not penalised for the months since.
+	recurring := recurrence.Detect(windowed, windowed[len(windowed)-1].TransactionDate)
+
+	return CreditScoreFeatures{
+		IncomeStability:   boundFeature(c.calculateIncomeStability(monthly, recurring)),
+		CashFlow:          boundFeature(c.calculateCashFlow(monthly)),
+		PaymentBehavior:   boundFeature(c.calculatePaymentBehavior(recurring)),
+		TransactionHabits: boundFeature(c.calculateTransactionHabits(windowed, monthly)),
+		CreditHistory:     boundFeature(c.calculateCreditHistory()),
 	}
-	sort.Slice(dates, func(i, j int) bool { return dates[i].Before(dates[j]) })
-	return dates[0], dates[len(dates)-1]
 }
 
-func (c *CreditScoreCalculator) calculateIncomeStability(income []models.Transaction) float64 {
-	monthlyIncome := make(map[time.Month]float64)
-	for _, tx := range income {
-		month := tx.TransactionDate.Month()
-		monthlyIncome[month] += tx.Amount
+// window returns the first and last month of the scoring window. The window
+// ends with the most recent transaction and never starts before the first.
+func (c *CreditScoreCalculator) window() (YearMonth, YearMonth) {
+	first := YearMonthOf(c.Transactions[0].TransactionDate)
+	end := YearMonthOf(c.Transactions[len(c.Transactions)-1].TransactionDate)
+
+	months := c.WindowMonths
+	if months <= 0 {
+		months = DefaultScoringWindowMonths
 	}
 
-	var amounts []float64
-	for _, amt := range monthlyIncome {
-		amounts = append(amounts, amt)
+	start := end.AddMonths(-(months - 1))
+	if start.Before(first) {
+		start = first
 	}
 
-	avg := average(amounts)
-	stdDev := standardDeviation(amounts, avg)
+	return start, end
+}
 
-	if stdDev == 0 {
-		return 100
+func (c *CreditScoreCalculator) transactionsBetween(start, end time.Time) []models.Transaction {
+	var windowed []models.Transaction
+	for _, tx := range c.Transactions {
+		if tx.TransactionDate.Before(start) || tx.TransactionDate.After(end) {
+			continue
+		}
+		windowed = append(windowed, tx)
 	}
-	return math.Max(0, 100-(stdDev/avg)*100)
+	return windowed
 }
 
-func (c *CreditScoreCalculator) calculateCashFlow(income, expenses []models.Transaction) float64 {
-	totalIncome := 0.0
-	for _, tx := range income {
-		totalIncome += tx.Amount
-	}
+// calculateIncomeStability weighs how little monthly income varies equally
+// with how much of it arrives as active recurring income, such as a salary,
+// scaled by the confidence in each series.
+func (c *CreditScoreCalculator) calculateIncomeStability(
+	monthly MonthlySeries, recurring []recurrence.Series,
+) float64 {
+	amounts := monthly.Income()
 
-	totalExpenses := 0.0
-	for _, tx := range expenses {
-		totalExpenses += tx.Amount + tx.Fees
+	avg := average(amounts)
+	if avg <= 0 {
+		return 0
 	}
 
-	netCashFlow := totalIncome - totalExpenses
-	savingsRate := (netCashFlow / totalIncome) * 100
+	stdDev := standardDeviation(amounts, avg)
+	steadiness := boundFeature(100 - (stdDev/avg)*100)
+
+	recurringIncome := 0.0
+	for _, series := range recurring {
+		if series.Incoming && series.Active && series.Category != categories.Loan {
+			recurringIncome += series.MonthlyAmount() * series.Confidence
+		}
+	}
+	coverage := math.Min(recurringIncome/avg, 1) * 100
 
-	return math.Min(savingsRate, 100)
+	return (steadiness + coverage) / 2
 }
 
-func (c *CreditScoreCalculator) calculatePaymentBehavior(expenses []models.Transaction) float64 {
-	payeeCount := make(map[string]int)
-	for _, tx := range expenses {
-		payeeCount[tx.ToNumber]++
+func (c *CreditScoreCalculator) calculateCashFlow(monthly MonthlySeries) float64 {
+	totalIncome := monthly.TotalIncome()
+	if totalIncome <= 0 {
+		return 0
 	}
 
-	recurringPayments := 0
-	for _, count := range payeeCount {
-		if count > 1 {
-			recurringPayments++
+	netCashFlow := totalIncome - monthly.TotalExpenses()
+	return (netCashFlow / totalIncome) * 100
+}
+
+// obligationCategories are the recurring payments that show payment
+// discipline. Regular betting or transfers to family say nothing about it.
+var obligationCategories = map[string]bool{
+	categories.Rent:      true,
+	categories.Utilities: true,
+	categories.Loan:      true,
+	categories.Education: true,
+}
+
+// calculatePaymentBehavior rewards regular obligations such as rent, bills
+// and loan repayments: up to half the feature for how many there are, and the
+// rest for how punctually they are paid, weighted by their monthly amount.
+func (c *CreditScoreCalculator) calculatePaymentBehavior(recurring []recurrence.Series) float64 {
+	obligations := 0
+	punctuality, weight := 0.0, 0.0
+	for _, series := range recurring {
+		if series.Incoming || !obligationCategories[series.Category] {
+			continue
 		}
+
+		obligations++
+		punctuality += series.Punctuality * series.MonthlyAmount()
+		weight += series.MonthlyAmount()
+	}
+
+	if obligations == 0 || weight <= 0 {
+		return 0
 	}
 
-	return math.Min(float64(recurringPayments*20), 100)
+	return float64(min(obligations, 5))*10 + punctuality/weight*50
 }
 
-func (c *CreditScoreCalculator) calculateTransactionHabits() float64 {
+// calculateTransactionHabits rewards a varied use of the account and takes
+// off the share of spending that went on betting. Moving money to savings
+// says nothing about how the account is used and is left out.
+func (c *CreditScoreCalculator) calculateTransactionHabits(
+	windowed []models.Transaction, monthly MonthlySeries,
+) float64 {
 	typeCount := make(map[string]int)
-	for _, tx := range c.Transactions {
-		typeCount[tx.TransactionType]++
+	for _, tx := range windowed {
+		if tx.Category != categories.Savings {
+			typeCount[tx.TransactionType]++
+		}
 	}
-	return math.Min(float64(len(typeCount))*25, 100)
+
+	habits := float64(len(typeCount)) * 25
+	if expenses := monthly.TotalExpenses(); expenses > 0 {
+		habits -= monthly.TotalSpending(categories.Betting) / expenses * 100
+	}
+	return habits
 }
 
-func (c *CreditScoreCalculator) calculateCreditHistory(first, last time.Time) float64 {
+// calculateCreditHistory measures the length of the whole history, not just
+// the scoring window, since a long track record is itself a signal.
+func (c *CreditScoreCalculator) calculateCreditHistory() float64 {
+	first := c.Transactions[0].TransactionDate
+	last := c.Transactions[len(c.Transactions)-1].TransactionDate
+
 	days := last.Sub(first).Hours() / 24
-	return math.Min(float64(days)/365*100, 100)
+	return days / 365 * 100
 }
 
-func (c *CreditScoreCalculator) normalizeScore(raw float64) float64 {
-	return (raw/100)*(c.ScoreRange.Max-c.ScoreRange.Min) + c.ScoreRange.Min
+// boundFeature clamps a feature to [0, 100] and maps NaN to zero so that a
+// single degenerate input cannot poison the whole score.
+func boundFeature(value float64) float64 {
+	if math.IsNaN(value) {
+		return 0
+	}
+	return math.Max(0, math.Min(value, 100))
 }
 
 func average(nums []float64) float64 {
+	if len(nums) == 0 {
+		return 0
+	}
+
 	sum := 0.0
 	for _, n := range nums {
 		sum += n
@@ -0,0 +1,415 @@
+package service
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"lumon-backend/internal/categories"
+	"lumon-backend/internal/domain/models"
+	"lumon-backend/internal/domain/schemas"
+	"lumon-backend/internal/recurrence"
+	"lumon-backend/internal/statements"
+)
+
+func date(year int, month time.Month, day int) time.Time {
+	return time.Date(year, month, day, 12, 0, 0, 0, time.UTC)
+}
+
+func cashIn(at time.Time, amount float64) models.Transaction {
+	return models.Transaction{TransactionDate: at, TransactionType: statements.TypeCashIn, Amount: amount}
+}
+
+func cashOut(at time.Time, amount float64) models.Transaction {
+	return models.Transaction{TransactionDate: at, TransactionType: statements.TypeCashOut, Amount: amount}
+}
+
+// monthlyHistory gives months of a salary paid in on the 1st and a few
+// payments out during the month, starting with the month of start.
+func monthlyHistory(start time.Time, months int, salary, spent float64) []models.Transaction {
+	var history []models.Transaction
+	for i := 0; i < months; i++ {
+		month := start.AddDate(0, i, 0)
+		history = append(history,
+			cashIn(month, salary),
+			cashOut(month.AddDate(0, 0, 5), spent/2),
+			cashOut(month.AddDate(0, 0, 15), spent/2),
+		)
+	}
+	return history
+}
+
+func TestCreditScoreCalculatorEvaluate(t *testing.T) {
+	jan := date(2024, time.January, 1)
+
+	tests := []struct {
+		name         string
+		transactions []models.Transaction
+		wantStatus   string
+		wantCount    int
+		wantActive   int
+	}{
+		{
+			name:       "no transactions",
+			wantStatus: CreditScoreStatusInsufficientData,
+		},
+		{
+			name:         "too few transactions",
+			transactions: monthlyHistory(jan, 3, 1000, 500)[:MinScoringTransactions-1],
+			wantStatus:   CreditScoreStatusInsufficientData,
+			wantCount:    MinScoringTransactions - 1,
+			wantActive:   3,
+		},
+		{
+			name: "a single active month",
+			transactions: func() []models.Transaction {
+				var history []models.Transaction
+				for day := 1; day <= 12; day++ {
+					history = append(history, cashOut(date(2024, time.March, day), 10))
+				}
+				return history
+			}(),
+			wantStatus: CreditScoreStatusInsufficientData,
+			wantCount:  12,
+			wantActive: 1,
+		},
+		{
+			name:         "steady salary",
+			transactions: monthlyHistory(jan, 6, 1000, 600),
+			wantStatus:   CreditScoreStatusScored,
+			wantCount:    18,
+			wantActive:   6,
+		},
+		{
+			name:         "no income",
+			transactions: monthlyHistory(jan, 6, 0, 600),
+			wantStatus:   CreditScoreStatusScored,
+			wantCount:    18,
+			wantActive:   6,
+		},
+		{
+			name:         "history longer than the window",
+			transactions: monthlyHistory(jan, 18, 1000, 600),
+			wantStatus:   CreditScoreStatusScored,
+			wantCount:    DefaultScoringWindowMonths * 3,
+			wantActive:   DefaultScoringWindowMonths,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calculator := NewCreditScoreCalculator(tt.transactions)
+			result := calculator.Evaluate()
+
+			if result.Status != tt.wantStatus {
+				t.Fatalf("status = %q, want %q", result.Status, tt.wantStatus)
+			}
+			if result.TransactionCount != tt.wantCount {
+				t.Errorf("transaction count = %d, want %d", result.TransactionCount, tt.wantCount)
+			}
+			if result.ActiveMonths != tt.wantActive {
+				t.Errorf("active months = %d, want %d", result.ActiveMonths, tt.wantActive)
+			}
+
+			if !result.Scored() {
+				if result.Score != 0 {
+					t.Errorf("score = %v for insufficient data, want 0", result.Score)
+				}
+				return
+			}
+
+			profile := calculator.Profile
+			if math.IsNaN(result.Score) || result.Score < profile.ScoreMin || result.Score > profile.ScoreMax {
+				t.Errorf("score = %v, want within [%v, %v]", result.Score, profile.ScoreMin, profile.ScoreMax)
+			}
+			for name, value := range map[string]float64{
+				"payment behavior":   result.Features.PaymentBehavior,
+				"income stability":   result.Features.IncomeStability,
+				"cash flow":          result.Features.CashFlow,
+				"transaction habits": result.Features.TransactionHabits,
+				"credit history":     result.Features.CreditHistory,
+			} {
+				if math.IsNaN(value) || value < 0 || value > 100 {
+					t.Errorf("%s = %v, want within [0, 100]", name, value)
+				}
+			}
+		})
+	}
+}
+
+func TestBuildMonthlySeriesKeepsYearsApart(t *testing.T) {
+	transactions := []models.Transaction{
+		cashIn(date(2023, time.January, 10), 100),
+		cashIn(date(2024, time.January, 10), 300),
+	}
+
+	series := BuildMonthlySeries(transactions, YearMonthOf(transactions[0].TransactionDate), YearMonthOf(transactions[1].TransactionDate))
+
+	if len(series) != 13 {
+		t.Fatalf("months = %d, want 13", len(series))
+	}
+	if series[0].Income != 100 || series[12].Income != 300 {
+		t.Errorf("January incomes = %v and %v, want 100 and 300", series[0].Income, series[12].Income)
+	}
+	if active := series.ActiveMonths(); active != 2 {
+		t.Errorf("active months = %d, want 2", active)
+	}
+}
+
+func TestCreditScoreCalculatorFeatures(t *testing.T) {
+	jan := date(2024, time.January, 1)
+
+	tests := []struct {
+		name         string
+		transactions []models.Transaction
+		feature      func(*CreditScoreCalculator, MonthlySeries) float64
+		want         float64
+	}{
+		{
+			name: "cash flow without income",
+			feature: func(c *CreditScoreCalculator, monthly MonthlySeries) float64 {
+				return c.calculateCashFlow(monthly)
+			},
+			transactions: monthlyHistory(jan, 3, 0, 300),
+			want:         0,
+		},
+		{
+			name: "cash flow keeping half of income",
+			feature: func(c *CreditScoreCalculator, monthly MonthlySeries) float64 {
+				return c.calculateCashFlow(monthly)
+			},
+			transactions: monthlyHistory(jan, 3, 1000, 500),
+			want:         50,
+		},
+		{
+			name: "income stability without income",
+			feature: func(c *CreditScoreCalculator, monthly MonthlySeries) float64 {
+				return c.calculateIncomeStability(monthly, nil)
+			},
+			transactions: monthlyHistory(jan, 3, 0, 300),
+			want:         0,
+		},
+		{
+			name: "credit history of a year",
+			feature: func(c *CreditScoreCalculator, _ MonthlySeries) float64 {
+				return c.calculateCreditHistory()
+			},
+			transactions: []models.Transaction{cashIn(jan, 10), cashIn(jan.AddDate(0, 0, 365), 10)},
+			want:         100,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calculator := NewCreditScoreCalculator(tt.transactions)
+			start, end := calculator.window()
+			monthly := BuildMonthlySeries(calculator.Transactions, start, end)
+
+			got := tt.feature(calculator, monthly)
+			if math.Abs(got-tt.want) > 1e-9 {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// payments gives months of payments of amount in category to one number,
+// on the same day each month.
+func payments(start time.Time, months int, amount float64, category, number string) []models.Transaction {
+	var history []models.Transaction
+	for i := 0; i < months; i++ {
+		payment := cashOut(start.AddDate(0, i, 0), amount)
+		payment.ToNumber = number
+		payment.Category = category
+		history = append(history, payment)
+	}
+	return history
+}
+
+func TestCalculatePaymentBehavior(t *testing.T) {
+	jan := date(2024, time.January, 3)
+
+	tests := []struct {
+		name         string
+		transactions []models.Transaction
+		wantPositive bool
+	}{
+		{
+			name:         "rent",
+			transactions: payments(jan, 6, 800, categories.Rent, "0241000001"),
+			wantPositive: true,
+		},
+		{
+			name:         "utilities",
+			transactions: payments(jan, 6, 120, categories.Utilities, "0241000002"),
+			wantPositive: true,
+		},
+		{
+			name:         "loan repayments",
+			transactions: payments(jan, 6, 300, categories.Loan, "0241000003"),
+			wantPositive: true,
+		},
+		{
+			name:         "school fees",
+			transactions: payments(jan, 6, 500, categories.Education, "0241000004"),
+			wantPositive: true,
+		},
+		{
+			name:         "betting",
+			transactions: payments(jan, 6, 50, categories.Betting, "0241000005"),
+		},
+		{
+			name:         "transfers",
+			transactions: payments(jan, 6, 200, categories.Transfer, "0241000006"),
+		},
+		{
+			name:         "uncategorised",
+			transactions: payments(jan, 6, 200, "", "0241000007"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calculator := NewCreditScoreCalculator(tt.transactions)
+			last := tt.transactions[len(tt.transactions)-1].TransactionDate
+			recurring := recurrence.Detect(calculator.Transactions, last)
+			if len(recurring) != 1 {
+				t.Fatalf("recurring series = %d, want 1", len(recurring))
+			}
+
+			got := calculator.calculatePaymentBehavior(recurring)
+			if tt.wantPositive && got <= 0 {
+				t.Errorf("payment behavior = %v, want above 0", got)
+			}
+			if !tt.wantPositive && got != 0 {
+				t.Errorf("payment behavior = %v, want 0", got)
+			}
+		})
+	}
+}
+
+func TestCalculatePaymentBehaviorRewardsPunctuality(t *testing.T) {
+	punctual := []recurrence.Series{{Category: categories.Rent, Period: "monthly", ExpectedAmount: 800, Punctuality: 1}}
+	late := []recurrence.Series{{Category: categories.Rent, Period: "monthly", ExpectedAmount: 800, Punctuality: 0.5}}
+	calculator := NewCreditScoreCalculator(nil)
+
+	if got, want := calculator.calculatePaymentBehavior(punctual), 60.0; got != want {
+		t.Errorf("punctual rent = %v, want %v", got, want)
+	}
+	if got, want := calculator.calculatePaymentBehavior(late), 35.0; got != want {
+		t.Errorf("late rent = %v, want %v", got, want)
+	}
+}
+
+func TestCalculateIncomeStabilityRewardsRecurringIncome(t *testing.T) {
+	jan := date(2024, time.January, 1)
+
+	salary := monthlyHistory(jan, 6, 1000, 600)
+	for i := range salary {
+		if salary[i].TransactionType == statements.TypeCashIn {
+			salary[i].FromNumber = "0200000001"
+			salary[i].Category = categories.Salary
+		}
+	}
+
+	calculator := NewCreditScoreCalculator(salary)
+	start, end := calculator.window()
+	monthly := BuildMonthlySeries(calculator.Transactions, start, end)
+	recurring := recurrence.Detect(calculator.Transactions, calculator.Transactions[len(salary)-1].TransactionDate)
+
+	without := calculator.calculateIncomeStability(monthly, nil)
+	with := calculator.calculateIncomeStability(monthly, recurring)
+	if with <= without {
+		t.Errorf("income stability with a salary series = %v, want above %v", with, without)
+	}
+}
+
+func TestCategoriesInScoring(t *testing.T) {
+	jan := date(2024, time.January, 1)
+
+	t.Run("loan disbursements are not income", func(t *testing.T) {
+		disbursement := cashIn(jan.AddDate(0, 0, 2), 5000)
+		disbursement.Category = categories.Loan
+		uncategorised := cashIn(jan.AddDate(0, 0, 2), 5000)
+
+		series := BuildMonthlySeries([]models.Transaction{disbursement, uncategorised}, YearMonthOf(jan), YearMonthOf(jan))
+		if got := series.TotalIncome(); got != 5000 {
+			t.Errorf("income = %v, want 5000", got)
+		}
+	})
+
+	t.Run("spending is broken down by category", func(t *testing.T) {
+		bet := cashOut(jan.AddDate(0, 0, 4), 40)
+		bet.Category = categories.Betting
+		bet.Fees = 1
+		groceries := cashOut(jan.AddDate(0, 0, 5), 60)
+		groceries.Category = categories.Groceries
+
+		series := BuildMonthlySeries([]models.Transaction{bet, groceries, cashOut(jan, 10)}, YearMonthOf(jan), YearMonthOf(jan))
+		if got := series.TotalSpending(categories.Betting); got != 41 {
+			t.Errorf("betting = %v, want 41", got)
+		}
+		if got := series.TotalExpenses(); got != 111 {
+			t.Errorf("expenses = %v, want 111", got)
+		}
+	})
+
+	t.Run("betting takes off transaction habits", func(t *testing.T) {
+		history := monthlyHistory(jan, 3, 1000, 600)
+		calculator := NewCreditScoreCalculator(history)
+		start, end := calculator.window()
+		base := calculator.calculateTransactionHabits(calculator.Transactions, BuildMonthlySeries(calculator.Transactions, start, end))
+
+		for i := range history {
+			if history[i].TransactionType == statements.TypeCashOut {
+				history[i].Category = categories.Betting
+				break
+			}
+		}
+		calculator = NewCreditScoreCalculator(history)
+		betting := calculator.calculateTransactionHabits(calculator.Transactions, BuildMonthlySeries(calculator.Transactions, start, end))
+
+		// One of six equal payments went on betting.
+		if want := base - 100.0/6; math.Abs(betting-want) > 1e-9 {
+			t.Errorf("transaction habits = %v, want %v", betting, want)
+		}
+	})
+}
+
+func TestSimulateCreditScoreDoesNotScoreThinFiles(t *testing.T) {
+	jan := date(2024, time.January, 1)
+	scenario := schemas.CreditScoreSimulationDetails{ExtraMonthlyIncome: 500, MonthlySavingsTransfer: 100}
+
+	// Nine transactions over three months is below MinScoringTransactions;
+	// the synthetic income and savings must not make up the difference.
+	thin := SimulateCreditScore(monthlyHistory(jan, 3, 1000, 600), DefaultScoringProfile(), HeuristicScoringModel{}, scenario)
+	if thin.Projected.Status != CreditScoreStatusInsufficientData {
+		t.Errorf("thin file projected status = %q, want %q", thin.Projected.Status, CreditScoreStatusInsufficientData)
+	}
+
+	scored := SimulateCreditScore(monthlyHistory(jan, 6, 1000, 600), DefaultScoringProfile(), HeuristicScoringModel{}, scenario)
+	if scored.Baseline.Status != CreditScoreStatusScored || scored.Projected.Status != CreditScoreStatusScored {
+		t.Errorf("statuses = %q, %q, want both scored", scored.Baseline.Status, scored.Projected.Status)
+	}
+}
+
+func TestSimulateCreditScoreSavingsDoNotLowerTheScore(t *testing.T) {
+	jan := date(2024, time.January, 1)
+	history := monthlyHistory(jan, 6, 1000, 600)
+
+	for _, saved := range []float64{100, 300} {
+		scenario := schemas.CreditScoreSimulationDetails{MonthlySavingsTransfer: saved}
+		simulation := SimulateCreditScore(history, DefaultScoringProfile(), HeuristicScoringModel{}, scenario)
+
+		baseline, projected := simulation.Baseline.Features, simulation.Projected.Features
+		if projected.CashFlow < baseline.CashFlow {
+			t.Errorf("saving %v: cash flow = %v, was %v", saved, projected.CashFlow, baseline.CashFlow)
+		}
+		if projected.TransactionHabits != baseline.TransactionHabits {
+			t.Errorf("saving %v: transaction habits = %v, was %v", saved, projected.TransactionHabits, baseline.TransactionHabits)
+		}
+		if simulation.ScoreDelta < 0 {
+			t.Errorf("saving %v: score delta = %v", saved, simulation.ScoreDelta)
+		}
+	}
+}
@@ -0,0 +1,172 @@
+package service
+
+import (
+	"strings"
+
+	"lumon-backend/internal/categories"
+	"lumon-backend/internal/domain/models"
+	"lumon-backend/internal/domain/schemas"
+	"lumon-backend/internal/statements"
+)
+
+const (
+	simulatedIncomeSource  = "SIMULATED_INCOME"
+	simulatedSavingsTarget = "SIMULATED_SAVINGS"
+)
+
+type CreditScoreProjection struct {
+	Status        string              `json:"status"`
+	Score         float64             `json:"score"`
+	Features      CreditScoreFeatures `json:"features"`
+	ReasonCodes   []string            `json:"reason_codes"`
+	Contributions []ScoreContribution `json:"contributions"`
+}
+
+type ReasonCodeDelta struct {
+	ReasonCode    string  `json:"reason_code"`
+	Feature       string  `json:"feature"`
+	PointsBefore  float64 `json:"points_before"`
+	PointsAfter   float64 `json:"points_after"`
+	PointsDelta   float64 `json:"points_delta"`
+	AdverseBefore bool    `json:"adverse_before"`
+	AdverseAfter  bool    `json:"adverse_after"`
+	Resolved      bool    `json:"resolved"`
+}
+
+type CreditScoreSimulation struct {
+	Baseline         CreditScoreProjection `json:"baseline"`
+	Projected        CreditScoreProjection `json:"projected"`
+	ScoreDelta       float64               `json:"score_delta"`
+	ReasonCodeDeltas []ReasonCodeDelta     `json:"reason_code_deltas"`
+}
+
+// SimulateCreditScore scores the transactions as they are and again with the
+// hypothetical changes applied. Whether there is enough data to score is
+// decided on the user's own transactions alone, so that synthetic income or
+// savings cannot lift a thin file into a score. Nothing is persisted.
+func SimulateCreditScore(
+	transactions []models.Transaction,
+	profile *models.ScoringProfile,
+	model ScoringModel,
+	scenario schemas.CreditScoreSimulationDetails,
+) CreditScoreSimulation {
+	baselineCalculator := NewCreditScoreCalculatorWithModel(transactions, profile, model)
+	baseline := baselineCalculator.Evaluate()
+
+	remaining := removePayees(baselineCalculator.Transactions, scenario)
+	projectedCalculator := NewCreditScoreCalculatorWithModel(remaining, profile, model)
+	projected := projectedCalculator.Evaluate()
+	if synthetic := scenarioTransactions(baselineCalculator, scenario); projected.Scored() && len(synthetic) > 0 {
+		projectedCalculator = NewCreditScoreCalculatorWithModel(append(remaining, synthetic...), profile, model)
+		projected = projectedCalculator.Evaluate()
+	}
+
+	simulation := CreditScoreSimulation{
+		Baseline:  newCreditScoreProjection(baselineCalculator, baseline),
+		Projected: newCreditScoreProjection(projectedCalculator, projected),
+	}
+	simulation.ScoreDelta = roundPoints(projected.Score - baseline.Score)
+	simulation.ReasonCodeDeltas = reasonCodeDeltas(simulation.Baseline.Contributions, simulation.Projected.Contributions)
+
+	return simulation
+}
+
+func newCreditScoreProjection(calculator *CreditScoreCalculator, result CreditScoreResult) CreditScoreProjection {
+	projection := CreditScoreProjection{
+		Status:      result.Status,
+		Score:       result.Score,
+		Features:    result.Features,
+		ReasonCodes: calculator.ReasonCodes(result),
+	}
+	if result.Scored() {
+		projection.Contributions = calculator.Contributions(result.Features)
+	}
+	return projection
+}
+
+// removePayees returns a copy of the transactions without those to the
+// scenario's payees.
+func removePayees(transactions []models.Transaction, scenario schemas.CreditScoreSimulationDetails) []models.Transaction {
+	removed := make(map[string]bool)
+	for _, payee := range scenario.RemovePayees {
+		if payee = strings.ToLower(strings.TrimSpace(payee)); payee != "" {
+			removed[payee] = true
+		}
+	}
+
+	var remaining []models.Transaction
+	for _, tx := range transactions {
+		if removed[strings.ToLower(tx.ToNumber)] || removed[strings.ToLower(tx.ToName)] {
+			continue
+		}
+		remaining = append(remaining, tx)
+	}
+
+	return remaining
+}
+
+// scenarioTransactions returns one synthetic income and savings transaction
+// for every month of the calculator's scoring window.
+func scenarioTransactions(calculator *CreditScoreCalculator, scenario schemas.CreditScoreSimulationDetails) []models.Transaction {
+	if len(calculator.Transactions) == 0 {
+		return nil
+	}
+
+	var simulated []models.Transaction
+
+	start, end := calculator.window()
+	for ym := start; !end.Before(ym); ym = ym.AddMonths(1) {
+		date := ym.Start()
+
+		if scenario.ExtraMonthlyIncome > 0 {
+			simulated = append(simulated, models.Transaction{
+				TransactionDate: date,
+				TransactionType: statements.TypeCashIn,
+				Amount:          scenario.ExtraMonthlyIncome,
+				FromName:        simulatedIncomeSource,
+				FromNumber:      simulatedIncomeSource,
+			})
+		}
+
+		if scenario.MonthlySavingsTransfer > 0 {
+			simulated = append(simulated, models.Transaction{
+				TransactionDate: date,
+				TransactionType: statements.TypeTransfer,
+				Category:        categories.Savings,
+				Amount:          scenario.MonthlySavingsTransfer,
+				ToName:          simulatedSavingsTarget,
+				ToNumber:        simulatedSavingsTarget,
+			})
+		}
+	}
+
+	return simulated
+}
+
+func reasonCodeDeltas(before, after []ScoreContribution) []ReasonCodeDelta {
+	afterByCode := make(map[string]ScoreContribution)
+	for _, contribution := range after {
+		afterByCode[contribution.ReasonCode] = contribution
+	}
+
+	deltas := []ReasonCodeDelta{}
+	for _, b := range before {
+		a, ok := afterByCode[b.ReasonCode]
+		if !ok {
+			continue
+		}
+
+		deltas = append(deltas, ReasonCodeDelta{
+			ReasonCode:    b.ReasonCode,
+			Feature:       b.Feature,
+			PointsBefore:  b.Points,
+			PointsAfter:   a.Points,
+			PointsDelta:   roundPoints(a.Points - b.Points),
+			AdverseBefore: b.Adverse,
+			AdverseAfter:  a.Adverse,
+			Resolved:      b.Adverse && !a.Adverse,
+		})
+	}
+
+	return deltas
+}
@@ -1,6 +1,10 @@
 package service
 
 import (
+	"errors"
+	"fmt"
+	"strings"
+
 	"lumon-backend/internal/domain/models"
 	"lumon-backend/internal/repository/interfaces"
 	"lumon-backend/pkg/common/logger"
@@ -8,12 +12,28 @@ import (
 	"github.com/google/uuid"
 )
 
+const (
+	LoanRequestStatusPending  = "pending"
+	LoanRequestStatusApproved = "approved"
+	LoanRequestStatusRejected = "rejected"
+)
+
+// ErrLoanRequestFlagged is returned when a loan is approved for a borrower
+// whose transactions have medium or high severity flags that have not been
+// reviewed yet.
+var ErrLoanRequestFlagged = errors.New("borrower has transaction flags awaiting review")
+
+// ErrInvalidLoanRequestStatus is returned when a loan request is moved to a
+// status other than pending, approved or rejected.
+var ErrInvalidLoanRequestStatus = errors.New("invalid loan request status")
+
 type LoanRequestService struct {
-	repo interfaces.LoanRequestRepository
+	repo           interfaces.LoanRequestRepository
+	anomalyService *AnomalyService
 }
 
-func NewLoanRequestService(repo interfaces.LoanRequestRepository) *LoanRequestService {
-	return &LoanRequestService{repo: repo}
+func NewLoanRequestService(repo interfaces.LoanRequestRepository, anomalyService *AnomalyService) *LoanRequestService {
+	return &LoanRequestService{repo: repo, anomalyService: anomalyService}
 }
 
 func (s *LoanRequestService) CreateLoanRequest(loanRequest *models.LoanRequest) error {
@@ -68,7 +88,33 @@ func (s *LoanRequestService) ListLoanRequests(page, pageSize int) ([]models.Loan
 	return dbLoanRequests, total, nil
 }
 
+// UpdateLoanRequestStatus moves a loan request to the given status, matched
+// without regard to case. Approving it is refused while the borrower has flags
+// awaiting review.
 func (s *LoanRequestService) UpdateLoanRequestStatus(id string, status string) error {
+	status = strings.ToLower(strings.TrimSpace(status))
+	switch status {
+	case LoanRequestStatusPending, LoanRequestStatusApproved, LoanRequestStatusRejected:
+	default:
+		return fmt.Errorf("%w: %q", ErrInvalidLoanRequestStatus, status)
+	}
+
+	if status == LoanRequestStatusApproved {
+		loanRequest, err := s.repo.GetByID(uuid.MustParse(id))
+		if err != nil {
+			logger.APILogger.Error(err)
+			return err
+		}
+
+		flagged, err := s.anomalyService.HasBlockingFlags(loanRequest.BorrowerID)
+		if err != nil {
+			return err
+		}
+		if flagged {
+			return ErrLoanRequestFlagged
+		}
+	}
+
 	err := s.repo.UpdateStatus(uuid.MustParse(id), status)
 	if err != nil {
 		logger.APILogger.Error(err)
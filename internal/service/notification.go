@@ -0,0 +1,126 @@
+package service
+
+import (
+	"errors"
+	"time"
+
+	"lumon-backend/internal/domain/models"
+	"lumon-backend/internal/domain/schemas"
+	"lumon-backend/internal/repository/interfaces"
+	"lumon-backend/pkg/common/logger"
+
+	"github.com/google/uuid"
+)
+
+var ErrNotificationNotFound = errors.New("notification not found")
+
+const (
+	defaultNotificationPageSize = 20
+	maxNotificationPageSize     = 100
+)
+
+// NotificationService delivers notifications to users' inboxes, where they
+// stay until read.
+type NotificationService struct {
+	repo interfaces.NotificationRepository
+}
+
+func NewNotificationService(repo interfaces.NotificationRepository) *NotificationService {
+	return &NotificationService{repo: repo}
+}
+
+// Notify delivers the notifications.
+func (s *NotificationService) Notify(notifications ...*models.Notification) error {
+	if err := s.repo.Create(notifications); err != nil {
+		logger.APILogger.Error(err)
+		return err
+	}
+
+	logNotifications(notifications)
+	return nil
+}
+
+// logNotifications records the delivery of notifications stored, by Notify
+// or together with what they are about.
+func logNotifications(notifications []*models.Notification) {
+	for _, notification := range notifications {
+		logger.APILogger.Infow("notification sent",
+			"user_id", notification.UserID,
+			"kind", notification.Kind,
+			"notification_id", notification.ID,
+		)
+	}
+}
+
+// ListNotifications returns a page of the user's notifications, newest
+// first, with how many match the request and how many are unread.
+func (s *NotificationService) ListNotifications(
+	userID string, req schemas.NotificationListRequest,
+) ([]models.Notification, int64, int64, error) {
+	query := interfaces.NotificationQuery{
+		UserID:     uuid.MustParse(userID),
+		UnreadOnly: req.Unread,
+		Page:       req.Page,
+		PageSize:   req.PageSize,
+	}
+
+	if query.Page < 1 {
+		query.Page = 1
+	}
+	switch {
+	case query.PageSize <= 0:
+		query.PageSize = defaultNotificationPageSize
+	case query.PageSize > maxNotificationPageSize:
+		query.PageSize = maxNotificationPageSize
+	}
+
+	notifications, total, err := s.repo.List(query)
+	if err != nil {
+		logger.APILogger.Error(err)
+		return nil, 0, 0, err
+	}
+
+	unread, err := s.repo.CountUnread(query.UserID)
+	if err != nil {
+		logger.APILogger.Error(err)
+		return nil, 0, 0, err
+	}
+
+	return notifications, total, unread, nil
+}
+
+// MarkRead marks one of the user's notifications as read. Another user's
+// notification is reported as not found.
+func (s *NotificationService) MarkRead(userID, id string) (*models.Notification, error) {
+	notification, err := s.repo.GetByID(uuid.MustParse(id))
+	if err != nil {
+		logger.APILogger.Error(err)
+		return nil, ErrNotificationNotFound
+	}
+
+	if notification.UserID != uuid.MustParse(userID) {
+		return nil, ErrNotificationNotFound
+	}
+	if notification.ReadAt != nil {
+		return notification, nil
+	}
+
+	now := time.Now()
+	if err := s.repo.MarkRead(notification.UserID, []uuid.UUID{notification.ID}, now); err != nil {
+		logger.APILogger.Error(err)
+		return nil, err
+	}
+
+	notification.ReadAt = &now
+	return notification, nil
+}
+
+// MarkAllRead marks all of the user's notifications as read.
+func (s *NotificationService) MarkAllRead(userID string) error {
+	if err := s.repo.MarkRead(uuid.MustParse(userID), nil, time.Now()); err != nil {
+		logger.APILogger.Error(err)
+		return err
+	}
+
+	return nil
+}
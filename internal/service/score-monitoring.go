@@ -0,0 +1,474 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"lumon-backend/internal/domain/models"
+	"lumon-backend/internal/domain/schemas"
+	"lumon-backend/internal/repository/interfaces"
+	"lumon-backend/pkg/common/logger"
+
+	"github.com/google/uuid"
+)
+
+const (
+	MonitoringTriggerScheduled = "scheduled"
+	MonitoringTriggerManual    = "manual"
+)
+
+const (
+	DriftStatusBaseline    = "baseline"
+	DriftStatusStable      = "stable"
+	DriftStatusModerate    = "moderate"
+	DriftStatusSignificant = "significant"
+)
+
+// The usual PSI rules of thumb: below 0.1 the population is stable, above
+// 0.25 it has shifted enough to need investigating.
+const (
+	psiModerateThreshold    = 0.1
+	psiSignificantThreshold = 0.25
+)
+
+const (
+	monitoringScoreBinCount   = 10
+	monitoringFeatureBinCount = 10
+	monitoringApprovalBands   = 5
+
+	// psiMinShare stands in for empty bins so that the index stays finite.
+	psiMinShare = 0.0001
+
+	unknownCohort = "unknown"
+)
+
+// ScoreMonitoringService reports on how the score and feature distributions
+// move over time and how scores and approvals differ between cohorts, so that
+// a shift in the statement-extraction pipeline shows up before it skews
+// lending decisions.
+type ScoreMonitoringService struct {
+	repo         interfaces.ScoreMonitoringRepository
+	profileRepo  interfaces.ScoringProfileRepository
+	scheduleHour int
+}
+
+func NewScoreMonitoringService(
+	repo interfaces.ScoreMonitoringRepository,
+	profileRepo interfaces.ScoringProfileRepository,
+	scheduleHour int,
+) *ScoreMonitoringService {
+	return &ScoreMonitoringService{
+		repo:         repo,
+		profileRepo:  profileRepo,
+		scheduleHour: scheduleHour,
+	}
+}
+
+// Start saves a report once a day at the configured UTC hour until ctx is
+// cancelled.
+func (s *ScoreMonitoringService) Start(ctx context.Context) {
+	go func() {
+		for {
+			timer := time.NewTimer(time.Until(nextNightlyRun(time.Now().UTC(), s.scheduleHour)))
+
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+				return
+			case <-timer.C:
+				if _, err := s.SaveReport(MonitoringTriggerScheduled); err != nil {
+					logger.APILogger.Errorf("Scheduled score monitoring report failed: %v", err)
+				}
+			}
+		}
+	}()
+}
+
+// GenerateReport builds a report of the current population against the
+// baseline without saving it.
+func (s *ScoreMonitoringService) GenerateReport() (*schemas.ScoreMonitoringReport, error) {
+	baseline, err := s.loadBaseline()
+	if err != nil {
+		return nil, err
+	}
+
+	return s.buildReport(baseline)
+}
+
+// SaveReport builds and stores a report. The first report ever saved becomes
+// the baseline the later ones are compared against.
+func (s *ScoreMonitoringService) SaveReport(trigger string) (*schemas.ScoreMonitoringReport, error) {
+	baseline, err := s.loadBaseline()
+	if err != nil {
+		return nil, err
+	}
+
+	report, err := s.buildReport(baseline)
+	if err != nil {
+		return nil, err
+	}
+
+	payload, err := json.Marshal(report)
+	if err != nil {
+		logger.APILogger.Error(err)
+		return nil, err
+	}
+
+	record := &models.ScoreMonitoringReport{
+		Trigger:     trigger,
+		Population:  report.Population,
+		ScorePSI:    report.Score.PSI,
+		DriftStatus: report.Score.Status,
+		IsBaseline:  baseline == nil,
+		BaselineID:  report.BaselineID,
+		Payload:     string(payload),
+	}
+	if err := s.repo.Create(record); err != nil {
+		logger.APILogger.Error(err)
+		return nil, err
+	}
+
+	if drifted := driftedDistributions(report); len(drifted) > 0 {
+		logger.APILogger.Warnw("credit score inputs drifted from baseline",
+			"report_id", record.ID,
+			"baseline_id", report.BaselineID,
+			"distributions", drifted,
+		)
+	}
+
+	return decodeMonitoringReport(record)
+}
+
+func (s *ScoreMonitoringService) GetReport(id string) (*schemas.ScoreMonitoringReport, error) {
+	record, err := s.repo.GetByID(uuid.MustParse(id))
+	if err != nil {
+		logger.APILogger.Error(err)
+		return nil, err
+	}
+
+	return decodeMonitoringReport(record)
+}
+
+func (s *ScoreMonitoringService) ListReports(page, pageSize int) ([]models.ScoreMonitoringReport, int64, error) {
+	reports, total, err := s.repo.List(page, pageSize)
+	if err != nil {
+		logger.APILogger.Error(err)
+		return nil, 0, err
+	}
+
+	return reports, total, nil
+}
+
+// SetBaseline makes a saved report the one later reports are compared against.
+func (s *ScoreMonitoringService) SetBaseline(id string) error {
+	if err := s.repo.SetBaseline(uuid.MustParse(id)); err != nil {
+		logger.APILogger.Error(err)
+		return err
+	}
+
+	return nil
+}
+
+func (s *ScoreMonitoringService) loadBaseline() (*schemas.ScoreMonitoringReport, error) {
+	record, err := s.repo.GetBaseline()
+	if err != nil {
+		logger.APILogger.Error(err)
+		return nil, err
+	}
+
+	if record == nil {
+		return nil, nil
+	}
+
+	return decodeMonitoringReport(record)
+}
+
+func (s *ScoreMonitoringService) buildReport(baseline *schemas.ScoreMonitoringReport) (*schemas.ScoreMonitoringReport, error) {
+	subjects, err := s.repo.ListSubjects()
+	if err != nil {
+		logger.APILogger.Error(err)
+		return nil, err
+	}
+
+	decisions, err := s.repo.ListDecisions([]string{LoanRequestStatusApproved, LoanRequestStatusRejected})
+	if err != nil {
+		logger.APILogger.Error(err)
+		return nil, err
+	}
+
+	profile, err := loadActiveScoringProfile(s.profileRepo)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &schemas.ScoreMonitoringReport{
+		GeneratedAt: time.Now().UTC(),
+		Population:  len(subjects),
+		Cohorts:     scoreCohorts(subjects),
+	}
+	if baseline != nil {
+		report.BaselineID = baseline.ID
+	}
+
+	scores := make([]float64, len(subjects))
+	for i, subject := range subjects {
+		scores[i] = subject.Score
+	}
+
+	scoreEdges := equalWidthEdges(profile.ScoreMin, profile.ScoreMax, monitoringScoreBinCount)
+	var baselineScore *schemas.DistributionDrift
+	if baseline != nil {
+		baselineScore = &baseline.Score
+		scoreEdges = binEdges(baseline.Score.Bins)
+	}
+	report.Score = distributionDrift("score", scores, scoreEdges, baselineScore)
+
+	for _, feature := range scoringFeatures {
+		values := make([]float64, len(subjects))
+		for i, subject := range subjects {
+			values[i] = feature.value(subjectFeatures(subject))
+		}
+
+		edges := equalWidthEdges(0, 100, monitoringFeatureBinCount)
+		var baselineFeature *schemas.DistributionDrift
+		if baseline != nil {
+			for i := range baseline.Features {
+				if baseline.Features[i].Name == feature.name {
+					baselineFeature = &baseline.Features[i]
+					edges = binEdges(baselineFeature.Bins)
+				}
+			}
+		}
+
+		report.Features = append(report.Features, distributionDrift(feature.name, values, edges, baselineFeature))
+	}
+
+	report.ApprovalRates, report.UnscoredDecisions = bandApprovalRates(decisions, profile)
+
+	return report, nil
+}
+
+func decodeMonitoringReport(record *models.ScoreMonitoringReport) (*schemas.ScoreMonitoringReport, error) {
+	var report schemas.ScoreMonitoringReport
+	if err := json.Unmarshal([]byte(record.Payload), &report); err != nil {
+		logger.APILogger.Error(err)
+		return nil, fmt.Errorf("monitoring report %s is corrupt: %w", record.ID, err)
+	}
+
+	report.ID = &record.ID
+	report.Trigger = record.Trigger
+	report.IsBaseline = record.IsBaseline
+	report.BaselineID = record.BaselineID
+
+	return &report, nil
+}
+
+func subjectFeatures(subject models.ScoreMonitoringSubject) CreditScoreFeatures {
+	return CreditScoreFeatures{
+		PaymentBehavior:   subject.PaymentBehavior,
+		IncomeStability:   subject.IncomeStability,
+		CashFlow:          subject.CashFlow,
+		TransactionHabits: subject.TransactionHabits,
+		CreditHistory:     subject.CreditHistory,
+	}
+}
+
+// distributionDrift bins the values using the given edges and compares the
+// shares with the baseline's. Without a baseline the report is the baseline
+// and its PSI is zero.
+func distributionDrift(
+	name string, values []float64, edges []float64, baseline *schemas.DistributionDrift,
+) schemas.DistributionDrift {
+	drift := schemas.DistributionDrift{
+		Name:   name,
+		Mean:   roundPoints(average(values)),
+		Status: DriftStatusBaseline,
+		Bins:   histogram(values, edges),
+	}
+
+	if baseline == nil {
+		return drift
+	}
+
+	drift.PSI = populationStabilityIndex(baseline.Bins, drift.Bins)
+	drift.Status = driftStatus(drift.PSI)
+
+	return drift
+}
+
+func equalWidthEdges(lower, upper float64, count int) []float64 {
+	width := (upper - lower) / float64(count)
+
+	edges := make([]float64, count+1)
+	for i := range edges {
+		edges[i] = lower + float64(i)*width
+	}
+	return edges
+}
+
+func binEdges(bins []schemas.DistributionBin) []float64 {
+	if len(bins) == 0 {
+		return nil
+	}
+
+	edges := []float64{bins[0].Lower}
+	for _, bin := range bins {
+		edges = append(edges, bin.Upper)
+	}
+	return edges
+}
+
+// histogram counts the values falling in each bin. Values outside the edges
+// are counted in the first or last bin.
+func histogram(values []float64, edges []float64) []schemas.DistributionBin {
+	if len(edges) < 2 {
+		return []schemas.DistributionBin{}
+	}
+
+	bins := make([]schemas.DistributionBin, len(edges)-1)
+	for i := range bins {
+		bins[i].Lower = edges[i]
+		bins[i].Upper = edges[i+1]
+		bins[i].Label = fmt.Sprintf("%.0f-%.0f", edges[i], edges[i+1])
+	}
+
+	for _, value := range values {
+		i := sort.SearchFloat64s(edges[1:len(edges)-1], value)
+		if i < len(bins)-1 && value == edges[i+1] {
+			i++
+		}
+		bins[i].Count++
+	}
+
+	for i := range bins {
+		if len(values) > 0 {
+			bins[i].Share = float64(bins[i].Count) / float64(len(values))
+		}
+	}
+
+	return bins
+}
+
+func populationStabilityIndex(expected, actual []schemas.DistributionBin) float64 {
+	psi := 0.0
+	for i := range actual {
+		if i >= len(expected) {
+			break
+		}
+
+		e := math.Max(expected[i].Share, psiMinShare)
+		a := math.Max(actual[i].Share, psiMinShare)
+		psi += (a - e) * math.Log(a/e)
+	}
+
+	return math.Round(psi*10000) / 10000
+}
+
+func driftStatus(psi float64) string {
+	switch {
+	case psi >= psiSignificantThreshold:
+		return DriftStatusSignificant
+	case psi >= psiModerateThreshold:
+		return DriftStatusModerate
+	default:
+		return DriftStatusStable
+	}
+}
+
+func driftedDistributions(report *schemas.ScoreMonitoringReport) []string {
+	var drifted []string
+	for _, drift := range append([]schemas.DistributionDrift{report.Score}, report.Features...) {
+		if drift.Status == DriftStatusSignificant {
+			drifted = append(drifted, drift.Name)
+		}
+	}
+	return drifted
+}
+
+func scoreCohorts(subjects []models.ScoreMonitoringSubject) schemas.ScoreCohorts {
+	return schemas.ScoreCohorts{
+		SignupMonth: cohortScores(subjects, func(s models.ScoreMonitoringSubject) string {
+			if s.SignedUpAt == nil || s.SignedUpAt.IsZero() {
+				return unknownCohort
+			}
+			return YearMonthOf(*s.SignedUpAt).String()
+		}),
+		DocumentType: cohortScores(subjects, func(s models.ScoreMonitoringSubject) string {
+			return s.DocumentType
+		}),
+		Region: cohortScores(subjects, func(s models.ScoreMonitoringSubject) string {
+			return s.Region
+		}),
+	}
+}
+
+func cohortScores(
+	subjects []models.ScoreMonitoringSubject, cohortOf func(models.ScoreMonitoringSubject) string,
+) []schemas.CohortScore {
+	scores := make(map[string][]float64)
+	for _, subject := range subjects {
+		cohort := strings.TrimSpace(cohortOf(subject))
+		if cohort == "" {
+			cohort = unknownCohort
+		}
+		scores[cohort] = append(scores[cohort], subject.Score)
+	}
+
+	cohorts := make([]schemas.CohortScore, 0, len(scores))
+	for cohort, values := range scores {
+		cohorts = append(cohorts, schemas.CohortScore{
+			Cohort:    cohort,
+			Users:     len(values),
+			MeanScore: roundPoints(average(values)),
+		})
+	}
+
+	sort.Slice(cohorts, func(i, j int) bool { return cohorts[i].Cohort < cohorts[j].Cohort })
+	return cohorts
+}
+
+// bandApprovalRates splits the profile's score range into equal-width bands
+// and reports the share of decided loan requests approved in each. Decisions
+// made before the borrower was first scored are counted separately.
+func bandApprovalRates(
+	decisions []models.ScoreMonitoringDecision, profile *models.ScoringProfile,
+) ([]schemas.BandApprovalRate, int) {
+	edges := equalWidthEdges(profile.ScoreMin, profile.ScoreMax, monitoringApprovalBands)
+
+	bands := make([]schemas.BandApprovalRate, monitoringApprovalBands)
+	for i := range bands {
+		bands[i].MinScore = edges[i]
+		bands[i].MaxScore = edges[i+1]
+		bands[i].Label = fmt.Sprintf("%.0f-%.0f", edges[i], edges[i+1])
+	}
+
+	unscored := 0
+	width := edges[1] - edges[0]
+	for _, decision := range decisions {
+		if decision.Score == nil {
+			unscored++
+			continue
+		}
+
+		i := int((*decision.Score - profile.ScoreMin) / width)
+		i = max(0, min(i, monitoringApprovalBands-1))
+
+		bands[i].Decided++
+		if decision.Status == LoanRequestStatusApproved {
+			bands[i].Approved++
+		}
+	}
+
+	for i := range bands {
+		if bands[i].Decided > 0 {
+			bands[i].ApprovalRate = roundPoints(float64(bands[i].Approved) / float64(bands[i].Decided))
+		}
+	}
+
+	return bands, unscored
+}
@@ -0,0 +1,214 @@
+package service
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"sort"
+
+	"lumon-backend/internal/domain/models"
+)
+
+const (
+	ScoringModelHeuristic = "heuristic"
+	ScoringModelLogistic  = "logistic"
+)
+
+// ScoringModel turns a feature vector into a score within the profile's score
+// range, along with a per-feature explanation of how the score was reached.
+type ScoringModel interface {
+	Version() string
+	Score(features CreditScoreFeatures, profile *models.ScoringProfile) ScoreExplanation
+}
+
+type ScoreExplanation struct {
+	ModelVersion  string              `json:"model_version"`
+	Score         float64             `json:"score"`
+	Contributions []ScoreContribution `json:"contributions"`
+}
+
+// ReasonCodes lists the adverse reason codes, weakest contribution first.
+func (e ScoreExplanation) ReasonCodes() []string {
+	codes := []string{}
+	for _, contribution := range e.Contributions {
+		if contribution.Adverse {
+			codes = append(codes, contribution.ReasonCode)
+		}
+	}
+	return codes
+}
+
+type scoringFeature struct {
+	name       string
+	reasonCode string
+	value      func(CreditScoreFeatures) float64
+	weight     func(*models.ScoringProfile) float64
+}
+
+var scoringFeatures = []scoringFeature{
+	{
+		name:       "payment_behavior",
+		reasonCode: ReasonCodeLowPaymentBehavior,
+		value:      func(f CreditScoreFeatures) float64 { return f.PaymentBehavior },
+		weight:     func(p *models.ScoringProfile) float64 { return p.PaymentHistoryWeight },
+	},
+	{
+		name:       "income_stability",
+		reasonCode: ReasonCodeUnstableIncome,
+		value:      func(f CreditScoreFeatures) float64 { return f.IncomeStability },
+		weight:     func(p *models.ScoringProfile) float64 { return p.IncomeStabilityWeight },
+	},
+	{
+		name:       "cash_flow",
+		reasonCode: ReasonCodeWeakCashFlow,
+		value:      func(f CreditScoreFeatures) float64 { return f.CashFlow },
+		weight:     func(p *models.ScoringProfile) float64 { return p.CashFlowWeight },
+	},
+	{
+		name:       "transaction_habits",
+		reasonCode: ReasonCodeNarrowActivity,
+		value:      func(f CreditScoreFeatures) float64 { return f.TransactionHabits },
+		weight:     func(p *models.ScoringProfile) float64 { return p.TransactionHabitsWeight },
+	},
+	{
+		name:       "credit_history",
+		reasonCode: ReasonCodeShortHistory,
+		value:      func(f CreditScoreFeatures) float64 { return f.CreditHistory },
+		weight:     func(p *models.ScoringProfile) float64 { return p.CreditHistoryWeight },
+	},
+}
+
+func sortContributions(contributions []ScoreContribution) {
+	sort.SliceStable(contributions, func(i, j int) bool {
+		return contributions[i].Points < contributions[j].Points
+	})
+}
+
+// HeuristicScoringModel is the original weighted sum of features, using the
+// weights of the scoring profile.
+type HeuristicScoringModel struct{}
+
+func (HeuristicScoringModel) Version() string {
+	return CreditScoreModelVersion
+}
+
+func (m HeuristicScoringModel) Score(features CreditScoreFeatures, profile *models.ScoringProfile) ScoreExplanation {
+	scale := (profile.ScoreMax - profile.ScoreMin) / 100
+
+	explanation := ScoreExplanation{ModelVersion: m.Version(), Score: profile.ScoreMin}
+	for _, feature := range scoringFeatures {
+		value := feature.value(features)
+		points := value * feature.weight(profile) * scale
+
+		explanation.Score += points
+		explanation.Contributions = append(explanation.Contributions, ScoreContribution{
+			ReasonCode: feature.reasonCode,
+			Feature:    feature.name,
+			Value:      value,
+			Points:     roundPoints(points),
+			Adverse:    value < adverseFeatureThreshold,
+		})
+	}
+
+	sortContributions(explanation.Contributions)
+	return explanation
+}
+
+// LogisticScoringModel is a logistic regression trained offline. It predicts
+// the probability of repayment from features scaled to [0, 1] and maps that
+// probability linearly onto the profile's score range.
+type LogisticScoringModel struct {
+	ModelVersion string             `json:"version"`
+	Intercept    float64            `json:"intercept"`
+	Coefficients map[string]float64 `json:"coefficients"`
+	// Reference holds the feature values, on the 0-100 scale, of a typical
+	// borrower. A feature is adverse when it pulls the score below what the
+	// reference value would have given.
+	Reference map[string]float64 `json:"reference"`
+}
+
+func LoadLogisticScoringModel(path string) (*LogisticScoringModel, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var model LogisticScoringModel
+	if err := json.Unmarshal(data, &model); err != nil {
+		return nil, fmt.Errorf("invalid logistic model file %s: %w", path, err)
+	}
+
+	if model.ModelVersion == "" {
+		return nil, fmt.Errorf("logistic model file %s has no version", path)
+	}
+
+	known := make(map[string]bool)
+	for _, feature := range scoringFeatures {
+		known[feature.name] = true
+		if _, ok := model.Coefficients[feature.name]; !ok {
+			return nil, fmt.Errorf("logistic model file %s has no coefficient for %q", path, feature.name)
+		}
+	}
+	for name := range model.Coefficients {
+		if !known[name] {
+			return nil, fmt.Errorf("logistic model file %s has unknown feature %q", path, name)
+		}
+	}
+
+	return &model, nil
+}
+
+func (m *LogisticScoringModel) Version() string {
+	return m.ModelVersion
+}
+
+func (m *LogisticScoringModel) Score(features CreditScoreFeatures, profile *models.ScoringProfile) ScoreExplanation {
+	z := m.Intercept
+	for _, feature := range scoringFeatures {
+		z += m.Coefficients[feature.name] * feature.value(features) / 100
+	}
+
+	p := 1 / (1 + math.Exp(-z))
+	scoreRange := profile.ScoreMax - profile.ScoreMin
+
+	explanation := ScoreExplanation{
+		ModelVersion: m.Version(),
+		Score:        profile.ScoreMin + p*scoreRange,
+	}
+
+	// Contributions are the linearised effect on the score of moving each
+	// feature from its reference value to the borrower's value.
+	slope := p * (1 - p) * scoreRange
+	for _, feature := range scoringFeatures {
+		value := feature.value(features)
+		points := m.Coefficients[feature.name] * (value - m.Reference[feature.name]) / 100 * slope
+
+		explanation.Contributions = append(explanation.Contributions, ScoreContribution{
+			ReasonCode: feature.reasonCode,
+			Feature:    feature.name,
+			Value:      value,
+			Points:     roundPoints(points),
+			Adverse:    points < 0,
+		})
+	}
+
+	sortContributions(explanation.Contributions)
+	return explanation
+}
+
+// NewScoringModel builds the model named by kind. The path is only used by
+// models whose parameters live in a file.
+func NewScoringModel(kind, path string) (ScoringModel, error) {
+	switch kind {
+	case "", ScoringModelHeuristic:
+		return HeuristicScoringModel{}, nil
+	case ScoringModelLogistic:
+		if path == "" {
+			return nil, fmt.Errorf("scoring model %q requires a model file", kind)
+		}
+		return LoadLogisticScoringModel(path)
+	default:
+		return nil, fmt.Errorf("unknown scoring model %q", kind)
+	}
+}
@@ -0,0 +1,141 @@
+package service
+
+import (
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"lumon-backend/internal/domain/models"
+)
+
+// writeModel writes a logistic model file with the given coefficients.
+func writeModel(t *testing.T, coefficients string) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "model.json")
+	data := `{
+		"version": "logistic-test",
+		"intercept": -1,
+		"coefficients": {` + coefficients + `},
+		"reference": {"payment_behavior": 50}
+	}`
+	if err := os.WriteFile(path, []byte(data), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+const allCoefficients = `"payment_behavior": 2, "income_stability": 0, "cash_flow": 0,
+	"transaction_habits": 0, "credit_history": 0`
+
+func TestLoadLogisticScoringModel(t *testing.T) {
+	tests := []struct {
+		name         string
+		coefficients string
+		err          string
+	}{
+		{name: "every feature", coefficients: allCoefficients},
+		{
+			name:         "missing coefficient",
+			coefficients: `"payment_behavior": 2, "income_stability": 0, "cash_flow": 0, "transaction_habits": 0`,
+			err:          `no coefficient for "credit_history"`,
+		},
+		{
+			name:         "unknown coefficient",
+			coefficients: allCoefficients + `, "payment_behaviour": 1`,
+			err:          `unknown feature "payment_behaviour"`,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			model, err := LoadLogisticScoringModel(writeModel(t, test.coefficients))
+			if test.err == "" {
+				if err != nil {
+					t.Fatal(err)
+				}
+				if model.Version() != "logistic-test" {
+					t.Errorf("version = %q, want logistic-test", model.Version())
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), test.err) {
+				t.Errorf("error = %v, want one about %s", err, test.err)
+			}
+		})
+	}
+}
+
+func TestLogisticScoringModelScore(t *testing.T) {
+	profile := &models.ScoringProfile{ScoreMin: 300, ScoreMax: 850}
+	model, err := LoadLogisticScoringModel(writeModel(t, allCoefficients))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Payment behaviour of 100 gives z = -1 + 2 = 1 and a repayment
+	// probability of 1/(1+e^-1), about 0.731.
+	explanation := model.Score(CreditScoreFeatures{PaymentBehavior: 100}, profile)
+	want := 300 + 550/(1+math.Exp(-1))
+	if math.Abs(explanation.Score-want) > 1e-9 {
+		t.Errorf("score = %v, want %v", explanation.Score, want)
+	}
+	if codes := explanation.ReasonCodes(); len(codes) != 0 {
+		t.Errorf("reason codes = %v, want none above the reference", codes)
+	}
+
+	explanation = model.Score(CreditScoreFeatures{PaymentBehavior: 10}, profile)
+	if codes := explanation.ReasonCodes(); len(codes) != 1 || codes[0] != ReasonCodeLowPaymentBehavior {
+		t.Errorf("reason codes = %v, want [%s] below the reference", codes, ReasonCodeLowPaymentBehavior)
+	}
+
+	// However extreme the intercept, the score stays within the range.
+	for _, intercept := range []float64{-1000, 1000} {
+		model.Intercept = intercept
+		score := model.Score(CreditScoreFeatures{PaymentBehavior: 100}, profile).Score
+		if score < profile.ScoreMin || score > profile.ScoreMax {
+			t.Errorf("score with intercept %v = %v, outside %v-%v", intercept, score, profile.ScoreMin, profile.ScoreMax)
+		}
+	}
+}
+
+func TestNewScoringModel(t *testing.T) {
+	tests := []struct {
+		name    string
+		kind    string
+		path    string
+		version string
+		err     bool
+	}{
+		{name: "default", version: CreditScoreModelVersion},
+		{name: "heuristic", kind: ScoringModelHeuristic, version: CreditScoreModelVersion},
+		{name: "logistic", kind: ScoringModelLogistic, path: "model", version: "logistic-test"},
+		{name: "logistic without a file", kind: ScoringModelLogistic, err: true},
+		{name: "unknown kind", kind: "neural", err: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			path := test.path
+			if path != "" {
+				path = writeModel(t, allCoefficients)
+			}
+
+			model, err := NewScoringModel(test.kind, path)
+			if test.err {
+				if err == nil {
+					t.Errorf("model = %v, want an error", model.Version())
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if model.Version() != test.version {
+				t.Errorf("version = %q, want %q", model.Version(), test.version)
+			}
+		})
+	}
+}
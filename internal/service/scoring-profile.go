@@ -0,0 +1,120 @@
+package service
+
+import (
+	"fmt"
+	"math"
+
+	"lumon-backend/internal/domain/models"
+	"lumon-backend/internal/repository/interfaces"
+	"lumon-backend/pkg/common/logger"
+
+	"github.com/google/uuid"
+)
+
+// scoringWeightScale is the number of units in a weight of one. Weights are
+// stored with four decimals, so they are checked in those units to accept
+// exactly the profiles that are stored.
+const scoringWeightScale = 10000
+
+type ScoringProfileService struct {
+	repo interfaces.ScoringProfileRepository
+}
+
+func NewScoringProfileService(repo interfaces.ScoringProfileRepository) *ScoringProfileService {
+	return &ScoringProfileService{repo: repo}
+}
+
+func (s *ScoringProfileService) CreateScoringProfile(profile *models.ScoringProfile) error {
+	if err := ValidateScoringProfile(profile); err != nil {
+		return err
+	}
+
+	profile.IsActive = false
+	if err := s.repo.Create(profile); err != nil {
+		logger.APILogger.Error(err)
+		return err
+	}
+
+	return nil
+}
+
+func (s *ScoringProfileService) GetScoringProfile(id string) (*models.ScoringProfile, error) {
+	profile, err := s.repo.GetByID(uuid.MustParse(id))
+	if err != nil {
+		logger.APILogger.Error(err)
+		return nil, err
+	}
+
+	return profile, nil
+}
+
+// GetActiveScoringProfile returns the active profile, falling back to the
+// built-in default when none has been activated.
+func (s *ScoringProfileService) GetActiveScoringProfile() (*models.ScoringProfile, error) {
+	return loadActiveScoringProfile(s.repo)
+}
+
+func loadActiveScoringProfile(repo interfaces.ScoringProfileRepository) (*models.ScoringProfile, error) {
+	profile, err := repo.GetActive()
+	if err != nil {
+		logger.APILogger.Error(err)
+		return nil, err
+	}
+
+	if profile == nil {
+		return DefaultScoringProfile(), nil
+	}
+
+	return profile, nil
+}
+
+func (s *ScoringProfileService) ListScoringProfiles() ([]models.ScoringProfile, error) {
+	profiles, err := s.repo.List()
+	if err != nil {
+		logger.APILogger.Error(err)
+		return nil, err
+	}
+
+	return profiles, nil
+}
+
+func (s *ScoringProfileService) ActivateScoringProfile(id string) error {
+	if err := s.repo.Activate(uuid.MustParse(id)); err != nil {
+		logger.APILogger.Error(err)
+		return err
+	}
+
+	return nil
+}
+
+func ValidateScoringProfile(profile *models.ScoringProfile) error {
+	weights := map[string]float64{
+		"payment_history_weight":    profile.PaymentHistoryWeight,
+		"income_stability_weight":   profile.IncomeStabilityWeight,
+		"cash_flow_weight":          profile.CashFlowWeight,
+		"transaction_habits_weight": profile.TransactionHabitsWeight,
+		"credit_history_weight":     profile.CreditHistoryWeight,
+	}
+
+	sum := 0
+	for name, weight := range weights {
+		if weight < 0 || weight > 1 {
+			return fmt.Errorf("%s must be between 0 and 1", name)
+		}
+		units := math.Round(weight * scoringWeightScale)
+		if math.Abs(weight*scoringWeightScale-units) > 1e-6 {
+			return fmt.Errorf("%s must have at most 4 decimal places", name)
+		}
+		sum += int(units)
+	}
+
+	if sum != scoringWeightScale {
+		return fmt.Errorf("weights must sum to 1, got %.4f", float64(sum)/scoringWeightScale)
+	}
+
+	if profile.ScoreMin < 0 || profile.ScoreMax <= profile.ScoreMin {
+		return fmt.Errorf("score range must satisfy 0 <= score_min < score_max")
+	}
+
+	return nil
+}
@@ -0,0 +1,497 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"runtime/debug"
+	"strings"
+	"sync"
+	"time"
+
+	"lumon-backend/internal/domain/models"
+	"lumon-backend/internal/domain/schemas"
+	"lumon-backend/internal/fees"
+	"lumon-backend/internal/repository/interfaces"
+	"lumon-backend/internal/statements"
+	"lumon-backend/pkg/common/logger"
+
+	"github.com/google/uuid"
+)
+
+const (
+	StatementImportStatusQueued     = "queued"
+	StatementImportStatusProcessing = "processing"
+	StatementImportStatusCompleted  = "completed"
+	StatementImportStatusFailed     = "failed"
+)
+
+const (
+	StatementImportStageQueued       = "queued"
+	StatementImportStageFetching     = "fetching"
+	StatementImportStageExtracting   = "extracting"
+	StatementImportStageValidating   = "validating"
+	StatementImportStageCategorizing = "categorizing"
+	StatementImportStageLinking      = "linking"
+	StatementImportStageInserting    = "inserting"
+	StatementImportStageDone         = "done"
+)
+
+// transactionInsertBatchSize keeps each insert well below the Postgres limit
+// on bind parameters now that whole statements are ingested.
+const transactionInsertBatchSize = 500
+
+// maxStatementImportAttempts bounds how often an import interrupted by a
+// restart is picked up again before it is given up on.
+const maxStatementImportAttempts = 3
+
+// statementImportSweepInterval is how often imports still queued in the
+// database are handed to the workers again, such as those that did not fit in
+// the queue when they were created.
+const statementImportSweepInterval = time.Minute
+
+var ErrStatementImportNotFound = errors.New("statement import not found")
+
+type StatementImportServiceConfig struct {
+	Workers   int
+	QueueSize int
+}
+
+// StatementImportService ingests statements in the background. Every import
+// is persisted before it is queued, so imports still queued or in progress
+// when the server stops, or that did not fit in the queue, are picked up again
+// by the sweep Start runs.
+type StatementImportService struct {
+	importRepo       interfaces.StatementImportRepository
+	statementService *StatementService
+	categoryService  *CategoryService
+	counterparties   *CounterpartyService
+	fees             *fees.Engine
+	analytics        *AnalyticsService
+	anomalyService   *AnomalyService
+	budgets          *BudgetService
+	scheduler        *CreditScoreScheduler
+	cfg              StatementImportServiceConfig
+	jobs             chan uuid.UUID
+
+	mu sync.Mutex
+	// queued holds the imports in the queue or being worked on, so the sweep
+	// does not hand them out twice.
+	queued map[uuid.UUID]bool
+}
+
+func NewStatementImportService(
+	importRepo interfaces.StatementImportRepository,
+	statementService *StatementService,
+	categoryService *CategoryService,
+	counterparties *CounterpartyService,
+	feeEngine *fees.Engine,
+	analytics *AnalyticsService,
+	anomalyService *AnomalyService,
+	budgets *BudgetService,
+	scheduler *CreditScoreScheduler,
+	cfg StatementImportServiceConfig,
+) *StatementImportService {
+	if cfg.Workers <= 0 {
+		cfg.Workers = 1
+	}
+	if cfg.QueueSize <= 0 {
+		cfg.QueueSize = 100
+	}
+
+	return &StatementImportService{
+		importRepo:       importRepo,
+		statementService: statementService,
+		categoryService:  categoryService,
+		counterparties:   counterparties,
+		fees:             feeEngine,
+		analytics:        analytics,
+		anomalyService:   anomalyService,
+		budgets:          budgets,
+		scheduler:        scheduler,
+		cfg:              cfg,
+		jobs:             make(chan uuid.UUID, cfg.QueueSize),
+		queued:           make(map[uuid.UUID]bool),
+	}
+}
+
+// Start launches the worker pool, requeues the imports left unfinished by the
+// previous run and keeps sweeping for imports that are not in the queue. The
+// workers and the sweep stop when ctx is cancelled.
+func (s *StatementImportService) Start(ctx context.Context) {
+	for i := 0; i < s.cfg.Workers; i++ {
+		go s.work(ctx)
+	}
+
+	s.requeue()
+	go s.sweep(ctx)
+}
+
+func (s *StatementImportService) sweep(ctx context.Context) {
+	ticker := time.NewTicker(statementImportSweepInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.requeue()
+		}
+	}
+}
+
+// requeue hands the workers every unfinished import that is not already in
+// the queue or being worked on.
+func (s *StatementImportService) requeue() {
+	unfinished, err := s.importRepo.ListByStatus(StatementImportStatusQueued, StatementImportStatusProcessing)
+	if err != nil {
+		logger.APILogger.Errorf("Failed to load unfinished statement imports: %v", err)
+		return
+	}
+
+	for _, statementImport := range unfinished {
+		s.enqueue(statementImport.ID)
+	}
+}
+
+// CreateFromUpload stores an uploaded statement and queues it for import. The
+// file is validated before the import is created so that an unusable upload
+// is refused in the request.
+func (s *StatementImportService) CreateFromUpload(
+	ctx context.Context, userID, filename string, r io.Reader, provider string,
+) (*models.StatementImport, error) {
+	if err := s.statementService.CheckProvider(provider); err != nil {
+		return nil, err
+	}
+
+	file, err := s.statementService.Upload(ctx, userID, filename, r)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.create(&models.StatementImport{
+		StorageKey: file.Key,
+		Filename:   file.Filename,
+		MIMEType:   file.MIMEType,
+		Size:       file.Size,
+		Provider:   provider,
+		UserID:     uuid.MustParse(userID),
+	})
+}
+
+// CreateFromURL queues the import of a statement that a worker fetches from
+// the URL. Only the host is checked here.
+func (s *StatementImportService) CreateFromURL(userID, rawURL, provider string) (*models.StatementImport, error) {
+	if err := s.statementService.CheckProvider(provider); err != nil {
+		return nil, err
+	}
+
+	if err := s.statementService.CheckURL(rawURL); err != nil {
+		return nil, err
+	}
+
+	return s.create(&models.StatementImport{
+		SourceURL: rawURL,
+		Provider:  provider,
+		UserID:    uuid.MustParse(userID),
+	})
+}
+
+// GetImport returns the progress of one of the user's imports, with the rows
+// that were rejected.
+func (s *StatementImportService) GetImport(userID, id string) (*schemas.StatementImportResponse, error) {
+	statementImport, err := s.importRepo.GetByID(uuid.MustParse(id))
+	if err != nil {
+		logger.APILogger.Error(err)
+		return nil, ErrStatementImportNotFound
+	}
+
+	if statementImport.UserID != uuid.MustParse(userID) {
+		return nil, ErrStatementImportNotFound
+	}
+
+	rejections, err := s.importRepo.ListRejections(statementImport.ID)
+	if err != nil {
+		logger.APILogger.Error(err)
+		return nil, err
+	}
+
+	response := &schemas.StatementImportResponse{
+		ID:               statementImport.ID.String(),
+		Status:           statementImport.Status,
+		Stage:            statementImport.Stage,
+		Filename:         statementImport.Filename,
+		MIMEType:         statementImport.MIMEType,
+		SourceURL:        statementImport.SourceURL,
+		Provider:         statementImport.Provider,
+		ExtractionSource: statementImport.ExtractionSource,
+		TotalRows:        statementImport.TotalRows,
+		ImportedRows:     statementImport.ImportedRows,
+		RejectedRows:     statementImport.RejectedRows,
+		DuplicateRows:    statementImport.DuplicateRows,
+		Error:            statementImport.Error,
+		Rejections:       make([]schemas.StatementImportRejection, len(rejections)),
+		CreatedAt:        statementImport.CreatedAt,
+		UpdatedAt:        statementImport.UpdatedAt,
+		FinishedAt:       statementImport.FinishedAt,
+	}
+
+	for i, rejection := range rejections {
+		response.Rejections[i] = schemas.StatementImportRejection{
+			Row:    rejection.RowNumber,
+			Reason: rejection.Reason,
+		}
+
+		var transaction schemas.TransactionResponse
+		if err := json.Unmarshal([]byte(rejection.Payload), &transaction); err == nil {
+			response.Rejections[i].Transaction = &transaction
+		}
+	}
+
+	return response, nil
+}
+
+func (s *StatementImportService) create(statementImport *models.StatementImport) (*models.StatementImport, error) {
+	statementImport.Status = StatementImportStatusQueued
+	statementImport.Stage = StatementImportStageQueued
+
+	if err := s.importRepo.Create(statementImport); err != nil {
+		logger.APILogger.Error(err)
+		return nil, err
+	}
+
+	s.enqueue(statementImport.ID)
+
+	return statementImport, nil
+}
+
+// enqueue hands an import to the workers without holding up the caller. When
+// the queue is full the import is left queued in the database, where the
+// sweep finds it once there is room.
+func (s *StatementImportService) enqueue(id uuid.UUID) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.queued[id] {
+		return
+	}
+
+	select {
+	case s.jobs <- id:
+		s.queued[id] = true
+	default:
+	}
+}
+
+func (s *StatementImportService) work(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case id := <-s.jobs:
+			s.process(ctx, id)
+
+			s.mu.Lock()
+			delete(s.queued, id)
+			s.mu.Unlock()
+		}
+	}
+}
+
+// process runs an import, counting the attempt. An import that panics is
+// failed rather than taking the worker down with it.
+func (s *StatementImportService) process(ctx context.Context, id uuid.UUID) {
+	statementImport, err := s.importRepo.GetByID(id)
+	if err != nil {
+		logger.APILogger.Errorf("Failed to load statement import: %v", err)
+		return
+	}
+
+	if statementImport.Status != StatementImportStatusQueued && statementImport.Status != StatementImportStatusProcessing {
+		return
+	}
+
+	statementImport.Attempts++
+	if statementImport.Attempts > maxStatementImportAttempts {
+		s.fail(statementImport, fmt.Errorf("import was interrupted %d times", maxStatementImportAttempts))
+		return
+	}
+
+	finished := false
+	defer func() {
+		if r := recover(); r != nil {
+			logger.APILogger.Errorf("Statement import %s panicked: %v\n%s", id, r, debug.Stack())
+			if !finished {
+				s.fail(statementImport, fmt.Errorf("import panicked: %v", r))
+			}
+		}
+	}()
+
+	statementImport.Status = StatementImportStatusProcessing
+	imported, err := s.run(ctx, statementImport)
+	if err != nil {
+		s.fail(statementImport, err)
+		return
+	}
+	finished = true
+
+	if statementImport.ImportedRows > 0 {
+		s.analytics.Invalidate(statementImport.UserID)
+		if _, err := s.anomalyService.ScanTransactions(statementImport.UserID); err != nil {
+			logger.APILogger.Errorf("Failed to scan imported transactions for anomalies: %v", err)
+		}
+		if err := s.budgets.Evaluate(statementImport.UserID, budgetMonths(imported)...); err != nil {
+			logger.APILogger.Errorf("Failed to evaluate budgets after import: %v", err)
+		}
+		s.scheduler.RecalculateAfterIngestion(statementImport.UserID.String())
+	}
+}
+
+// run takes an import through fetching, extraction, validation,
+// categorisation and linking to counterparties, recording each stage as it is
+// reached, and stores the result in one go so that an import interrupted by a
+// restart can safely be run again from the start. Counterparties added on the
+// way are kept, and found again when the import is run again.
+// It returns the transactions imported.
+func (s *StatementImportService) run(ctx context.Context, statementImport *models.StatementImport) ([]*models.Transaction, error) {
+	userID := statementImport.UserID.String()
+
+	if err := s.advance(statementImport, StatementImportStageFetching); err != nil {
+		return nil, err
+	}
+
+	var file *StatementFile
+	var err error
+	if statementImport.StorageKey == "" {
+		file, err = s.statementService.FetchURL(ctx, userID, statementImport.SourceURL)
+		if err != nil {
+			return nil, err
+		}
+
+		statementImport.StorageKey = file.Key
+		statementImport.Filename = file.Filename
+		statementImport.MIMEType = file.MIMEType
+		statementImport.Size = file.Size
+	} else {
+		file, err = s.statementService.Open(
+			ctx, statementImport.StorageKey, statementImport.Filename, statementImport.MIMEType,
+		)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if err := s.advance(statementImport, StatementImportStageExtracting); err != nil {
+		return nil, err
+	}
+
+	extraction, err := s.statementService.ExtractTransactions(ctx, file, statementImport.Provider)
+	if err != nil {
+		return nil, err
+	}
+
+	statementImport.Provider = extraction.Provider
+	statementImport.ExtractionSource = extraction.Source
+	statementImport.TotalRows = len(extraction.Transactions)
+
+	if err := s.advance(statementImport, StatementImportStageValidating); err != nil {
+		return nil, err
+	}
+
+	// Rows that fail validation are quarantined with every reason they failed
+	// for, and the rest of the statement is stored.
+	reasons := make(map[int][]string)
+	for _, problem := range statements.Validate(extraction.Transactions) {
+		reasons[problem.Row] = append(reasons[problem.Row], problem.Reason)
+	}
+
+	var accepted []*models.Transaction
+	var rejections []models.StatementImportRejection
+	for i, transaction := range extraction.Transactions {
+		if rowReasons, ok := reasons[i+1]; ok {
+			payload, _ := json.Marshal(NewTransactionResponse(*transaction))
+			rejections = append(rejections, models.StatementImportRejection{
+				RowNumber: i + 1,
+				Reason:    strings.Join(rowReasons, "; "),
+				Payload:   string(payload),
+				ImportID:  statementImport.ID,
+			})
+			continue
+		}
+
+		transaction.UserID = statementImport.UserID
+		transaction.StatementImportID = &statementImport.ID
+		accepted = append(accepted, transaction)
+	}
+
+	// The expected charges are recorded for the anomaly scan to compare
+	// with the ones the statement printed.
+	s.fees.Apply(accepted)
+
+	if err := s.advance(statementImport, StatementImportStageCategorizing); err != nil {
+		return nil, err
+	}
+
+	if err := s.categoryService.Categorize(ctx, statementImport.UserID, accepted); err != nil {
+		return nil, err
+	}
+
+	if err := s.advance(statementImport, StatementImportStageLinking); err != nil {
+		return nil, err
+	}
+
+	if err := s.counterparties.Link(statementImport.UserID, accepted); err != nil {
+		return nil, err
+	}
+
+	if err := s.advance(statementImport, StatementImportStageInserting); err != nil {
+		return nil, err
+	}
+
+	finishedAt := time.Now().UTC()
+	statementImport.Status = StatementImportStatusCompleted
+	statementImport.Stage = StatementImportStageDone
+	statementImport.ImportedRows = len(accepted)
+	statementImport.RejectedRows = len(rejections)
+	statementImport.FinishedAt = &finishedAt
+
+	if err := s.importRepo.Complete(statementImport, accepted, rejections, transactionInsertBatchSize); err != nil {
+		statementImport.ImportedRows = 0
+		statementImport.RejectedRows = 0
+		statementImport.DuplicateRows = 0
+		return nil, err
+	}
+
+	return accepted, nil
+}
+
+func (s *StatementImportService) advance(statementImport *models.StatementImport, stage string) error {
+	statementImport.Stage = stage
+
+	if err := s.importRepo.Update(statementImport); err != nil {
+		logger.APILogger.Error(err)
+		return err
+	}
+
+	return nil
+}
+
+func (s *StatementImportService) fail(statementImport *models.StatementImport, err error) {
+	logger.APILogger.Errorw("statement import failed",
+		"import_id", statementImport.ID,
+		"stage", statementImport.Stage,
+		"error", err,
+	)
+
+	finishedAt := time.Now().UTC()
+	statementImport.Status = StatementImportStatusFailed
+	statementImport.Error = err.Error()
+	statementImport.FinishedAt = &finishedAt
+
+	if err := s.importRepo.Update(statementImport); err != nil {
+		logger.APILogger.Errorf("Failed to record statement import failure: %v", err)
+	}
+}
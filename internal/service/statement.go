@@ -0,0 +1,384 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"lumon-backend/internal/domain/models"
+	"lumon-backend/internal/ml"
+	"lumon-backend/internal/statements"
+	"lumon-backend/pkg/common/logger"
+	"lumon-backend/pkg/common/storage"
+
+	"github.com/gabriel-vasile/mimetype"
+	"github.com/google/uuid"
+)
+
+var (
+	ErrStatementTooLarge       = errors.New("statement file is too large")
+	ErrStatementEmpty          = errors.New("statement file is empty")
+	ErrUnsupportedStatement    = errors.New("statement must be a PDF, CSV or XLSX file")
+	ErrStatementHostNotAllowed = errors.New("statement URL host is not allowed")
+)
+
+const statementFetchTimeout = 30 * time.Second
+
+// llmExtractionRetries is how often the LLM is asked to correct an extraction
+// that failed validation, and maxReportedProblems caps how many of the
+// problems it is told about at once.
+const (
+	llmExtractionRetries = 2
+	maxReportedProblems  = 50
+)
+
+const (
+	ExtractionSourceParser = "parser"
+	ExtractionSourceLLM    = "llm"
+)
+
+// statementExtensions maps the accepted statement types to the extension they
+// are stored under.
+var statementExtensions = map[string]string{
+	statements.PDFMIMEType:  ".pdf",
+	statements.CSVMIMEType:  ".csv",
+	statements.XLSXMIMEType: ".xlsx",
+}
+
+type StatementServiceConfig struct {
+	MaxSize int64
+	// AllowedHosts lists the hosts statements may be fetched from by URL. A
+	// host also allows its subdomains.
+	AllowedHosts []string
+}
+
+// StatementFile is an uploaded or fetched statement that passed validation
+// and has been stored.
+type StatementFile struct {
+	Key      string `json:"key"`
+	Filename string `json:"filename"`
+	MIMEType string `json:"mime_type"`
+	Size     int64  `json:"size"`
+	Data     []byte `json:"-"`
+}
+
+// StatementService validates statement files and keeps a copy of each in
+// storage before they are handed to the extraction pipeline.
+type StatementService struct {
+	storage storage.Storage
+	parsers *statements.Registry
+	cfg     StatementServiceConfig
+	client  *http.Client
+}
+
+func NewStatementService(
+	storage storage.Storage, parsers *statements.Registry, cfg StatementServiceConfig,
+) *StatementService {
+	s := &StatementService{
+		storage: storage,
+		parsers: parsers,
+		cfg:     cfg,
+	}
+
+	s.client = &http.Client{
+		Timeout: statementFetchTimeout,
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			if len(via) >= 5 {
+				return errors.New("too many redirects")
+			}
+			return s.checkURL(req.URL)
+		},
+	}
+
+	return s
+}
+
+// Upload validates and stores a statement file sent by the user.
+func (s *StatementService) Upload(ctx context.Context, userID, filename string, r io.Reader) (*StatementFile, error) {
+	data, err := s.read(r)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.store(ctx, userID, filename, data)
+}
+
+// FetchURL downloads a statement from one of the allowed hosts, then validates
+// and stores it like an upload.
+func (s *StatementService) FetchURL(ctx context.Context, userID, rawURL string) (*StatementFile, error) {
+	target, err := s.parseURL(rawURL)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, target.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		if errors.Is(err, ErrStatementHostNotAllowed) {
+			return nil, ErrStatementHostNotAllowed
+		}
+		return nil, fmt.Errorf("failed to fetch statement: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to fetch statement: %s", resp.Status)
+	}
+
+	data, err := s.read(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.store(ctx, userID, path.Base(target.Path), data)
+}
+
+// StatementExtraction is the outcome of reading the transactions out of a
+// statement.
+type StatementExtraction struct {
+	Provider     string                `json:"provider"`
+	Source       string                `json:"source"`
+	Transactions []*models.Transaction `json:"-"`
+}
+
+// ExtractTransactions reads every transaction from a stored statement with the
+// parser of the given provider, or of the detected one when provider is empty,
+// and only asks the LLM when the statement cannot be parsed.
+func (s *StatementService) ExtractTransactions(
+	ctx context.Context, file *StatementFile, provider string,
+) (*StatementExtraction, error) {
+	if err := s.CheckProvider(provider); err != nil {
+		return nil, err
+	}
+
+	var parser statements.StatementParser
+	statement, err := statements.NewStatement(file.Data, file.MIMEType)
+	if err == nil {
+		var transactions []*models.Transaction
+		parser, transactions, err = s.parsers.Parse(statement, provider)
+		if err == nil {
+			return &StatementExtraction{
+				Provider:     parser.Provider(),
+				Source:       ExtractionSourceParser,
+				Transactions: transactions,
+			}, nil
+		}
+	}
+
+	logger.APILogger.Warnw("statement parser failed, falling back to LLM extraction",
+		"key", file.Key,
+		"mime_type", file.MIMEType,
+		"provider", provider,
+		"error", err,
+	)
+
+	providerName := ""
+	if parser != nil {
+		provider, providerName = parser.Provider(), parser.Name()
+	}
+
+	transactions, err := s.extractWithLLM(ctx, file, provider, providerName)
+	if err != nil {
+		return nil, err
+	}
+
+	return &StatementExtraction{
+		Provider:     provider,
+		Source:       ExtractionSourceLLM,
+		Transactions: transactions,
+	}, nil
+}
+
+// extractWithLLM asks the LLM for the transactions of a statement, and asks
+// again with the problems Validate finds until the rows check out or the
+// retries run out. The extraction with the fewest problems is kept; its bad
+// rows are quarantined when the import is validated.
+func (s *StatementService) extractWithLLM(
+	ctx context.Context, file *StatementFile, provider, providerName string,
+) ([]*models.Transaction, error) {
+	var best []*models.Transaction
+	var bestProblems []statements.RowError
+	var problems []string
+
+	for attempt := 0; attempt <= llmExtractionRetries; attempt++ {
+		scrapes, _, err := ml.GetStatementTransactions(ctx, file.Data, file.MIMEType, providerName, problems)
+		if err != nil {
+			if attempt > 0 {
+				logger.APILogger.Warnw("LLM re-extraction failed, keeping the previous extraction",
+					"key", file.Key,
+					"attempt", attempt+1,
+					"error", err,
+				)
+				break
+			}
+			logger.APILogger.Error(err)
+			return nil, err
+		}
+
+		transactions := statements.FromScrapes(scrapes, provider)
+		rowErrors := statements.Validate(transactions)
+		if attempt == 0 || len(rowErrors) < len(bestProblems) {
+			best, bestProblems = transactions, rowErrors
+		}
+		if len(rowErrors) == 0 {
+			break
+		}
+
+		logger.APILogger.Warnw("LLM extraction failed validation",
+			"key", file.Key,
+			"attempt", attempt+1,
+			"problems", len(rowErrors),
+		)
+
+		problems = problems[:0]
+		for _, rowError := range rowErrors[:min(len(rowErrors), maxReportedProblems)] {
+			problems = append(problems, rowError.Error())
+		}
+	}
+
+	return best, nil
+}
+
+// CheckProvider reports whether statements can be parsed as coming from the
+// provider. An empty provider means it is detected and is always accepted.
+func (s *StatementService) CheckProvider(provider string) error {
+	if provider == "" {
+		return nil
+	}
+
+	_, err := s.parsers.Parser(provider)
+	return err
+}
+
+// CheckURL reports whether a statement may be fetched from the URL, so that a
+// fetch deferred to later can be refused straight away.
+func (s *StatementService) CheckURL(rawURL string) error {
+	_, err := s.parseURL(rawURL)
+	return err
+}
+
+// Open reads back a statement that was stored earlier.
+func (s *StatementService) Open(ctx context.Context, key, filename, mimeType string) (*StatementFile, error) {
+	r, err := s.storage.Open(ctx, key)
+	if err != nil {
+		logger.APILogger.Error(err)
+		return nil, err
+	}
+	defer r.Close()
+
+	data, err := io.ReadAll(r)
+	if err != nil {
+		logger.APILogger.Error(err)
+		return nil, err
+	}
+
+	return &StatementFile{
+		Key:      key,
+		Filename: filename,
+		MIMEType: mimeType,
+		Size:     int64(len(data)),
+		Data:     data,
+	}, nil
+}
+
+func (s *StatementService) parseURL(rawURL string) (*url.URL, error) {
+	target, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid statement URL: %w", err)
+	}
+
+	if err := s.checkURL(target); err != nil {
+		return nil, err
+	}
+
+	return target, nil
+}
+
+func (s *StatementService) checkURL(target *url.URL) error {
+	if target.Scheme != "https" && target.Scheme != "http" {
+		return ErrStatementHostNotAllowed
+	}
+
+	host := strings.ToLower(target.Hostname())
+	for _, allowed := range s.cfg.AllowedHosts {
+		allowed = strings.ToLower(strings.TrimSpace(allowed))
+		if allowed == "" {
+			continue
+		}
+		if host == allowed || strings.HasSuffix(host, "."+allowed) {
+			return nil
+		}
+	}
+
+	return ErrStatementHostNotAllowed
+}
+
+func (s *StatementService) read(r io.Reader) ([]byte, error) {
+	data, err := io.ReadAll(io.LimitReader(r, s.cfg.MaxSize+1))
+	if err != nil {
+		return nil, err
+	}
+
+	if int64(len(data)) > s.cfg.MaxSize {
+		return nil, ErrStatementTooLarge
+	}
+	if len(data) == 0 {
+		return nil, ErrStatementEmpty
+	}
+
+	return data, nil
+}
+
+func (s *StatementService) store(ctx context.Context, userID, filename string, data []byte) (*StatementFile, error) {
+	mimeType, err := detectStatementType(filename, data)
+	if err != nil {
+		return nil, err
+	}
+
+	file := &StatementFile{
+		Key:      fmt.Sprintf("statements/%s/%s%s", userID, uuid.New(), statementExtensions[mimeType]),
+		Filename: filename,
+		MIMEType: mimeType,
+		Size:     int64(len(data)),
+		Data:     data,
+	}
+
+	if err := s.storage.Save(ctx, file.Key, bytes.NewReader(data)); err != nil {
+		logger.APILogger.Error(err)
+		return nil, err
+	}
+
+	return file, nil
+}
+
+// detectStatementType sniffs the file content rather than trusting the
+// client's content type. CSV exports are often detected as plain text, so
+// plain text is accepted when the file is named as a CSV.
+func detectStatementType(filename string, data []byte) (string, error) {
+	detected := mimetype.Detect(data)
+
+	switch {
+	case detected.Is(statements.PDFMIMEType):
+		return statements.PDFMIMEType, nil
+	case detected.Is(statements.XLSXMIMEType):
+		return statements.XLSXMIMEType, nil
+	case detected.Is(statements.CSVMIMEType):
+		return statements.CSVMIMEType, nil
+	case detected.Is("text/plain") && strings.EqualFold(filepath.Ext(filename), ".csv"):
+		return statements.CSVMIMEType, nil
+	}
+
+	return "", ErrUnsupportedStatement
+}
@@ -0,0 +1,499 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"lumon-backend/internal/domain/models"
+	"lumon-backend/internal/domain/schemas"
+	"lumon-backend/internal/repository/interfaces"
+	"lumon-backend/pkg/common/logger"
+	"lumon-backend/pkg/common/storage"
+
+	"github.com/gabriel-vasile/mimetype"
+	"github.com/google/uuid"
+)
+
+var (
+	ErrInvalidTag                    = errors.New("tag is not valid")
+	ErrTransactionTagNotFound        = errors.New("transaction tag not found")
+	ErrInvalidTransactionNote        = errors.New("transaction note is not valid")
+	ErrTransactionNoteNotFound       = errors.New("transaction note not found")
+	ErrTransactionAttachmentNotFound = errors.New("transaction attachment not found")
+	ErrAttachmentTooLarge            = errors.New("attachment is too large")
+	ErrAttachmentEmpty               = errors.New("attachment is empty")
+	ErrUnsupportedAttachment         = errors.New("attachment must be a PDF, JPEG, PNG or WebP file")
+	ErrTooManyAttachments            = errors.New("transaction has too many attachments")
+)
+
+// DocumentTypeTransactionAttachment is the type of the documents attachments
+// are stored as.
+const DocumentTypeTransactionAttachment = "transaction_attachment"
+
+const (
+	maxTagLength                 = 50
+	maxTransactionNoteLength     = 2000
+	maxAttachmentsPerTransaction = 10
+	maxAttachmentFilenameLength  = 255
+)
+
+// attachmentExtensions maps the accepted attachment types, receipts as
+// scans, photos or PDFs, to the extension they are stored under.
+var attachmentExtensions = map[string]string{
+	"application/pdf": ".pdf",
+	"image/jpeg":      ".jpg",
+	"image/png":       ".png",
+	"image/webp":      ".webp",
+}
+
+// TransactionAnnotationService keeps the tags, notes and attachments users
+// add to their transactions. Every method checks that the transaction belongs
+// to the user, and reports another user's transaction as not found.
+type TransactionAnnotationService struct {
+	transactionRepo   interfaces.TransactionRepository
+	tagRepo           interfaces.TransactionTagRepository
+	noteRepo          interfaces.TransactionNoteRepository
+	attachmentRepo    interfaces.TransactionAttachmentRepository
+	storage           storage.Storage
+	maxAttachmentSize int64
+}
+
+func NewTransactionAnnotationService(
+	transactionRepo interfaces.TransactionRepository,
+	tagRepo interfaces.TransactionTagRepository,
+	noteRepo interfaces.TransactionNoteRepository,
+	attachmentRepo interfaces.TransactionAttachmentRepository,
+	storage storage.Storage,
+	maxAttachmentSize int64,
+) *TransactionAnnotationService {
+	return &TransactionAnnotationService{
+		transactionRepo:   transactionRepo,
+		tagRepo:           tagRepo,
+		noteRepo:          noteRepo,
+		attachmentRepo:    attachmentRepo,
+		storage:           storage,
+		maxAttachmentSize: maxAttachmentSize,
+	}
+}
+
+// NormalizeTag puts a tag in the form it is stored and searched in: lower
+// case, with single spaces between words.
+func NormalizeTag(tag string) string {
+	return strings.ToLower(strings.Join(strings.Fields(tag), " "))
+}
+
+// GetAnnotations returns everything the user added to one of their
+// transactions.
+func (s *TransactionAnnotationService) GetAnnotations(
+	userID, transactionID string,
+) (*schemas.TransactionAnnotationsResponse, error) {
+	transaction, err := s.transaction(userID, transactionID)
+	if err != nil {
+		return nil, err
+	}
+
+	tags, err := s.tagRepo.ListByTransaction(transaction.ID)
+	if err != nil {
+		logger.APILogger.Error(err)
+		return nil, err
+	}
+	notes, err := s.noteRepo.ListByTransaction(transaction.ID)
+	if err != nil {
+		logger.APILogger.Error(err)
+		return nil, err
+	}
+	attachments, err := s.attachmentRepo.ListByTransaction(transaction.ID)
+	if err != nil {
+		logger.APILogger.Error(err)
+		return nil, err
+	}
+
+	response := &schemas.TransactionAnnotationsResponse{
+		TransactionID: transaction.ID.String(),
+		Tags:          tagNames(tags),
+		Notes:         []schemas.TransactionNoteResponse{},
+		Attachments:   []schemas.TransactionAttachmentResponse{},
+	}
+	for _, note := range notes {
+		response.Notes = append(response.Notes, newTransactionNoteResponse(note))
+	}
+	for _, attachment := range attachments {
+		response.Attachments = append(response.Attachments, newTransactionAttachmentResponse(attachment))
+	}
+
+	return response, nil
+}
+
+// AddTags tags one of the user's transactions and returns all of its tags.
+// Tags it already has are left as they are.
+func (s *TransactionAnnotationService) AddTags(userID, transactionID string, names []string) ([]string, error) {
+	transaction, err := s.transaction(userID, transactionID)
+	if err != nil {
+		return nil, err
+	}
+
+	var tags []*models.TransactionTag
+	seen := make(map[string]bool)
+	for _, name := range names {
+		name = NormalizeTag(name)
+		if name == "" || utf8.RuneCountInString(name) > maxTagLength || strings.Contains(name, ",") {
+			return nil, fmt.Errorf("%w: tags must be 1 to %d characters, without commas", ErrInvalidTag, maxTagLength)
+		}
+		if seen[name] {
+			continue
+		}
+		seen[name] = true
+
+		tags = append(tags, &models.TransactionTag{
+			Name:          name,
+			TransactionID: transaction.ID,
+			UserID:        transaction.UserID,
+		})
+	}
+
+	if err := s.tagRepo.Create(tags); err != nil {
+		logger.APILogger.Error(err)
+		return nil, err
+	}
+
+	stored, err := s.tagRepo.ListByTransaction(transaction.ID)
+	if err != nil {
+		logger.APILogger.Error(err)
+		return nil, err
+	}
+
+	return tagNames(stored), nil
+}
+
+// RemoveTag takes a tag off one of the user's transactions.
+func (s *TransactionAnnotationService) RemoveTag(userID, transactionID, name string) error {
+	transaction, err := s.transaction(userID, transactionID)
+	if err != nil {
+		return err
+	}
+
+	removed, err := s.tagRepo.Delete(transaction.ID, NormalizeTag(name))
+	if err != nil {
+		logger.APILogger.Error(err)
+		return err
+	}
+	if !removed {
+		return ErrTransactionTagNotFound
+	}
+
+	return nil
+}
+
+// ListTags returns the user's tags with what moved through the transactions
+// carrying each of them.
+func (s *TransactionAnnotationService) ListTags(userID string) ([]schemas.TagResponse, error) {
+	totals, err := s.tagRepo.Totals(uuid.MustParse(userID))
+	if err != nil {
+		logger.APILogger.Error(err)
+		return nil, err
+	}
+
+	tags := []schemas.TagResponse{}
+	for _, total := range totals {
+		tags = append(tags, schemas.TagResponse{
+			Name:             total.Name,
+			TransactionCount: total.TransactionCount,
+			Inflow:           total.Inflow,
+			Outflow:          total.Outflow,
+		})
+	}
+
+	return tags, nil
+}
+
+func (s *TransactionAnnotationService) AddNote(
+	userID, transactionID, body string,
+) (*schemas.TransactionNoteResponse, error) {
+	transaction, err := s.transaction(userID, transactionID)
+	if err != nil {
+		return nil, err
+	}
+
+	body, err = checkTransactionNote(body)
+	if err != nil {
+		return nil, err
+	}
+
+	note := &models.TransactionNote{
+		Body:          body,
+		TransactionID: transaction.ID,
+		UserID:        transaction.UserID,
+	}
+	if err := s.noteRepo.Create(note); err != nil {
+		logger.APILogger.Error(err)
+		return nil, err
+	}
+
+	response := newTransactionNoteResponse(*note)
+	return &response, nil
+}
+
+func (s *TransactionAnnotationService) UpdateNote(
+	userID, transactionID, noteID, body string,
+) (*schemas.TransactionNoteResponse, error) {
+	note, err := s.note(userID, transactionID, noteID)
+	if err != nil {
+		return nil, err
+	}
+
+	note.Body, err = checkTransactionNote(body)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.noteRepo.Update(note); err != nil {
+		logger.APILogger.Error(err)
+		return nil, err
+	}
+
+	response := newTransactionNoteResponse(*note)
+	return &response, nil
+}
+
+func (s *TransactionAnnotationService) DeleteNote(userID, transactionID, noteID string) error {
+	note, err := s.note(userID, transactionID, noteID)
+	if err != nil {
+		return err
+	}
+
+	if err := s.noteRepo.Delete(note.ID); err != nil {
+		logger.APILogger.Error(err)
+		return err
+	}
+
+	return nil
+}
+
+// AddAttachment checks and stores a file the user attached to one of their
+// transactions, such as a receipt, as a document of the user's.
+func (s *TransactionAnnotationService) AddAttachment(
+	ctx context.Context, userID, transactionID, filename string, r io.Reader,
+) (*schemas.TransactionAttachmentResponse, error) {
+	transaction, err := s.transaction(userID, transactionID)
+	if err != nil {
+		return nil, err
+	}
+
+	existing, err := s.attachmentRepo.ListByTransaction(transaction.ID)
+	if err != nil {
+		logger.APILogger.Error(err)
+		return nil, err
+	}
+	if len(existing) >= maxAttachmentsPerTransaction {
+		return nil, fmt.Errorf("%w: at most %d are allowed", ErrTooManyAttachments, maxAttachmentsPerTransaction)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(r, s.maxAttachmentSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(data)) > s.maxAttachmentSize {
+		return nil, ErrAttachmentTooLarge
+	}
+	if len(data) == 0 {
+		return nil, ErrAttachmentEmpty
+	}
+
+	// The content is sniffed rather than trusting the client's content type.
+	mimeType := mimetype.Detect(data).String()
+	if i := strings.IndexByte(mimeType, ';'); i >= 0 {
+		mimeType = mimeType[:i]
+	}
+	extension, ok := attachmentExtensions[mimeType]
+	if !ok {
+		return nil, ErrUnsupportedAttachment
+	}
+
+	filename = attachmentFilename(filename, extension)
+	key := fmt.Sprintf("attachments/%s/%s%s", transaction.UserID, uuid.New(), extension)
+	if err := s.storage.Save(ctx, key, bytes.NewReader(data)); err != nil {
+		logger.APILogger.Error(err)
+		return nil, err
+	}
+
+	now := time.Now()
+	attachment := &models.TransactionAttachment{
+		TransactionID: transaction.ID,
+		UserID:        transaction.UserID,
+		Document: models.Document{
+			ContentSummary: fmt.Sprintf("%s attached to transaction %s (%s)", filename, transaction.ID, key),
+			Type:           DocumentTypeTransactionAttachment,
+			UploadedAt:     &now,
+			StorageKey:     key,
+			Filename:       filename,
+			MIMEType:       mimeType,
+			Size:           int64(len(data)),
+			UserID:         transaction.UserID,
+		},
+	}
+	if err := s.attachmentRepo.Create(attachment); err != nil {
+		logger.APILogger.Error(err)
+		s.deleteFile(ctx, key)
+		return nil, err
+	}
+
+	response := newTransactionAttachmentResponse(*attachment)
+	return &response, nil
+}
+
+// OpenAttachment returns one of the user's attachments with its file, which
+// the caller must close.
+func (s *TransactionAnnotationService) OpenAttachment(
+	ctx context.Context, userID, transactionID, attachmentID string,
+) (*schemas.TransactionAttachmentResponse, io.ReadCloser, error) {
+	attachment, err := s.attachment(userID, transactionID, attachmentID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	file, err := s.storage.Open(ctx, attachment.Document.StorageKey)
+	if err != nil {
+		logger.APILogger.Error(err)
+		return nil, nil, err
+	}
+
+	response := newTransactionAttachmentResponse(*attachment)
+	return &response, file, nil
+}
+
+// DeleteAttachment removes one of the user's attachments along with its
+// document and file.
+func (s *TransactionAnnotationService) DeleteAttachment(
+	ctx context.Context, userID, transactionID, attachmentID string,
+) error {
+	attachment, err := s.attachment(userID, transactionID, attachmentID)
+	if err != nil {
+		return err
+	}
+
+	if err := s.attachmentRepo.Delete(attachment); err != nil {
+		logger.APILogger.Error(err)
+		return err
+	}
+
+	s.deleteFile(ctx, attachment.Document.StorageKey)
+	return nil
+}
+
+// deleteFile removes a stored file that is no longer referenced. A file left
+// behind is only wasted space, so failures are logged rather than returned.
+func (s *TransactionAnnotationService) deleteFile(ctx context.Context, key string) {
+	if err := s.storage.Delete(ctx, key); err != nil {
+		logger.APILogger.Warnw("Failed to delete attachment file", "key", key, "error", err)
+	}
+}
+
+func (s *TransactionAnnotationService) transaction(userID, transactionID string) (*models.Transaction, error) {
+	transaction, err := s.transactionRepo.GetByID(uuid.MustParse(transactionID))
+	if err != nil {
+		logger.APILogger.Error(err)
+		return nil, ErrTransactionNotFound
+	}
+
+	if transaction.UserID != uuid.MustParse(userID) {
+		return nil, ErrTransactionNotFound
+	}
+
+	return transaction, nil
+}
+
+func (s *TransactionAnnotationService) note(userID, transactionID, noteID string) (*models.TransactionNote, error) {
+	transaction, err := s.transaction(userID, transactionID)
+	if err != nil {
+		return nil, err
+	}
+
+	note, err := s.noteRepo.GetByID(uuid.MustParse(noteID))
+	if err != nil {
+		logger.APILogger.Error(err)
+		return nil, ErrTransactionNoteNotFound
+	}
+	if note.TransactionID != transaction.ID {
+		return nil, ErrTransactionNoteNotFound
+	}
+
+	return note, nil
+}
+
+func (s *TransactionAnnotationService) attachment(
+	userID, transactionID, attachmentID string,
+) (*models.TransactionAttachment, error) {
+	transaction, err := s.transaction(userID, transactionID)
+	if err != nil {
+		return nil, err
+	}
+
+	attachment, err := s.attachmentRepo.GetByID(uuid.MustParse(attachmentID))
+	if err != nil {
+		logger.APILogger.Error(err)
+		return nil, ErrTransactionAttachmentNotFound
+	}
+	if attachment.TransactionID != transaction.ID {
+		return nil, ErrTransactionAttachmentNotFound
+	}
+
+	return attachment, nil
+}
+
+func checkTransactionNote(body string) (string, error) {
+	body = strings.TrimSpace(body)
+	if body == "" || utf8.RuneCountInString(body) > maxTransactionNoteLength {
+		return "", fmt.Errorf("%w: notes must be 1 to %d characters", ErrInvalidTransactionNote, maxTransactionNoteLength)
+	}
+	return body, nil
+}
+
+// attachmentFilename keeps the base name the client sent, for downloads, and
+// makes one up when there is none.
+func attachmentFilename(filename, extension string) string {
+	filename = strings.TrimSpace(filepath.Base(strings.ReplaceAll(filename, `\`, "/")))
+	if filename == "" || filename == "." || filename == "/" {
+		filename = "attachment" + extension
+	}
+
+	if utf8.RuneCountInString(filename) > maxAttachmentFilenameLength {
+		runes := []rune(filename)
+		filename = string(runes[:maxAttachmentFilenameLength])
+	}
+	return filename
+}
+
+func tagNames(tags []models.TransactionTag) []string {
+	names := []string{}
+	for _, tag := range tags {
+		names = append(names, tag.Name)
+	}
+	return names
+}
+
+func newTransactionNoteResponse(note models.TransactionNote) schemas.TransactionNoteResponse {
+	return schemas.TransactionNoteResponse{
+		ID:        note.ID.String(),
+		Body:      note.Body,
+		CreatedAt: note.CreatedAt,
+		UpdatedAt: note.UpdatedAt,
+	}
+}
+
+func newTransactionAttachmentResponse(attachment models.TransactionAttachment) schemas.TransactionAttachmentResponse {
+	return schemas.TransactionAttachmentResponse{
+		ID:         attachment.ID.String(),
+		DocumentID: attachment.DocumentID.String(),
+		Filename:   attachment.Document.Filename,
+		MIMEType:   attachment.Document.MIMEType,
+		Size:       attachment.Document.Size,
+		CreatedAt:  attachment.CreatedAt,
+	}
+}
@@ -0,0 +1,444 @@
+package service
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+	// The time zone database is embedded so that exports can be written in
+	// any zone whatever the host has installed.
+	_ "time/tzdata"
+
+	"lumon-backend/internal/categories"
+	"lumon-backend/internal/domain/models"
+	"lumon-backend/internal/domain/schemas"
+	"lumon-backend/internal/repository/interfaces"
+	"lumon-backend/pkg/common/logger"
+	"lumon-backend/pkg/common/ofx"
+	"lumon-backend/pkg/common/xlsx"
+
+	"github.com/google/uuid"
+)
+
+var ErrInvalidTransactionExport = errors.New("invalid transaction export")
+
+const (
+	TransactionExportCSV  = "csv"
+	TransactionExportXLSX = "xlsx"
+	TransactionExportOFX  = "ofx"
+)
+
+// transactionExportBatchSize is how many transactions are read at a time
+// while an export is streamed.
+const transactionExportBatchSize = 500
+
+const (
+	transactionExportDateLayout = "2006-01-02 15:04:05"
+	transactionExportCurrency   = "GHS"
+)
+
+// transactionExportColumn is a column of a CSV or XLSX export. Values are
+// strings or float64 amounts, which spreadsheets keep as numbers.
+type transactionExportColumn struct {
+	name  string
+	value func(transaction *models.Transaction, loc *time.Location) any
+}
+
+// transactionExportColumns are the columns that can be exported, in the order
+// they are written when none are chosen.
+var transactionExportColumns = []transactionExportColumn{
+	{"id", func(t *models.Transaction, _ *time.Location) any { return t.ID.String() }},
+	{"date", func(t *models.Transaction, loc *time.Location) any {
+		return t.TransactionDate.In(loc).Format(transactionExportDateLayout)
+	}},
+	{"type", func(t *models.Transaction, _ *time.Location) any { return t.TransactionType }},
+	{"direction", func(t *models.Transaction, _ *time.Location) any {
+		if categories.IsIncoming(t) {
+			return "in"
+		}
+		return "out"
+	}},
+	{"counterparty", func(t *models.Transaction, _ *time.Location) any {
+		name, _ := categories.Counterparty(t)
+		return name
+	}},
+	{"counterparty_number", func(t *models.Transaction, _ *time.Location) any {
+		_, number := categories.Counterparty(t)
+		return number
+	}},
+	{"counterparty_id", func(t *models.Transaction, _ *time.Location) any {
+		if t.CounterpartyID == nil {
+			return ""
+		}
+		return t.CounterpartyID.String()
+	}},
+	{"amount", func(t *models.Transaction, _ *time.Location) any { return t.Amount }},
+	{"fees", func(t *models.Transaction, _ *time.Location) any { return t.Fees }},
+	{"e_levy", func(t *models.Transaction, _ *time.Location) any { return t.ELevy }},
+	{"balance_before", func(t *models.Transaction, _ *time.Location) any { return t.BalanceBefore }},
+	{"balance_after", func(t *models.Transaction, _ *time.Location) any { return t.BalanceAfter }},
+	{"category", func(t *models.Transaction, _ *time.Location) any { return t.Category }},
+	{"tags", func(t *models.Transaction, _ *time.Location) any {
+		names := make([]string, len(t.Tags))
+		for i, tag := range t.Tags {
+			names[i] = tag.Name
+		}
+		return strings.Join(names, ";")
+	}},
+	{"reference", func(t *models.Transaction, _ *time.Location) any { return t.Reference }},
+	{"description", func(t *models.Transaction, _ *time.Location) any { return t.Description }},
+	{"from_name", func(t *models.Transaction, _ *time.Location) any { return t.FromName }},
+	{"from_number", func(t *models.Transaction, _ *time.Location) any { return t.FromNumber }},
+	{"from_account", func(t *models.Transaction, _ *time.Location) any { return t.FromAccount }},
+	{"to_name", func(t *models.Transaction, _ *time.Location) any { return t.ToName }},
+	{"to_number", func(t *models.Transaction, _ *time.Location) any { return t.ToNumber }},
+	{"to_account", func(t *models.Transaction, _ *time.Location) any { return t.ToAccount }},
+	{"provider", func(t *models.Transaction, _ *time.Location) any { return t.Provider }},
+}
+
+// TransactionExport is a checked export request, ready to be streamed once
+// the response headers are written.
+type TransactionExport struct {
+	Format      string
+	Filename    string
+	ContentType string
+
+	repo    interfaces.TransactionRepository
+	query   interfaces.TransactionQuery
+	columns []transactionExportColumn
+	loc     *time.Location
+	userID  uuid.UUID
+}
+
+// NewExport checks an export of the user's transactions. The filters and
+// sort are those of SearchTransactions, but the whole result is exported,
+// oldest first unless another order is asked for. OFX statements are always
+// in date order and have a fixed set of fields.
+func (s *TransactionService) NewExport(
+	userID string, req schemas.TransactionExportRequest,
+) (*TransactionExport, error) {
+	export := &TransactionExport{
+		Format: strings.ToLower(req.Format),
+		repo:   s.repo,
+		userID: uuid.MustParse(userID),
+		loc:    time.UTC,
+	}
+
+	switch export.Format {
+	case "":
+		export.Format = TransactionExportCSV
+		export.ContentType = "text/csv; charset=utf-8"
+	case TransactionExportCSV:
+		export.ContentType = "text/csv; charset=utf-8"
+	case TransactionExportXLSX:
+		export.ContentType = "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+	case TransactionExportOFX:
+		export.ContentType = "application/x-ofx"
+	default:
+		return nil, fmt.Errorf("%w: format must be csv, xlsx or ofx", ErrInvalidTransactionExport)
+	}
+
+	if req.Timezone != "" {
+		loc, err := time.LoadLocation(req.Timezone)
+		if err != nil {
+			return nil, fmt.Errorf("%w: unknown time zone %q", ErrInvalidTransactionExport, req.Timezone)
+		}
+		export.loc = loc
+	}
+
+	columns, err := transactionExportColumnsNamed(req.Columns)
+	if err != nil {
+		return nil, err
+	}
+	if export.Format == TransactionExportOFX && req.Columns != "" {
+		return nil, fmt.Errorf("%w: columns cannot be chosen for ofx", ErrInvalidTransactionExport)
+	}
+	export.columns = columns
+
+	search := req.TransactionSearchRequest
+	search.Limit, search.Cursor = 0, ""
+	if search.Sort == "" || export.Format == TransactionExportOFX {
+		search.Sort = "date"
+	}
+
+	query, err := newTransactionQuery(export.userID, search, export.loc)
+	if err != nil {
+		return nil, err
+	}
+	query.Limit = transactionExportBatchSize
+	export.query = query
+
+	export.Filename = fmt.Sprintf("transactions-%s.%s", time.Now().In(export.loc).Format("20060102"), export.Format)
+
+	return export, nil
+}
+
+// WriteTo streams the export to w. It stops early when ctx is cancelled, as
+// when the client goes away.
+func (e *TransactionExport) WriteTo(ctx context.Context, w io.Writer) error {
+	var err error
+	switch e.Format {
+	case TransactionExportXLSX:
+		err = e.writeXLSX(ctx, w)
+	case TransactionExportOFX:
+		err = e.writeOFX(ctx, w)
+	default:
+		err = e.writeCSV(ctx, w)
+	}
+
+	if err != nil {
+		logger.APILogger.Errorw("transaction export failed",
+			"user_id", e.userID,
+			"format", e.Format,
+			"error", err,
+		)
+	}
+	return err
+}
+
+func (e *TransactionExport) writeCSV(ctx context.Context, w io.Writer) error {
+	writer := csv.NewWriter(w)
+
+	header := make([]string, len(e.columns))
+	for i, column := range e.columns {
+		header[i] = column.name
+	}
+	if err := writer.Write(header); err != nil {
+		return err
+	}
+
+	record := make([]string, len(e.columns))
+	err := e.each(ctx, func(transaction *models.Transaction) error {
+		for i, column := range e.columns {
+			switch value := column.value(transaction, e.loc).(type) {
+			case float64:
+				record[i] = strconv.FormatFloat(value, 'f', 2, 64)
+			default:
+				record[i] = escapeCSVFormula(fmt.Sprint(value))
+			}
+		}
+		return writer.Write(record)
+	}, writer.Flush)
+	if err != nil {
+		return err
+	}
+
+	writer.Flush()
+	return writer.Error()
+}
+
+// escapeCSVFormula keeps spreadsheets from running text taken from
+// statements, such as a counterparty name, as a formula when the CSV is
+// opened, by quoting text that starts like one.
+func escapeCSVFormula(value string) string {
+	if value != "" && strings.ContainsRune("=+-@\t\r", rune(value[0])) {
+		return "'" + value
+	}
+	return value
+}
+
+func (e *TransactionExport) writeXLSX(ctx context.Context, w io.Writer) error {
+	writer, err := xlsx.NewWriter(w, "Transactions")
+	if err != nil {
+		return err
+	}
+
+	row := make([]any, len(e.columns))
+	for i, column := range e.columns {
+		row[i] = column.name
+	}
+	if err := writer.WriteRow(row); err != nil {
+		return err
+	}
+
+	err = e.each(ctx, func(transaction *models.Transaction) error {
+		for i, column := range e.columns {
+			row[i] = column.value(transaction, e.loc)
+		}
+		return writer.WriteRow(row)
+	}, nil)
+	if err != nil {
+		return err
+	}
+
+	return writer.Close()
+}
+
+// writeOFX writes the transactions as a single account statement, with the
+// fees and E-levy of each transaction as a separate entry. The statement
+// header needs the first transaction, so it is written once the first batch
+// has been read.
+func (e *TransactionExport) writeOFX(ctx context.Context, w io.Writer) error {
+	end := time.Now()
+	if e.query.To != nil {
+		end = *e.query.To
+	}
+
+	var writer *ofx.Writer
+	start := func(first *models.Transaction) error {
+		from := end
+		if e.query.From != nil {
+			from = *e.query.From
+		} else if first != nil {
+			from = first.TransactionDate
+		}
+
+		var err error
+		writer, err = ofx.NewWriter(w, e.ofxAccount(first), from, end, e.loc)
+		return err
+	}
+
+	var last *models.Transaction
+	err := e.each(ctx, func(transaction *models.Transaction) error {
+		if writer == nil {
+			if err := start(transaction); err != nil {
+				return err
+			}
+		}
+
+		name, number := categories.Counterparty(transaction)
+		if name == "" {
+			name = number
+		}
+		memo := transaction.Description
+		if memo == "" {
+			memo = transaction.Reference
+		}
+
+		entry := ofx.Transaction{
+			Type:   ofx.TypeDebit,
+			Posted: transaction.TransactionDate,
+			Amount: -transaction.Amount,
+			ID:     transaction.ID.String(),
+			Name:   name,
+			Memo:   memo,
+		}
+		if categories.IsIncoming(transaction) {
+			entry.Type, entry.Amount = ofx.TypeCredit, transaction.Amount
+		}
+		if err := writer.WriteTransaction(entry); err != nil {
+			return err
+		}
+
+		if charges := transaction.Fees + transaction.ELevy; charges > 0 {
+			err := writer.WriteTransaction(ofx.Transaction{
+				Type:   ofx.TypeFee,
+				Posted: transaction.TransactionDate,
+				Amount: -charges,
+				ID:     transaction.ID.String() + "-fees",
+				Name:   "Fees and E-levy",
+				Memo:   memo,
+			})
+			if err != nil {
+				return err
+			}
+		}
+
+		copied := *transaction
+		last = &copied
+		return nil
+	}, nil)
+	if err != nil {
+		return err
+	}
+
+	if writer == nil {
+		if err := start(nil); err != nil {
+			return err
+		}
+	}
+
+	if last != nil && (last.BalanceBefore != 0 || last.BalanceAfter != 0) {
+		return writer.Close(&last.BalanceAfter, last.TransactionDate)
+	}
+	return writer.Close(nil, end)
+}
+
+// ofxAccount names the account after the user's own number on the first
+// transaction, falling back to the user's ID.
+func (e *TransactionExport) ofxAccount(first *models.Transaction) ofx.Account {
+	account := ofx.Account{BankID: "LUMON", AccountID: e.userID.String(), Currency: transactionExportCurrency}
+	if first == nil {
+		return account
+	}
+
+	if first.Provider != "" {
+		account.BankID = strings.ToUpper(first.Provider)
+	}
+
+	own := first.FromNumber
+	if categories.IsIncoming(first) {
+		own = first.ToNumber
+	}
+	if own != "" {
+		account.AccountID = own
+	}
+	return account
+}
+
+// each calls fn with every transaction of the export, reading them a batch at
+// a time. flush, when set, is called after each batch.
+func (e *TransactionExport) each(
+	ctx context.Context, fn func(transaction *models.Transaction) error, flush func(),
+) error {
+	query := e.query
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		batch, err := e.repo.Search(query)
+		if err != nil {
+			return err
+		}
+
+		for i := range batch {
+			if err := fn(&batch[i]); err != nil {
+				return err
+			}
+		}
+		if flush != nil {
+			flush()
+		}
+
+		if len(batch) < query.Limit {
+			return nil
+		}
+
+		last := batch[len(batch)-1]
+		query.After = &interfaces.TransactionCursor{Date: last.TransactionDate, Amount: last.Amount, ID: last.ID}
+	}
+}
+
+func transactionExportColumnsNamed(names string) ([]transactionExportColumn, error) {
+	if strings.TrimSpace(names) == "" {
+		return transactionExportColumns, nil
+	}
+
+	byName := make(map[string]transactionExportColumn, len(transactionExportColumns))
+	for _, column := range transactionExportColumns {
+		byName[column.name] = column
+	}
+
+	var columns []transactionExportColumn
+	chosen := make(map[string]bool)
+	for _, name := range strings.Split(names, ",") {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" || chosen[name] {
+			continue
+		}
+
+		column, ok := byName[name]
+		if !ok {
+			return nil, fmt.Errorf("%w: unknown column %q", ErrInvalidTransactionExport, name)
+		}
+		chosen[name] = true
+		columns = append(columns, column)
+	}
+
+	return columns, nil
+}
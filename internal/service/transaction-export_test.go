@@ -0,0 +1,110 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"errors"
+	"testing"
+	"time"
+
+	"lumon-backend/internal/domain/models"
+	"lumon-backend/internal/domain/schemas"
+	"lumon-backend/internal/repository/interfaces"
+	"lumon-backend/internal/statements"
+
+	"github.com/google/uuid"
+)
+
+// fakeTransactionRepository returns its transactions from a search, keeping
+// the queries it was given.
+type fakeTransactionRepository struct {
+	interfaces.TransactionRepository
+	transactions []models.Transaction
+	queries      []interfaces.TransactionQuery
+}
+
+func (r *fakeTransactionRepository) Search(query interfaces.TransactionQuery) ([]models.Transaction, error) {
+	r.queries = append(r.queries, query)
+	return r.transactions, nil
+}
+
+func TestTransactionExportEscapesFormulas(t *testing.T) {
+	repo := &fakeTransactionRepository{transactions: []models.Transaction{{
+		TransactionDate: time.Date(2024, time.June, 1, 10, 0, 0, 0, time.UTC),
+		TransactionType: statements.TypeTransfer,
+		Amount:          50,
+		ToName:          "=cmd|' /C calc'!A0",
+		Description:     "-2+3",
+	}}}
+	service := NewTransactionService(repo, nil)
+
+	export, err := service.NewExport(uuid.NewString(), schemas.TransactionExportRequest{
+		Columns: "counterparty,description,amount",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var out bytes.Buffer
+	if err := export.WriteTo(context.Background(), &out); err != nil {
+		t.Fatal(err)
+	}
+
+	records, err := csv.NewReader(&out).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"'=cmd|' /C calc'!A0", "'-2+3", "50.00"}
+	if len(records) != 2 {
+		t.Fatalf("records = %q, want a header and one row", records)
+	}
+	for i, value := range want {
+		if records[1][i] != value {
+			t.Errorf("%s = %q, want %q", records[0][i], records[1][i], value)
+		}
+	}
+}
+
+func TestTransactionExportDatesInTimeZone(t *testing.T) {
+	repo := &fakeTransactionRepository{transactions: []models.Transaction{{
+		TransactionDate: time.Date(2024, time.June, 2, 3, 30, 0, 0, time.UTC),
+		TransactionType: statements.TypeTransfer,
+		Amount:          50,
+	}}}
+	service := NewTransactionService(repo, nil)
+
+	export, err := service.NewExport(uuid.NewString(), schemas.TransactionExportRequest{
+		TransactionSearchRequest: schemas.TransactionSearchRequest{From: "2024-06-01", To: "2024-06-01"},
+		Columns:                  "date",
+		Timezone:                 "America/New_York",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The first of June in New York runs from 04:00 UTC to 04:00 the next day.
+	from, to := export.query.From, export.query.To
+	if from == nil || !from.Equal(time.Date(2024, time.June, 1, 4, 0, 0, 0, time.UTC)) {
+		t.Errorf("from = %v, want 2024-06-01 04:00 UTC", from)
+	}
+	if to == nil || !to.Equal(time.Date(2024, time.June, 2, 3, 59, 59, 999999000, time.UTC)) {
+		t.Errorf("to = %v, want just before 2024-06-02 04:00 UTC", to)
+	}
+
+	var out bytes.Buffer
+	if err := export.WriteTo(context.Background(), &out); err != nil {
+		t.Fatal(err)
+	}
+	records, err := csv.NewReader(&out).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 2 || records[1][0] != "2024-06-01 23:30:00" {
+		t.Errorf("records = %q, want the date in New York time", records)
+	}
+
+	_, err = service.NewExport(uuid.NewString(), schemas.TransactionExportRequest{Timezone: "Mars/Olympus"})
+	if !errors.Is(err, ErrInvalidTransactionExport) {
+		t.Errorf("export in an unknown time zone: error = %v, want %v", err, ErrInvalidTransactionExport)
+	}
+}
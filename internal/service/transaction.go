@@ -1,221 +1,275 @@
 package service
 
 import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
 	"strings"
 	"time"
 
 	"lumon-backend/internal/domain/models"
 	"lumon-backend/internal/domain/schemas"
+	"lumon-backend/internal/recurrence"
 	"lumon-backend/internal/repository/interfaces"
 	"lumon-backend/pkg/common/logger"
 
 	"github.com/google/uuid"
 )
 
+var (
+	ErrTransactionNotFound     = errors.New("transaction not found")
+	ErrInvalidTransactionQuery = errors.New("invalid transaction query")
+)
+
+const (
+	defaultTransactionPageSize = 20
+	maxTransactionPageSize     = 100
+)
+
 type TransactionService struct {
-	repo interfaces.TransactionRepository
+	repo      interfaces.TransactionRepository
+	analytics *AnalyticsService
 }
 
-func NewTransactionService(repo interfaces.TransactionRepository) *TransactionService {
-	return &TransactionService{repo: repo}
+func NewTransactionService(repo interfaces.TransactionRepository, analytics *AnalyticsService) *TransactionService {
+	return &TransactionService{repo: repo, analytics: analytics}
 }
 
 func (s *TransactionService) CreateTransaction(transaction *models.Transaction) error {
-	return s.repo.Create(transaction)
+	if err := s.repo.Create(transaction); err != nil {
+		return err
+	}
+	s.analytics.Invalidate(transaction.UserID)
+	return nil
 }
 
+// CreateTransactionBatch stores the transactions extracted from one of the
+// user's statements and returns how many were skipped because the user
+// already had them.
 func (s *TransactionService) CreateTransactionBatch(
-	transactions []*schemas.MTNMoMoTransactionScrape, batchSize int, userID string,
-) error {
-	newTransactions := make([]*models.Transaction, len(transactions))
-
-	for i, trans := range transactions {
-		timeStr := strings.Trim(trans.TransactionDate, "\"")
-		layout := "02-Jan-2006 03:04:05 PM"
-
-		parsedTime, err := time.Parse(layout, timeStr)
-		if err != nil {
-			logger.APILogger.Error(err)
-			return err
-		}
-
-		newTransactions[i] = &models.Transaction{
-			TransactionDate: parsedTime,
-			FromName:        trans.FromName,
-			FromNumber:      trans.FromNumber,
-			TransactionType: trans.TransactionType,
-			Amount:          trans.Amount,
-			ToNumber:        trans.ToNumber,
-			ToName:          trans.ToName,
-			Reference:       trans.Reference,
-			FromAccount:     trans.FromAccount,
-			Fees:            trans.Fees,
-			BalanceBefore:   trans.BalanceBefore,
-			BalanceAfter:    trans.BalanceAfter,
-			ToAccount:       trans.ToAccount,
-			UserID:          uuid.MustParse(userID),
-		}
+	transactions []*models.Transaction, batchSize int, userID string,
+) (int, error) {
+	for _, transaction := range transactions {
+		transaction.UserID = uuid.MustParse(userID)
 	}
 
-	return s.repo.CreateBatch(newTransactions, batchSize)
+	skipped, err := s.repo.CreateBatch(transactions, batchSize)
+	if err != nil {
+		return skipped, err
+	}
+	s.analytics.Invalidate(uuid.MustParse(userID))
+	return skipped, nil
 }
 
 func (s *TransactionService) UpdateTransaction(transaction *models.Transaction) error {
-	return s.repo.Update(transaction)
+	if err := s.repo.Update(transaction); err != nil {
+		return err
+	}
+	s.analytics.Invalidate(transaction.UserID)
+	return nil
 }
 
 func (s *TransactionService) DeleteTransaction(id string) error {
-	return s.repo.Delete(uuid.MustParse(id))
+	transaction, err := s.repo.GetByID(uuid.MustParse(id))
+	if err != nil {
+		return err
+	}
+	if err := s.repo.Delete(transaction.ID); err != nil {
+		return err
+	}
+	s.analytics.Invalidate(transaction.UserID)
+	return nil
 }
 
-func (s *TransactionService) GetTransaction(id string) (*schemas.TransactionResponse, error) {
+// GetTransaction returns one of the user's transactions. Another user's
+// transaction is reported as not found.
+func (s *TransactionService) GetTransaction(userID, id string) (*schemas.TransactionResponse, error) {
 	dbTransaction, err := s.repo.GetByID(uuid.MustParse(id))
 	if err != nil {
 		logger.APILogger.Error(err)
+		return nil, ErrTransactionNotFound
+	}
+
+	if dbTransaction.UserID != uuid.MustParse(userID) {
+		return nil, ErrTransactionNotFound
+	}
+
+	response := NewTransactionResponse(*dbTransaction)
+	return &response, nil
+}
+
+// SearchTransactions returns a page of the user's transactions matching the
+// request, newest first unless another order is asked for.
+func (s *TransactionService) SearchTransactions(
+	userID string, req schemas.TransactionSearchRequest,
+) (*schemas.TransactionPage, error) {
+	query, err := newTransactionQuery(uuid.MustParse(userID), req, time.UTC)
+	if err != nil {
 		return nil, err
 	}
 
-	return &schemas.TransactionResponse{
-		UserID:          dbTransaction.UserID.String(),
-		TransactionDate: dbTransaction.TransactionDate,
-		FromName:        dbTransaction.FromName,
-		FromNumber:      dbTransaction.FromNumber,
-		TransactionType: dbTransaction.TransactionType,
-		BalanceBefore:   dbTransaction.BalanceBefore,
-		BalanceAfter:    dbTransaction.BalanceAfter,
-		Amount:          dbTransaction.Amount,
-		ToNumber:        dbTransaction.ToNumber,
-		ToName:          dbTransaction.ToName,
-		Reference:       dbTransaction.Reference,
-	}, nil
-}
-
-func (s *TransactionService) GetTransactionsByType(
-	transactionType string, page, pageSize int,
-) ([]schemas.TransactionResponse, int64, error) {
-	dbTransactions, total, err := s.repo.GetByType(transactionType, page, pageSize)
+	// One extra transaction is fetched to tell whether there is a next page.
+	limit := query.Limit
+	query.Limit++
+
+	dbTransactions, err := s.repo.Search(query)
 	if err != nil {
 		logger.APILogger.Error(err)
-		return nil, 0, err
-	}
-
-	transactions := make([]schemas.TransactionResponse, len(dbTransactions))
-	for i, dbTransaction := range dbTransactions {
-		transactions[i] = schemas.TransactionResponse{
-			UserID:          dbTransaction.UserID.String(),
-			TransactionDate: dbTransaction.TransactionDate,
-			FromName:        dbTransaction.FromName,
-			FromNumber:      dbTransaction.FromNumber,
-			TransactionType: dbTransaction.TransactionType,
-			BalanceBefore:   dbTransaction.BalanceBefore,
-			BalanceAfter:    dbTransaction.BalanceAfter,
-			Amount:          dbTransaction.Amount,
-			ToNumber:        dbTransaction.ToNumber,
-			ToName:          dbTransaction.ToName,
-			Reference:       dbTransaction.Reference,
-		}
+		return nil, err
+	}
+
+	page := &schemas.TransactionPage{Transactions: []schemas.TransactionResponse{}}
+	if len(dbTransactions) > limit {
+		dbTransactions = dbTransactions[:limit]
+		page.HasMore = true
+	}
+
+	for _, dbTransaction := range dbTransactions {
+		page.Transactions = append(page.Transactions, NewTransactionResponse(dbTransaction))
 	}
 
-	return transactions, total, nil
+	if page.HasMore {
+		last := dbTransactions[len(dbTransactions)-1]
+		page.NextCursor = encodeTransactionCursor(transactionCursor{
+			Sort:   req.Sort,
+			Date:   last.TransactionDate,
+			Amount: last.Amount,
+			ID:     last.ID,
+		})
+	}
+
+	return page, nil
 }
 
-func (s *TransactionService) GetTransactionsByAccountNumber(
-	accountNumber string, page, pageSize int,
-) ([]schemas.TransactionResponse, int64, error) {
-	dbTransactions, total, err := s.repo.GetByAccountNumber(accountNumber, page, pageSize)
-	if err != nil {
-		logger.APILogger.Error(err)
-		return nil, 0, err
-	}
-
-	transactions := make([]schemas.TransactionResponse, len(dbTransactions))
-	for i, dbTransaction := range dbTransactions {
-		transactions[i] = schemas.TransactionResponse{
-			UserID:          dbTransaction.UserID.String(),
-			TransactionDate: dbTransaction.TransactionDate,
-			FromName:        dbTransaction.FromName,
-			FromNumber:      dbTransaction.FromNumber,
-			TransactionType: dbTransaction.TransactionType,
-			BalanceBefore:   dbTransaction.BalanceBefore,
-			BalanceAfter:    dbTransaction.BalanceAfter,
-			Amount:          dbTransaction.Amount,
-			ToNumber:        dbTransaction.ToNumber,
-			ToName:          dbTransaction.ToName,
-			Reference:       dbTransaction.Reference,
+// newTransactionQuery checks a search request. Dates given on their own are
+// whole days in loc.
+func newTransactionQuery(
+	userID uuid.UUID, req schemas.TransactionSearchRequest, loc *time.Location,
+) (interfaces.TransactionQuery, error) {
+	query := interfaces.TransactionQuery{
+		UserID:       userID,
+		MinAmount:    req.MinAmount,
+		MaxAmount:    req.MaxAmount,
+		Counterparty: strings.TrimSpace(req.Counterparty),
+		Category:     strings.ToLower(strings.TrimSpace(req.Category)),
+		Text:         strings.TrimSpace(req.Query),
+		Limit:        req.Limit,
+	}
+
+	for _, transactionType := range strings.Split(req.Type, ",") {
+		if transactionType = strings.ToUpper(strings.TrimSpace(transactionType)); transactionType != "" {
+			query.Types = append(query.Types, transactionType)
 		}
 	}
 
-	return transactions, total, nil
-}
+	for _, tag := range strings.Split(req.Tag, ",") {
+		if tag = NormalizeTag(tag); tag != "" {
+			query.Tags = append(query.Tags, tag)
+		}
+	}
 
-func (s *TransactionService) GetTransactionsByDateRange(
-	start, end string, page, pageSize int,
-) ([]schemas.TransactionResponse, int64, error) {
-	startTime, err := time.Parse(time.RFC3339, start)
-	if err != nil {
-		logger.APILogger.Error(err)
-		return nil, 0, err
+	if req.CounterpartyID != "" {
+		counterpartyID, err := uuid.Parse(req.CounterpartyID)
+		if err != nil {
+			return query, fmt.Errorf("%w: counterparty_id must be a UUID", ErrInvalidTransactionQuery)
+		}
+		query.CounterpartyID = &counterpartyID
 	}
 
-	endTime, err := time.Parse(time.RFC3339, end)
-	if err != nil {
-		logger.APILogger.Error(err)
-		return nil, 0, err
+	if req.From != "" {
+		from, _, err := parseQueryTime(req.From, loc)
+		if err != nil {
+			return query, fmt.Errorf("%w: from: %v", ErrInvalidTransactionQuery, err)
+		}
+		from = from.UTC()
+		query.From = &from
+	}
+	if req.To != "" {
+		to, dateOnly, err := parseQueryTime(req.To, loc)
+		if err != nil {
+			return query, fmt.Errorf("%w: to: %v", ErrInvalidTransactionQuery, err)
+		}
+		// A date on its own includes the whole of that day.
+		if dateOnly {
+			to = to.AddDate(0, 0, 1).Add(-time.Microsecond)
+		}
+		to = to.UTC()
+		query.To = &to
+	}
+	if query.From != nil && query.To != nil && query.To.Before(*query.From) {
+		return query, fmt.Errorf("%w: to is before from", ErrInvalidTransactionQuery)
+	}
+	if query.MinAmount != nil && query.MaxAmount != nil && *query.MaxAmount < *query.MinAmount {
+		return query, fmt.Errorf("%w: max_amount is below min_amount", ErrInvalidTransactionQuery)
 	}
 
-	dbTransactions, total, err := s.repo.GetByDateRange(startTime, endTime, page, pageSize)
-	if err != nil {
-		logger.APILogger.Error(err)
-		return nil, 0, err
-	}
-
-	transactions := make([]schemas.TransactionResponse, len(dbTransactions))
-	for i, dbTransaction := range dbTransactions {
-		transactions[i] = schemas.TransactionResponse{
-			UserID:          dbTransaction.UserID.String(),
-			TransactionDate: dbTransaction.TransactionDate,
-			FromName:        dbTransaction.FromName,
-			FromNumber:      dbTransaction.FromNumber,
-			TransactionType: dbTransaction.TransactionType,
-			BalanceBefore:   dbTransaction.BalanceBefore,
-			BalanceAfter:    dbTransaction.BalanceAfter,
-			Amount:          dbTransaction.Amount,
-			ToNumber:        dbTransaction.ToNumber,
-			ToName:          dbTransaction.ToName,
-			Reference:       dbTransaction.Reference,
+	switch strings.TrimPrefix(req.Sort, "-") {
+	case "", "date":
+		query.SortBy = interfaces.TransactionSortDate
+	case "amount":
+		query.SortBy = interfaces.TransactionSortAmount
+	default:
+		return query, fmt.Errorf("%w: sort must be date or amount", ErrInvalidTransactionQuery)
+	}
+	query.Descending = req.Sort == "" || strings.HasPrefix(req.Sort, "-")
+
+	switch {
+	case query.Limit <= 0:
+		query.Limit = defaultTransactionPageSize
+	case query.Limit > maxTransactionPageSize:
+		query.Limit = maxTransactionPageSize
+	}
+
+	if req.Cursor != "" {
+		cursor, err := decodeTransactionCursor(req.Cursor)
+		if err != nil || cursor.Sort != req.Sort {
+			return query, fmt.Errorf("%w: cursor does not belong to this search", ErrInvalidTransactionQuery)
 		}
+		query.After = &interfaces.TransactionCursor{Date: cursor.Date, Amount: cursor.Amount, ID: cursor.ID}
+	}
+
+	return query, nil
+}
+
+// parseQueryTime reads an RFC 3339 time or a date, reporting which it was. A
+// date is the start of that day in loc.
+func parseQueryTime(value string, loc *time.Location) (time.Time, bool, error) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t.UTC(), false, nil
+	}
+
+	t, err := time.ParseInLocation(time.DateOnly, value, loc)
+	if err != nil {
+		return time.Time{}, false, fmt.Errorf("%q is not a date or RFC 3339 time", value)
 	}
+	return t, true, nil
+}
+
+// transactionCursor is handed to clients as an opaque token. It records the
+// sort it was issued for so that it cannot be replayed against another one.
+type transactionCursor struct {
+	Sort   string    `json:"s"`
+	Date   time.Time `json:"t"`
+	Amount float64   `json:"a"`
+	ID     uuid.UUID `json:"id"`
+}
 
-	return transactions, total, nil
+func encodeTransactionCursor(cursor transactionCursor) string {
+	data, _ := json.Marshal(cursor)
+	return base64.RawURLEncoding.EncodeToString(data)
 }
 
-func (s *TransactionService) ListTransactions(userID string,
-	page, pageSize int,
-) ([]schemas.TransactionResponse, int64, error) {
-	dbTransactions, total, err := s.repo.List(uuid.MustParse(userID), page, pageSize)
+func decodeTransactionCursor(token string) (transactionCursor, error) {
+	var cursor transactionCursor
+
+	data, err := base64.RawURLEncoding.DecodeString(token)
 	if err != nil {
-		logger.APILogger.Error(err)
-		return nil, 0, err
-	}
-
-	transactions := make([]schemas.TransactionResponse, len(dbTransactions))
-	for i, dbTransaction := range dbTransactions {
-		transactions[i] = schemas.TransactionResponse{
-			UserID:          dbTransaction.UserID.String(),
-			TransactionDate: dbTransaction.TransactionDate,
-			FromName:        dbTransaction.FromName,
-			FromNumber:      dbTransaction.FromNumber,
-			TransactionType: dbTransaction.TransactionType,
-			BalanceBefore:   dbTransaction.BalanceBefore,
-			BalanceAfter:    dbTransaction.BalanceAfter,
-			Amount:          dbTransaction.Amount,
-			ToNumber:        dbTransaction.ToNumber,
-			ToName:          dbTransaction.ToName,
-			Reference:       dbTransaction.Reference,
-		}
+		return cursor, err
 	}
 
-	return transactions, total, nil
+	err = json.Unmarshal(data, &cursor)
+	return cursor, err
 }
 
 func (s *TransactionService) ListAllTransactions(userID string) ([]models.Transaction, error) {
@@ -227,3 +281,95 @@ func (s *TransactionService) ListAllTransactions(userID string) ([]models.Transa
 
 	return dbTransactions, nil
 }
+
+// ListRecurring returns the income and payments that recur in the user's
+// transactions, as of now.
+func (s *TransactionService) ListRecurring(userID string) (*schemas.RecurringResponse, error) {
+	transactions, err := s.repo.ListAll(uuid.MustParse(userID))
+	if err != nil {
+		logger.APILogger.Error(err)
+		return nil, err
+	}
+
+	response := &schemas.RecurringResponse{
+		Income:   []schemas.RecurringSeriesResponse{},
+		Payments: []schemas.RecurringSeriesResponse{},
+	}
+
+	for _, series := range recurrence.Detect(transactions, time.Now().UTC()) {
+		item := schemas.RecurringSeriesResponse{
+			Counterparty:     series.Counterparty,
+			Number:           series.Number,
+			Category:         series.Category,
+			Period:           series.Period,
+			ExpectedAmount:   series.ExpectedAmount,
+			MonthlyAmount:    roundAmount(series.MonthlyAmount()),
+			Occurrences:      series.Occurrences,
+			Missed:           series.Missed,
+			Punctuality:      series.Punctuality,
+			FirstDate:        series.FirstDate,
+			LastDate:         series.LastDate,
+			NextExpectedDate: series.NextExpected,
+			Confidence:       series.Confidence,
+			Active:           series.Active,
+		}
+
+		if series.Incoming {
+			response.Income = append(response.Income, item)
+			if series.Active {
+				response.MonthlyIncome += series.MonthlyAmount()
+			}
+			continue
+		}
+
+		response.Payments = append(response.Payments, item)
+		if series.Active {
+			response.MonthlyPayments += series.MonthlyAmount()
+		}
+	}
+
+	response.MonthlyIncome = roundAmount(response.MonthlyIncome)
+	response.MonthlyPayments = roundAmount(response.MonthlyPayments)
+
+	return response, nil
+}
+
+// NewTransactionResponse is the API representation of a stored or freshly
+// extracted transaction.
+func NewTransactionResponse(transaction models.Transaction) schemas.TransactionResponse {
+	response := schemas.TransactionResponse{
+		TransactionDate: transaction.TransactionDate,
+		Provider:        transaction.Provider,
+		FromAccount:     transaction.FromAccount,
+		FromName:        transaction.FromName,
+		FromNumber:      transaction.FromNumber,
+		TransactionType: transaction.TransactionType,
+		BalanceBefore:   transaction.BalanceBefore,
+		BalanceAfter:    transaction.BalanceAfter,
+		Amount:          transaction.Amount,
+		Fees:            transaction.Fees,
+		ELevy:           transaction.ELevy,
+		ExpectedFees:    transaction.ExpectedFees,
+		ExpectedELevy:   transaction.ExpectedELevy,
+		ToAccount:       transaction.ToAccount,
+		ToNumber:        transaction.ToNumber,
+		ToName:          transaction.ToName,
+		Reference:       transaction.Reference,
+		Description:     transaction.Description,
+		Category:        transaction.Category,
+		CategorySource:  transaction.CategorySource,
+	}
+	if transaction.ID != uuid.Nil {
+		response.ID = transaction.ID.String()
+	}
+	if transaction.CounterpartyID != nil {
+		response.CounterpartyID = transaction.CounterpartyID.String()
+	}
+	for _, tag := range transaction.Tags {
+		response.Tags = append(response.Tags, tag.Name)
+	}
+	if transaction.UserID != uuid.Nil {
+		response.UserID = transaction.UserID.String()
+	}
+	return response
+}
@@ -1,81 +1,115 @@
 package service
 
 import (
+	"errors"
 	"fmt"
+	"math"
+	"time"
+
 	"lumon-backend/internal/domain/models"
+	"lumon-backend/internal/domain/schemas"
+	"lumon-backend/internal/fees"
 	"lumon-backend/internal/repository/interfaces"
+	"lumon-backend/internal/statements"
 	"lumon-backend/pkg/common/logger"
 
 	"github.com/google/uuid"
 )
 
+const (
+	WalletEntryTopUp      = "top_up"
+	WalletEntryWithdrawal = "withdrawal"
+)
+
 type WalletService struct {
-	repo interfaces.WalletRepository
+	repo           interfaces.WalletRepository
+	anomalyService *AnomalyService
+	analytics      *AnalyticsService
+	fees           *fees.Engine
 }
 
-func NewWalletService(repo interfaces.WalletRepository) *WalletService {
-	return &WalletService{repo: repo}
+func NewWalletService(repo interfaces.WalletRepository, anomalyService *AnomalyService, analytics *AnalyticsService, feeEngine *fees.Engine) *WalletService {
+	return &WalletService{repo: repo, anomalyService: anomalyService, analytics: analytics, fees: feeEngine}
 }
 
-func (s *WalletService) TopUpAccount(wallet *models.Wallet) error {
+// TopUpAccount credits the wallet with the amount less the top-up fee.
+func (s *WalletService) TopUpAccount(wallet *models.Wallet) (*schemas.WalletOperationResponse, error) {
 	if wallet.Balance <= 0 {
-		return fmt.Errorf("amount must be positive")
+		return nil, fmt.Errorf("amount must be positive")
+	}
+
+	amount := wallet.Balance
+	fee := s.charge(statements.TypeCashIn, amount)
+	if fee >= amount {
+		return nil, fmt.Errorf("amount does not cover the fee of %d", fee)
 	}
+	wallet.Balance = amount - fee
 
 	existingWallet, err := s.repo.FindByID(wallet.ID.String())
 	if err != nil {
 		if err.Error() == "record not found" { 
-			err = s.repo.Create(wallet)
+			err = s.repo.CreateWithEntry(wallet, &models.WalletLedgerEntry{
+				Type: WalletEntryTopUp, Amount: amount, Fee: fee, BalanceAfter: wallet.Balance,
+			})
 			if err != nil {
 				logger.APILogger.Errorf("Failed to create wallet: %v", err)
-				return fmt.Errorf("failed to create wallet: %v", err)
+				return nil, fmt.Errorf("failed to create wallet: %v", err)
 			}
-			return nil
+			s.analytics.Invalidate(wallet.UserID)
+			s.anomalyService.CheckWalletMovement(wallet.UserID, float64(amount), true)
+			return &schemas.WalletOperationResponse{Amount: amount, Fee: fee, Balance: wallet.Balance}, nil
 		}
 		logger.APILogger.Errorf("Failed to get wallet: %v", err)
-		return fmt.Errorf("failed to get wallet: %v", err)
+		return nil, fmt.Errorf("failed to get wallet: %v", err)
 	}
 
-	newBalance := existingWallet.Balance + wallet.Balance
-	existingWallet.Balance = newBalance
-
-	err = s.repo.Update(existingWallet)
+	existingWallet, err = s.repo.MoveBalance(existingWallet.ID, wallet.Balance, &models.WalletLedgerEntry{
+		Type: WalletEntryTopUp, Amount: amount, Fee: fee,
+	})
 	if err != nil {
 		logger.APILogger.Errorf("Failed to update wallet: %v", err)
-		return fmt.Errorf("failed to update wallet: %v", err)
+		return nil, fmt.Errorf("failed to update wallet: %v", err)
 	}
 
-	return nil
+	s.analytics.Invalidate(existingWallet.UserID)
+	s.anomalyService.CheckWalletMovement(existingWallet.UserID, float64(amount), true)
+
+	return &schemas.WalletOperationResponse{Amount: amount, Fee: fee, Balance: existingWallet.Balance}, nil
 }
 
-func (s *WalletService) WithdrawAccount(walletID string, amount int64) error {
+// WithdrawAccount debits the wallet with the amount and the withdrawal fee.
+func (s *WalletService) WithdrawAccount(walletID string, amount int64) (*schemas.WalletOperationResponse, error) {
 	if amount <= 0 {
-		return fmt.Errorf("amount must be positive")
+		return nil, fmt.Errorf("amount must be positive")
 	}
 
 	id, err := uuid.Parse(walletID)
 	if err != nil {
-		return fmt.Errorf("invalid wallet ID: %v", err)
-	}
-
-	currentWallet, err := s.repo.FindByID(id.String())
-	if err != nil {
-		logger.APILogger.Errorf("Failed to get wallet: %v", err)
-		return fmt.Errorf("failed to get wallet: %v", err)
+		return nil, fmt.Errorf("invalid wallet ID: %v", err)
 	}
 
-	if currentWallet.Balance < amount {
-		return fmt.Errorf("insufficient funds")
+	fee := s.charge(statements.TypeCashOut, amount)
+	currentWallet, err := s.repo.MoveBalance(id, -(amount + fee), &models.WalletLedgerEntry{
+		Type: WalletEntryWithdrawal, Amount: amount, Fee: fee,
+	})
+	if errors.Is(err, interfaces.ErrInsufficientBalance) {
+		return nil, err
 	}
-
-	newBalance := currentWallet.Balance - amount
-	currentWallet.Balance = newBalance
-
-	err = s.repo.Update(currentWallet)
 	if err != nil {
 		logger.APILogger.Errorf("Failed to update wallet: %v", err)
-		return fmt.Errorf("failed to update wallet: %v", err)
+		return nil, fmt.Errorf("failed to update wallet: %v", err)
 	}
 
-	return nil
+	s.analytics.Invalidate(currentWallet.UserID)
+	s.anomalyService.CheckWalletMovement(currentWallet.UserID, float64(amount), false)
+
+	return &schemas.WalletOperationResponse{Amount: amount, Fee: fee, Balance: currentWallet.Balance}, nil
+}
+
+// charge is the fee and levy on a wallet operation. Wallets hold whole
+// units, so the charge is rounded up to the next one, never waived.
+func (s *WalletService) charge(transactionType string, amount int64) int64 {
+	charges := s.fees.Charge(fees.ProviderWallet, transactionType, float64(amount), time.Now().UTC(), false, 0)
+	cents := math.Round((charges.Fee + charges.ELevy) * 100)
+	return int64(math.Ceil(cents / 100))
 }
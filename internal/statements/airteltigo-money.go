@@ -0,0 +1,54 @@
+package statements
+
+const ProviderAirtelTigoMoney = "airteltigo_money"
+
+// airtelTigoHeadings maps the headings of AirtelTigo Money (AT Money)
+// statements. Amounts are unsigned, with a Dr/Cr column giving the direction,
+// and there is a single counterparty column.
+var airtelTigoHeadings = map[string]int{
+	"DATE":               columnDate,
+	"DATETIME":           columnDate,
+	"TRANSACTIONDATE":    columnDate,
+	"TRANSID":            columnReference,
+	"TRANSACTIONID":      columnReference,
+	"TRANSTYPE":          columnType,
+	"TRANSACTIONTYPE":    columnType,
+	"SERVICE":            columnType,
+	"DRCR":               columnDirection,
+	"DEBITCREDIT":        columnDirection,
+	"AMOUNT":             columnAmount,
+	"AMOUNTGHS":          columnAmount,
+	"CHARGES":            columnFees,
+	"FEE":                columnFees,
+	"FEES":               columnFees,
+	"ELEVY":              columnELevy,
+	"OPENINGBALANCE":     columnBalanceBefore,
+	"PREVBALANCE":        columnBalanceBefore,
+	"PREVIOUSBALANCE":    columnBalanceBefore,
+	"CLOSINGBALANCE":     columnBalanceAfter,
+	"NEWBALANCE":         columnBalanceAfter,
+	"COUNTERPARTY":       columnCounterpartyName,
+	"PARTYNAME":          columnCounterpartyName,
+	"COUNTERPARTYNUMBER": columnCounterpartyNumber,
+	"COUNTERPARTYMSISDN": columnCounterpartyNumber,
+	"PARTYNUMBER":        columnCounterpartyNumber,
+	"REMARKS":            columnDescription,
+	"DETAILS":            columnDescription,
+}
+
+func isAirtelTigoTable(columns map[int]bool) bool {
+	return columns[columnDirection] && columns[columnAmount] &&
+		(columns[columnBalanceBefore] || columns[columnBalanceAfter])
+}
+
+func NewAirtelTigoMoneyParser() StatementParser {
+	return &tableParser{
+		provider: ProviderAirtelTigoMoney,
+		name:     "AirtelTigo Money",
+		markers:  []string{"AIRTELTIGO", "AIRTEL TIGO", "AT MONEY", "TIGO CASH"},
+		format: &tableFormat{
+			headings: airtelTigoHeadings,
+			accept:   isAirtelTigoTable,
+		},
+	}
+}
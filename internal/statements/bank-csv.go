@@ -0,0 +1,126 @@
+package statements
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+)
+
+const ProviderBankCSV = "bank_csv"
+
+// BankCSVMapping describes a bank's statement export by the headings of its
+// columns. Each field lists the headings the column may appear under; amounts
+// come either from a signed amount column, negative for money out, or from
+// debit and credit columns.
+type BankCSVMapping struct {
+	Provider string `json:"provider"`
+	Name     string `json:"name"`
+	// Markers are names printed above the table that identify the bank.
+	Markers []string `json:"markers"`
+	// DateLayout is a Go time layout tried before the common date formats,
+	// for banks whose dates would otherwise be ambiguous.
+	DateLayout string `json:"date_layout"`
+
+	Date         []string `json:"date"`
+	Time         []string `json:"time"`
+	Description  []string `json:"description"`
+	Reference    []string `json:"reference"`
+	Type         []string `json:"type"`
+	Amount       []string `json:"amount"`
+	Debit        []string `json:"debit"`
+	Credit       []string `json:"credit"`
+	Balance      []string `json:"balance"`
+	Counterparty []string `json:"counterparty"`
+}
+
+// DefaultBankCSVMapping covers the headings most bank exports use. It is
+// registered last so that configured banks and mobile money providers win.
+func DefaultBankCSVMapping() BankCSVMapping {
+	return BankCSVMapping{
+		Provider:    ProviderBankCSV,
+		Name:        "Bank statement",
+		Date:        []string{"Date", "Transaction Date", "Trans Date", "Posting Date", "Posted Date", "Book Date"},
+		Description: []string{"Description", "Narration", "Narrative", "Details", "Particulars", "Remarks"},
+		Reference:   []string{"Reference", "Ref", "Ref No", "Cheque No", "Document No"},
+		Amount:      []string{"Amount", "Transaction Amount"},
+		Debit:       []string{"Debit", "Debits", "Withdrawal", "Withdrawals", "Debit Amount", "Money Out"},
+		Credit:      []string{"Credit", "Credits", "Deposit", "Deposits", "Lodgement", "Credit Amount", "Money In"},
+		Balance:     []string{"Balance", "Running Balance", "Closing Balance", "Ledger Balance"},
+	}
+}
+
+// LoadBankCSVMappings reads a JSON array of bank mappings. An empty path
+// means no banks are configured.
+func LoadBankCSVMappings(path string) ([]BankCSVMapping, error) {
+	if path == "" {
+		return nil, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var mappings []BankCSVMapping
+	if err := json.Unmarshal(data, &mappings); err != nil {
+		return nil, fmt.Errorf("bank mappings %s: %w", path, err)
+	}
+	return mappings, nil
+}
+
+func NewBankCSVParser(mapping BankCSVMapping) (StatementParser, error) {
+	if mapping.Provider == "" {
+		return nil, fmt.Errorf("bank mapping provider cannot be empty")
+	}
+	if len(mapping.Date) == 0 {
+		return nil, fmt.Errorf("bank mapping %s has no date column", mapping.Provider)
+	}
+	if len(mapping.Amount) == 0 && (len(mapping.Debit) == 0 || len(mapping.Credit) == 0) {
+		return nil, fmt.Errorf("bank mapping %s needs an amount column or debit and credit columns", mapping.Provider)
+	}
+
+	headings := make(map[string]int)
+	for column, names := range map[int][]string{
+		columnDate:             mapping.Date,
+		columnTime:             mapping.Time,
+		columnDescription:      mapping.Description,
+		columnReference:        mapping.Reference,
+		columnType:             mapping.Type,
+		columnAmount:           mapping.Amount,
+		columnDebit:            mapping.Debit,
+		columnCredit:           mapping.Credit,
+		columnBalanceAfter:     mapping.Balance,
+		columnCounterpartyName: mapping.Counterparty,
+	} {
+		for _, name := range names {
+			headings[normalizeHeader(name)] = column
+		}
+	}
+
+	markers := make([]string, len(mapping.Markers))
+	for i, marker := range mapping.Markers {
+		markers[i] = strings.ToUpper(marker)
+	}
+
+	name := mapping.Name
+	if name == "" {
+		name = mapping.Provider
+	}
+
+	return &tableParser{
+		provider: mapping.Provider,
+		name:     name,
+		markers:  markers,
+		format: &tableFormat{
+			headings:      headings,
+			accept:        isBankTable,
+			signedAmounts: true,
+			dateLayout:    mapping.DateLayout,
+		},
+	}, nil
+}
+
+func isBankTable(columns map[int]bool) bool {
+	return columns[columnAmount] || (columns[columnDebit] && columns[columnCredit])
+}
@@ -0,0 +1,96 @@
+package statements_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"lumon-backend/internal/domain/schemas"
+	"lumon-backend/internal/service"
+	"lumon-backend/internal/statements"
+)
+
+// goldenOutput is what each fixture directory's expected.json holds, the same
+// as cmd/statement-parse prints.
+type goldenOutput struct {
+	Provider     string                        `json:"provider"`
+	Transactions []schemas.TransactionResponse `json:"transactions"`
+}
+
+var fixtureTypes = map[string]string{
+	".pdf":  statements.PDFMIMEType,
+	".csv":  statements.CSVMIMEType,
+	".xlsx": statements.XLSXMIMEType,
+}
+
+// TestGoldenStatements parses every fixture statement under testdata with the
+// bank mappings stored next to them and compares the result with the
+// directory's expected.json. The provider must be detected from the file
+// alone and every transaction must pass validation. Run
+// `go run ./cmd/statement-parse -check internal/statements/testdata -bank-mappings internal/statements/testdata/bank-mappings.json -update`
+// to regenerate the expected output after a deliberate parser change.
+func TestGoldenStatements(t *testing.T) {
+	mappings, err := statements.LoadBankCSVMappings(filepath.Join("testdata", "bank-mappings.json"))
+	if err != nil {
+		t.Fatalf("load bank mappings: %v", err)
+	}
+	registry, err := statements.NewDefaultRegistry(mappings...)
+	if err != nil {
+		t.Fatalf("register parsers: %v", err)
+	}
+
+	paths, err := filepath.Glob(filepath.Join("testdata", "*", "statement.*"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(paths) == 0 {
+		t.Fatal("no fixture statements found")
+	}
+
+	for _, path := range paths {
+		mimeType, ok := fixtureTypes[strings.ToLower(filepath.Ext(path))]
+		if !ok {
+			continue
+		}
+
+		t.Run(filepath.ToSlash(path), func(t *testing.T) {
+			data, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			statement, err := statements.NewStatement(data, mimeType)
+			if err != nil {
+				t.Fatalf("read statement: %v", err)
+			}
+
+			parser, transactions, err := registry.Parse(statement, "")
+			if err != nil {
+				t.Fatalf("parse statement: %v", err)
+			}
+			for _, problem := range statements.Validate(transactions) {
+				t.Errorf("invalid %v", problem)
+			}
+
+			result := goldenOutput{Provider: parser.Provider()}
+			for _, transaction := range transactions {
+				result.Transactions = append(result.Transactions, service.NewTransactionResponse(*transaction))
+			}
+			output, err := json.MarshalIndent(result, "", "  ")
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			expected, err := os.ReadFile(filepath.Join(filepath.Dir(path), "expected.json"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(bytes.TrimSpace(expected), bytes.TrimSpace(output)) {
+				t.Errorf("output differs from expected.json:\n%s", output)
+			}
+		})
+	}
+}
@@ -0,0 +1,60 @@
+package statements
+
+const ProviderMTNMoMo = "mtn_momo"
+
+// momoHeadings maps the normalised column headings of MTN MoMo statement
+// exports to their columns. Columns not listed, such as F_ID and OVA, are
+// ignored.
+var momoHeadings = map[string]int{
+	"TRANSACTIONDATE": columnDate,
+	"TRANSDATE":       columnDate,
+	"DATE":            columnDate,
+	"DATETIME":        columnDate,
+	"FROMACCT":        columnFromAccount,
+	"FROMACCOUNT":     columnFromAccount,
+	"FROMNAME":        columnFromName,
+	"FROMNO":          columnFromNumber,
+	"FROMNUMBER":      columnFromNumber,
+	"FROMMSISDN":      columnFromNumber,
+	"TRANSTYPE":       columnType,
+	"TRANSACTIONTYPE": columnType,
+	"TYPE":            columnType,
+	"AMOUNT":          columnAmount,
+	"AMT":             columnAmount,
+	"FEES":            columnFees,
+	"FEE":             columnFees,
+	"ELEVY":           columnELevy,
+	"BALBEFORE":       columnBalanceBefore,
+	"BALANCEBEFORE":   columnBalanceBefore,
+	"BALAFTER":        columnBalanceAfter,
+	"BALANCEAFTER":    columnBalanceAfter,
+	"TONO":            columnToNumber,
+	"TONUMBER":        columnToNumber,
+	"TOMSISDN":        columnToNumber,
+	"TONAME":          columnToName,
+	"TOACCT":          columnToAccount,
+	"TOACCOUNT":       columnToAccount,
+	"REF":             columnReference,
+	"REFERENCE":       columnReference,
+}
+
+func isMoMoTable(columns map[int]bool) bool {
+	return columns[columnType] && columns[columnAmount] &&
+		(columns[columnBalanceBefore] || columns[columnBalanceAfter])
+}
+
+// NewMTNMoMoParser reads MTN MoMo statements exported as CSV or XLSX, and the
+// text layer of the PDF statement. MTN's transaction types are the normalised
+// ones, so they are kept as they are.
+func NewMTNMoMoParser() StatementParser {
+	return &tableParser{
+		provider: ProviderMTNMoMo,
+		name:     "MTN Mobile Money",
+		markers:  []string{"MTN"},
+		format: &tableFormat{
+			headings:  momoHeadings,
+			accept:    isMoMoTable,
+			keepTypes: true,
+		},
+	}
+}
@@ -0,0 +1,228 @@
+package statements
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"lumon-backend/internal/domain/models"
+	"lumon-backend/pkg/common/pdf"
+)
+
+var ErrUnknownProvider = errors.New("statement provider not recognised")
+
+// Confidence is how sure a parser is that a statement is one of its
+// provider's exports.
+type Confidence int
+
+const (
+	NoMatch Confidence = iota
+	// LayoutMatch means the parser recognises the transaction table.
+	LayoutMatch
+	// BrandMatch means the table is recognised and the statement also names
+	// the provider above it.
+	BrandMatch
+)
+
+// StatementParser reads the statements of one provider into transactions.
+type StatementParser interface {
+	// Provider is the key the parser is registered under.
+	Provider() string
+	// Name is the provider's name as it appears to users.
+	Name() string
+	Detect(statement *Statement) Confidence
+	Parse(statement *Statement) ([]*models.Transaction, error)
+}
+
+// Statement is a statement file decoded once so that every parser can inspect
+// it during detection without reading it again.
+type Statement struct {
+	MIMEType string
+	rows     [][]string
+	pages    []pdf.Page
+}
+
+func NewStatement(data []byte, mimeType string) (*Statement, error) {
+	statement := &Statement{MIMEType: mimeType}
+
+	var err error
+	if mimeType == PDFMIMEType {
+		statement.pages, err = pdf.ReadPages(data)
+	} else {
+		statement.rows, err = ReadRows(data, mimeType)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return statement, nil
+}
+
+// preamble returns the upper-cased text above the first transaction row,
+// which is where statements print the provider's name.
+func (s *Statement) preamble() string {
+	var lines [][]string
+	if s.pages != nil {
+		for _, page := range s.pages {
+			for _, line := range page.Lines {
+				cells := make([]string, len(line.Cells))
+				for i, cell := range line.Cells {
+					cells[i] = cell.Text
+				}
+				lines = append(lines, cells)
+			}
+		}
+	} else {
+		lines = s.rows
+	}
+
+	var b strings.Builder
+	for _, cells := range lines {
+		for _, cell := range cells {
+			if looksLikeDate(cell) {
+				return strings.ToUpper(b.String())
+			}
+		}
+		b.WriteString(strings.Join(cells, " "))
+		b.WriteString("\n")
+	}
+	return strings.ToUpper(b.String())
+}
+
+// Registry holds the statement parsers by provider. Detection asks every
+// parser and prefers the most confident one, then the one registered first.
+type Registry struct {
+	parsers []StatementParser
+}
+
+func NewRegistry(parsers ...StatementParser) *Registry {
+	registry := &Registry{}
+	for _, parser := range parsers {
+		registry.Register(parser)
+	}
+	return registry
+}
+
+// NewDefaultRegistry registers the mobile money parsers, then a parser for
+// each configured bank and finally the generic bank CSV parser.
+func NewDefaultRegistry(bankMappings ...BankCSVMapping) (*Registry, error) {
+	registry := NewRegistry(NewMTNMoMoParser(), NewTelecelCashParser(), NewAirtelTigoMoneyParser())
+
+	for _, mapping := range append(bankMappings, DefaultBankCSVMapping()) {
+		parser, err := NewBankCSVParser(mapping)
+		if err != nil {
+			return nil, err
+		}
+		registry.Register(parser)
+	}
+
+	return registry, nil
+}
+
+// Register adds a parser, replacing any registered for the same provider.
+func (r *Registry) Register(parser StatementParser) {
+	for i, registered := range r.parsers {
+		if registered.Provider() == parser.Provider() {
+			r.parsers[i] = parser
+			return
+		}
+	}
+	r.parsers = append(r.parsers, parser)
+}
+
+func (r *Registry) Parser(provider string) (StatementParser, error) {
+	for _, parser := range r.parsers {
+		if parser.Provider() == provider {
+			return parser, nil
+		}
+	}
+	return nil, fmt.Errorf("%w: %q", ErrUnknownProvider, provider)
+}
+
+func (r *Registry) Providers() []string {
+	providers := make([]string, len(r.parsers))
+	for i, parser := range r.parsers {
+		providers[i] = parser.Provider()
+	}
+	return providers
+}
+
+// Detect returns the parser for the provider the statement came from.
+func (r *Registry) Detect(statement *Statement) (StatementParser, error) {
+	var best StatementParser
+	bestConfidence := NoMatch
+	for _, parser := range r.parsers {
+		if confidence := parser.Detect(statement); confidence > bestConfidence {
+			best, bestConfidence = parser, confidence
+		}
+	}
+
+	if best == nil {
+		return nil, ErrUnknownProvider
+	}
+	return best, nil
+}
+
+// Parse reads the statement with the given provider's parser, or with the
+// detected one when provider is empty. The parser is returned whenever one was
+// chosen, even if parsing then failed.
+func (r *Registry) Parse(statement *Statement, provider string) (StatementParser, []*models.Transaction, error) {
+	var parser StatementParser
+	var err error
+	if provider != "" {
+		parser, err = r.Parser(provider)
+	} else {
+		parser, err = r.Detect(statement)
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+
+	transactions, err := parser.Parse(statement)
+	if err != nil {
+		return parser, nil, err
+	}
+
+	for _, transaction := range transactions {
+		transaction.Provider = parser.Provider()
+	}
+
+	return parser, transactions, nil
+}
+
+// tableParser is a provider whose statements are a single transaction table,
+// which is the case for every provider supported so far.
+type tableParser struct {
+	provider string
+	name     string
+	// markers are upper-case names printed above the table that identify
+	// the provider.
+	markers []string
+	format  *tableFormat
+}
+
+func (p *tableParser) Provider() string {
+	return p.provider
+}
+
+func (p *tableParser) Name() string {
+	return p.name
+}
+
+func (p *tableParser) Detect(statement *Statement) Confidence {
+	if !p.format.hasHeader(statement) {
+		return NoMatch
+	}
+
+	preamble := statement.preamble()
+	for _, marker := range p.markers {
+		if strings.Contains(preamble, marker) {
+			return BrandMatch
+		}
+	}
+	return LayoutMatch
+}
+
+func (p *tableParser) Parse(statement *Statement) ([]*models.Transaction, error) {
+	return p.format.transactions(statement)
+}
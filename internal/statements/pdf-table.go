@@ -0,0 +1,182 @@
+package statements
+
+import (
+	"math"
+	"strings"
+
+	"lumon-backend/pkg/common/pdf"
+)
+
+// unmappedColumn marks a heading the caller does not use. The column is still
+// tracked so that its cells are not mistaken for a neighbour's.
+const unmappedColumn = -1
+
+// rowGapFactor is how many font sizes apart two lines can be and still belong
+// to the same table row.
+const rowGapFactor = 3.0
+
+type tableLayout struct {
+	centers []float64
+	columns []int
+	date    int
+}
+
+// tableRecord is a table row keyed by the caller's column identifiers.
+type tableRecord map[int]string
+
+// readPDFTable rebuilds a statement table from the text layer of a PDF. The
+// header is found by looking up each heading with columnOf; accept decides
+// whether the recognised columns make up a transaction table. Rows start at a
+// line with a date in dateColumn, and lines without one are wrapped text that
+// is appended to the row above. The header is located again on every page,
+// and pages without one reuse the previous layout.
+func readPDFTable(
+	pages []pdf.Page, columnOf func(heading string) (int, bool), accept func(columns map[int]bool) bool, dateColumn int,
+) ([]tableRecord, error) {
+	var records []tableRecord
+	var layout *tableLayout
+	for _, page := range pages {
+		start := 0
+		if found, next := findPDFHeader(page.Lines, columnOf, accept, dateColumn); found != nil {
+			layout, start = found, next
+		}
+		if layout == nil {
+			continue
+		}
+
+		var current tableRecord
+		var lastLine pdf.TextLine
+		for _, line := range page.Lines[start:] {
+			cells := layout.assign(line)
+
+			if looksLikeDate(cells[layout.date]) {
+				current = tableRecord{}
+				records = append(records, current)
+			} else if current == nil || !sameRow(lastLine, line) {
+				current = nil
+				continue
+			}
+
+			for column, text := range cells {
+				if column == unmappedColumn {
+					continue
+				}
+				current[column] = strings.TrimSpace(current[column] + " " + text)
+			}
+			lastLine = line
+		}
+	}
+
+	if len(records) == 0 {
+		return nil, ErrNoTransactions
+	}
+	return records, nil
+}
+
+// findPDFHeader looks for the table header on a page, allowing headings that
+// wrap onto a second line. It returns the layout and the index of the first
+// line after the header.
+func findPDFHeader(
+	lines []pdf.TextLine, columnOf func(string) (int, bool), accept func(map[int]bool) bool, dateColumn int,
+) (*tableLayout, int) {
+	for i, line := range lines {
+		// The wrapped header is tried first, since the first line alone can
+		// already look like a header with the wrapped headings cut short.
+		if i+1 < len(lines) && sameRow(line, lines[i+1]) && !hasDate(lines[i+1]) {
+			cells := mergeHeaderCells(line.Cells, lines[i+1].Cells)
+			if layout := headerLayout(cells, columnOf, accept, dateColumn); layout != nil {
+				return layout, i + 2
+			}
+		}
+
+		if layout := headerLayout(line.Cells, columnOf, accept, dateColumn); layout != nil {
+			return layout, i + 1
+		}
+	}
+	return nil, 0
+}
+
+func hasDate(line pdf.TextLine) bool {
+	for _, cell := range line.Cells {
+		if looksLikeDate(cell.Text) {
+			return true
+		}
+	}
+	return false
+}
+
+func headerLayout(
+	cells []pdf.TextCell, columnOf func(string) (int, bool), accept func(map[int]bool) bool, dateColumn int,
+) *tableLayout {
+	layout := &tableLayout{date: dateColumn}
+	found := make(map[int]bool)
+
+	for _, cell := range cells {
+		column, ok := columnOf(cell.Text)
+		if !ok {
+			column = unmappedColumn
+		} else {
+			found[column] = true
+		}
+		layout.centers = append(layout.centers, (cell.X+cell.EndX)/2)
+		layout.columns = append(layout.columns, column)
+	}
+
+	if !found[dateColumn] || !accept(found) {
+		return nil
+	}
+	return layout
+}
+
+// mergeHeaderCells joins the second line of a wrapped header onto the cells of
+// the first line that it sits under.
+func mergeHeaderCells(first, second []pdf.TextCell) []pdf.TextCell {
+	merged := append([]pdf.TextCell{}, first...)
+	for _, cell := range second {
+		center := (cell.X + cell.EndX) / 2
+
+		best, distance := -1, math.Inf(1)
+		for i, m := range merged {
+			if d := math.Abs((m.X+m.EndX)/2 - center); d < distance {
+				best, distance = i, d
+			}
+		}
+
+		if best >= 0 && cell.X < merged[best].EndX+cell.Size && cell.EndX > merged[best].X-cell.Size {
+			merged[best].Text += " " + cell.Text
+			merged[best].X = math.Min(merged[best].X, cell.X)
+			merged[best].EndX = math.Max(merged[best].EndX, cell.EndX)
+		} else {
+			merged = append(merged, cell)
+		}
+	}
+	return merged
+}
+
+// assign puts each cell of a line in the column whose heading is centred
+// nearest to it.
+func (t *tableLayout) assign(line pdf.TextLine) map[int]string {
+	cells := make(map[int]string)
+	for _, cell := range line.Cells {
+		center := (cell.X + cell.EndX) / 2
+
+		best, distance := 0, math.Inf(1)
+		for i, c := range t.centers {
+			if d := math.Abs(c - center); d < distance {
+				best, distance = i, d
+			}
+		}
+
+		column := t.columns[best]
+		cells[column] = strings.TrimSpace(cells[column] + " " + cell.Text)
+	}
+	return cells
+}
+
+func sameRow(previous, next pdf.TextLine) bool {
+	size := 0.0
+	for _, cell := range append(previous.Cells, next.Cells...) {
+		size = math.Max(size, cell.Size)
+	}
+	return previous.Y-next.Y <= rowGapFactor*math.Max(size, 1)
+}
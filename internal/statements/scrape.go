@@ -0,0 +1,38 @@
+package statements
+
+import (
+	"strings"
+
+	"lumon-backend/internal/domain/models"
+	"lumon-backend/internal/domain/schemas"
+)
+
+// FromScrapes normalises the transactions the LLM extracted from a statement
+// of the given provider, which may be empty when it was not recognised. A date
+// that cannot be read is left zero for Validate to report.
+func FromScrapes(scrapes []*schemas.TransactionScrape, provider string) []*models.Transaction {
+	transactions := make([]*models.Transaction, len(scrapes))
+	for i, scrape := range scrapes {
+		date, _ := parseDate(strings.Trim(scrape.TransactionDate, "\""))
+
+		transactions[i] = &models.Transaction{
+			TransactionDate: date,
+			FromAccount:     scrape.FromAccount,
+			FromName:        scrape.FromName,
+			FromNumber:      scrape.FromNumber,
+			TransactionType: strings.ToUpper(scrape.TransactionType),
+			Amount:          scrape.Amount,
+			Fees:            scrape.Fees,
+			ELevy:           scrape.ELevy,
+			BalanceBefore:   scrape.BalanceBefore,
+			BalanceAfter:    scrape.BalanceAfter,
+			ToNumber:        scrape.ToNumber,
+			ToName:          scrape.ToName,
+			ToAccount:       scrape.ToAccount,
+			Reference:       scrape.Reference,
+			Provider:        provider,
+		}
+	}
+
+	return transactions
+}
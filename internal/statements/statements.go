@@ -0,0 +1,132 @@
+// Package statements parses mobile money and bank statement exports into
+// transactions without involving the LLM.
+package statements
+
+import (
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"lumon-backend/pkg/common/xlsx"
+)
+
+const (
+	PDFMIMEType  = "application/pdf"
+	CSVMIMEType  = "text/csv"
+	XLSXMIMEType = "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+)
+
+var ErrNoTransactions = errors.New("no transactions found in statement")
+
+// ReadRows returns the cells of a CSV or XLSX statement.
+func ReadRows(data []byte, mimeType string) ([][]string, error) {
+	switch mimeType {
+	case CSVMIMEType:
+		reader := csv.NewReader(bytes.NewReader(bytes.TrimPrefix(data, []byte("\xef\xbb\xbf"))))
+		reader.FieldsPerRecord = -1
+		reader.LazyQuotes = true
+		reader.TrimLeadingSpace = true
+		return reader.ReadAll()
+	case XLSXMIMEType:
+		return xlsx.ReadRows(data)
+	}
+
+	return nil, fmt.Errorf("%s statements cannot be read as rows", mimeType)
+}
+
+// normalizeHeader reduces a column heading to upper-case letters and digits so
+// that "Trans. Type", "TRANS_TYPE" and "Trans Type" compare equal.
+func normalizeHeader(heading string) string {
+	var b strings.Builder
+	for _, r := range strings.ToUpper(heading) {
+		if (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// dateStart matches the beginning of the date formats statements use, and is
+// how a table row is told apart from a wrapped line or a footer.
+var dateStart = regexp.MustCompile(`^(\d{1,2}[-/ ][A-Za-z]{3}[-/ ]\d{2,4}|\d{4}-\d{1,2}-\d{1,2}|\d{1,2}[-/.]\d{1,2}[-/.]\d{2,4})`)
+
+func looksLikeDate(value string) bool {
+	return dateStart.MatchString(strings.TrimSpace(value))
+}
+
+var dateLayouts = []string{
+	"02-Jan-2006 03:04:05 PM",
+	"02-Jan-2006 3:04:05 PM",
+	"02-Jan-2006 03:04 PM",
+	"02-Jan-2006 15:04:05",
+	"02-Jan-2006 15:04",
+	"02-Jan-06 03:04:05 PM",
+	"02-Jan-06 15:04:05",
+	"02 Jan 2006 03:04:05 PM",
+	"02 Jan 2006 15:04:05",
+	"02 Jan 2006 15:04",
+	"2006-01-02 15:04:05",
+	"2006-01-02T15:04:05",
+	"2006-01-02T15:04:05Z07:00",
+	"2006-01-02 15:04",
+	"02/01/2006 15:04:05",
+	"02/01/2006 03:04:05 PM",
+	"02/01/2006 15:04",
+	"02-01-2006 15:04:05",
+	"02.01.2006 15:04:05",
+	"02-Jan-2006",
+	"02 Jan 2006",
+	"2006-01-02",
+	"02/01/2006",
+	"02-01-2006",
+}
+
+// parseDate reads a statement date. Ambiguous numeric dates are read day
+// first, as Ghanaian providers write them.
+func parseDate(value string) (time.Time, error) {
+	value = strings.Join(strings.Fields(value), " ")
+	for _, layout := range dateLayouts {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("unrecognised date %q", value)
+}
+
+// parseAmount reads a money value, ignoring currency symbols and thousands
+// separators. Parentheses or a trailing "DR" mark a negative amount.
+func parseAmount(value string) (float64, error) {
+	value = strings.ToUpper(strings.TrimSpace(value))
+	if value == "" || value == "-" {
+		return 0, nil
+	}
+
+	negative := false
+	if strings.HasPrefix(value, "(") && strings.HasSuffix(value, ")") {
+		negative = true
+		value = strings.Trim(value, "()")
+	}
+	if strings.HasSuffix(value, "DR") {
+		negative = true
+		value = strings.TrimSuffix(value, "DR")
+	}
+	value = strings.TrimSuffix(value, "CR")
+
+	for _, symbol := range []string{"GHS", "GH₵", "GHC", "₵", ",", " "} {
+		value = strings.ReplaceAll(value, symbol, "")
+	}
+
+	amount, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return 0, fmt.Errorf("unrecognised amount %q", value)
+	}
+	if negative {
+		amount = -amount
+	}
+	return amount, nil
+}
@@ -0,0 +1,352 @@
+package statements
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"lumon-backend/internal/domain/models"
+)
+
+// Columns that statement tables are read into. Each provider maps its own
+// headings onto these.
+const (
+	columnDate = iota
+	columnTime
+	columnType
+	columnDescription
+	columnDirection
+	columnAmount
+	columnDebit
+	columnCredit
+	columnFees
+	columnELevy
+	columnBalanceBefore
+	columnBalanceAfter
+	columnFromAccount
+	columnFromName
+	columnFromNumber
+	columnToAccount
+	columnToName
+	columnToNumber
+	columnCounterpartyName
+	columnCounterpartyNumber
+	columnReference
+)
+
+// Transaction types that statements are normalised to. They are the types MTN
+// MoMo uses, which the scoring features were written against.
+const (
+	TypeCashIn   = "CASH_IN"
+	TypeCashOut  = "CASH_OUT"
+	TypeTransfer = "TRANSFER"
+	TypePayment  = "PAYMENT"
+	TypeDebit    = "DEBIT"
+)
+
+type direction int
+
+const (
+	directionUnknown direction = iota
+	directionIn
+	directionOut
+)
+
+// tableFormat describes a provider's transaction table.
+type tableFormat struct {
+	// headings maps normalised column headings to columns. Headings not
+	// listed are ignored.
+	headings map[string]int
+	// accept decides whether the recognised columns make up the table.
+	accept func(columns map[int]bool) bool
+	// keepTypes keeps the provider's transaction types as they are instead
+	// of normalising them.
+	keepTypes bool
+	// signedAmounts means a positive amount is money received, rather than
+	// every amount being positive.
+	signedAmounts bool
+	// dateLayout is tried before the common date layouts.
+	dateLayout string
+}
+
+func (f *tableFormat) column(heading string) (int, bool) {
+	column, ok := f.headings[normalizeHeader(heading)]
+	return column, ok
+}
+
+func (f *tableFormat) hasHeader(statement *Statement) bool {
+	if statement.pages != nil {
+		for _, page := range statement.pages {
+			if layout, _ := findPDFHeader(page.Lines, f.column, f.accept, columnDate); layout != nil {
+				return true
+			}
+		}
+		return false
+	}
+
+	for _, row := range statement.rows {
+		if f.rowHeader(row) != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// records returns the table rows of the statement, skipping any preamble
+// before the header and rows that do not start with a date, such as totals.
+func (f *tableFormat) records(statement *Statement) ([]tableRecord, error) {
+	if statement.pages != nil {
+		return readPDFTable(statement.pages, f.column, f.accept, columnDate)
+	}
+
+	var header map[int]int
+	var records []tableRecord
+	for _, row := range statement.rows {
+		if header == nil {
+			header = f.rowHeader(row)
+			continue
+		}
+
+		record := tableRecord{}
+		for i, value := range row {
+			if column, ok := header[i]; ok {
+				record[column] = strings.TrimSpace(value)
+			}
+		}
+
+		if looksLikeDate(record[columnDate]) {
+			records = append(records, record)
+		}
+	}
+
+	if header == nil {
+		return nil, fmt.Errorf("statement header not found")
+	}
+	if len(records) == 0 {
+		return nil, ErrNoTransactions
+	}
+	return records, nil
+}
+
+func (f *tableFormat) rowHeader(row []string) map[int]int {
+	header := make(map[int]int)
+	found := make(map[int]bool)
+	for i, heading := range row {
+		if column, ok := f.column(heading); ok && !found[column] {
+			header[i] = column
+			found[column] = true
+		}
+	}
+
+	if !found[columnDate] || !f.accept(found) {
+		return nil
+	}
+	return header
+}
+
+// transactions reads every transaction of the statement. It fails rather than
+// guessing when a row cannot be read.
+func (f *tableFormat) transactions(statement *Statement) ([]*models.Transaction, error) {
+	records, err := f.records(statement)
+	if err != nil {
+		return nil, err
+	}
+
+	transactions := make([]*models.Transaction, len(records))
+	for i, record := range records {
+		transaction, err := f.transaction(record)
+		if err != nil {
+			return nil, fmt.Errorf("transaction %d: %w", i+1, err)
+		}
+		transactions[i] = transaction
+	}
+
+	return transactions, nil
+}
+
+func (f *tableFormat) transaction(record tableRecord) (*models.Transaction, error) {
+	date, err := f.parseDate(strings.TrimSpace(record[columnDate] + " " + record[columnTime]))
+	if err != nil {
+		return nil, err
+	}
+
+	if record[columnAmount] == "" && record[columnDebit] == "" && record[columnCredit] == "" {
+		return nil, fmt.Errorf("missing amount")
+	}
+
+	amounts := make(map[int]float64)
+	for _, column := range []int{
+		columnAmount, columnDebit, columnCredit, columnFees, columnELevy, columnBalanceBefore, columnBalanceAfter,
+	} {
+		if amounts[column], err = parseAmount(record[column]); err != nil {
+			return nil, err
+		}
+	}
+
+	amount, flow := recordAmount(record, amounts)
+	if flow == directionUnknown && f.signedAmounts && amount != 0 {
+		flow = directionIn
+	}
+
+	transaction := &models.Transaction{
+		TransactionDate: date,
+		FromAccount:     record[columnFromAccount],
+		FromName:        record[columnFromName],
+		FromNumber:      record[columnFromNumber],
+		Amount:          amount,
+		Fees:            math.Abs(amounts[columnFees]),
+		ELevy:           math.Abs(amounts[columnELevy]),
+		BalanceBefore:   amounts[columnBalanceBefore],
+		BalanceAfter:    amounts[columnBalanceAfter],
+		ToAccount:       record[columnToAccount],
+		ToName:          record[columnToName],
+		ToNumber:        record[columnToNumber],
+		Reference:       record[columnReference],
+		Description:     record[columnDescription],
+	}
+
+	// Statements that only print the running balance get the balance before
+	// the transaction worked out from it, and the other way round.
+	charges := transaction.Fees + transaction.ELevy
+	switch {
+	case record[columnBalanceBefore] == "" && flow == directionIn:
+		transaction.BalanceBefore = round2(transaction.BalanceAfter - amount)
+	case record[columnBalanceBefore] == "" && flow == directionOut:
+		transaction.BalanceBefore = round2(transaction.BalanceAfter + amount + charges)
+	case record[columnBalanceAfter] == "" && flow == directionIn:
+		transaction.BalanceAfter = round2(transaction.BalanceBefore + amount)
+	case record[columnBalanceAfter] == "" && flow == directionOut:
+		transaction.BalanceAfter = round2(transaction.BalanceBefore - amount - charges)
+	}
+
+	// Providers that print a single counterparty column are filled into the
+	// side of the transaction the account holder is not on.
+	if flow == directionIn {
+		transaction.FromName = firstNonEmpty(transaction.FromName, record[columnCounterpartyName])
+		transaction.FromNumber = firstNonEmpty(transaction.FromNumber, record[columnCounterpartyNumber])
+	} else {
+		transaction.ToName = firstNonEmpty(transaction.ToName, record[columnCounterpartyName])
+		transaction.ToNumber = firstNonEmpty(transaction.ToNumber, record[columnCounterpartyNumber])
+	}
+
+	if f.keepTypes {
+		transaction.TransactionType = strings.ToUpper(record[columnType])
+	} else {
+		transaction.TransactionType = classifyType(record[columnType]+" "+record[columnDescription], flow)
+	}
+
+	return transaction, nil
+}
+
+func (f *tableFormat) parseDate(value string) (time.Time, error) {
+	if f.dateLayout != "" {
+		if t, err := time.Parse(f.dateLayout, value); err == nil {
+			return t, nil
+		}
+	}
+	return parseDate(value)
+}
+
+// recordAmount returns the unsigned amount of a row and which way the money
+// moved, going by the first of these the provider prints: a debit/credit
+// marker, separate debit and credit columns, a signed amount, or the change
+// in balance.
+func recordAmount(record tableRecord, amounts map[int]float64) (float64, direction) {
+	amount := amounts[columnAmount]
+	flow := directionUnknown
+
+	switch marker := normalizeHeader(record[columnDirection]); {
+	case marker == "DR" || marker == "D" || marker == "DEBIT" || marker == "OUT":
+		flow = directionOut
+	case marker == "CR" || marker == "C" || marker == "CREDIT" || marker == "IN":
+		flow = directionIn
+	case amounts[columnDebit] != 0:
+		amount, flow = amounts[columnDebit], directionOut
+	case amounts[columnCredit] != 0:
+		amount, flow = amounts[columnCredit], directionIn
+	case amount < 0:
+		flow = directionOut
+	case record[columnBalanceBefore] != "" && record[columnBalanceAfter] != "" &&
+		amounts[columnBalanceBefore] != amounts[columnBalanceAfter]:
+		if amounts[columnBalanceAfter] > amounts[columnBalanceBefore] {
+			flow = directionIn
+		} else {
+			flow = directionOut
+		}
+	}
+
+	return math.Abs(amount), flow
+}
+
+// typeKeywords maps words in a provider's transaction type or description to
+// the normalised type of an outgoing transaction. Earlier entries win.
+var typeKeywords = []struct {
+	keyword         string
+	transactionType string
+}{
+	{"CASH OUT", TypeCashOut},
+	{"CASHOUT", TypeCashOut},
+	{"WITHDRAWAL", TypeCashOut},
+	{"WITHDRAW", TypeCashOut},
+	{"ATM", TypeCashOut},
+	{"AIRTIME", TypeDebit},
+	{"BUNDLE", TypeDebit},
+	{"PAYMENT", TypePayment},
+	{"PAY", TypePayment},
+	{"MERCHANT", TypePayment},
+	{"BILL", TypePayment},
+	{"POS", TypePayment},
+	{"PURCHASE", TypePayment},
+	{"TRANSFER", TypeTransfer},
+	{"SEND", TypeTransfer},
+	{"SENT", TypeTransfer},
+}
+
+// incomingKeywords identify incoming transactions when the statement does not
+// say which way the money moved.
+var incomingKeywords = []string{"CASH IN", "CASHIN", "DEPOSIT", "RECEIVED", "RECEIVE", "CREDIT", "SALARY"}
+
+// classifyType normalises a provider's transaction type. Every incoming
+// transaction counts as a cash-in, as that is what the scoring features treat
+// as income.
+func classifyType(text string, flow direction) string {
+	words := " " + strings.Join(strings.Fields(strings.Map(func(r rune) rune {
+		if (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') {
+			return r
+		}
+		return ' '
+	}, strings.ToUpper(text))), " ") + " "
+
+	if flow == directionUnknown {
+		for _, keyword := range incomingKeywords {
+			if strings.Contains(words, " "+keyword+" ") {
+				flow = directionIn
+				break
+			}
+		}
+	}
+	if flow == directionIn {
+		return TypeCashIn
+	}
+
+	for _, keyword := range typeKeywords {
+		if strings.Contains(words, " "+keyword.keyword+" ") {
+			return keyword.transactionType
+		}
+	}
+	return TypeDebit
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, value := range values {
+		if value != "" {
+			return value
+		}
+	}
+	return ""
+}
+
+func round2(value float64) float64 {
+	return math.Round(value*100) / 100
+}
@@ -0,0 +1,59 @@
+package statements
+
+const ProviderTelecelCash = "telecel_cash"
+
+// telecelHeadings maps the headings of Telecel Cash statements, which were
+// issued as Vodafone Cash statements before the rebrand and keep the same
+// layout. Amounts are split into debit and credit columns and only the
+// balance after each transaction is printed.
+var telecelHeadings = map[string]int{
+	"DATE":                  columnDate,
+	"TRANSACTIONDATE":       columnDate,
+	"TIME":                  columnTime,
+	"TRANSACTIONTIME":       columnTime,
+	"TRANSACTIONID":         columnReference,
+	"TRANSID":               columnReference,
+	"TYPE":                  columnType,
+	"TRANSACTIONTYPE":       columnType,
+	"DESCRIPTION":           columnDescription,
+	"NARRATION":             columnDescription,
+	"TRANSACTIONDETAILS":    columnDescription,
+	"RECIPIENTSENDER":       columnCounterpartyName,
+	"SENDERRECIPIENT":       columnCounterpartyName,
+	"OTHERPARTY":            columnCounterpartyName,
+	"RECIPIENTSENDERNUMBER": columnCounterpartyNumber,
+	"SENDERRECIPIENTNUMBER": columnCounterpartyNumber,
+	"OTHERPARTYNUMBER":      columnCounterpartyNumber,
+	"NUMBER":                columnCounterpartyNumber,
+	"RECIPIENTSENDERMSISDN": columnCounterpartyNumber,
+	"SENDERRECIPIENTMSISDN": columnCounterpartyNumber,
+	"OTHERPARTYMSISDN":      columnCounterpartyNumber,
+	"DEBIT":                 columnDebit,
+	"MONEYOUT":              columnDebit,
+	"CREDIT":                columnCredit,
+	"MONEYIN":               columnCredit,
+	"FEE":                   columnFees,
+	"FEES":                  columnFees,
+	"CHARGES":               columnFees,
+	"ELEVY":                 columnELevy,
+	"BALANCE":               columnBalanceAfter,
+	"RUNNINGBALANCE":        columnBalanceAfter,
+	"CLOSINGBALANCE":        columnBalanceAfter,
+	"AVAILABLEBALANCE":      columnBalanceAfter,
+}
+
+func isTelecelTable(columns map[int]bool) bool {
+	return columns[columnDebit] && columns[columnCredit] && columns[columnBalanceAfter]
+}
+
+func NewTelecelCashParser() StatementParser {
+	return &tableParser{
+		provider: ProviderTelecelCash,
+		name:     "Telecel Cash",
+		markers:  []string{"TELECEL", "VODAFONE CASH"},
+		format: &tableFormat{
+			headings: telecelHeadings,
+			accept:   isTelecelTable,
+		},
+	}
+}
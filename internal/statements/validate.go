@@ -0,0 +1,174 @@
+package statements
+
+import (
+	"fmt"
+	"math"
+	"time"
+
+	"lumon-backend/internal/domain/models"
+)
+
+// balanceTolerance absorbs the rounding of balances printed to the cent.
+const balanceTolerance = 0.011
+
+// RowError is a problem with one transaction of a statement. Rows are numbered
+// from 1 in statement order.
+type RowError struct {
+	Row    int
+	Reason string
+}
+
+func (e RowError) Error() string {
+	return fmt.Sprintf("row %d: %s", e.Row, e.Reason)
+}
+
+// Validate checks every transaction of a statement on its own and against its
+// neighbours, and returns the problems found in row order. A row is expected
+// to have a readable date, a positive amount, balances that move by the
+// amount and charges in the direction its type implies, and a balance that
+// carries on from the rows around it.
+func Validate(transactions []*models.Transaction) []RowError {
+	var linked []bool
+	if len(transactions) > 1 {
+		linked = chainLinks(transactions)
+	}
+
+	var problems []RowError
+	for i, transaction := range transactions {
+		for _, reason := range validateRow(transaction) {
+			problems = append(problems, RowError{Row: i + 1, Reason: reason})
+		}
+		if isCutOff(linked, i) {
+			reason := fmt.Sprintf("balance before %.2f does not carry on from the neighbouring rows",
+				transaction.BalanceBefore)
+			problems = append(problems, RowError{Row: i + 1, Reason: reason})
+		}
+	}
+
+	return problems
+}
+
+func validateRow(transaction *models.Transaction) []string {
+	var reasons []string
+
+	switch {
+	case transaction.TransactionDate.IsZero():
+		reasons = append(reasons, "missing or unreadable transaction date")
+	case transaction.TransactionDate.After(time.Now().UTC().Add(24 * time.Hour)):
+		reasons = append(reasons, "transaction date is in the future")
+	}
+
+	if transaction.TransactionType == "" {
+		reasons = append(reasons, "missing transaction type")
+	}
+	if transaction.Amount <= 0 {
+		reasons = append(reasons, "amount must be positive")
+	}
+	if transaction.Fees < 0 || transaction.ELevy < 0 {
+		reasons = append(reasons, "fees cannot be negative")
+	}
+
+	if !hasBalances(transaction) || transaction.Amount <= 0 {
+		return reasons
+	}
+
+	// Charges are left out by statements that list them as rows of their own,
+	// so the balance may move by the amount with or without them.
+	delta := transaction.BalanceAfter - transaction.BalanceBefore
+	charges := transaction.Fees + transaction.ELevy
+	in := closeTo(delta, transaction.Amount) || closeTo(delta, transaction.Amount-charges)
+	out := closeTo(-delta, transaction.Amount) || closeTo(-delta, transaction.Amount+charges)
+
+	switch flow := typeDirection(transaction.TransactionType); {
+	case !in && !out:
+		reasons = append(reasons, fmt.Sprintf(
+			"balance moves from %.2f to %.2f, which does not match the amount %.2f and charges %.2f",
+			transaction.BalanceBefore, transaction.BalanceAfter, transaction.Amount, charges,
+		))
+	case flow == directionIn && !in:
+		reasons = append(reasons, fmt.Sprintf(
+			"%s transaction lowers the balance from %.2f to %.2f",
+			transaction.TransactionType, transaction.BalanceBefore, transaction.BalanceAfter,
+		))
+	case flow == directionOut && !out:
+		reasons = append(reasons, fmt.Sprintf(
+			"%s transaction raises the balance from %.2f to %.2f",
+			transaction.TransactionType, transaction.BalanceBefore, transaction.BalanceAfter,
+		))
+	}
+
+	return reasons
+}
+
+// isCutOff reports whether the balance of row i carries on from neither of its
+// neighbours, given the links between consecutive rows. A single break in the
+// chain is as likely to be a row missing from the statement as a misread one,
+// so only a row cut off on both sides is blamed; at either end of the
+// statement the neighbour must carry on from the row after it.
+func isCutOff(linked []bool, i int) bool {
+	n := len(linked) + 1
+	if n < 3 {
+		return false
+	}
+
+	if (i > 0 && linked[i-1]) || (i < n-1 && linked[i]) {
+		return false
+	}
+	if (i == 0 && !linked[1]) || (i == n-1 && !linked[n-3]) {
+		return false
+	}
+	return true
+}
+
+// chainLinks reports for each pair of consecutive rows whether the balance
+// carries on from one to the other. Statements list transactions oldest or
+// newest first, so the order in which more rows link up is taken. Rows without
+// balances are treated as linked.
+func chainLinks(transactions []*models.Transaction) []bool {
+	forward := make([]bool, len(transactions)-1)
+	backward := make([]bool, len(transactions)-1)
+	forwardCount, backwardCount := 0, 0
+
+	for i := range forward {
+		current, next := transactions[i], transactions[i+1]
+		if !hasBalances(current) || !hasBalances(next) {
+			forward[i], backward[i] = true, true
+			continue
+		}
+
+		if closeTo(current.BalanceAfter, next.BalanceBefore) {
+			forward[i] = true
+			forwardCount++
+		}
+		if closeTo(next.BalanceAfter, current.BalanceBefore) {
+			backward[i] = true
+			backwardCount++
+		}
+	}
+
+	if backwardCount > forwardCount {
+		return backward
+	}
+	return forward
+}
+
+// typeDirection returns the way money moves for a normalised transaction type.
+// Transfers go either way, as do types a provider uses that are not known.
+func typeDirection(transactionType string) direction {
+	switch transactionType {
+	case TypeCashIn:
+		return directionIn
+	case TypeCashOut, TypePayment, TypeDebit:
+		return directionOut
+	}
+	return directionUnknown
+}
+
+// hasBalances reports whether the statement printed balances for the row.
+func hasBalances(transaction *models.Transaction) bool {
+	return transaction.BalanceBefore != 0 || transaction.BalanceAfter != 0
+}
+
+func closeTo(a, b float64) bool {
+	return math.Abs(a-b) < balanceTolerance
+}
@@ -0,0 +1,107 @@
+package statements_test
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"lumon-backend/internal/domain/models"
+	"lumon-backend/internal/statements"
+)
+
+// payments returns payment rows moving between each pair of balances in turn.
+func payments(balances ...[2]float64) []*models.Transaction {
+	var rows []*models.Transaction
+	for i, balance := range balances {
+		rows = append(rows, &models.Transaction{
+			TransactionDate: time.Date(2024, time.March, 1+i, 9, 0, 0, 0, time.UTC),
+			TransactionType: statements.TypePayment,
+			Amount:          balance[0] - balance[1],
+			BalanceBefore:   balance[0],
+			BalanceAfter:    balance[1],
+		})
+	}
+	return rows
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name string
+		rows []*models.Transaction
+		edit func(rows []*models.Transaction)
+		// want lists each problem as the row number and part of the reason.
+		want []string
+	}{
+		{
+			name: "unbroken chain",
+			rows: payments([2]float64{1000, 900}, [2]float64{900, 800}, [2]float64{800, 700}),
+		},
+		{
+			name: "newest first",
+			rows: payments([2]float64{800, 700}, [2]float64{900, 800}, [2]float64{1000, 900}),
+		},
+		{
+			name: "one row missing",
+			rows: payments([2]float64{1000, 900}, [2]float64{900, 800}, [2]float64{700, 600}, [2]float64{600, 500}),
+		},
+		{
+			name: "broken chain",
+			rows: payments([2]float64{1000, 900}, [2]float64{900, 800}, [2]float64{750, 650}, [2]float64{700, 600}),
+			want: []string{"3: balance before 750.00 does not carry on"},
+		},
+		{
+			name: "broken chain at the start",
+			rows: payments([2]float64{2000, 1900}, [2]float64{900, 800}, [2]float64{800, 700}),
+			want: []string{"1: balance before 2000.00 does not carry on"},
+		},
+		{
+			name: "zero amount",
+			rows: payments([2]float64{1000, 900}, [2]float64{900, 800}),
+			edit: func(rows []*models.Transaction) { rows[1].Amount = 0 },
+			want: []string{"2: amount must be positive"},
+		},
+		{
+			name: "negative amount",
+			rows: payments([2]float64{1000, 900}),
+			edit: func(rows []*models.Transaction) { rows[0].Amount = -100 },
+			want: []string{"1: amount must be positive"},
+		},
+		{
+			name: "amount the balance does not move by",
+			rows: payments([2]float64{1000, 900}),
+			edit: func(rows []*models.Transaction) { rows[0].Amount = 120 },
+			want: []string{"1: balance moves from 1000.00 to 900.00, which does not match the amount 120.00"},
+		},
+		{
+			name: "missing date",
+			rows: payments([2]float64{1000, 900}, [2]float64{900, 800}),
+			edit: func(rows []*models.Transaction) { rows[0].TransactionDate = time.Time{} },
+			want: []string{"1: missing or unreadable transaction date"},
+		},
+		{
+			name: "payment raising the balance",
+			rows: payments([2]float64{900, 1000}),
+			edit: func(rows []*models.Transaction) { rows[0].Amount = 100 },
+			want: []string{"1: PAYMENT transaction raises the balance"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if test.edit != nil {
+				test.edit(test.rows)
+			}
+
+			problems := statements.Validate(test.rows)
+			if len(problems) != len(test.want) {
+				t.Fatalf("problems = %v, want %v", problems, test.want)
+			}
+			for i, want := range test.want {
+				if got := fmt.Sprintf("%d: %s", problems[i].Row, problems[i].Reason); !strings.HasPrefix(got, want) {
+					t.Errorf("problem %d = %q, want %q", i, got, want)
+				}
+			}
+		})
+	}
+}
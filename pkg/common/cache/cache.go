@@ -0,0 +1,109 @@
+// Package cache keeps computed values in memory for a limited time, grouped so
+// that every value derived from the same data can be dropped at once.
+package cache
+
+import (
+	"container/list"
+	"sync"
+	"time"
+)
+
+type entry[V any] struct {
+	group     string
+	key       string
+	value     V
+	expiresAt time.Time
+}
+
+// Cache holds values under a key within a group, such as a user. It holds at
+// most maxEntries values across all groups, dropping the least recently used
+// one to make room. It is safe for concurrent use.
+type Cache[V any] struct {
+	mu         sync.Mutex
+	ttl        time.Duration
+	maxEntries int
+	// order lists every entry, most recently used first.
+	order  *list.List
+	groups map[string]map[string]*list.Element
+	now    func() time.Time
+}
+
+// New returns a cache whose values expire after ttl. A maxEntries of zero or
+// less leaves the number of values unbounded.
+func New[V any](ttl time.Duration, maxEntries int) *Cache[V] {
+	return &Cache[V]{
+		ttl:        ttl,
+		maxEntries: maxEntries,
+		order:      list.New(),
+		groups:     make(map[string]map[string]*list.Element),
+		now:        time.Now,
+	}
+}
+
+// Get returns the value stored under key in group, if it has not expired.
+func (c *Cache[V]) Get(group, key string) (V, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var zero V
+	element, ok := c.groups[group][key]
+	if !ok {
+		return zero, false
+	}
+
+	e := element.Value.(*entry[V])
+	if c.now().After(e.expiresAt) {
+		c.remove(element)
+		return zero, false
+	}
+
+	c.order.MoveToFront(element)
+	return e.value, true
+}
+
+// Set stores value under key in group, dropping the least recently used
+// values when the cache is full.
+func (c *Cache[V]) Set(group, key string, value V) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	expiresAt := c.now().Add(c.ttl)
+	if element, ok := c.groups[group][key]; ok {
+		e := element.Value.(*entry[V])
+		e.value = value
+		e.expiresAt = expiresAt
+		c.order.MoveToFront(element)
+		return
+	}
+
+	entries, ok := c.groups[group]
+	if !ok {
+		entries = make(map[string]*list.Element)
+		c.groups[group] = entries
+	}
+	entries[key] = c.order.PushFront(&entry[V]{group: group, key: key, value: value, expiresAt: expiresAt})
+
+	for c.maxEntries > 0 && c.order.Len() > c.maxEntries {
+		c.remove(c.order.Back())
+	}
+}
+
+// Invalidate drops every value of the group.
+func (c *Cache[V]) Invalidate(group string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for _, element := range c.groups[group] {
+		c.order.Remove(element)
+	}
+	delete(c.groups, group)
+}
+
+func (c *Cache[V]) remove(element *list.Element) {
+	e := c.order.Remove(element).(*entry[V])
+	entries := c.groups[e.group]
+	delete(entries, e.key)
+	if len(entries) == 0 {
+		delete(c.groups, e.group)
+	}
+}
@@ -0,0 +1,87 @@
+package cache
+
+import (
+	"testing"
+	"time"
+)
+
+// newTestCache returns a cache whose clock only moves when advance is called.
+func newTestCache(ttl time.Duration, maxEntries int) (*Cache[int], func(time.Duration)) {
+	now := time.Date(2024, time.March, 1, 12, 0, 0, 0, time.UTC)
+	c := New[int](ttl, maxEntries)
+	c.now = func() time.Time { return now }
+	return c, func(d time.Duration) { now = now.Add(d) }
+}
+
+func TestCacheExpiry(t *testing.T) {
+	c, advance := newTestCache(time.Minute, 0)
+	c.Set("user", "score", 700)
+
+	advance(time.Minute)
+	if value, ok := c.Get("user", "score"); !ok || value != 700 {
+		t.Fatalf("Get at the TTL = %v, %v, want 700, true", value, ok)
+	}
+
+	advance(time.Second)
+	if _, ok := c.Get("user", "score"); ok {
+		t.Fatal("Get after the TTL found the value")
+	}
+	if len(c.groups) != 0 || c.order.Len() != 0 {
+		t.Errorf("expired value still held: %d groups, %d entries", len(c.groups), c.order.Len())
+	}
+
+	// Setting a value again restarts its TTL.
+	c.Set("user", "score", 710)
+	advance(50 * time.Second)
+	c.Set("user", "score", 720)
+	advance(50 * time.Second)
+	if value, ok := c.Get("user", "score"); !ok || value != 720 {
+		t.Errorf("Get after a refresh = %v, %v, want 720, true", value, ok)
+	}
+}
+
+func TestCacheEvictsLeastRecentlyUsed(t *testing.T) {
+	c, _ := newTestCache(time.Hour, 2)
+	c.Set("a", "score", 1)
+	c.Set("b", "score", 2)
+
+	// Reading a makes b the least recently used.
+	c.Get("a", "score")
+	c.Set("c", "score", 3)
+
+	if _, ok := c.Get("b", "score"); ok {
+		t.Error("b was kept, want it evicted")
+	}
+	for group, want := range map[string]int{"a": 1, "c": 3} {
+		if value, ok := c.Get(group, "score"); !ok || value != want {
+			t.Errorf("Get(%s) = %v, %v, want %v, true", group, value, ok, want)
+		}
+	}
+
+	// Updating a value does not count towards the limit.
+	c.Set("c", "score", 4)
+	if c.order.Len() != 2 {
+		t.Errorf("%d entries after an update, want 2", c.order.Len())
+	}
+}
+
+func TestCacheInvalidate(t *testing.T) {
+	c, _ := newTestCache(time.Hour, 0)
+	c.Set("user-1", "score", 1)
+	c.Set("user-1", "features", 2)
+	c.Set("user-2", "score", 3)
+
+	c.Invalidate("user-1")
+
+	for _, key := range []string{"score", "features"} {
+		if _, ok := c.Get("user-1", key); ok {
+			t.Errorf("user-1 %s kept after Invalidate", key)
+		}
+	}
+	if value, ok := c.Get("user-2", "score"); !ok || value != 3 {
+		t.Errorf("user-2 score = %v, %v, want 3, true", value, ok)
+	}
+	if c.order.Len() != 1 {
+		t.Errorf("%d entries after Invalidate, want 1", c.order.Len())
+	}
+}
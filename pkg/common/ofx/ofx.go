@@ -0,0 +1,141 @@
+// Package ofx writes bank statements in the Open Financial Exchange 2.2
+// format that accounting packages import. Transactions are streamed, so the
+// statement's date range has to be known before the first one is written.
+package ofx
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// Transaction types used by the writer's callers.
+const (
+	TypeCredit = "CREDIT"
+	TypeDebit  = "DEBIT"
+	TypeFee    = "FEE"
+)
+
+// Account identifies the account a statement is for.
+type Account struct {
+	BankID    string
+	AccountID string
+	Currency  string
+}
+
+// Transaction is one entry of a statement. Amount is negative for money
+// leaving the account. ID must be unique within the account and stable
+// across exports, so that importing a statement twice does not duplicate it.
+type Transaction struct {
+	Type   string
+	Posted time.Time
+	Amount float64
+	ID     string
+	Name   string
+	Memo   string
+}
+
+// Writer streams a statement.
+type Writer struct {
+	w      io.Writer
+	loc    *time.Location
+	err    error
+	closed bool
+}
+
+// NewWriter writes the header of a statement for account covering start to
+// end, with times given in loc.
+func NewWriter(w io.Writer, account Account, start, end time.Time, loc *time.Location) (*Writer, error) {
+	if loc == nil {
+		loc = time.UTC
+	}
+	writer := &Writer{w: w, loc: loc}
+
+	now := time.Now()
+	writer.printf(`<?xml version="1.0" encoding="UTF-8" standalone="no"?>` + "\n" +
+		`<?OFX OFXHEADER="200" VERSION="220" SECURITY="NONE" OLDFILEUID="NONE" NEWFILEUID="NONE"?>` + "\n")
+	writer.printf("<OFX>\n<SIGNONMSGSRSV1><SONRS>" +
+		"<STATUS><CODE>0</CODE><SEVERITY>INFO</SEVERITY></STATUS>")
+	writer.printf("<DTSERVER>%s</DTSERVER><LANGUAGE>ENG</LANGUAGE></SONRS></SIGNONMSGSRSV1>\n", writer.date(now))
+	writer.printf("<BANKMSGSRSV1><STMTTRNRS><TRNUID>%d</TRNUID>"+
+		"<STATUS><CODE>0</CODE><SEVERITY>INFO</SEVERITY></STATUS>\n<STMTRS>", now.Unix())
+	writer.printf("<CURDEF>%s</CURDEF>", escape(account.Currency, 3))
+	writer.printf("<BANKACCTFROM><BANKID>%s</BANKID><ACCTID>%s</ACCTID><ACCTTYPE>CHECKING</ACCTTYPE></BANKACCTFROM>\n",
+		escape(account.BankID, 9), escape(account.AccountID, 22))
+	writer.printf("<BANKTRANLIST><DTSTART>%s</DTSTART><DTEND>%s</DTEND>\n", writer.date(start), writer.date(end))
+
+	return writer, writer.err
+}
+
+// WriteTransaction appends a transaction to the statement.
+func (w *Writer) WriteTransaction(transaction Transaction) error {
+	if w.closed {
+		return errors.New("ofx: write to closed writer")
+	}
+
+	w.printf("<STMTTRN><TRNTYPE>%s</TRNTYPE><DTPOSTED>%s</DTPOSTED><TRNAMT>%.2f</TRNAMT><FITID>%s</FITID>",
+		transaction.Type, w.date(transaction.Posted), transaction.Amount, escape(transaction.ID, 255))
+	if name := escape(transaction.Name, 32); name != "" {
+		w.printf("<NAME>%s</NAME>", name)
+	}
+	if memo := escape(transaction.Memo, 255); memo != "" {
+		w.printf("<MEMO>%s</MEMO>", memo)
+	}
+	w.printf("</STMTTRN>\n")
+
+	return w.err
+}
+
+// Close ends the statement with the ledger balance as of asOf, when it is
+// known. It does not close the underlying writer.
+func (w *Writer) Close(balance *float64, asOf time.Time) error {
+	if w.closed {
+		return nil
+	}
+	w.closed = true
+
+	w.printf("</BANKTRANLIST>\n")
+	if balance != nil {
+		w.printf("<LEDGERBAL><BALAMT>%.2f</BALAMT><DTASOF>%s</DTASOF></LEDGERBAL>\n", *balance, w.date(asOf))
+	}
+	w.printf("</STMTRS></STMTTRNRS></BANKMSGSRSV1>\n</OFX>\n")
+
+	return w.err
+}
+
+func (w *Writer) printf(format string, args ...any) {
+	if w.err != nil {
+		return
+	}
+	_, w.err = fmt.Fprintf(w.w, format, args...)
+}
+
+// date formats t in the writer's location with its offset, as in
+// 20240131143000.000[+0:GMT].
+func (w *Writer) date(t time.Time) string {
+	t = t.In(w.loc)
+	name, offset := t.Zone()
+	hours := float64(offset) / 3600
+
+	zone := fmt.Sprintf("%+g", hours)
+	if strings.HasPrefix(name, "+") || strings.HasPrefix(name, "-") {
+		return fmt.Sprintf("%s.000[%s]", t.Format("20060102150405"), zone)
+	}
+	return fmt.Sprintf("%s.000[%s:%s]", t.Format("20060102150405"), zone, name)
+}
+
+// escape prepares text for an element, trimmed to the length the
+// specification allows.
+func escape(value string, limit int) string {
+	value = strings.Join(strings.Fields(value), " ")
+	if runes := []rune(value); len(runes) > limit {
+		value = string(runes[:limit])
+	}
+
+	var b strings.Builder
+	_ = xml.EscapeText(&b, []byte(value))
+	return b.String()
+}
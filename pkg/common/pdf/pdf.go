@@ -0,0 +1,146 @@
+package pdf
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+)
+
+const (
+	pageWidth    = 595.0
+	pageHeight   = 842.0
+	marginLeft   = 50.0
+	marginTop    = 60.0
+	marginBottom = 60.0
+	lineSpacing  = 1.4
+)
+
+type line struct {
+	text string
+	size float64
+	bold bool
+}
+
+// Document is a minimal text-only PDF writer using the standard Helvetica
+// fonts, enough for tabular reports without an external dependency.
+type Document struct {
+	pages   [][]line
+	cursorY float64
+}
+
+func New() *Document {
+	d := &Document{}
+	d.newPage()
+	return d
+}
+
+func (d *Document) newPage() {
+	d.pages = append(d.pages, nil)
+	d.cursorY = pageHeight - marginTop
+}
+
+func (d *Document) add(l line) {
+	height := l.size * lineSpacing
+	if d.cursorY-height < marginBottom {
+		d.newPage()
+	}
+	d.pages[len(d.pages)-1] = append(d.pages[len(d.pages)-1], l)
+	d.cursorY -= height
+}
+
+func (d *Document) Heading(text string) {
+	d.add(line{text: text, size: 14, bold: true})
+}
+
+func (d *Document) Text(text string) {
+	d.add(line{text: text, size: 10})
+}
+
+func (d *Document) Blank() {
+	d.add(line{size: 10})
+}
+
+// Bytes serialises the document.
+func (d *Document) Bytes() []byte {
+	var objects []string
+
+	// Object numbers: 1 catalog, 2 page tree, 3 regular font, 4 bold font,
+	// then a page object and a content stream per page.
+	objects = append(objects, "<< /Type /Catalog /Pages 2 0 R >>")
+
+	kids := make([]string, len(d.pages))
+	for i := range d.pages {
+		kids[i] = fmt.Sprintf("%d 0 R", 5+2*i)
+	}
+	objects = append(objects, fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(d.pages)))
+	objects = append(objects, "<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica /Encoding /WinAnsiEncoding >>")
+	objects = append(objects, "<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica-Bold /Encoding /WinAnsiEncoding >>")
+
+	for i, page := range d.pages {
+		objects = append(objects, fmt.Sprintf(
+			"<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %.0f %.0f] /Resources << /Font << /F1 3 0 R /F2 4 0 R >> >> /Contents %d 0 R >>",
+			pageWidth, pageHeight, 6+2*i,
+		))
+
+		stream := renderPage(page)
+		objects = append(objects, fmt.Sprintf("<< /Length %d >>\nstream\n%s\nendstream", len(stream), stream))
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString("%PDF-1.4\n")
+
+	offsets := make([]int, len(objects))
+	for i, object := range objects {
+		offsets[i] = buf.Len()
+		fmt.Fprintf(&buf, "%d 0 obj\n%s\nendobj\n", i+1, object)
+	}
+
+	xref := buf.Len()
+	fmt.Fprintf(&buf, "xref\n0 %d\n0000000000 65535 f \n", len(objects)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(&buf, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(&buf, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(objects)+1, xref)
+
+	return buf.Bytes()
+}
+
+func renderPage(lines []line) string {
+	var b strings.Builder
+	y := pageHeight - marginTop
+
+	b.WriteString("BT\n")
+	for _, l := range lines {
+		y -= l.size * lineSpacing
+		if l.text == "" {
+			continue
+		}
+
+		font := "F1"
+		if l.bold {
+			font = "F2"
+		}
+		fmt.Fprintf(&b, "/%s %.1f Tf\n1 0 0 1 %.1f %.1f Tm\n(%s) Tj\n", font, l.size, marginLeft, y, escape(l.text))
+	}
+	b.WriteString("ET")
+
+	return b.String()
+}
+
+// escape makes text safe inside a PDF literal string. Characters outside
+// printable ASCII are replaced since the standard fonts cannot show them.
+func escape(text string) string {
+	var b strings.Builder
+	for _, r := range text {
+		switch {
+		case r == '(' || r == ')' || r == '\\':
+			b.WriteRune('\\')
+			b.WriteRune(r)
+		case r < 32 || r > 126:
+			b.WriteRune('?')
+		default:
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
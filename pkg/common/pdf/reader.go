@@ -0,0 +1,574 @@
+package pdf
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/ascii85"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+)
+
+// The reader understands enough of the PDF format to recover the text layer
+// of generated documents such as bank and mobile money statements: indirect
+// objects, object streams, the common stream filters and the page tree.
+// Encryption, predictors and image filters are not supported.
+
+type (
+	name   string
+	dict   map[name]any
+	array  []any
+	ref    struct{ num, gen int }
+	stream struct {
+		dict dict
+		data []byte
+	}
+	keyword string
+)
+
+var errUnexpectedEOF = errors.New("unexpected end of PDF data")
+
+// maxInflatedSize caps what a single compressed stream may expand to, so a
+// small document cannot claim all memory with a zlib bomb. Statement pages
+// decompress to well under a megabyte.
+const maxInflatedSize = 32 << 20
+
+var errStreamTooLarge = fmt.Errorf("PDF stream inflates to more than %d bytes", maxInflatedSize)
+
+type lexer struct {
+	data []byte
+	pos  int
+	peek []any
+}
+
+func newLexer(data []byte) *lexer {
+	return &lexer{data: data}
+}
+
+func isWhitespace(c byte) bool {
+	return c == 0 || c == '\t' || c == '\n' || c == '\f' || c == '\r' || c == ' '
+}
+
+func isDelimiter(c byte) bool {
+	switch c {
+	case '(', ')', '<', '>', '[', ']', '{', '}', '/', '%':
+		return true
+	}
+	return false
+}
+
+func (l *lexer) skipSpace() {
+	for l.pos < len(l.data) {
+		c := l.data[l.pos]
+		switch {
+		case isWhitespace(c):
+			l.pos++
+		case c == '%':
+			for l.pos < len(l.data) && l.data[l.pos] != '\n' && l.data[l.pos] != '\r' {
+				l.pos++
+			}
+		default:
+			return
+		}
+	}
+}
+
+// next returns the next token: a number, name, string ([]byte), keyword or
+// one of the structural keywords "<<", ">>", "[" and "]".
+func (l *lexer) next() (any, error) {
+	if len(l.peek) > 0 {
+		tok := l.peek[0]
+		l.peek = l.peek[1:]
+		return tok, nil
+	}
+
+	l.skipSpace()
+	if l.pos >= len(l.data) {
+		return nil, errUnexpectedEOF
+	}
+
+	c := l.data[l.pos]
+	switch {
+	case c == '/':
+		return l.readName(), nil
+	case c == '(':
+		return l.readLiteral()
+	case c == '<':
+		if l.pos+1 < len(l.data) && l.data[l.pos+1] == '<' {
+			l.pos += 2
+			return keyword("<<"), nil
+		}
+		return l.readHex()
+	case c == '>':
+		if l.pos+1 < len(l.data) && l.data[l.pos+1] == '>' {
+			l.pos += 2
+			return keyword(">>"), nil
+		}
+		l.pos++
+		return keyword(">"), nil
+	case c == '[' || c == ']' || c == '{' || c == '}':
+		l.pos++
+		return keyword(string(c)), nil
+	}
+
+	start := l.pos
+	for l.pos < len(l.data) && !isWhitespace(l.data[l.pos]) && !isDelimiter(l.data[l.pos]) {
+		l.pos++
+	}
+	if l.pos == start {
+		l.pos++
+		return keyword(string(c)), nil
+	}
+
+	word := string(l.data[start:l.pos])
+	if f, err := strconv.ParseFloat(word, 64); err == nil {
+		return f, nil
+	}
+	return keyword(word), nil
+}
+
+func (l *lexer) unread(tok any) {
+	l.peek = append([]any{tok}, l.peek...)
+}
+
+func (l *lexer) readName() name {
+	l.pos++
+	var b []byte
+	for l.pos < len(l.data) && !isWhitespace(l.data[l.pos]) && !isDelimiter(l.data[l.pos]) {
+		c := l.data[l.pos]
+		if c == '#' && l.pos+2 < len(l.data) {
+			if v, err := strconv.ParseUint(string(l.data[l.pos+1:l.pos+3]), 16, 8); err == nil {
+				b = append(b, byte(v))
+				l.pos += 3
+				continue
+			}
+		}
+		b = append(b, c)
+		l.pos++
+	}
+	return name(b)
+}
+
+func (l *lexer) readLiteral() ([]byte, error) {
+	l.pos++
+	depth := 1
+	var b []byte
+	for l.pos < len(l.data) {
+		c := l.data[l.pos]
+		l.pos++
+		switch c {
+		case '(':
+			depth++
+		case ')':
+			depth--
+			if depth == 0 {
+				return b, nil
+			}
+		case '\\':
+			if l.pos >= len(l.data) {
+				return nil, errUnexpectedEOF
+			}
+			e := l.data[l.pos]
+			l.pos++
+			switch e {
+			case 'n':
+				c = '\n'
+			case 'r':
+				c = '\r'
+			case 't':
+				c = '\t'
+			case 'b':
+				c = '\b'
+			case 'f':
+				c = '\f'
+			case '\r':
+				if l.pos < len(l.data) && l.data[l.pos] == '\n' {
+					l.pos++
+				}
+				continue
+			case '\n':
+				continue
+			default:
+				if e >= '0' && e <= '7' {
+					v := int(e - '0')
+					for i := 0; i < 2 && l.pos < len(l.data) && l.data[l.pos] >= '0' && l.data[l.pos] <= '7'; i++ {
+						v = v*8 + int(l.data[l.pos]-'0')
+						l.pos++
+					}
+					c = byte(v)
+				} else {
+					c = e
+				}
+			}
+		}
+		b = append(b, c)
+	}
+	return nil, errUnexpectedEOF
+}
+
+func (l *lexer) readHex() ([]byte, error) {
+	l.pos++
+	var digits []byte
+	for l.pos < len(l.data) && l.data[l.pos] != '>' {
+		if c := l.data[l.pos]; !isWhitespace(c) {
+			digits = append(digits, c)
+		}
+		l.pos++
+	}
+	if l.pos >= len(l.data) {
+		return nil, errUnexpectedEOF
+	}
+	l.pos++
+
+	if len(digits)%2 == 1 {
+		digits = append(digits, '0')
+	}
+	return hex.DecodeString(string(digits))
+}
+
+// readObject parses a complete object, resolving "n g R" into references.
+func (l *lexer) readObject() (any, error) {
+	tok, err := l.next()
+	if err != nil {
+		return nil, err
+	}
+
+	switch t := tok.(type) {
+	case keyword:
+		switch t {
+		case "<<":
+			return l.readDict()
+		case "[":
+			return l.readArray()
+		case "true":
+			return true, nil
+		case "false":
+			return false, nil
+		case "null":
+			return nil, nil
+		}
+		return t, nil
+	case float64:
+		return l.readNumberOrRef(t)
+	}
+	return tok, nil
+}
+
+func (l *lexer) readNumberOrRef(num float64) (any, error) {
+	gen, err := l.next()
+	if err != nil {
+		return num, nil
+	}
+	g, ok := gen.(float64)
+	if !ok {
+		l.unread(gen)
+		return num, nil
+	}
+
+	r, err := l.next()
+	if err != nil {
+		l.unread(gen)
+		return num, nil
+	}
+	if r == keyword("R") {
+		return ref{num: int(num), gen: int(g)}, nil
+	}
+
+	l.unread(r)
+	l.unread(gen)
+	return num, nil
+}
+
+func (l *lexer) readDict() (dict, error) {
+	d := dict{}
+	for {
+		tok, err := l.next()
+		if err != nil {
+			return nil, err
+		}
+		if tok == keyword(">>") {
+			return d, nil
+		}
+
+		key, ok := tok.(name)
+		if !ok {
+			return nil, fmt.Errorf("dictionary key is %v, not a name", tok)
+		}
+
+		value, err := l.readObject()
+		if err != nil {
+			return nil, err
+		}
+		d[key] = value
+	}
+}
+
+func (l *lexer) readArray() (array, error) {
+	var a array
+	for {
+		tok, err := l.next()
+		if err != nil {
+			return nil, err
+		}
+		if tok == keyword("]") {
+			return a, nil
+		}
+
+		l.unread(tok)
+		value, err := l.readObject()
+		if err != nil {
+			return nil, err
+		}
+		a = append(a, value)
+	}
+}
+
+// reader holds every indirect object of a document.
+type reader struct {
+	objects map[int]any
+}
+
+var objectHeader = regexp.MustCompile(`(\d+)\s+(\d+)\s+obj\b`)
+
+func newReader(data []byte) (*reader, error) {
+	if !bytes.HasPrefix(bytes.TrimLeft(data, "\x00\t\n\f\r "), []byte("%PDF-")) {
+		return nil, errors.New("not a PDF document")
+	}
+
+	r := &reader{objects: make(map[int]any)}
+
+	// Objects are located by scanning rather than through the cross-reference
+	// table, which generators frequently get wrong. Later definitions win, as
+	// they do with incremental updates.
+	for _, match := range objectHeader.FindAllSubmatchIndex(data, -1) {
+		num, _ := strconv.Atoi(string(data[match[2]:match[3]]))
+
+		l := newLexer(data)
+		l.pos = match[1]
+		obj, err := l.readObject()
+		if err != nil {
+			continue
+		}
+
+		if d, ok := obj.(dict); ok {
+			if s, ok := r.readStream(l, d); ok {
+				obj = s
+			}
+		}
+		r.objects[num] = obj
+	}
+
+	for num, obj := range r.objects {
+		if s, ok := obj.(stream); ok && s.dict["Type"] == name("ObjStm") {
+			if err := r.expandObjectStream(s); err != nil {
+				return nil, fmt.Errorf("object stream %d: %w", num, err)
+			}
+		}
+	}
+
+	return r, nil
+}
+
+func (r *reader) readStream(l *lexer, d dict) (stream, bool) {
+	l.skipSpace()
+	if !bytes.HasPrefix(l.data[l.pos:], []byte("stream")) {
+		return stream{}, false
+	}
+
+	start := l.pos + len("stream")
+	if start < len(l.data) && l.data[start] == '\r' {
+		start++
+	}
+	if start < len(l.data) && l.data[start] == '\n' {
+		start++
+	}
+
+	if length, ok := d["Length"].(float64); ok {
+		end := start + int(length)
+		if end <= len(l.data) && bytes.HasPrefix(bytes.TrimLeft(l.data[end:], "\r\n "), []byte("endstream")) {
+			return stream{dict: d, data: l.data[start:end]}, true
+		}
+	}
+
+	end := bytes.Index(l.data[start:], []byte("endstream"))
+	if end < 0 {
+		return stream{}, false
+	}
+	return stream{dict: d, data: bytes.TrimRight(l.data[start:start+end], "\r\n")}, true
+}
+
+func (r *reader) expandObjectStream(s stream) error {
+	data, err := r.decode(s)
+	if err != nil {
+		return err
+	}
+
+	n, _ := r.resolve(s.dict["N"]).(float64)
+	first, _ := r.resolve(s.dict["First"]).(float64)
+
+	l := newLexer(data)
+	offsets := make([][2]int, 0, int(n))
+	for i := 0; i < int(n); i++ {
+		num, err1 := l.next()
+		offset, err2 := l.next()
+		if err1 != nil || err2 != nil {
+			return errors.New("truncated object stream header")
+		}
+		numF, _ := num.(float64)
+		offsetF, _ := offset.(float64)
+		offsets = append(offsets, [2]int{int(numF), int(offsetF)})
+	}
+
+	for _, entry := range offsets {
+		if _, exists := r.objects[entry[0]]; exists {
+			continue
+		}
+
+		obj := newLexer(data)
+		obj.pos = int(first) + entry[1]
+		if obj.pos >= len(data) {
+			continue
+		}
+		value, err := obj.readObject()
+		if err != nil {
+			continue
+		}
+		r.objects[entry[0]] = value
+	}
+
+	return nil
+}
+
+// resolve follows references until it reaches a direct object.
+func (r *reader) resolve(obj any) any {
+	for i := 0; i < 32; i++ {
+		rf, ok := obj.(ref)
+		if !ok {
+			return obj
+		}
+		obj = r.objects[rf.num]
+	}
+	return nil
+}
+
+func (r *reader) dict(obj any) dict {
+	switch v := r.resolve(obj).(type) {
+	case dict:
+		return v
+	case stream:
+		return v.dict
+	}
+	return nil
+}
+
+// decode applies the stream's filters in order.
+func (r *reader) decode(s stream) ([]byte, error) {
+	var filters []any
+	switch f := r.resolve(s.dict["Filter"]).(type) {
+	case name:
+		filters = []any{f}
+	case array:
+		filters = f
+	}
+
+	data := s.data
+	for _, f := range filters {
+		var err error
+		switch r.resolve(f) {
+		case name("FlateDecode"), name("Fl"):
+			data, err = inflate(data)
+		case name("ASCIIHexDecode"), name("AHx"):
+			data, err = newLexer(append(append([]byte("<"), bytes.TrimSuffix(bytes.TrimSpace(data), []byte(">"))...), '>')).readHex()
+		case name("ASCII85Decode"), name("A85"):
+			data, err = decodeASCII85(data)
+		default:
+			return nil, fmt.Errorf("unsupported stream filter %v", f)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return data, nil
+}
+
+// inflate returns as much as could be decompressed, since truncated streams
+// are common and the readable part is still useful. Streams that expand past
+// maxInflatedSize are refused.
+func inflate(data []byte) ([]byte, error) {
+	zr, err := zlib.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	defer zr.Close()
+
+	out, err := io.ReadAll(io.LimitReader(zr, maxInflatedSize+1))
+	if len(out) > maxInflatedSize {
+		return nil, errStreamTooLarge
+	}
+	if err != nil && len(out) == 0 {
+		return nil, err
+	}
+	return out, nil
+}
+
+func decodeASCII85(data []byte) ([]byte, error) {
+	data = bytes.TrimSpace(data)
+	data = bytes.TrimPrefix(data, []byte("<~"))
+	if i := bytes.Index(data, []byte("~>")); i >= 0 {
+		data = data[:i]
+	}
+
+	out := make([]byte, 4*len(data))
+	n, _, err := ascii85.Decode(out, data, true)
+	if err != nil {
+		return nil, err
+	}
+	return out[:n], nil
+}
+
+// pages walks the page tree in order, passing down inherited resources.
+func (r *reader) pages() ([]dict, error) {
+	var catalog dict
+	for _, obj := range r.objects {
+		if d := r.dict(obj); d != nil && d["Type"] == name("Catalog") {
+			catalog = d
+			break
+		}
+	}
+	if catalog == nil {
+		return nil, errors.New("PDF document has no catalog")
+	}
+
+	var pages []dict
+	var walk func(node dict, resources any, depth int)
+	walk = func(node dict, resources any, depth int) {
+		if node == nil || depth > 64 {
+			return
+		}
+		if res, ok := node["Resources"]; ok {
+			resources = res
+		}
+
+		if node["Type"] == name("Pages") || node["Kids"] != nil {
+			kids, _ := r.resolve(node["Kids"]).(array)
+			for _, kid := range kids {
+				walk(r.dict(kid), resources, depth+1)
+			}
+			return
+		}
+
+		page := dict{}
+		for k, v := range node {
+			page[k] = v
+		}
+		page["Resources"] = resources
+		pages = append(pages, page)
+	}
+	walk(r.dict(catalog["Pages"]), nil, 0)
+
+	return pages, nil
+}
@@ -0,0 +1,723 @@
+package pdf
+
+import (
+	"errors"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+)
+
+// TextCell is a run of text on a line, positioned in PDF user space units
+// from the bottom-left corner of the page.
+type TextCell struct {
+	X    float64
+	EndX float64
+	Size float64
+	Text string
+}
+
+// TextLine is a row of cells sharing a baseline, ordered left to right.
+type TextLine struct {
+	Y     float64
+	Cells []TextCell
+}
+
+func (l TextLine) String() string {
+	texts := make([]string, len(l.Cells))
+	for i, cell := range l.Cells {
+		texts[i] = cell.Text
+	}
+	return strings.Join(texts, "\t")
+}
+
+// Page is the text layer of a page, ordered top to bottom.
+type Page struct {
+	Lines []TextLine
+}
+
+func (p Page) String() string {
+	lines := make([]string, len(p.Lines))
+	for i, line := range p.Lines {
+		lines[i] = line.String()
+	}
+	return strings.Join(lines, "\n")
+}
+
+// ReadPages extracts the positioned text of every page. Text that is close
+// enough together is merged into cells, so table layouts can be rebuilt by
+// comparing the positions of cells across lines.
+func ReadPages(data []byte) ([]Page, error) {
+	r, err := newReader(data)
+	if err != nil {
+		return nil, err
+	}
+
+	pageDicts, err := r.pages()
+	if err != nil {
+		return nil, err
+	}
+	if len(pageDicts) == 0 {
+		return nil, errors.New("PDF document has no pages")
+	}
+
+	pages := make([]Page, len(pageDicts))
+	for i, page := range pageDicts {
+		content, err := r.pageContent(page)
+		if err != nil {
+			return nil, err
+		}
+
+		in := &interpreter{r: r, fonts: make(map[int]*font)}
+		in.run(content, r.dict(page["Resources"]), identity, 0)
+		pages[i] = Page{Lines: groupLines(in.fragments)}
+	}
+
+	return pages, nil
+}
+
+func (r *reader) pageContent(page dict) ([]byte, error) {
+	var parts []any
+	switch c := r.resolve(page["Contents"]).(type) {
+	case stream:
+		parts = []any{c}
+	case array:
+		parts = c
+	}
+
+	var content []byte
+	for _, part := range parts {
+		s, ok := r.resolve(part).(stream)
+		if !ok {
+			continue
+		}
+		data, err := r.decode(s)
+		if err != nil {
+			return nil, err
+		}
+		content = append(content, data...)
+		content = append(content, '\n')
+	}
+
+	return content, nil
+}
+
+type matrix [6]float64
+
+var identity = matrix{1, 0, 0, 1, 0, 0}
+
+func (m matrix) multiply(n matrix) matrix {
+	return matrix{
+		m[0]*n[0] + m[1]*n[2],
+		m[0]*n[1] + m[1]*n[3],
+		m[2]*n[0] + m[3]*n[2],
+		m[2]*n[1] + m[3]*n[3],
+		m[4]*n[0] + m[5]*n[2] + n[4],
+		m[4]*n[1] + m[5]*n[3] + n[5],
+	}
+}
+
+func (m matrix) apply(x, y float64) (float64, float64) {
+	return x*m[0] + y*m[2] + m[4], x*m[1] + y*m[3] + m[5]
+}
+
+func translate(x, y float64) matrix {
+	return matrix{1, 0, 0, 1, x, y}
+}
+
+type graphicsState struct {
+	ctm       matrix
+	font      *font
+	fontSize  float64
+	charSpace float64
+	wordSpace float64
+	hScale    float64
+	leading   float64
+	rise      float64
+}
+
+type fragment struct {
+	x, endX, y, size float64
+	text             string
+}
+
+type interpreter struct {
+	r         *reader
+	fonts     map[int]*font
+	fragments []fragment
+}
+
+func (in *interpreter) run(content []byte, resources dict, ctm matrix, depth int) {
+	if depth > 8 {
+		return
+	}
+
+	gs := graphicsState{ctm: ctm, hScale: 1}
+	var stack []graphicsState
+	var tm, tlm matrix
+	var operands []any
+
+	l := newLexer(content)
+	for {
+		tok, err := l.next()
+		if err != nil {
+			return
+		}
+
+		op, isOperator := tok.(keyword)
+		switch {
+		case !isOperator:
+			operands = append(operands, tok)
+			continue
+		case op == "[":
+			l.unread(tok)
+			value, err := l.readObject()
+			if err != nil {
+				return
+			}
+			operands = append(operands, value)
+			continue
+		case op == "<<":
+			value, err := l.readDict()
+			if err != nil {
+				return
+			}
+			operands = append(operands, value)
+			continue
+		case op == "BI":
+			skipInlineImage(l)
+			operands = operands[:0]
+			continue
+		}
+
+		num := func(i int) float64 {
+			if i < len(operands) {
+				f, _ := operands[i].(float64)
+				return f
+			}
+			return 0
+		}
+
+		switch op {
+		case "q":
+			stack = append(stack, gs)
+		case "Q":
+			if len(stack) > 0 {
+				gs = stack[len(stack)-1]
+				stack = stack[:len(stack)-1]
+			}
+		case "cm":
+			if len(operands) == 6 {
+				gs.ctm = matrix{num(0), num(1), num(2), num(3), num(4), num(5)}.multiply(gs.ctm)
+			}
+		case "BT":
+			tm, tlm = identity, identity
+		case "Tf":
+			if len(operands) == 2 {
+				if fontName, ok := operands[0].(name); ok {
+					gs.font = in.font(resources, fontName)
+				}
+				gs.fontSize = num(1)
+			}
+		case "Tc":
+			gs.charSpace = num(0)
+		case "Tw":
+			gs.wordSpace = num(0)
+		case "Tz":
+			gs.hScale = num(0) / 100
+		case "TL":
+			gs.leading = num(0)
+		case "Ts":
+			gs.rise = num(0)
+		case "Td":
+			tlm = translate(num(0), num(1)).multiply(tlm)
+			tm = tlm
+		case "TD":
+			gs.leading = -num(1)
+			tlm = translate(num(0), num(1)).multiply(tlm)
+			tm = tlm
+		case "Tm":
+			if len(operands) == 6 {
+				tlm = matrix{num(0), num(1), num(2), num(3), num(4), num(5)}
+				tm = tlm
+			}
+		case "T*":
+			tlm = translate(0, -gs.leading).multiply(tlm)
+			tm = tlm
+		case "Tj", "'", "\"":
+			if op != "Tj" {
+				tlm = translate(0, -gs.leading).multiply(tlm)
+				tm = tlm
+			}
+			if op == "\"" && len(operands) == 3 {
+				gs.wordSpace, gs.charSpace = num(0), num(1)
+			}
+			if len(operands) > 0 {
+				if s, ok := operands[len(operands)-1].([]byte); ok {
+					tm = in.show(&gs, tm, s)
+				}
+			}
+		case "TJ":
+			if len(operands) > 0 {
+				items, _ := operands[0].(array)
+				for _, item := range items {
+					switch v := item.(type) {
+					case []byte:
+						tm = in.show(&gs, tm, v)
+					case float64:
+						tm = translate(-v/1000*gs.fontSize*gs.hScale, 0).multiply(tm)
+					}
+				}
+			}
+		case "Do":
+			if len(operands) > 0 {
+				if xName, ok := operands[0].(name); ok {
+					in.form(resources, xName, gs.ctm, depth)
+				}
+			}
+		}
+
+		operands = operands[:0]
+	}
+}
+
+func skipInlineImage(l *lexer) {
+	for l.pos+2 < len(l.data) {
+		if isWhitespace(l.data[l.pos]) && l.data[l.pos+1] == 'E' && l.data[l.pos+2] == 'I' &&
+			(l.pos+3 >= len(l.data) || isWhitespace(l.data[l.pos+3])) {
+			l.pos += 3
+			return
+		}
+		l.pos++
+	}
+	l.pos = len(l.data)
+}
+
+func (in *interpreter) form(resources dict, xName name, ctm matrix, depth int) {
+	xobjects := in.r.dict(resources["XObject"])
+	s, ok := in.r.resolve(xobjects[xName]).(stream)
+	if !ok || s.dict["Subtype"] != name("Form") {
+		return
+	}
+
+	data, err := in.r.decode(s)
+	if err != nil {
+		return
+	}
+
+	if m, ok := in.r.resolve(s.dict["Matrix"]).(array); ok && len(m) == 6 {
+		var fm matrix
+		for i := range fm {
+			fm[i], _ = in.r.resolve(m[i]).(float64)
+		}
+		ctm = fm.multiply(ctm)
+	}
+
+	formResources := in.r.dict(s.dict["Resources"])
+	if formResources == nil {
+		formResources = resources
+	}
+	in.run(data, formResources, ctm, depth+1)
+}
+
+// show records a string at the current text position and returns the text
+// matrix advanced past it.
+func (in *interpreter) show(gs *graphicsState, tm matrix, s []byte) matrix {
+	f := gs.font
+	if f == nil {
+		f = defaultFont
+	}
+
+	trm := tm.multiply(gs.ctm)
+	x, y := trm.apply(0, gs.rise)
+	size := gs.fontSize * math.Hypot(trm[2], trm[3])
+
+	var text strings.Builder
+	advance := 0.0
+	for _, g := range f.decode(s) {
+		text.WriteString(g.text)
+		tx := g.width/1000*gs.fontSize + gs.charSpace
+		if g.space {
+			tx += gs.wordSpace
+		}
+		advance += tx * gs.hScale
+	}
+
+	endX, _ := trm.apply(advance, gs.rise)
+	if t := text.String(); strings.TrimSpace(t) != "" {
+		in.fragments = append(in.fragments, fragment{x: x, endX: endX, y: y, size: size, text: t})
+	}
+
+	return translate(advance, 0).multiply(tm)
+}
+
+func (in *interpreter) font(resources dict, fontName name) *font {
+	fonts := in.r.dict(resources["Font"])
+	d := in.r.dict(fonts[fontName])
+	if d == nil {
+		return defaultFont
+	}
+
+	// Fonts shared between pages are indirect objects, so they are cached by
+	// object number.
+	rf, indirect := fonts[fontName].(ref)
+	if f, ok := in.fonts[rf.num]; indirect && ok {
+		return f
+	}
+
+	f := in.r.loadFont(d)
+	if indirect {
+		in.fonts[rf.num] = f
+	}
+	return f
+}
+
+// groupLines puts fragments sharing a baseline on one line, top to bottom,
+// and merges fragments on a line that are close enough to be one cell.
+func groupLines(fragments []fragment) []TextLine {
+	sort.SliceStable(fragments, func(i, j int) bool {
+		if math.Abs(fragments[i].y-fragments[j].y) > 0.01 {
+			return fragments[i].y > fragments[j].y
+		}
+		return fragments[i].x < fragments[j].x
+	})
+
+	var lines []TextLine
+	var current []fragment
+	flush := func() {
+		if len(current) > 0 {
+			lines = append(lines, mergeCells(current))
+			current = nil
+		}
+	}
+
+	for _, f := range fragments {
+		if len(current) > 0 {
+			tolerance := math.Max(math.Min(current[0].size, f.size)*0.4, 1)
+			if math.Abs(current[0].y-f.y) > tolerance {
+				flush()
+			}
+		}
+		current = append(current, f)
+	}
+	flush()
+
+	return lines
+}
+
+func mergeCells(fragments []fragment) TextLine {
+	sort.SliceStable(fragments, func(i, j int) bool { return fragments[i].x < fragments[j].x })
+
+	line := TextLine{Y: fragments[0].y}
+	for _, f := range fragments {
+		if n := len(line.Cells); n > 0 {
+			last := &line.Cells[n-1]
+			gap := f.x - last.EndX
+			size := math.Max(math.Max(last.Size, f.size), 1)
+			if gap < 0.3*size {
+				if gap > 0.1*size && !strings.HasSuffix(last.Text, " ") && !strings.HasPrefix(f.text, " ") {
+					last.Text += " "
+				}
+				last.Text += f.text
+				last.EndX = math.Max(last.EndX, f.endX)
+				continue
+			}
+		}
+		line.Cells = append(line.Cells, TextCell{X: f.x, EndX: f.endX, Size: f.size, Text: f.text})
+	}
+
+	for i := range line.Cells {
+		line.Cells[i].Text = strings.Join(strings.Fields(line.Cells[i].Text), " ")
+	}
+
+	return line
+}
+
+type glyph struct {
+	text  string
+	width float64
+	space bool
+}
+
+type font struct {
+	codeLength   int
+	toUnicode    map[uint32]string
+	widths       map[uint32]float64
+	defaultWidth float64
+	encoding     *[256]rune
+}
+
+// defaultFont is used when a font cannot be found; text is read as Latin-1
+// with an average glyph width.
+var defaultFont = &font{codeLength: 1, defaultWidth: 500, encoding: &winAnsi}
+
+func (f *font) decode(s []byte) []glyph {
+	var glyphs []glyph
+	for i := 0; i+f.codeLength <= len(s); i += f.codeLength {
+		var code uint32
+		for j := 0; j < f.codeLength; j++ {
+			code = code<<8 | uint32(s[i+j])
+		}
+
+		g := glyph{width: f.defaultWidth, space: f.codeLength == 1 && code == 32}
+		if w, ok := f.widths[code]; ok {
+			g.width = w
+		}
+
+		switch {
+		case f.toUnicode != nil && f.toUnicode[code] != "":
+			g.text = f.toUnicode[code]
+		case f.codeLength == 1 && f.encoding != nil:
+			g.text = string(f.encoding[code])
+		}
+
+		glyphs = append(glyphs, g)
+	}
+	return glyphs
+}
+
+func (r *reader) loadFont(d dict) *font {
+	f := &font{codeLength: 1, defaultWidth: 500, encoding: &winAnsi, widths: make(map[uint32]float64)}
+
+	if d["Subtype"] == name("Type0") {
+		f.codeLength = 2
+		f.encoding = nil
+		f.defaultWidth = 1000
+
+		descendants, _ := r.resolve(d["DescendantFonts"]).(array)
+		if len(descendants) > 0 {
+			r.loadCIDWidths(f, r.dict(descendants[0]))
+		}
+	} else {
+		r.loadSimpleWidths(f, d)
+		f.encoding = r.loadEncoding(d)
+	}
+
+	if s, ok := r.resolve(d["ToUnicode"]).(stream); ok {
+		if data, err := r.decode(s); err == nil {
+			toUnicode, codeLength := parseCMap(data)
+			if len(toUnicode) > 0 {
+				f.toUnicode = toUnicode
+				if codeLength > 0 {
+					f.codeLength = codeLength
+				}
+			}
+		}
+	}
+
+	return f
+}
+
+func (r *reader) loadSimpleWidths(f *font, d dict) {
+	firstChar, _ := r.resolve(d["FirstChar"]).(float64)
+	widths, _ := r.resolve(d["Widths"]).(array)
+	for i, w := range widths {
+		if width, ok := r.resolve(w).(float64); ok {
+			f.widths[uint32(int(firstChar)+i)] = width
+		}
+	}
+
+	if descriptor := r.dict(d["FontDescriptor"]); descriptor != nil {
+		if missing, ok := r.resolve(descriptor["MissingWidth"]).(float64); ok && missing > 0 {
+			f.defaultWidth = missing
+		}
+	}
+}
+
+func (r *reader) loadCIDWidths(f *font, d dict) {
+	if dw, ok := r.resolve(d["DW"]).(float64); ok {
+		f.defaultWidth = dw
+	}
+
+	w, _ := r.resolve(d["W"]).(array)
+	for i := 0; i < len(w); {
+		first, ok := r.resolve(w[i]).(float64)
+		if !ok || i+1 >= len(w) {
+			return
+		}
+
+		if list, ok := r.resolve(w[i+1]).(array); ok {
+			for j, width := range list {
+				if v, ok := r.resolve(width).(float64); ok {
+					f.widths[uint32(int(first)+j)] = v
+				}
+			}
+			i += 2
+			continue
+		}
+
+		if i+2 >= len(w) {
+			return
+		}
+		last, _ := r.resolve(w[i+1]).(float64)
+		width, _ := r.resolve(w[i+2]).(float64)
+		for code := int(first); code <= int(last) && code-int(first) < 65536; code++ {
+			f.widths[uint32(code)] = width
+		}
+		i += 3
+	}
+}
+
+func (r *reader) loadEncoding(d dict) *[256]rune {
+	encoding := winAnsi
+
+	enc := r.resolve(d["Encoding"])
+	encDict, ok := enc.(dict)
+	if !ok {
+		return &encoding
+	}
+
+	differences, _ := r.resolve(encDict["Differences"]).(array)
+	code := 0
+	for _, item := range differences {
+		switch v := r.resolve(item).(type) {
+		case float64:
+			code = int(v)
+		case name:
+			if code >= 0 && code < 256 {
+				if ru, ok := glyphRune(string(v)); ok {
+					encoding[code] = ru
+				}
+			}
+			code++
+		}
+	}
+
+	return &encoding
+}
+
+// parseCMap reads the bfchar and bfrange mappings of a ToUnicode CMap and
+// returns them with the code length declared by its codespace range.
+func parseCMap(data []byte) (map[uint32]string, int) {
+	mapping := make(map[uint32]string)
+	codeLength := 0
+
+	l := newLexer(data)
+	readCode := func() ([]byte, bool) {
+		tok, err := l.next()
+		if err != nil {
+			return nil, false
+		}
+		b, ok := tok.([]byte)
+		return b, ok
+	}
+
+	for {
+		tok, err := l.next()
+		if err != nil {
+			break
+		}
+
+		switch tok {
+		case keyword("begincodespacerange"):
+			if lo, ok := readCode(); ok {
+				codeLength = len(lo)
+				readCode()
+			}
+		case keyword("beginbfchar"):
+			for {
+				src, ok := readCode()
+				if !ok {
+					break
+				}
+				dst, _ := readCode()
+				mapping[codeValue(src)] = utf16String(dst)
+			}
+		case keyword("beginbfrange"):
+			for {
+				lo, ok := readCode()
+				if !ok {
+					break
+				}
+				hi, _ := readCode()
+				dst, err := l.readObject()
+				if err != nil {
+					break
+				}
+
+				start, end := codeValue(lo), codeValue(hi)
+				switch v := dst.(type) {
+				case []byte:
+					base := []rune(utf16String(v))
+					for code := start; code <= end && code-start < 65536 && len(base) > 0; code++ {
+						runes := append([]rune{}, base...)
+						runes[len(runes)-1] += rune(code - start)
+						mapping[code] = string(runes)
+					}
+				case array:
+					for i, item := range v {
+						if b, ok := item.([]byte); ok {
+							mapping[start+uint32(i)] = utf16String(b)
+						}
+					}
+				}
+			}
+		}
+	}
+
+	return mapping, codeLength
+}
+
+func codeValue(b []byte) uint32 {
+	var v uint32
+	for _, c := range b {
+		v = v<<8 | uint32(c)
+	}
+	return v
+}
+
+func utf16String(b []byte) string {
+	units := make([]uint16, 0, len(b)/2)
+	for i := 0; i+1 < len(b); i += 2 {
+		units = append(units, uint16(b[i])<<8|uint16(b[i+1]))
+	}
+	if len(b)%2 == 1 {
+		units = append(units, uint16(b[len(b)-1]))
+	}
+	return string(utf16.Decode(units))
+}
+
+var glyphNames = map[string]rune{
+	"space": ' ', "exclam": '!', "quotedbl": '"', "numbersign": '#', "dollar": '$', "percent": '%',
+	"ampersand": '&', "quotesingle": '\'', "quoteright": '\'', "parenleft": '(', "parenright": ')',
+	"asterisk": '*', "plus": '+', "comma": ',', "hyphen": '-', "minus": '-', "period": '.',
+	"slash": '/', "colon": ':', "semicolon": ';', "less": '<', "equal": '=', "greater": '>',
+	"question": '?', "at": '@', "bracketleft": '[', "backslash": '\\', "bracketright": ']',
+	"underscore": '_', "endash": '–', "emdash": '—', "bullet": '•', "Euro": '€', "cedi": '₵',
+	"zero": '0', "one": '1', "two": '2', "three": '3', "four": '4',
+	"five": '5', "six": '6', "seven": '7', "eight": '8', "nine": '9',
+}
+
+func glyphRune(glyphName string) (rune, bool) {
+	if ru, ok := glyphNames[glyphName]; ok {
+		return ru, true
+	}
+	if len(glyphName) == 1 {
+		return rune(glyphName[0]), true
+	}
+	if strings.HasPrefix(glyphName, "uni") && len(glyphName) == 7 {
+		if v, err := strconv.ParseUint(glyphName[3:], 16, 32); err == nil {
+			return rune(v), true
+		}
+	}
+	return 0, false
+}
+
+// winAnsi is the WinAnsiEncoding used by most generated PDFs: Latin-1 with
+// typographic punctuation in 0x80-0x9F.
+var winAnsi = func() [256]rune {
+	var table [256]rune
+	for i := range table {
+		table[i] = rune(i)
+	}
+	for code, ru := range map[int]rune{
+		0x80: '€', 0x82: '‚', 0x83: 'ƒ', 0x84: '„', 0x85: '…', 0x86: '†', 0x87: '‡', 0x88: 'ˆ',
+		0x89: '‰', 0x8A: 'Š', 0x8B: '‹', 0x8C: 'Œ', 0x8E: 'Ž', 0x91: '‘', 0x92: '’', 0x93: '“',
+		0x94: '”', 0x95: '•', 0x96: '–', 0x97: '—', 0x98: '˜', 0x99: '™', 0x9A: 'š', 0x9B: '›',
+		0x9C: 'œ', 0x9E: 'ž', 0x9F: 'Ÿ',
+	} {
+		table[code] = ru
+	}
+	return table
+}()
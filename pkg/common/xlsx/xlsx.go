@@ -0,0 +1,200 @@
+// Package xlsx reads the cell values of the first worksheet of an Office Open
+// XML spreadsheet, and writes single-sheet spreadsheets of plain values. It
+// only understands what statement exports use: shared, inline and plain
+// values. Formulas are read as their cached values and all formatting is
+// ignored.
+package xlsx
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"strconv"
+	"strings"
+)
+
+type workbook struct {
+	Sheets []struct {
+		Name string `xml:"name,attr"`
+		RID  string `xml:"http://schemas.openxmlformats.org/officeDocument/2006/relationships id,attr"`
+	} `xml:"sheets>sheet"`
+}
+
+type relationships struct {
+	Relationships []struct {
+		ID     string `xml:"Id,attr"`
+		Target string `xml:"Target,attr"`
+	} `xml:"Relationship"`
+}
+
+type sharedStrings struct {
+	Items []richText `xml:"si"`
+}
+
+type richText struct {
+	Text string `xml:"t"`
+	Runs []struct {
+		Text string `xml:"t"`
+	} `xml:"r"`
+}
+
+func (t richText) String() string {
+	if len(t.Runs) == 0 {
+		return t.Text
+	}
+
+	var b strings.Builder
+	for _, run := range t.Runs {
+		b.WriteString(run.Text)
+	}
+	return b.String()
+}
+
+type worksheet struct {
+	Rows []struct {
+		Cells []struct {
+			Ref    string   `xml:"r,attr"`
+			Type   string   `xml:"t,attr"`
+			Value  string   `xml:"v"`
+			Inline richText `xml:"is"`
+		} `xml:"c"`
+	} `xml:"sheetData>row"`
+}
+
+// ReadRows returns the rows of the first worksheet. Cells missing from a row
+// are returned as empty strings so that columns stay aligned.
+func ReadRows(data []byte) ([][]string, error) {
+	archive, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, fmt.Errorf("not a spreadsheet: %w", err)
+	}
+
+	files := make(map[string]*zip.File, len(archive.File))
+	for _, file := range archive.File {
+		files[file.Name] = file
+	}
+
+	sheetPath, err := firstSheetPath(files)
+	if err != nil {
+		return nil, err
+	}
+
+	var shared sharedStrings
+	if file, ok := files["xl/sharedStrings.xml"]; ok {
+		if err := decode(file, &shared); err != nil {
+			return nil, err
+		}
+	}
+
+	file, ok := files[sheetPath]
+	if !ok {
+		return nil, fmt.Errorf("worksheet %s is missing", sheetPath)
+	}
+
+	var sheet worksheet
+	if err := decode(file, &sheet); err != nil {
+		return nil, err
+	}
+
+	rows := make([][]string, 0, len(sheet.Rows))
+	for _, row := range sheet.Rows {
+		var values []string
+		for _, cell := range row.Cells {
+			column := columnIndex(cell.Ref)
+			if column < 0 {
+				column = len(values)
+			}
+			if column >= maxColumns {
+				return nil, fmt.Errorf("cell %s is past the last column XFD", cell.Ref)
+			}
+			for len(values) <= column {
+				values = append(values, "")
+			}
+
+			switch cell.Type {
+			case "s":
+				i, err := strconv.Atoi(cell.Value)
+				if err != nil || i < 0 || i >= len(shared.Items) {
+					return nil, fmt.Errorf("cell %s refers to a missing shared string", cell.Ref)
+				}
+				values[column] = shared.Items[i].String()
+			case "inlineStr":
+				values[column] = cell.Inline.String()
+			default:
+				values[column] = cell.Value
+			}
+		}
+		rows = append(rows, values)
+	}
+
+	return rows, nil
+}
+
+func firstSheetPath(files map[string]*zip.File) (string, error) {
+	var book workbook
+	file, ok := files["xl/workbook.xml"]
+	if !ok {
+		return "", errors.New("not a spreadsheet: workbook is missing")
+	}
+	if err := decode(file, &book); err != nil {
+		return "", err
+	}
+	if len(book.Sheets) == 0 {
+		return "", errors.New("spreadsheet has no worksheets")
+	}
+
+	var rels relationships
+	if file, ok := files["xl/_rels/workbook.xml.rels"]; ok {
+		if err := decode(file, &rels); err != nil {
+			return "", err
+		}
+	}
+
+	for _, rel := range rels.Relationships {
+		if rel.ID != book.Sheets[0].RID {
+			continue
+		}
+		if strings.HasPrefix(rel.Target, "/") {
+			return strings.TrimPrefix(rel.Target, "/"), nil
+		}
+		return path.Join("xl", rel.Target), nil
+	}
+
+	return "xl/worksheets/sheet1.xml", nil
+}
+
+func decode(file *zip.File, v any) error {
+	r, err := file.Open()
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+
+	if err := xml.NewDecoder(io.LimitReader(r, 256<<20)).Decode(v); err != nil {
+		return fmt.Errorf("failed to read %s: %w", file.Name, err)
+	}
+	return nil
+}
+
+// maxColumns is the number of columns a worksheet can have, up to XFD.
+const maxColumns = 16384
+
+// columnIndex converts the letters of a cell reference such as "AB12" to a
+// zero-based column index. References past XFD give maxColumns.
+func columnIndex(ref string) int {
+	index := 0
+	for _, r := range ref {
+		if r < 'A' || r > 'Z' {
+			break
+		}
+		index = index*26 + int(r-'A'+1)
+		if index > maxColumns {
+			return maxColumns
+		}
+	}
+	return index - 1
+}